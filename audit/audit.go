@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionUserRegistered  = "user.registered"
+	ActionUserCreated     = "user.created"
+	ActionUserUpdated     = "user.updated"
+	ActionUserDeleted     = "user.deleted"
+	ActionUserRoleChanged = "user.role_changed"
+	ActionLogin           = "auth.login"
+	ActionLoginFailed     = "auth.login_failed"
+	ActionPostDeleted     = "post.deleted"
+	ActionCommentDeleted  = "comment.deleted"
+)
+
+// Target types
+const (
+	TargetUser    = "user"
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+// Entry describes an action to record. ActorID defaults to the authenticated
+// user in the context; request details are always taken from the context.
+type Entry struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	Changes    map[string]Change
+	Metadata   map[string]string
+}
+
+// Recorder persists audit entries
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+var recorder atomic.Pointer[Recorder]
+
+// SetRecorder installs the recorder used by Record. Until one is set, entries
+// are dropped, which keeps services usable in tests and tools.
+func SetRecorder(r Recorder) {
+	if r == nil {
+		recorder.Store(nil)
+		return
+	}
+	recorder.Store(&r)
+}
+
+// Record writes an audit entry. A failure to record is logged rather than
+// returned so that auditing never undoes an action that already happened.
+func Record(ctx context.Context, entry Entry) {
+	r := recorder.Load()
+	if r == nil {
+		return
+	}
+	if err := (*r).Record(ctx, entry); err != nil {
+		logger.FromContext(ctx).Error().
+			Err(err).
+			Str("action", entry.Action).
+			Str("target_type", entry.TargetType).
+			Uint("target_id", entry.TargetID).
+			Msg("Failed to record audit entry")
+	}
+}
@@ -0,0 +1,39 @@
+package audit
+
+import "context"
+
+type contextKey int
+
+const (
+	requestKey contextKey = iota
+	actorKey
+)
+
+// RequestInfo identifies the HTTP request an action was made in
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// WithRequest stores the request details recorded with every entry
+func WithRequest(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey, info)
+}
+
+// RequestFromContext returns the request details, or zero values outside a request
+func RequestFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestKey).(RequestInfo)
+	return info
+}
+
+// WithActor stores the authenticated user performing the actions
+func WithActor(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, actorKey, userID)
+}
+
+// ActorFromContext returns the authenticated user, if any
+func ActorFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(actorKey).(uint)
+	return userID, ok
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the values of sensitive fields in recorded changes
+const Redacted = "[REDACTED]"
+
+// sensitiveNames are substrings of field names whose values are never stored.
+// A field can also opt in explicitly with the struct tag `audit:"redact"`.
+var sensitiveNames = []string{"password", "secret", "token"}
+
+// ignoredFields are bookkeeping fields that change on every write and would
+// only add noise to a diff. A field can also opt out with `audit:"-"`.
+var ignoredFields = map[string]bool{
+	"CreatedAt": true,
+	"UpdatedAt": true,
+	"DeletedAt": true,
+	"Version":   true,
+}
+
+// Diff returns the fields that differ between two values of the same struct
+// type. Either side may be nil: a nil before records a creation, a nil after a
+// deletion. Sensitive fields are reported as changed but their values are
+// replaced with Redacted.
+func Diff(before, after interface{}) map[string]Change {
+	bv, av := structValue(before), structValue(after)
+	var t reflect.Type
+	switch {
+	case bv.IsValid():
+		t = bv.Type()
+	case av.IsValid():
+		t = av.Type()
+	default:
+		return nil
+	}
+
+	changes := make(map[string]Change)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("audit")
+		if !field.IsExported() || ignoredFields[field.Name] || tag == "-" {
+			continue
+		}
+
+		var b, a interface{}
+		if bv.IsValid() {
+			b = bv.Field(i).Interface()
+		}
+		if av.IsValid() {
+			a = av.Field(i).Interface()
+		}
+		if bv.IsValid() && av.IsValid() && reflect.DeepEqual(b, a) {
+			continue
+		}
+
+		name := fieldName(field)
+		if tag == "redact" || IsSensitive(name) {
+			if b != nil {
+				b = Redacted
+			}
+			if a != nil {
+				a = Redacted
+			}
+		}
+		changes[name] = Change{Before: b, After: a}
+	}
+	return changes
+}
+
+// IsSensitive reports whether a field or metadata key must be redacted
+func IsSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of metadata with sensitive values replaced
+func Redact(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if IsSensitive(k) {
+			v = Redacted
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+func structValue(v interface{}) reflect.Value {
+	if v == nil {
+		return reflect.Value{}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return rv
+}
+
+// fieldName uses the JSON name so entries read like the API, falling back to
+// snake case for fields hidden from JSON such as Password
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	var b strings.Builder
+	prevLower := false
+	for _, r := range field.Name {
+		if unicode.IsUpper(r) && prevLower {
+			b.WriteByte('_')
+		}
+		prevLower = unicode.IsLower(r)
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package audit
+
+type Response struct {
+	ID         uint              `json:"id"`
+	ActorID    *uint             `json:"actor_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type,omitempty"`
+	TargetID   *uint             `json:"target_id,omitempty"`
+	Changes    map[string]Change `json:"changes,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+type ListResponse struct {
+	Entries []Response `json:"entries"`
+	Total   int64      `json:"total"`
+	Limit   int        `json:"limit"`
+	Offset  int        `json:"offset"`
+}
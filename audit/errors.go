@@ -0,0 +1,9 @@
+package audit
+
+import (
+	"errors"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+var ErrInvalidFilter = errors.Join(domain.ErrValidation, errors.New("invalid audit filter"))
@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// ListAuditEntries godoc
+// @Summary List audit log entries
+// @Description Get a filtered, paginated list of audit log entries, newest first. Admins only
+// @Tags admin
+// @Produce json
+// @Param actor_id query int false "Actor user ID"
+// @Param action query string false "Action, e.g. user.deleted"
+// @Param target_type query string false "Target type, e.g. user"
+// @Param target_id query int false "Target ID"
+// @Param from query string false "Start time (RFC3339, inclusive)"
+// @Param to query string false "End time (RFC3339, exclusive)"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_audit_filter")
+		return
+	}
+	limit, offset := httputil.GetPaginationParams(r)
+
+	result, err := h.service.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_list_audit_entries")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, result)
+}
+
+// ExportAuditEntries godoc
+// @Summary Export audit log entries
+// @Description Stream all audit log entries matching the filters, oldest first, as CSV or newline-delimited JSON. Admins only
+// @Tags admin
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv or ndjson" default(csv)
+// @Param actor_id query int false "Actor user ID"
+// @Param action query string false "Action, e.g. user.deleted"
+// @Param target_type query string false "Target type, e.g. user"
+// @Param target_id query int false "Target ID"
+// @Param from query string false "Start time (RFC3339, inclusive)"
+// @Param to query string false "End time (RFC3339, exclusive)"
+// @Success 200 {string} string
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Security BearerAuth
+// @Router /admin/audit/export [get]
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_audit_filter")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	var write func(*Response) error
+	var flush func() error
+	switch format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		write = func(entry *Response) error { return cw.Write(csvRecord(entry)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+		format = "csv"
+		if err := cw.Write(csvHeader); err != nil {
+			return
+		}
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		write = func(entry *Response) error { return enc.Encode(entry) }
+		flush = func() error { return nil }
+	default:
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_export_format")
+		return
+	}
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	// Headers are already sent once streaming starts, so a failure midway can
+	// only be logged; the truncated file is the client's signal
+	if err := h.service.Export(r.Context(), filter, write); err != nil {
+		logger.FromContext(r.Context()).Error().Err(err).Msg("Audit export failed")
+	}
+	if err := flush(); err != nil {
+		logger.FromContext(r.Context()).Error().Err(err).Msg("Failed to flush audit export")
+	}
+}
+
+var csvHeader = []string{
+	"id", "created_at", "actor_id", "action", "target_type", "target_id",
+	"changes", "metadata", "ip", "user_agent", "request_id",
+}
+
+func csvRecord(entry *Response) []string {
+	changes, _ := json.Marshal(entry.Changes)
+	metadata, _ := json.Marshal(entry.Metadata)
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt,
+		optionalID(entry.ActorID),
+		entry.Action,
+		entry.TargetType,
+		optionalID(entry.TargetID),
+		string(changes),
+		string(metadata),
+		entry.IP,
+		entry.UserAgent,
+		entry.RequestID,
+	}
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+	}
+
+	var err error
+	if filter.ActorID, err = parseOptionalID(query.Get("actor_id")); err != nil {
+		return Filter{}, err
+	}
+	if filter.TargetID, err = parseOptionalID(query.Get("target_id")); err != nil {
+		return Filter{}, err
+	}
+	if filter.From, err = parseOptionalTime(query.Get("from")); err != nil {
+		return Filter{}, err
+	}
+	if filter.To, err = parseOptionalTime(query.Get("to")); err != nil {
+		return Filter{}, err
+	}
+	return filter, nil
+}
+
+func parseOptionalID(value string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+	result := uint(id)
+	return &result, nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+	return &t, nil
+}
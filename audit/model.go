@@ -0,0 +1,30 @@
+package audit
+
+import "time"
+
+// Change is the value of a field before and after an action. A nil Before
+// means the field was set by the action, a nil After that it was removed.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Model is one audit log entry. Entries are append-only: the repository has
+// no update or delete.
+type Model struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	ActorID    *uint             `gorm:"index" json:"actor_id"`
+	Action     string            `gorm:"not null;size:100;index" json:"action"`
+	TargetType string            `gorm:"size:50;index:idx_audit_target" json:"target_type"`
+	TargetID   *uint             `gorm:"index:idx_audit_target" json:"target_id"`
+	Changes    map[string]Change `gorm:"serializer:json;type:jsonb" json:"changes"`
+	Metadata   map[string]string `gorm:"serializer:json;type:jsonb" json:"metadata"`
+	IP         string            `gorm:"size:64" json:"ip"`
+	UserAgent  string            `gorm:"size:512" json:"user_agent"`
+	RequestID  string            `gorm:"size:100" json:"request_id"`
+	CreatedAt  time.Time         `gorm:"index" json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"gorm.io/gorm"
+)
+
+// Filter narrows audit log queries. Zero values match everything.
+type Filter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   *uint
+	From       *time.Time
+	To         *time.Time
+}
+
+// Repository stores audit entries. It is append-only by design.
+type Repository interface {
+	Create(ctx context.Context, entry *Model) error
+	List(ctx context.Context, filter Filter, limit, offset int) ([]Model, error)
+	Count(ctx context.Context, filter Filter) (int64, error)
+	// ListAfter returns entries with an ID greater than afterID in ID order.
+	// Exports page with it so concurrent inserts do not shift pages.
+	ListAfter(ctx context.Context, filter Filter, afterID uint, limit int) ([]Model, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default, so an
+// entry recorded inside a transaction commits or rolls back with it
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+func (r *repository) Create(ctx context.Context, entry *Model) error {
+	if err := r.getDB(ctx).WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, filter Filter, limit, offset int) ([]Model, error) {
+	var entries []Model
+	query := applyFilter(r.getDB(ctx).WithContext(ctx).Model(&Model{}), filter)
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *repository) ListAfter(ctx context.Context, filter Filter, afterID uint, limit int) ([]Model, error) {
+	var entries []Model
+	query := applyFilter(r.getDB(ctx).WithContext(ctx).Model(&Model{}), filter)
+	if err := query.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit entries after %d: %w", afterID, err)
+	}
+	return entries, nil
+}
+
+func (r *repository) Count(ctx context.Context, filter Filter) (int64, error) {
+	var count int64
+	query := applyFilter(r.getDB(ctx).WithContext(ctx).Model(&Model{}), filter)
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+	return count, nil
+}
+
+func applyFilter(query *gorm.DB, filter Filter) *gorm.DB {
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+// exportBatchSize is how many entries an export reads per query
+const exportBatchSize = 500
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record stores an entry together with the actor and request details from
+// the context. Sensitive metadata is redacted before it is stored.
+func (s *Service) Record(ctx context.Context, entry Entry) error {
+	model := &Model{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		Changes:    entry.Changes,
+		Metadata:   Redact(entry.Metadata),
+	}
+	if model.ActorID == nil {
+		if actorID, ok := ActorFromContext(ctx); ok {
+			model.ActorID = &actorID
+		}
+	}
+	if entry.TargetID != 0 {
+		targetID := entry.TargetID
+		model.TargetID = &targetID
+	}
+	info := RequestFromContext(ctx)
+	model.IP = truncate(info.IP, 64)
+	model.UserAgent = truncate(info.UserAgent, 512)
+	model.RequestID = truncate(info.RequestID, 100)
+
+	return s.repo.Create(ctx, model)
+}
+
+func (s *Service) List(ctx context.Context, filter Filter, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "audit.Service.List")
+	defer span.End()
+
+	entries, err := s.repo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	responses := make([]Response, len(entries))
+	for i := range entries {
+		responses[i] = *toResponse(&entries[i])
+	}
+	return &ListResponse{
+		Entries: responses,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// Export calls fn for every entry matching the filter, oldest first, reading
+// the log in batches so large exports do not load it into memory
+func (s *Service) Export(ctx context.Context, filter Filter, fn func(*Response) error) error {
+	ctx, span := tracing.Start(ctx, "audit.Service.Export")
+	defer span.End()
+
+	var afterID uint
+	for {
+		entries, err := s.repo.ListAfter(ctx, filter, afterID, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to export audit entries: %w", err)
+		}
+		for i := range entries {
+			if err := fn(toResponse(&entries[i])); err != nil {
+				return err
+			}
+		}
+		if len(entries) < exportBatchSize {
+			return nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
+
+// truncate cuts client supplied values to their column size
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+func toResponse(entry *Model) *Response {
+	return &Response{
+		ID:         entry.ID,
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Changes:    entry.Changes,
+		Metadata:   entry.Metadata,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -1,11 +1,10 @@
 package auth
 
-import "errors"
+import "github.com/urdogan0000/social/internal/domain"
 
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUsernameExists     = errors.New("username already exists")
-	ErrEmailExists        = errors.New("email already exists")
-	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidCredentials = domain.ErrInvalidCredentials
+	ErrUsernameExists     = domain.ErrUsernameExists
+	ErrEmailExists        = domain.ErrEmailExists
+	ErrInvalidToken       = domain.ErrInvalidToken
 )
-
@@ -27,9 +27,9 @@ func NewHandler(service *Service) *Handler {
 // @Produce json
 // @Param user body RegisterRequest true "Registration request"
 // @Success 201 {object} AuthResponse
-// @Failure 400 {object} map[string]string
-// @Failure 409 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /auth/register [post]
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
@@ -45,24 +45,11 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.service.Register(r.Context(), req)
 	if err != nil {
-		if err == ErrUsernameExists || err == ErrEmailExists {
-			logger.Logger().Warn().
-				Str("username", req.Username).
-				Str("email", req.Email).
-				Msg("User registration failed: already exists")
-			httputil.RespondError(w, r, http.StatusConflict, "user_already_exists")
-			return
-		}
-		logger.Logger().Error().
-			Err(err).
-			Str("username", req.Username).
-			Str("email", req.Email).
-			Msg("Failed to register user")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_register_user")
+		httputil.RespondDomainError(w, r, err, "failed_to_register_user")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("user_id", response.User.ID).
 		Str("username", response.User.Username).
 		Str("email", response.User.Email).
@@ -78,9 +65,9 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param credentials body LoginRequest true "Login request"
 // @Success 200 {object} AuthResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /auth/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
@@ -96,22 +83,11 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.service.Login(r.Context(), req)
 	if err != nil {
-		if err == ErrInvalidCredentials {
-			logger.Logger().Warn().
-				Str("email", req.Email).
-				Msg("Login failed: invalid credentials")
-			httputil.RespondError(w, r, http.StatusUnauthorized, "invalid_credentials")
-			return
-		}
-		logger.Logger().Error().
-			Err(err).
-			Str("email", req.Email).
-			Msg("Failed to login")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_login")
+		httputil.RespondDomainError(w, r, err, "failed_to_login")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("user_id", response.User.ID).
 		Str("username", response.User.Username).
 		Str("email", response.User.Email).
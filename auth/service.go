@@ -2,11 +2,15 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/tracing"
 	"github.com/urdogan0000/social/users"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -26,8 +30,11 @@ func NewService(userRepo users.Repository, jwtSecret string, expirationHours int
 }
 
 func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Register")
+	defer span.End()
+
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
-	if err != nil && err != domain.ErrUserNotFound {
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
 		return nil, fmt.Errorf("failed to check username existence: %w", err)
 	}
 	if existingUser != nil {
@@ -35,7 +42,7 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthRespo
 	}
 
 	existingUser, err = s.userRepo.GetByEmail(ctx, req.Email)
-	if err != nil && err != domain.ErrUserNotFound {
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
 		return nil, fmt.Errorf("failed to check email existence: %w", err)
 	}
 	if existingUser != nil {
@@ -56,6 +63,14 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthRespo
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	metrics.UsersRegistered.Inc()
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &user.ID,
+		Action:     audit.ActionUserRegistered,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Changes:    audit.Diff(nil, user),
+	})
 
 	token, err := s.generateToken(user.ID, user.Email)
 	if err != nil {
@@ -73,15 +88,30 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthRespo
 }
 
 func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Login")
+	defer span.End()
+
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		if err == domain.ErrUserNotFound {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			metrics.Logins.WithLabelValues(metrics.LoginFailure).Inc()
+			audit.Record(ctx, audit.Entry{
+				Action:   audit.ActionLoginFailed,
+				Metadata: map[string]string{"email": req.Email, "reason": "unknown_email"},
+			})
 			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(req.Password)); err != nil {
+		metrics.Logins.WithLabelValues(metrics.LoginFailure).Inc()
+		audit.Record(ctx, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Metadata:   map[string]string{"email": req.Email, "reason": "wrong_password"},
+		})
 		return nil, ErrInvalidCredentials
 	}
 
@@ -89,6 +119,13 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
+	metrics.Logins.WithLabelValues(metrics.LoginSuccess).Inc()
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &user.ID,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
 
 	return &AuthResponse{
 		Token: token,
@@ -100,6 +137,19 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, e
 	}, nil
 }
 
+// IsAdmin reports whether the user currently has the admin role. The role is
+// read from the store rather than the token so revoking it takes effect at once.
+func (s *Service) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	return user.Role == users.RoleAdmin, nil
+}
+
 func (s *Service) generateToken(userID uint, email string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
@@ -0,0 +1,45 @@
+package bookmarks
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// Cursor marks where a page ended: the sort key of its last row and the id
+// that breaks ties between equal keys. Clients pass it back opaquely.
+type Cursor struct {
+	Key int64
+	ID  uint
+}
+
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.Key, 10) + ":" + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor from a previous page. An empty string starts at
+// the first page and yields nil.
+func ParseCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	key, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	c := &Cursor{}
+	if c.Key, err = strconv.ParseInt(key, 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsed, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c.ID = uint(parsed)
+	return c, nil
+}
@@ -0,0 +1,52 @@
+package bookmarks
+
+import "github.com/urdogan0000/social/posts"
+
+type CreateCollectionRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description,omitempty" validate:"max=1000"`
+}
+
+type UpdateCollectionRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
+
+// PutItemRequest adds a post to a collection or moves it there. Without a
+// position new posts go to the end and present ones stay where they are.
+type PutItemRequest struct {
+	Position *int `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+type CollectionResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Listings are paged with cursors: pass next_cursor as ?cursor= to get the
+// page after. It is left out on the last page.
+type CollectionListResponse struct {
+	Collections []CollectionResponse `json:"collections"`
+	NextCursor  string               `json:"next_cursor,omitempty"`
+	Limit       int                  `json:"limit"`
+}
+
+type PostListResponse struct {
+	Posts      []posts.Response `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Limit      int              `json:"limit"`
+}
+
+type ItemResponse struct {
+	Position int            `json:"position"`
+	Post     posts.Response `json:"post"`
+}
+
+type ItemListResponse struct {
+	Items      []ItemResponse `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Limit      int            `json:"limit"`
+}
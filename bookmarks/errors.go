@@ -0,0 +1,11 @@
+package bookmarks
+
+import "github.com/urdogan0000/social/internal/domain"
+
+var (
+	ErrCollectionNotFound = domain.ErrCollectionNotFound
+	ErrCollectionExists   = domain.ErrCollectionExists
+	ErrCollectionLimit    = domain.ErrCollectionLimit
+	ErrInvalidPosition    = domain.ErrInvalidPosition
+	ErrInvalidCursor      = domain.ErrInvalidCursor
+)
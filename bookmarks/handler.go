@@ -0,0 +1,463 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Bookmark godoc
+// @Summary Bookmark a post
+// @Description Save a post for later. Bookmarks are private; bookmarking a post twice has no effect
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/bookmark [put]
+func (h *Handler) Bookmark(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Bookmark(r.Context(), userID, uint(postID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_bookmark_post")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unbookmark godoc
+// @Summary Remove a bookmark
+// @Description Remove the bookmark of a post. Removing a missing bookmark has no effect
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/bookmark [delete]
+func (h *Handler) Unbookmark(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Unbookmark(r.Context(), userID, uint(postID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_unbookmark_post")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBookmarks godoc
+// @Summary Get my bookmarks
+// @Description Get the posts the authenticated user bookmarked, most recent first. Posts that were deleted or can no longer be read are left out
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} PostListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/bookmarks [get]
+func (h *Handler) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit, _ := httputil.GetPaginationParams(r)
+	result, err := h.service.GetBookmarks(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_bookmarks")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// CreateCollection godoc
+// @Summary Create a collection
+// @Description Create a named collection of posts. Names are unique per user
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param collection body CreateCollectionRequest true "Collection creation request"
+// @Success 201 {object} CollectionResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections [post]
+func (h *Handler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	collection, err := h.service.CreateCollection(r.Context(), userID, req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_create_collection")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("collection_id", collection.ID).
+		Uint("user_id", userID).
+		Msg("Collection created")
+	httputil.RespondJSON(w, http.StatusCreated, collection)
+}
+
+// GetCollections godoc
+// @Summary Get my collections
+// @Description Get the authenticated user's collections in the order they were created
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} CollectionListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections [get]
+func (h *Handler) GetCollections(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit, _ := httputil.GetPaginationParams(r)
+	result, err := h.service.GetCollections(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_collections")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// GetCollection godoc
+// @Summary Get a collection
+// @Description Get one of the authenticated user's collections
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Success 200 {object} CollectionResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id} [get]
+func (h *Handler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	collection, err := h.service.GetCollection(r.Context(), userID, uint(id))
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_collection")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, collection)
+}
+
+// UpdateCollection godoc
+// @Summary Update a collection
+// @Description Rename a collection or change its description
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param collection body UpdateCollectionRequest true "Collection update request"
+// @Success 200 {object} CollectionResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id} [patch]
+func (h *Handler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	var req UpdateCollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	collection, err := h.service.UpdateCollection(r.Context(), userID, uint(id), req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_update_collection")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, collection)
+}
+
+// DeleteCollection godoc
+// @Summary Delete a collection
+// @Description Delete a collection. The posts in it are not affected
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id} [delete]
+func (h *Handler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	if err := h.service.DeleteCollection(r.Context(), userID, uint(id)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_delete_collection")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("collection_id", uint(id)).Msg("Collection deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetItems godoc
+// @Summary Get collection items
+// @Description Get the posts in a collection in collection order. Posts that were deleted or can no longer be read are left out
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ItemListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id}/items [get]
+func (h *Handler) GetItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	limit, _ := httputil.GetPaginationParams(r)
+	result, err := h.service.GetItems(r.Context(), userID, uint(id), r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_collection_items")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// PutItem godoc
+// @Summary Add or move a collection item
+// @Description Add a post to a collection or move it to another position. Without a body new posts go to the end
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param postID path int true "Post ID"
+// @Param item body PutItemRequest false "Position in the collection, starting at 0"
+// @Success 200 {object} ItemResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id}/items/{postID} [put]
+func (h *Handler) PutItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	// The body is optional
+	var req PutItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	item, err := h.service.PutItem(r.Context(), userID, uint(id), uint(postID), req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_put_collection_item")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, item)
+}
+
+// RemoveItem godoc
+// @Summary Remove a collection item
+// @Description Take a post out of a collection. Removing a post that is not in it has no effect
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Collection ID"
+// @Param postID path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/collections/{id}/items/{postID} [delete]
+func (h *Handler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_collection_id")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.RemoveItem(r.Context(), userID, uint(id), uint(postID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_remove_collection_item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
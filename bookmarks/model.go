@@ -0,0 +1,41 @@
+package bookmarks
+
+import "time"
+
+// Model is a post a user saved for later. Bookmarks are private to the user.
+type Model struct {
+	UserID    uint      `gorm:"primaryKey;index:idx_bookmarks_user_created,priority:1" json:"user_id"`
+	PostID    uint      `gorm:"primaryKey;index" json:"post_id"`
+	CreatedAt time.Time `gorm:"index:idx_bookmarks_user_created,priority:2" json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "bookmarks"
+}
+
+// CollectionModel is a named, ordered list of posts kept by a user
+type CollectionModel struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_collections_user_name,priority:1" json:"user_id"`
+	Name        string    `gorm:"not null;size:100;uniqueIndex:idx_collections_user_name,priority:2" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (CollectionModel) TableName() string {
+	return "collections"
+}
+
+// CollectionItemModel places a post in a collection. Positions run from zero
+// without gaps.
+type CollectionItemModel struct {
+	CollectionID uint      `gorm:"primaryKey;index:idx_collection_items_position,priority:1" json:"collection_id"`
+	PostID       uint      `gorm:"primaryKey;index" json:"post_id"`
+	Position     int       `gorm:"not null;index:idx_collection_items_position,priority:2" json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (CollectionItemModel) TableName() string {
+	return "collection_items"
+}
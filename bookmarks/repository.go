@@ -0,0 +1,286 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/posts"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	domain.BookmarkRepository
+	Add(ctx context.Context, userID, postID uint) error
+	Remove(ctx context.Context, userID, postID uint) error
+	GetByUserID(ctx context.Context, userID uint, after *Cursor, limit int) ([]Model, error)
+	CreateCollection(ctx context.Context, collection *CollectionModel) error
+	GetCollection(ctx context.Context, userID, id uint) (*CollectionModel, error)
+	LockCollection(ctx context.Context, userID, id uint) (*CollectionModel, error)
+	GetCollections(ctx context.Context, userID uint, after *Cursor, limit int) ([]CollectionModel, error)
+	CountCollections(ctx context.Context, userID uint) (int64, error)
+	NameTaken(ctx context.Context, userID uint, name string, exceptID uint) (bool, error)
+	UpdateCollection(ctx context.Context, collection *CollectionModel) error
+	DeleteCollection(ctx context.Context, id uint) error
+	GetItem(ctx context.Context, collectionID, postID uint) (*CollectionItemModel, error)
+	CountItems(ctx context.Context, collectionID uint) (int64, error)
+	InsertItem(ctx context.Context, item *CollectionItemModel) error
+	RemoveItem(ctx context.Context, collectionID, postID uint) error
+	GetItems(ctx context.Context, collectionID, viewerID uint, after *Cursor, limit int) ([]CollectionItemModel, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+// readableBy restricts rows with a post_id column to posts that still exist
+// and that the user may read, so deleted and hidden posts drop out of lists
+func (r *repository) readableBy(table string, userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(table+".post_id IN (?)", r.db.Model(&posts.Model{}).Select("id").Scopes(posts.ReadableBy(userID)))
+	}
+}
+
+func (r *repository) BookmarkedPostIDs(ctx context.Context, userID domain.UserID, postIDs []domain.PostID) ([]domain.PostID, error) {
+	var ids []domain.PostID
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks of user %d: %w", userID, err)
+	}
+	return ids, nil
+}
+
+// Add bookmarks the post; bookmarking it again keeps the original time
+func (r *repository) Add(ctx context.Context, userID, postID uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Model{UserID: userID, PostID: postID}).Error; err != nil {
+		return fmt.Errorf("failed to bookmark post %d for user %d: %w", postID, userID, err)
+	}
+	return nil
+}
+
+func (r *repository) Remove(ctx context.Context, userID, postID uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&Model{}).Error; err != nil {
+		return fmt.Errorf("failed to remove bookmark of post %d for user %d: %w", postID, userID, err)
+	}
+	return nil
+}
+
+// GetByUserID returns the user's bookmarks of readable posts, newest first
+func (r *repository) GetByUserID(ctx context.Context, userID uint, after *Cursor, limit int) ([]Model, error) {
+	query := r.getDB(ctx).WithContext(ctx).
+		Where("bookmarks.user_id = ?", userID).
+		Scopes(r.readableBy("bookmarks", userID))
+	if after != nil {
+		query = query.Where("(bookmarks.created_at, bookmarks.post_id) < (?, ?)", time.UnixMicro(after.Key), after.ID)
+	}
+
+	var bookmarks []Model
+	if err := query.
+		Limit(limit).
+		Order("bookmarks.created_at DESC, bookmarks.post_id DESC").
+		Find(&bookmarks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks of user %d: %w", userID, err)
+	}
+	return bookmarks, nil
+}
+
+// CreateCollection stores the collection unless the user already has one with
+// its name. The unique index on (user_id, name) settles concurrent creates.
+func (r *repository) CreateCollection(ctx context.Context, collection *CollectionModel) error {
+	result := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(collection)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create collection: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCollectionExists
+	}
+	return nil
+}
+
+// GetCollection returns the user's collection. Other users' collections are
+// reported as not found since collections are private.
+func (r *repository) GetCollection(ctx context.Context, userID, id uint) (*CollectionModel, error) {
+	return r.getCollection(r.getDB(ctx).WithContext(ctx), userID, id)
+}
+
+// LockCollection is GetCollection that also locks the collection row until
+// the transaction ends, so item positions are rewritten by one writer at a time
+func (r *repository) LockCollection(ctx context.Context, userID, id uint) (*CollectionModel, error) {
+	return r.getCollection(r.getDB(ctx).WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), userID, id)
+}
+
+func (r *repository) getCollection(tx *gorm.DB, userID, id uint) (*CollectionModel, error) {
+	var collection CollectionModel
+	if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&collection).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, fmt.Errorf("failed to get collection %d: %w", id, err)
+	}
+	return &collection, nil
+}
+
+// GetCollections returns the user's collections, oldest first
+func (r *repository) GetCollections(ctx context.Context, userID uint, after *Cursor, limit int) ([]CollectionModel, error) {
+	query := r.getDB(ctx).WithContext(ctx).Where("user_id = ?", userID)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", time.UnixMicro(after.Key), after.ID)
+	}
+
+	var collections []CollectionModel
+	if err := query.
+		Limit(limit).
+		Order("created_at, id").
+		Find(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to get collections of user %d: %w", userID, err)
+	}
+	return collections, nil
+}
+
+func (r *repository) CountCollections(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&CollectionModel{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count collections of user %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+// NameTaken checks if another collection of the user has the name
+func (r *repository) NameTaken(ctx context.Context, userID uint, name string, exceptID uint) (bool, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&CollectionModel{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check collection name: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *repository) UpdateCollection(ctx context.Context, collection *CollectionModel) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(collection).
+		Select("name", "description").
+		Updates(collection).Error; err != nil {
+		return fmt.Errorf("failed to update collection %d: %w", collection.ID, err)
+	}
+	return nil
+}
+
+// DeleteCollection deletes the collection with its items
+func (r *repository) DeleteCollection(ctx context.Context, id uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.Where("collection_id = ?", id).Delete(&CollectionItemModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete items of collection %d: %w", id, err)
+	}
+	if err := tx.Delete(&CollectionModel{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete collection %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *repository) GetItem(ctx context.Context, collectionID, postID uint) (*CollectionItemModel, error) {
+	var item CollectionItemModel
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("collection_id = ? AND post_id = ?", collectionID, postID).
+		First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get post %d of collection %d: %w", postID, collectionID, err)
+	}
+	return &item, nil
+}
+
+func (r *repository) CountItems(ctx context.Context, collectionID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&CollectionItemModel{}).
+		Where("collection_id = ?", collectionID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count items of collection %d: %w", collectionID, err)
+	}
+	return count, nil
+}
+
+// InsertItem puts the item at its position and moves the items from there on
+// one place back. It must run inside a transaction holding LockCollection.
+func (r *repository) InsertItem(ctx context.Context, item *CollectionItemModel) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.Model(&CollectionItemModel{}).
+		Where("collection_id = ? AND position >= ?", item.CollectionID, item.Position).
+		UpdateColumn("position", gorm.Expr("position + 1")).Error; err != nil {
+		return fmt.Errorf("failed to make room in collection %d: %w", item.CollectionID, err)
+	}
+	if err := tx.Create(item).Error; err != nil {
+		return fmt.Errorf("failed to add post %d to collection %d: %w", item.PostID, item.CollectionID, err)
+	}
+	return nil
+}
+
+// RemoveItem takes the post out of the collection and closes the gap. It must
+// run inside a transaction holding LockCollection.
+func (r *repository) RemoveItem(ctx context.Context, collectionID, postID uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+
+	var item CollectionItemModel
+	result := tx.Clauses(clause.Returning{}).
+		Where("collection_id = ? AND post_id = ?", collectionID, postID).
+		Delete(&item)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove post %d from collection %d: %w", postID, collectionID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&CollectionItemModel{}).
+		Where("collection_id = ? AND position > ?", collectionID, item.Position).
+		UpdateColumn("position", gorm.Expr("position - 1")).Error; err != nil {
+		return fmt.Errorf("failed to close gap in collection %d: %w", collectionID, err)
+	}
+	return nil
+}
+
+// GetItems returns the items of readable posts in the collection, in order
+func (r *repository) GetItems(ctx context.Context, collectionID, viewerID uint, after *Cursor, limit int) ([]CollectionItemModel, error) {
+	query := r.getDB(ctx).WithContext(ctx).
+		Where("collection_items.collection_id = ?", collectionID).
+		Scopes(r.readableBy("collection_items", viewerID))
+	if after != nil {
+		query = query.Where("(collection_items.position, collection_items.post_id) > (?, ?)", after.Key, after.ID)
+	}
+
+	var items []CollectionItemModel
+	if err := query.
+		Limit(limit).
+		Order("collection_items.position, collection_items.post_id").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get items of collection %d: %w", collectionID, err)
+	}
+	return items, nil
+}
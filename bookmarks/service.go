@@ -0,0 +1,329 @@
+package bookmarks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/tracing"
+	"github.com/urdogan0000/social/posts"
+)
+
+type Service struct {
+	repo           Repository
+	postService    *posts.Service
+	transactionMgr db.TransactionManager
+}
+
+func NewService(repo Repository, postService *posts.Service, transactionMgr db.TransactionManager) *Service {
+	return &Service{
+		repo:           repo,
+		postService:    postService,
+		transactionMgr: transactionMgr,
+	}
+}
+
+// Bookmark saves a post the user may read. Bookmarking a post twice is a no-op.
+func (s *Service) Bookmark(ctx context.Context, userID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.Bookmark")
+	defer span.End()
+
+	if _, err := s.postService.GetByID(ctx, postID, userID); err != nil {
+		return err
+	}
+	return s.repo.Add(ctx, userID, postID)
+}
+
+// Unbookmark removes the bookmark, if any. It works for posts that were
+// deleted or hidden since.
+func (s *Service) Unbookmark(ctx context.Context, userID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.Unbookmark")
+	defer span.End()
+
+	return s.repo.Remove(ctx, userID, postID)
+}
+
+// GetBookmarks lists the bookmarked posts the user may still read, most
+// recently bookmarked first
+func (s *Service) GetBookmarks(ctx context.Context, userID uint, cursor string, limit int) (*PostListResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.GetBookmarks")
+	defer span.End()
+
+	after, err := ParseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := s.repo.GetByUserID(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks of user %d: %w", userID, err)
+	}
+
+	response := &PostListResponse{Limit: limit}
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[limit-1]
+		response.NextCursor = Cursor{Key: last.CreatedAt.UnixMicro(), ID: last.PostID}.String()
+	}
+
+	ids := make([]uint, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.PostID
+	}
+	if response.Posts, err = s.postService.GetByIDs(ctx, ids, userID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s *Service) CreateCollection(ctx context.Context, userID uint, req CreateCollectionRequest) (*CollectionResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.CreateCollection")
+	defer span.End()
+
+	name, err := domain.NormalizeCollectionName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountCollections(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count collections of user %d: %w", userID, err)
+	}
+	if count >= domain.MaxCollections {
+		return nil, ErrCollectionLimit
+	}
+
+	collection := &CollectionModel{
+		UserID:      userID,
+		Name:        name,
+		Description: req.Description,
+	}
+	if err := s.repo.CreateCollection(ctx, collection); err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	return s.toCollectionResponse(collection), nil
+}
+
+func (s *Service) GetCollection(ctx context.Context, userID, id uint) (*CollectionResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.GetCollection")
+	defer span.End()
+
+	collection, err := s.repo.GetCollection(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toCollectionResponse(collection), nil
+}
+
+// GetCollections lists the user's collections in the order they were created
+func (s *Service) GetCollections(ctx context.Context, userID uint, cursor string, limit int) (*CollectionListResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.GetCollections")
+	defer span.End()
+
+	after, err := ParseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := s.repo.GetCollections(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections of user %d: %w", userID, err)
+	}
+
+	response := &CollectionListResponse{Limit: limit}
+	if len(collections) > limit {
+		collections = collections[:limit]
+		last := collections[limit-1]
+		response.NextCursor = Cursor{Key: last.CreatedAt.UnixMicro(), ID: last.ID}.String()
+	}
+
+	response.Collections = make([]CollectionResponse, len(collections))
+	for i := range collections {
+		response.Collections[i] = *s.toCollectionResponse(&collections[i])
+	}
+	return response, nil
+}
+
+func (s *Service) UpdateCollection(ctx context.Context, userID, id uint, req UpdateCollectionRequest) (*CollectionResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.UpdateCollection")
+	defer span.End()
+
+	collection, err := s.repo.GetCollection(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := domain.NormalizeCollectionName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		taken, err := s.repo.NameTaken(ctx, userID, name, id)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrCollectionExists
+		}
+		collection.Name = name
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+
+	if err := s.repo.UpdateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+	return s.toCollectionResponse(collection), nil
+}
+
+func (s *Service) DeleteCollection(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.DeleteCollection")
+	defer span.End()
+
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.LockCollection(ctx, userID, id); err != nil {
+			return err
+		}
+		return s.repo.DeleteCollection(ctx, id)
+	})
+}
+
+// PutItem adds a post the user may read to the collection, or moves it when
+// it is there already. Positions past the end place the post last.
+func (s *Service) PutItem(ctx context.Context, userID, collectionID, postID uint, req PutItemRequest) (*ItemResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.PutItem")
+	defer span.End()
+
+	if req.Position != nil && *req.Position < 0 {
+		return nil, ErrInvalidPosition
+	}
+
+	post, err := s.postService.GetByID(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var position int
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.LockCollection(ctx, userID, collectionID); err != nil {
+			return err
+		}
+		existing, err := s.repo.GetItem(ctx, collectionID, postID)
+		if err != nil {
+			return err
+		}
+		count, err := s.repo.CountItems(ctx, collectionID)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			position = existing.Position
+			if req.Position == nil {
+				return nil
+			}
+			count--
+			if err := s.repo.RemoveItem(ctx, collectionID, postID); err != nil {
+				return err
+			}
+		} else if count >= domain.MaxCollectionItems {
+			return ErrCollectionLimit
+		}
+
+		position = int(count)
+		if req.Position != nil && int64(*req.Position) < count {
+			position = *req.Position
+		}
+		return s.repo.InsertItem(ctx, &CollectionItemModel{
+			CollectionID: collectionID,
+			PostID:       postID,
+			Position:     position,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put post %d in collection %d: %w", postID, collectionID, err)
+	}
+
+	return &ItemResponse{Position: position, Post: *post}, nil
+}
+
+func (s *Service) RemoveItem(ctx context.Context, userID, collectionID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.RemoveItem")
+	defer span.End()
+
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.LockCollection(ctx, userID, collectionID); err != nil {
+			return err
+		}
+		return s.repo.RemoveItem(ctx, collectionID, postID)
+	})
+}
+
+// GetItems lists the posts in the collection that the user may still read, in
+// collection order
+func (s *Service) GetItems(ctx context.Context, userID, collectionID uint, cursor string, limit int) (*ItemListResponse, error) {
+	ctx, span := tracing.Start(ctx, "bookmarks.Service.GetItems")
+	defer span.End()
+
+	after, err := ParseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetCollection(ctx, userID, collectionID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetItems(ctx, collectionID, userID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items of collection %d: %w", collectionID, err)
+	}
+
+	response := &ItemListResponse{Limit: limit}
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[limit-1]
+		response.NextCursor = Cursor{Key: int64(last.Position), ID: last.PostID}.String()
+	}
+
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.PostID
+	}
+	found, err := s.postService.GetByIDs(ctx, ids, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]posts.Response, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+	response.Items = make([]ItemResponse, 0, len(items))
+	for _, item := range items {
+		if post, ok := byID[item.PostID]; ok {
+			response.Items = append(response.Items, ItemResponse{Position: item.Position, Post: post})
+		}
+	}
+	return response, nil
+}
+
+// inTransaction runs fn in a transaction if a manager is available
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactionMgr != nil {
+		return s.transactionMgr.WithTransaction(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+func (s *Service) toCollectionResponse(collection *CollectionModel) *CollectionResponse {
+	return &CollectionResponse{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		CreatedAt:   collection.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   collection.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -16,30 +16,55 @@ package main
 // @description Enter your JWT token. You can enter just the token (e.g., "eyJhbGci...") or with "Bearer " prefix (e.g., "Bearer eyJhbGci...")
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/bookmarks"
 	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/follows"
+	"github.com/urdogan0000/social/idempotency"
 	"github.com/urdogan0000/social/internal/api"
 	"github.com/urdogan0000/social/internal/config"
 	"github.com/urdogan0000/social/internal/di"
+	"github.com/urdogan0000/social/internal/gql"
+	"github.com/urdogan0000/social/internal/health"
 	"github.com/urdogan0000/social/internal/i18n"
 	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/rpc"
+	"github.com/urdogan0000/social/media"
+	"github.com/urdogan0000/social/mentions"
+	"github.com/urdogan0000/social/polls"
 	"github.com/urdogan0000/social/posts"
+	"github.com/urdogan0000/social/tags"
 	"github.com/urdogan0000/social/users"
 	"go.uber.org/fx"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 func main() {
 	_ = godotenv.Load()
-	logger.Init("info")
+	logCfg := config.Load().Log
+	logger.Init(logCfg.Level, logCfg.Format)
 	i18n.Init()
 	fx.New(
 		di.Module,
 		fx.Invoke(registerHooks),
+		fx.Invoke(registerScheduler),
+		fx.Invoke(registerTagRanker),
+		fx.Invoke(registerPollCloser),
+		fx.Invoke(registerIdempotencySweeper),
+		fx.Invoke(registerMediaCollector),
+		fx.Invoke(registerMediaProcessor),
 		fx.Invoke(registerRoutes),
+		fx.Invoke(registerAdminServer),
+		fx.Invoke(registerGRPCServer),
 	).Run()
 }
 
@@ -70,22 +95,120 @@ func registerHooks(
 	})
 }
 
+func registerScheduler(lc fx.Lifecycle, scheduler *posts.Scheduler) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			scheduler.Start()
+			logger.Logger().Info().Msg("Post publish scheduler started")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return scheduler.Stop(ctx)
+		},
+	})
+}
+
+func registerTagRanker(lc fx.Lifecycle, ranker *tags.Ranker) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ranker.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return ranker.Stop(ctx)
+		},
+	})
+}
+
+func registerPollCloser(lc fx.Lifecycle, closer *polls.Closer) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			closer.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return closer.Stop(ctx)
+		},
+	})
+}
+
+func registerIdempotencySweeper(lc fx.Lifecycle, sweeper *idempotency.Sweeper) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			sweeper.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return sweeper.Stop(ctx)
+		},
+	})
+}
+
+func registerMediaCollector(lc fx.Lifecycle, collector *media.Collector) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			collector.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return collector.Stop(ctx)
+		},
+	})
+}
+
+func registerMediaProcessor(lc fx.Lifecycle, processor *media.Processor) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			processor.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return processor.Stop(ctx)
+		},
+	})
+}
+
 func registerRoutes(
 	lc fx.Lifecycle,
 	userHandler *users.Handler,
 	postHandler *posts.Handler,
 	commentHandler *comments.Handler,
+	bookmarkHandler *bookmarks.Handler,
+	pollHandler *polls.Handler,
+	followHandler *follows.Handler,
 	authHandler *auth.Handler,
 	authService *auth.Service,
+	auditHandler *audit.Handler,
+	graphQLHandler *gql.Handler,
+	mediaHandler *media.Handler,
+	mentionHandler *mentions.Handler,
+	tagHandler *tags.Handler,
+	idempotencyService *idempotency.Service,
+	healthRegistry *health.Registry,
 	cfg *config.Config,
-) {
+) error {
 	app := &api.Application{
-		Config:         *cfg,
-		UserHandler:    userHandler,
-		PostHandler:    postHandler,
-		CommentHandler: commentHandler,
-		AuthHandler:    authHandler,
-		AuthService:    authService,
+		Config:          *cfg,
+		UserHandler:     userHandler,
+		PostHandler:     postHandler,
+		CommentHandler:  commentHandler,
+		BookmarkHandler: bookmarkHandler,
+		PollHandler:     pollHandler,
+		FollowHandler:   followHandler,
+		AuthHandler:     authHandler,
+		AuthService:     authService,
+		AuditHandler:    auditHandler,
+		GraphQLHandler:  graphQLHandler,
+		MediaHandler:    mediaHandler,
+		MentionHandler:  mentionHandler,
+		TagHandler:      tagHandler,
+		Health:          healthRegistry,
+		Idempotency:     idempotencyService,
+	}
+
+	drainDelay, err := time.ParseDuration(cfg.Server.ShutdownDrainDelay)
+	if err != nil {
+		return err
 	}
 
 	var srv *http.Server
@@ -100,7 +223,7 @@ func registerRoutes(
 
 			go func() {
 				logger.Logger().Info().Str("addr", cfg.Server.Addr).Msg("Server starting")
-				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					logger.Logger().Fatal().Err(err).Msg("Server failed")
 				}
 			}()
@@ -108,8 +231,89 @@ func registerRoutes(
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			if srv != nil {
-				return srv.Shutdown(ctx)
+			if srv == nil {
+				return nil
+			}
+
+			// Fail readiness first and keep serving while load balancers
+			// notice, so in-flight and newly routed requests are not dropped
+			healthRegistry.SetDraining()
+			logger.Logger().Info().Dur("delay", drainDelay).Msg("Draining before shutdown")
+			select {
+			case <-time.After(drainDelay):
+			case <-ctx.Done():
+			}
+			return srv.Shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// registerAdminServer serves operational endpoints such as /metrics on a
+// separate listener that is not exposed to API clients
+func registerAdminServer(lc fx.Lifecycle, cfg *config.Config, healthRegistry *health.Registry) {
+	if cfg.Server.AdminAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/livez", healthRegistry.LiveHandler)
+	mux.HandleFunc("/readyz", healthRegistry.ReadyHandler)
+	srv := &http.Server{
+		Addr:    cfg.Server.AdminAddr,
+		Handler: mux,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				logger.Logger().Info().Str("addr", cfg.Server.AdminAddr).Msg("Admin server starting")
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Logger().Fatal().Err(err).Msg("Admin server failed")
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	})
+}
+
+// registerGRPCServer serves the gRPC API on its own listener
+func registerGRPCServer(lc fx.Lifecycle, cfg *config.Config, srv *grpc.Server, hub *rpc.EventHub) {
+	if cfg.Server.GRPCAddr == "" {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", cfg.Server.GRPCAddr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				logger.Logger().Info().Str("addr", cfg.Server.GRPCAddr).Msg("gRPC server starting")
+				if err := srv.Serve(lis); err != nil {
+					logger.Logger().Fatal().Err(err).Msg("gRPC server failed")
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// Event streams never finish on their own, so end them before
+			// waiting for in-flight calls
+			hub.Close()
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				srv.Stop()
 			}
 			return nil
 		},
@@ -117,9 +321,5 @@ func registerRoutes(
 }
 
 func runMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&users.Model{},
-		&posts.Model{},
-		&comments.Model{},
-	)
+	return db.AutoMigrate(di.SchemaModels()...)
 }
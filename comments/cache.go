@@ -0,0 +1,77 @@
+package comments
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/internal/events"
+)
+
+// CacheKey is the cache key of a single comment
+func CacheKey(id uint) string {
+	return "comments:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// cachedRepository serves GetByID from a read-through cache and drops entries
+// on every write it performs.
+type cachedRepository struct {
+	Repository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedRepository wraps repo with a read-through cache for comment lookups
+func NewCachedRepository(repo Repository, c cache.Cache, ttl time.Duration) Repository {
+	return &cachedRepository{Repository: repo, cache: c, ttl: ttl}
+}
+
+func (r *cachedRepository) GetByID(ctx context.Context, id uint) (*Model, error) {
+	return cache.GetOrLoad(ctx, r.cache, CacheKey(id), r.ttl, func(ctx context.Context) (*Model, error) {
+		return r.Repository.GetByID(ctx, id)
+	})
+}
+
+func (r *cachedRepository) Update(ctx context.Context, comment *Model) error {
+	err := r.Repository.Update(ctx, comment)
+	cache.Invalidate(ctx, r.cache, CacheKey(comment.ID))
+	return err
+}
+
+func (r *cachedRepository) Delete(ctx context.Context, id uint, version *uint) error {
+	err := r.Repository.Delete(ctx, id, version)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+func (r *cachedRepository) Pin(ctx context.Context, postID, id uint) ([]uint, error) {
+	unpinned, err := r.Repository.Pin(ctx, postID, id)
+	keys := []string{CacheKey(id)}
+	for _, unpinnedID := range unpinned {
+		keys = append(keys, CacheKey(unpinnedID))
+	}
+	cache.Invalidate(ctx, r.cache, keys...)
+	return unpinned, err
+}
+
+func (r *cachedRepository) Unpin(ctx context.Context, id uint) error {
+	err := r.Repository.Unpin(ctx, id)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+// RegisterCacheInvalidation drops cached comments once comment events are
+// published. Writes already invalidate inside the transaction, but a
+// concurrent read can repopulate the old row before commit; the events arrive
+// after commit.
+func RegisterCacheInvalidation(bus events.EventBus, c cache.Cache) {
+	bus.Subscribe(events.CommentUpdated{}.Type(), func(ctx context.Context, event events.Event) error {
+		cache.Invalidate(ctx, c, CacheKey(uint(event.(events.CommentUpdated).CommentID)))
+		return nil
+	})
+	bus.Subscribe(events.CommentDeleted{}.Type(), func(ctx context.Context, event events.Event) error {
+		cache.Invalidate(ctx, c, CacheKey(uint(event.(events.CommentDeleted).CommentID)))
+		return nil
+	})
+}
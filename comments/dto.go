@@ -1,21 +1,36 @@
 package comments
 
+import (
+	"time"
+
+	"github.com/urdogan0000/social/posts"
+)
+
 type CreateRequest struct {
 	PostID  uint   `json:"post_id" validate:"required"`
-	Content string `json:"content" validate:"required"`
+	Content string `json:"content" validate:"required,max=2000"`
 }
 
 type UpdateRequest struct {
-	Content *string `json:"content,omitempty" validate:"omitempty,min=1"`
+	Content *string `json:"content,omitempty" validate:"omitempty,min=1,max=2000"`
 }
 
 type Response struct {
-	ID        uint   `json:"id"`
-	PostID    uint   `json:"post_id"`
-	Content   string `json:"content"`
-	UserID    uint   `json:"user_id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID        uint            `json:"id"`
+	PostID    uint            `json:"post_id"`
+	Content   string          `json:"content"`
+	Mentions  []posts.Mention `json:"mentions"`
+	UserID    uint            `json:"user_id"`
+	Pinned    bool            `json:"pinned"`
+	Version   uint            `json:"version"`
+	CreatedAt string          `json:"created_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// LastModified returns UpdatedAt as a time for the Last-Modified header
+func (r *Response) LastModified() time.Time {
+	updatedAt, _ := time.Parse(time.RFC3339, r.UpdatedAt)
+	return updatedAt
 }
 
 type ListResponse struct {
@@ -8,8 +8,8 @@ import (
 )
 
 var (
-	ErrNotFound  = errors.Join(domain.ErrNotFound, errors.New("comment"))
-	ErrForbidden = errors.Join(domain.ErrForbidden, errors.New("you can only modify your own comments"))
+	ErrNotFound  = domain.ErrCommentNotFound
+	ErrForbidden = domain.ErrCommentForbidden
 )
 
 func IsNotFound(err error) bool {
@@ -24,17 +24,23 @@ func NewHandler(service *Service) *Handler {
 
 // CreateComment godoc
 // @Summary Create a new comment
-// @Description Create a new comment for a post
+// @Description Create a new comment for a post. Fails with 403 when the author disabled or locked comments, or limited replies to followers or mentioned users
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param postID path int true "Post ID"
 // @Param comment body CreateRequest true "Comment creation request"
+// @Param Idempotency-Key header string false "Makes retries safe: a retry with the same key and body replays the first response"
 // @Success 201 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} Idempotent-Replayed "true when the response is a replay"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/{postID}/comments [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -64,16 +70,11 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	comment, err := h.service.Create(r.Context(), userID, req)
 	if err != nil {
-		logger.Logger().Error().
-			Err(err).
-			Uint("user_id", userID).
-			Uint("post_id", req.PostID).
-			Msg("Failed to create comment")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_create_comment")
+		httputil.RespondDomainError(w, r, err, "failed_to_create_comment")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("comment_id", comment.ID).
 		Uint("user_id", userID).
 		Uint("post_id", req.PostID).
@@ -83,7 +84,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 // GetCommentsByPostID godoc
 // @Summary Get comments by post ID
-// @Description Get all comments for a specific post with pagination
+// @Description Get all comments for a specific post with pagination. The comment pinned by the post's author comes first. Fails with 403 when the author disabled comments
 // @Tags comments
 // @Accept json
 // @Produce json
@@ -91,8 +92,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/{postID}/comments [get]
 func (h *Handler) GetByPostID(w http.ResponseWriter, r *http.Request) {
 	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
@@ -102,13 +105,14 @@ func (h *Handler) GetByPostID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	limit, offset := httputil.GetPaginationParams(r)
-	result, err := h.service.GetByPostID(r.Context(), uint(postID), limit, offset)
+	viewerID, _ := middleware.GetUserID(r.Context())
+	result, err := h.service.GetByPostID(r.Context(), uint(postID), viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_comments")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_comments")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, result)
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
 }
 
 // GetComment godoc
@@ -118,10 +122,14 @@ func (h *Handler) GetByPostID(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Comment ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Header 200 {string} Last-Modified "Time of the last change"
+// @Success 304 "Not modified"
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /comments/{id} [get]
 func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
@@ -130,16 +138,16 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comment, err := h.service.GetByID(r.Context(), uint(id))
+	viewerID, _ := middleware.GetUserID(r.Context())
+	comment, err := h.service.GetByID(r.Context(), uint(id), viewerID)
 	if err != nil {
-		if err == ErrNotFound {
-			httputil.RespondError(w, r, http.StatusNotFound, "comment_not_found")
-			return
-		}
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_comment")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_comment")
 		return
 	}
 
+	if httputil.NotModified(w, r, httputil.ETag(comment.Version), comment.LastModified()) {
+		return
+	}
 	httputil.RespondJSON(w, http.StatusOK, comment)
 }
 
@@ -152,12 +160,16 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Security BearerAuth
 // @Param id path int true "Comment ID"
 // @Param comment body UpdateRequest true "Comment update request"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /comments/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -172,6 +184,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
 	var req UpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
@@ -183,30 +201,17 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comment, err := h.service.Update(r.Context(), uint(id), userID, req)
+	comment, err := h.service.Update(r.Context(), uint(id), userID, req, expectedVersion)
 	if err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("comment_id", uint(id)).Msg("Comment update failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "comment_not_found")
-			return
-		}
-		if err == ErrForbidden {
-			logger.Logger().Warn().
-				Uint("comment_id", uint(id)).
-				Uint("user_id", userID).
-				Msg("Comment update failed: forbidden")
-			httputil.RespondError(w, r, http.StatusForbidden, "forbidden")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("comment_id", uint(id)).Msg("Failed to update comment")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_update_comment")
+		httputil.RespondDomainError(w, r, err, "failed_to_update_comment")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("comment_id", comment.ID).
 		Uint("user_id", userID).
 		Msg("Comment updated successfully")
+	httputil.SetETag(w, comment.Version)
 	httputil.RespondJSON(w, http.StatusOK, comment)
 }
 
@@ -218,12 +223,15 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Comment ID"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 204
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /comments/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -238,26 +246,97 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(r.Context(), uint(id), userID); err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("comment_id", uint(id)).Msg("Comment delete failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "comment_not_found")
-			return
-		}
-		if err == ErrForbidden {
-			logger.Logger().Warn().
-				Uint("comment_id", uint(id)).
-				Uint("user_id", userID).
-				Msg("Comment delete failed: forbidden")
-			httputil.RespondError(w, r, http.StatusForbidden, "forbidden")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("comment_id", uint(id)).Msg("Failed to delete comment")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_delete_comment")
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), uint(id), userID, expectedVersion); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_delete_comment")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("comment_id", uint(id)).Msg("Comment deleted successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PinComment godoc
+// @Summary Pin a comment
+// @Description Pin a comment on one of your posts so it is listed first. A post has at most one pinned comment; pinning another replaces it
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /comments/{id}/pin [put]
+func (h *Handler) Pin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_comment_id")
+		return
+	}
+
+	comment, err := h.service.Pin(r.Context(), uint(id), userID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_pin_comment")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("comment_id", comment.ID).
+		Uint("post_id", comment.PostID).
+		Uint("user_id", userID).
+		Msg("Comment pinned")
+	httputil.RespondJSON(w, http.StatusOK, comment)
+}
+
+// UnpinComment godoc
+// @Summary Unpin a comment
+// @Description Unpin a comment on one of your posts
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /comments/{id}/pin [delete]
+func (h *Handler) Unpin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_comment_id")
+		return
+	}
+
+	if err := h.service.Unpin(r.Context(), uint(id), userID); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_unpin_comment")
 		return
 	}
 
-	logger.Logger().Info().Uint("comment_id", uint(id)).Msg("Comment deleted successfully")
+	logger.FromContext(r.Context()).Info().Uint("comment_id", uint(id)).Uint("user_id", userID).Msg("Comment unpinned")
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -270,16 +349,17 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListResponse
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} httputil.Problem
 // @Router /comments [get]
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	limit, offset := httputil.GetPaginationParams(r)
+	viewerID, _ := middleware.GetUserID(r.Context())
 
-	result, err := h.service.List(r.Context(), limit, offset)
+	result, err := h.service.List(r.Context(), viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_list_comments")
+		httputil.RespondDomainError(w, r, err, "failed_to_list_comments")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, result)
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
 }
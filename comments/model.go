@@ -3,14 +3,18 @@ package comments
 import (
 	"time"
 
+	"github.com/urdogan0000/social/posts"
 	"gorm.io/gorm"
 )
 
 type Model struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	PostID    uint           `gorm:"not null;index" json:"post_id"`
+	PostID    uint           `gorm:"not null;index;uniqueIndex:idx_comments_pinned_post,where:pinned AND deleted_at IS NULL" json:"post_id"`
 	Content   string         `gorm:"type:text;not null" json:"content"`
+	Mentions  posts.Mentions `gorm:"type:jsonb" json:"mentions"`
 	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	Pinned    bool           `gorm:"not null;default:false" json:"pinned"`
+	Version   uint           `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
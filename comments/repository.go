@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/posts"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
@@ -13,10 +17,16 @@ type Repository interface {
 	GetByID(ctx context.Context, id uint) (*Model, error)
 	GetByPostID(ctx context.Context, postID uint, limit, offset int) ([]Model, error)
 	Update(ctx context.Context, comment *Model) error
-	Delete(ctx context.Context, id uint) error
-	List(ctx context.Context, limit, offset int) ([]Model, error)
-	Count(ctx context.Context) (int64, error)
+	// Delete removes the comment. A non-nil version makes the delete conditional
+	// on the stored version, failing with domain.ErrVersionMismatch otherwise.
+	Delete(ctx context.Context, id uint, version *uint) error
+	List(ctx context.Context, viewerID uint, limit, offset int) ([]Model, error)
+	Count(ctx context.Context, viewerID uint) (int64, error)
 	CountByPostID(ctx context.Context, postID uint) (int64, error)
+	// Pin makes the comment the one pinned on its post and returns the
+	// comments it unpinned
+	Pin(ctx context.Context, postID, id uint) ([]uint, error)
+	Unpin(ctx context.Context, id uint) error
 }
 
 type repository struct {
@@ -27,8 +37,16 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
 func (r *repository) Create(ctx context.Context, comment *Model) error {
-	if err := r.db.WithContext(ctx).Create(comment).Error; err != nil {
+	if comment.Version == 0 {
+		comment.Version = 1
+	}
+	if err := r.getDB(ctx).WithContext(ctx).Create(comment).Error; err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 	return nil
@@ -36,7 +54,7 @@ func (r *repository) Create(ctx context.Context, comment *Model) error {
 
 func (r *repository) GetByID(ctx context.Context, id uint) (*Model, error) {
 	var comment Model
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&comment).Error; err != nil {
+	if err := r.getDB(ctx).WithContext(ctx).Where("id = ?", id).First(&comment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
 		}
@@ -45,38 +63,123 @@ func (r *repository) GetByID(ctx context.Context, id uint) (*Model, error) {
 	return &comment, nil
 }
 
+// GetByPostID lists the comments of a post with the pinned one first and the
+// rest newest first
 func (r *repository) GetByPostID(ctx context.Context, postID uint, limit, offset int) ([]Model, error) {
 	var comments []Model
-	query := r.db.WithContext(ctx).Where("post_id = ?", postID)
+	query := r.getDB(ctx).WithContext(ctx).Where("post_id = ?", postID)
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
 	if offset > 0 {
 		query = query.Offset(offset)
 	}
-	if err := query.Order("created_at DESC").Find(&comments).Error; err != nil {
+	if err := query.Order("pinned DESC, created_at DESC").Find(&comments).Error; err != nil {
 		return nil, fmt.Errorf("failed to get comments by post id: %w", err)
 	}
 	return comments, nil
 }
 
+// Update saves the comment only if the stored version is still the one it was
+// read with, and bumps the version. A concurrent write in between makes it fail
+// with domain.ErrVersionMismatch instead of being silently overwritten. The pin
+// is left alone; Pin and Unpin maintain it.
 func (r *repository) Update(ctx context.Context, comment *Model) error {
-	if err := r.db.WithContext(ctx).Save(comment).Error; err != nil {
-		return fmt.Errorf("failed to update comment: %w", err)
+	version := comment.Version
+	comment.Version++
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(comment).
+		Where("version = ?", version).
+		Select("*").
+		Omit("created_at", "pinned").
+		Updates(comment)
+	if result.Error != nil {
+		comment.Version = version
+		return fmt.Errorf("failed to update comment %d: %w", comment.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		comment.Version = version
+		return domain.ErrVersionMismatch
 	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&Model{}, id).Error; err != nil {
-		return fmt.Errorf("failed to delete comment: %w", err)
+// Delete soft-deletes the comment and unpins it, so the post can pin another
+func (r *repository) Delete(ctx context.Context, id uint, version *uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	query := tx.Where("id = ?", id)
+	if version != nil {
+		query = query.Where("version = ?", *version)
+	}
+	result := query.Delete(&Model{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete comment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if version != nil {
+			return domain.ErrVersionMismatch
+		}
+		return ErrNotFound
+	}
+	if err := tx.Unscoped().Model(&Model{}).Where("id = ?", id).UpdateColumn("pinned", false).Error; err != nil {
+		return fmt.Errorf("failed to unpin comment: %w", err)
 	}
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, limit, offset int) ([]Model, error) {
+// Pin must run inside a transaction: the post row is locked so concurrent
+// pins on the same post take turns instead of tripping the unique index that
+// allows one pinned comment per post. Pinning does not bump the version.
+func (r *repository) Pin(ctx context.Context, postID, id uint) ([]uint, error) {
+	tx := r.getDB(ctx).WithContext(ctx)
+
+	var locked []uint
+	if err := tx.Model(&posts.Model{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", postID).
+		Pluck("id", &locked).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock post %d: %w", postID, err)
+	}
+
+	var unpinned []Model
+	if err := tx.Model(&unpinned).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("post_id = ? AND pinned AND id <> ?", postID, id).
+		UpdateColumn("pinned", false).Error; err != nil {
+		return nil, fmt.Errorf("failed to unpin comments of post %d: %w", postID, err)
+	}
+	if err := tx.Model(&Model{}).Where("id = ?", id).UpdateColumn("pinned", true).Error; err != nil {
+		return nil, fmt.Errorf("failed to pin comment %d: %w", id, err)
+	}
+
+	ids := make([]uint, len(unpinned))
+	for i := range unpinned {
+		ids[i] = unpinned[i].ID
+	}
+	return ids, nil
+}
+
+func (r *repository) Unpin(ctx context.Context, id uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ?", id).
+		UpdateColumn("pinned", false).Error; err != nil {
+		return fmt.Errorf("failed to unpin comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// onListablePosts restricts comments to those whose post appears in the
+// viewer's listings and has not disabled its comments
+func (r *repository) onListablePosts(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("post_id IN (?)", r.db.Model(&posts.Model{}).Select("id").Scopes(posts.ListableBy(viewerID), posts.TakesComments))
+	}
+}
+
+func (r *repository) List(ctx context.Context, viewerID uint, limit, offset int) ([]Model, error) {
 	var comments []Model
-	query := r.db.WithContext(ctx)
+	query := r.getDB(ctx).WithContext(ctx).Scopes(r.onListablePosts(viewerID))
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -89,9 +192,9 @@ func (r *repository) List(ctx context.Context, limit, offset int) ([]Model, erro
 	return comments, nil
 }
 
-func (r *repository) Count(ctx context.Context) (int64, error) {
+func (r *repository) Count(ctx context.Context, viewerID uint) (int64, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&Model{}).Count(&count).Error; err != nil {
+	if err := r.getDB(ctx).WithContext(ctx).Model(&Model{}).Scopes(r.onListablePosts(viewerID)).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count comments: %w", err)
 	}
 	return count, nil
@@ -99,7 +202,7 @@ func (r *repository) Count(ctx context.Context) (int64, error) {
 
 func (r *repository) CountByPostID(ctx context.Context, postID uint) (int64, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&Model{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
+	if err := r.getDB(ctx).WithContext(ctx).Model(&Model{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count comments by post id: %w", err)
 	}
 	return count, nil
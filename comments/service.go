@@ -2,57 +2,136 @@ package comments
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/internal/db"
 	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/tracing"
+	"github.com/urdogan0000/social/posts"
 )
 
+// Service writes comments through repo. Reads that need no model, such as the
+// permission checks of pinning and comment counts, go through commentRepo.
 type Service struct {
 	repo           Repository
+	commentRepo    domain.CommentRepository
 	userRepo       domain.UserRepository
+	postRepo       domain.PostRepository
+	followRepo     domain.FollowRepository
+	mentionRepo    domain.MentionRepository
 	eventBus       events.EventBus
 	transactionMgr db.TransactionManager
 }
 
-func NewService(repo Repository, userRepo domain.UserRepository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
+func NewService(repo Repository, commentRepo domain.CommentRepository, userRepo domain.UserRepository, postRepo domain.PostRepository, followRepo domain.FollowRepository, mentionRepo domain.MentionRepository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
 	return &Service{
 		repo:           repo,
+		commentRepo:    commentRepo,
 		userRepo:       userRepo,
+		postRepo:       postRepo,
+		followRepo:     followRepo,
+		mentionRepo:    mentionRepo,
 		eventBus:       eventBus,
 		transactionMgr: transactionMgr,
 	}
 }
 
 func (s *Service) Create(ctx context.Context, userID uint, req CreateRequest) (*Response, error) {
-	comment := &Model{
-		PostID:  req.PostID,
+	ctx, span := tracing.Start(ctx, "comments.Service.Create")
+	defer span.End()
+
+	comment := &domain.Comment{
+		PostID:  domain.PostID(req.PostID),
+		UserID:  domain.UserID(userID),
 		Content: req.Content,
-		UserID:  userID,
 	}
-	if err := s.repo.Create(ctx, comment); err != nil {
+	if err := comment.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Comments inherit the parent post's visibility and follow its
+	// comment settings
+	post, err := s.getVisiblePost(ctx, req.PostID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := post.CheckCommenter(ctx, comment.UserID, s.followRepo); err != nil {
+		return nil, fmt.Errorf("failed to comment on post %d: %w", req.PostID, err)
+	}
+
+	comment.Mentions, err = domain.ResolveMentions(ctx, comment.Content, s.userRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+
+	model := s.domainToModel(comment)
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, model); err != nil {
+			return err
+		}
+		return s.indexMentions(ctx, model)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
-	response := s.toResponse(comment)
+
+	metrics.CommentsCreated.Inc()
+	// Events go out only after the transaction committed
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.CommentCreated{
+			CommentID: domain.CommentID(model.ID),
+			PostID:    domain.PostID(model.PostID),
+			UserID:    domain.UserID(model.UserID),
+		})
+	}
+	s.notifyMentions(ctx, model, nil)
+	response := s.toResponse(model)
 	return &response, nil
 }
 
-func (s *Service) GetByID(ctx context.Context, id uint) (*Response, error) {
+func (s *Service) GetByID(ctx context.Context, id uint, viewerID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "comments.Service.GetByID")
+	defer span.End()
+
 	comment, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comment by id: %w", err)
 	}
+	post, err := s.getVisiblePost(ctx, comment.PostID, viewerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPostNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !post.CommentsVisible() {
+		return nil, ErrNotFound
+	}
 	response := s.toResponse(comment)
 	return &response, nil
 }
 
-func (s *Service) GetByPostID(ctx context.Context, postID uint, limit, offset int) (*ListResponse, error) {
+func (s *Service) GetByPostID(ctx context.Context, postID, viewerID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "comments.Service.GetByPostID")
+	defer span.End()
+
+	post, err := s.getVisiblePost(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !post.CommentsVisible() {
+		return nil, domain.ErrCommentsDisabled
+	}
+
 	comments, err := s.repo.GetByPostID(ctx, postID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comments by post id: %w", err)
 	}
-	total, err := s.repo.CountByPostID(ctx, postID)
+	total, err := s.commentRepo.CountByPostID(ctx, domain.PostID(postID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to count comments by post id: %w", err)
 	}
@@ -68,55 +147,179 @@ func (s *Service) GetByPostID(ctx context.Context, postID uint, limit, offset in
 	}, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRequest) (*Response, error) {
-	comment, err := s.repo.GetByID(ctx, id)
+// Update applies the request to the comment. A non-nil expectedVersion makes
+// the update conditional on the version the client last saw.
+func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRequest, expectedVersion *uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "comments.Service.Update")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get comment by id: %w", err)
 	}
 
-	// Check if user owns the comment
-	if comment.UserID != userID {
+	// Check permission using domain method
+	comment := s.modelToDomain(model)
+	if !comment.CanBeEditedBy(domain.UserID(userID)) {
 		return nil, ErrForbidden
 	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Update content if provided
+	notified := model.Mentions.Domain()
 	if req.Content != nil {
-		comment.Content = *req.Content
+		if err := comment.UpdateContent(*req.Content); err != nil {
+			return nil, fmt.Errorf("failed to update content: %w", err)
+		}
+		comment.Mentions, err = domain.ResolveMentions(ctx, comment.Content, s.userRepo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+		}
 	}
 
-	if err := s.repo.Update(ctx, comment); err != nil {
-		return nil, fmt.Errorf("failed to update comment: %w", err)
+	updatedModel := s.domainToModel(comment)
+	updatedModel.ID = model.ID
+	updatedModel.Version = model.Version
+	updatedModel.CreatedAt = model.CreatedAt
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, updatedModel); err != nil {
+			return err
+		}
+		if req.Content != nil {
+			return s.indexMentions(ctx, updatedModel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment %d: %w", id, err)
 	}
 
-	response := s.toResponse(comment)
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.CommentUpdated{
+			CommentID: comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+		})
+	}
+	s.notifyMentions(ctx, updatedModel, notified)
+
+	response := s.toResponse(updatedModel)
 	return &response, nil
 }
 
-func (s *Service) Delete(ctx context.Context, id uint, userID uint) error {
-	comment, err := s.repo.GetByID(ctx, id)
+func (s *Service) Delete(ctx context.Context, id uint, userID uint, expectedVersion *uint) error {
+	ctx, span := tracing.Start(ctx, "comments.Service.Delete")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get comment by id: %w", err)
 	}
 
-	// Check if user owns the comment
-	if comment.UserID != userID {
+	// Check permission using domain method
+	comment := s.modelToDomain(model)
+	if !comment.CanBeDeletedBy(domain.UserID(userID)) {
 		return ErrForbidden
 	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return err
+	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete comment: %w", err)
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, id, expectedVersion)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete comment %d: %w", id, err)
+	}
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &userID,
+		Action:     audit.ActionCommentDeleted,
+		TargetType: audit.TargetComment,
+		TargetID:   id,
+		Changes:    audit.Diff(model, nil),
+	})
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.CommentDeleted{
+			CommentID: comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+		})
 	}
 
 	return nil
 }
 
-func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, error) {
-	comments, err := s.repo.List(ctx, limit, offset)
+// Pin pins the comment on its post, replacing the comment pinned before. Only
+// the author of the post may pin comments on it.
+func (s *Service) Pin(ctx context.Context, id, userID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "comments.Service.Pin")
+	defer span.End()
+
+	comment, err := s.getForPostAuthor(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.repo.Pin(ctx, uint(comment.PostID), id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin comment %d: %w", id, err)
+	}
+
+	model, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment by id: %w", err)
+	}
+	response := s.toResponse(model)
+	return &response, nil
+}
+
+// Unpin takes the comment off the top of its post. Only the author of the
+// post may unpin comments on it.
+func (s *Service) Unpin(ctx context.Context, id, userID uint) error {
+	ctx, span := tracing.Start(ctx, "comments.Service.Unpin")
+	defer span.End()
+
+	if _, err := s.getForPostAuthor(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Unpin(ctx, id); err != nil {
+		return fmt.Errorf("failed to unpin comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// getForPostAuthor returns the comment if userID wrote the post it is on
+func (s *Service) getForPostAuthor(ctx context.Context, id, userID uint) (*domain.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, domain.CommentID(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment by id: %w", err)
+	}
+	post, err := s.postRepo.GetByID(ctx, comment.PostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", comment.PostID, err)
+	}
+	if !post.CanBeEditedBy(domain.UserID(userID)) {
+		return nil, domain.ErrPostForbidden
+	}
+	return comment, nil
+}
+
+func (s *Service) List(ctx context.Context, viewerID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "comments.Service.List")
+	defer span.End()
+
+	comments, err := s.repo.List(ctx, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list comments: %w", err)
 	}
 
-	total, err := s.repo.Count(ctx)
+	total, err := s.repo.Count(ctx, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count comments: %w", err)
 	}
@@ -134,12 +337,106 @@ func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, e
 	}, nil
 }
 
+// inTransaction runs fn in a transaction if a manager is available
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactionMgr != nil {
+		return s.transactionMgr.WithTransaction(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+// getVisiblePost returns the parent post, or domain.ErrPostNotFound when it
+// does not exist or the viewer is not allowed to read it
+func (s *Service) getVisiblePost(ctx context.Context, postID, viewerID uint) (*domain.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(postID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+
+	visible, err := post.IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post visibility: %w", err)
+	}
+	if !visible {
+		return nil, domain.ErrPostNotFound
+	}
+	return post, nil
+}
+
+// indexMentions stores who the comment mentions for their mention lists
+func (s *Service) indexMentions(ctx context.Context, comment *Model) error {
+	if s.mentionRepo == nil {
+		return nil
+	}
+	source := domain.MentionSource{
+		PostID:    domain.PostID(comment.PostID),
+		CommentID: domain.CommentID(comment.ID),
+		AuthorID:  domain.UserID(comment.UserID),
+	}
+	userIDs := domain.MentionedUsers(comment.Mentions.Domain(), source.AuthorID)
+	if err := s.mentionRepo.Replace(ctx, source, userIDs); err != nil {
+		return fmt.Errorf("failed to store mentions: %w", err)
+	}
+	return nil
+}
+
+// notifyMentions fires UserMentioned for the users the comment mentions that
+// are not in notified and may read the post it is on
+func (s *Service) notifyMentions(ctx context.Context, comment *Model, notified []domain.Mention) {
+	authorID := domain.UserID(comment.UserID)
+	userIDs := domain.NewlyMentioned(notified, comment.Mentions.Domain(), authorID)
+	if s.eventBus == nil || len(userIDs) == 0 {
+		return
+	}
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(comment.PostID))
+	if err != nil {
+		return
+	}
+	for _, userID := range userIDs {
+		if visible, err := post.IsVisibleTo(ctx, userID, s.followRepo); err != nil || !visible {
+			continue
+		}
+		_ = s.eventBus.Publish(ctx, events.UserMentioned{
+			UserID:    userID,
+			AuthorID:  authorID,
+			PostID:    post.ID,
+			CommentID: domain.CommentID(comment.ID),
+		})
+	}
+}
+
+// domainToModel converts domain Comment to repository Model
+func (s *Service) domainToModel(comment *domain.Comment) *Model {
+	return &Model{
+		PostID:   uint(comment.PostID),
+		Content:  comment.Content,
+		Mentions: posts.NewMentions(comment.Mentions),
+		UserID:   uint(comment.UserID),
+		Pinned:   comment.Pinned,
+	}
+}
+
+// modelToDomain converts repository Model to domain Comment
+func (s *Service) modelToDomain(model *Model) *domain.Comment {
+	return &domain.Comment{
+		ID:       domain.CommentID(model.ID),
+		PostID:   domain.PostID(model.PostID),
+		UserID:   domain.UserID(model.UserID),
+		Content:  model.Content,
+		Mentions: model.Mentions.Domain(),
+		Pinned:   model.Pinned,
+	}
+}
+
 func (s *Service) toResponse(comment *Model) Response {
 	return Response{
 		ID:        comment.ID,
 		PostID:    comment.PostID,
 		Content:   comment.Content,
+		Mentions:  append([]posts.Mention{}, comment.Mentions...),
 		UserID:    comment.UserID,
+		Pinned:    comment.Pinned,
+		Version:   comment.Version,
 		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: comment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -0,0 +1,8 @@
+package follows
+
+import "github.com/urdogan0000/social/internal/domain"
+
+var (
+	ErrCannotFollowSelf = domain.ErrCannotFollowSelf
+	ErrUserNotFound     = domain.ErrUserNotFound
+)
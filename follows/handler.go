@@ -0,0 +1,98 @@
+package follows
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// FollowUser godoc
+// @Summary Follow user
+// @Description Follow a user so their followers-only posts become visible
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/{id}/follow [put]
+func (h *Handler) Follow(w http.ResponseWriter, r *http.Request) {
+	followerID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	if err := h.service.Follow(r.Context(), followerID, uint(id)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_follow_user")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("follower_id", followerID).
+		Uint("followee_id", uint(id)).
+		Msg("User followed successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnfollowUser godoc
+// @Summary Unfollow user
+// @Description Stop following a user
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/{id}/follow [delete]
+func (h *Handler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	followerID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	if err := h.service.Unfollow(r.Context(), followerID, uint(id)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_unfollow_user")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("follower_id", followerID).
+		Uint("followee_id", uint(id)).
+		Msg("User unfollowed successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,14 @@
+package follows
+
+import "time"
+
+// Model stores a directed follower relationship between two users
+type Model struct {
+	FollowerID uint      `gorm:"primaryKey" json:"follower_id"`
+	FolloweeID uint      `gorm:"primaryKey;index" json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "follows"
+}
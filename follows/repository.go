@@ -0,0 +1,58 @@
+package follows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(ctx context.Context, follow *Model) error
+	Delete(ctx context.Context, followerID, followeeID uint) error
+	Exists(ctx context.Context, followerID, followeeID uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+func (r *repository) Create(ctx context.Context, follow *Model) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(follow).Error; err != nil {
+		return fmt.Errorf("failed to create follow %d -> %d: %w", follow.FollowerID, follow.FolloweeID, err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, followerID, followeeID uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&Model{}).Error; err != nil {
+		return fmt.Errorf("failed to delete follow %d -> %d: %w", followerID, followeeID, err)
+	}
+	return nil
+}
+
+func (r *repository) Exists(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check follow %d -> %d: %w", followerID, followeeID, err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,64 @@
+package follows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+type Service struct {
+	repo     Repository
+	userRepo domain.UserRepository
+}
+
+func NewService(repo Repository, userRepo domain.UserRepository) *Service {
+	return &Service{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *Service) Follow(ctx context.Context, followerID, followeeID uint) error {
+	ctx, span := tracing.Start(ctx, "follows.Service.Follow")
+	defer span.End()
+
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+
+	exists, err := s.userRepo.Exists(ctx, domain.UserID(followeeID))
+	if err != nil {
+		return fmt.Errorf("failed to check user existence: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	if err := s.repo.Create(ctx, &Model{FollowerID: followerID, FolloweeID: followeeID}); err != nil {
+		return fmt.Errorf("failed to follow user %d: %w", followeeID, err)
+	}
+	return nil
+}
+
+func (s *Service) Unfollow(ctx context.Context, followerID, followeeID uint) error {
+	ctx, span := tracing.Start(ctx, "follows.Service.Unfollow")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, followerID, followeeID); err != nil {
+		return fmt.Errorf("failed to unfollow user %d: %w", followeeID, err)
+	}
+	return nil
+}
+
+func (s *Service) IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	ctx, span := tracing.Start(ctx, "follows.Service.IsFollowing")
+	defer span.End()
+
+	following, err := s.repo.Exists(ctx, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+	return following, nil
+}
@@ -0,0 +1,13 @@
+package idempotency
+
+import (
+	"errors"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+var (
+	ErrNotFound  = errors.Join(domain.ErrNotFound, errors.New("idempotency key"))
+	ErrKeyReused = domain.ErrIdempotencyKeyReused
+	ErrKeyInUse  = domain.ErrIdempotencyKeyInUse
+)
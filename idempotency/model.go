@@ -0,0 +1,28 @@
+package idempotency
+
+import "time"
+
+// Model is an idempotency key a user sent with a request, together with the
+// response to replay for retries. StatusCode is zero while the first request
+// is still in flight.
+type Model struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	UserID      uint                `gorm:"not null;uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Key         string              `gorm:"not null;size:255;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	Fingerprint string              `gorm:"not null;size:64" json:"fingerprint"`
+	StatusCode  int                 `gorm:"not null;default:0" json:"status_code"`
+	Header      map[string][]string `gorm:"serializer:json;type:jsonb" json:"header"`
+	Body        []byte              `json:"-"`
+	LockedAt    time.Time           `json:"locked_at"`
+	ExpiresAt   time.Time           `gorm:"index" json:"expires_at"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed reports whether the entry holds a response to replay
+func (m *Model) Completed() bool {
+	return m.StatusCode != 0
+}
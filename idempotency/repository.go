@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Insert stores a new key and reports false, without error, when the user
+	// already has an entry for it
+	Insert(ctx context.Context, entry *Model) (bool, error)
+	Get(ctx context.Context, userID uint, key string) (*Model, error)
+	// Reclaim hands the entry with entry.ID to a new request when it has
+	// expired or its request was abandoned while locked before staleBefore.
+	// It reports false when another request got there first.
+	Reclaim(ctx context.Context, entry *Model, now, staleBefore time.Time) (bool, error)
+	Complete(ctx context.Context, entry *Model) error
+	Delete(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+func (r *repository) Insert(ctx context.Context, entry *Model) (bool, error) {
+	result := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(entry)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to insert idempotency key: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) Get(ctx context.Context, userID uint, key string) (*Model, error) {
+	var entry Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ? AND key = ?", userID, key).
+		First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &entry, nil
+}
+
+func (r *repository) Reclaim(ctx context.Context, entry *Model, now, staleBefore time.Time) (bool, error) {
+	result := r.getDB(ctx).WithContext(ctx).Model(&Model{}).
+		Where("id = ?", entry.ID).
+		Where("expires_at <= ? OR (status_code = 0 AND locked_at <= ?)", now, staleBefore).
+		Updates(map[string]interface{}{
+			"fingerprint": entry.Fingerprint,
+			"status_code": 0,
+			"header":      gorm.Expr("NULL"),
+			"body":        gorm.Expr("NULL"),
+			"locked_at":   entry.LockedAt,
+			"expires_at":  entry.ExpiresAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reclaim idempotency key: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Complete stores the response of the request holding the entry
+func (r *repository) Complete(ctx context.Context, entry *Model) error {
+	if err := r.getDB(ctx).WithContext(ctx).Model(entry).
+		Where("status_code = 0").
+		Select("status_code", "header", "body").
+		Updates(entry).Error; err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).Delete(&Model{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.getDB(ctx).WithContext(ctx).Where("expires_at <= ?", now).Delete(&Model{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
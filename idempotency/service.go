@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Service tracks idempotency keys. A key belongs to one user and one request
+// fingerprint until it expires after the TTL.
+type Service struct {
+	repo Repository
+	ttl  time.Duration
+	// lockTimeout is how long a key stays locked by a request that never
+	// completed, e.g. because the server crashed, before a retry may take over
+	lockTimeout time.Duration
+}
+
+func NewService(repo Repository, ttl, lockTimeout time.Duration) *Service {
+	return &Service{
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Fingerprint identifies a request for key reuse checks
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key for a request. A completed entry is a stored response to
+// replay; otherwise the caller now holds the key and must Complete or Release
+// it. A key sent with a different request fails with ErrKeyReused, and one
+// whose first request is still running with ErrKeyInUse.
+func (s *Service) Begin(ctx context.Context, userID uint, key, fingerprint string) (*Model, error) {
+	now := time.Now()
+	entry := &Model{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		LockedAt:    now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	// A second pass covers an entry purged or reclaimed between the calls
+	for attempt := 0; attempt < 2; attempt++ {
+		inserted, err := s.repo.Insert(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		if inserted {
+			return entry, nil
+		}
+
+		existing, err := s.repo.Get(ctx, userID, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		expired := !existing.ExpiresAt.After(now)
+		if !expired {
+			if existing.Fingerprint != fingerprint {
+				return nil, ErrKeyReused
+			}
+			if existing.Completed() {
+				return existing, nil
+			}
+			if existing.LockedAt.After(now.Add(-s.lockTimeout)) {
+				return nil, ErrKeyInUse
+			}
+		}
+
+		entry.ID = existing.ID
+		claimed, err := s.repo.Reclaim(ctx, entry, now, now.Add(-s.lockTimeout))
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return entry, nil
+		}
+		entry.ID = 0
+	}
+	return nil, ErrKeyInUse
+}
+
+// Complete stores the response for retries of the request holding entry
+func (s *Service) Complete(ctx context.Context, entry *Model, statusCode int, header http.Header, body []byte) error {
+	entry.StatusCode = statusCode
+	entry.Header = header
+	entry.Body = body
+	return s.repo.Complete(ctx, entry)
+}
+
+// Release gives up the key so a retry runs the request again
+func (s *Service) Release(ctx context.Context, entry *Model) error {
+	return s.repo.Delete(ctx, entry.ID)
+}
+
+// PurgeExpired deletes keys past their TTL and returns how many went
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Sweeper periodically deletes expired keys. Begin already ignores expired
+// entries, so sweeping only keeps the table small.
+type Sweeper struct {
+	service  *Service
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewSweeper(service *Service, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start launches the sweeping loop in the background
+func (s *Sweeper) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// Stop signals the loop to exit and waits for the current sweep to finish
+func (s *Sweeper) Stop(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sweeper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *Sweeper) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+
+	count, err := s.service.PurgeExpired(ctx)
+	if err != nil {
+		logger.Logger().Error().Err(err).Msg("Failed to purge expired idempotency keys")
+		return
+	}
+	if count > 0 {
+		logger.Logger().Info().Int64("count", count).Msg("Purged expired idempotency keys")
+	}
+}
@@ -6,22 +6,44 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/bookmarks"
 	"github.com/urdogan0000/social/comments"
 	_ "github.com/urdogan0000/social/docs/swagger"
+	"github.com/urdogan0000/social/follows"
+	"github.com/urdogan0000/social/idempotency"
 	"github.com/urdogan0000/social/internal/config"
+	"github.com/urdogan0000/social/internal/gql"
+	"github.com/urdogan0000/social/internal/health"
+	"github.com/urdogan0000/social/internal/metrics"
 	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/media"
+	"github.com/urdogan0000/social/mentions"
+	"github.com/urdogan0000/social/polls"
 	"github.com/urdogan0000/social/posts"
+	"github.com/urdogan0000/social/tags"
 	"github.com/urdogan0000/social/users"
 )
 
 type Application struct {
-	Config         config.Config
-	UserHandler    *users.Handler
-	PostHandler    *posts.Handler
-	CommentHandler *comments.Handler
-	AuthHandler    *auth.Handler
-	AuthService    *auth.Service
+	Config          config.Config
+	UserHandler     *users.Handler
+	PostHandler     *posts.Handler
+	CommentHandler  *comments.Handler
+	BookmarkHandler *bookmarks.Handler
+	PollHandler     *polls.Handler
+	FollowHandler   *follows.Handler
+	AuthHandler     *auth.Handler
+	AuthService     *auth.Service
+	AuditHandler    *audit.Handler
+	GraphQLHandler  *gql.Handler
+	MediaHandler    *media.Handler
+	MentionHandler  *mentions.Handler
+	TagHandler      *tags.Handler
+	Health          *health.Registry
+	// Idempotency stores Idempotency-Key responses for retried creates
+	Idempotency *idempotency.Service
 }
 
 func (app *Application) Mount() http.Handler {
@@ -34,17 +56,39 @@ func (app *Application) Mount() http.Handler {
 
 	r.Use(middleware.RequestID())
 	r.Use(middleware.RealIP())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.AuditContext())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.Metrics())
 	r.Use(middleware.Recoverer())
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(middleware.RateLimit(app.Config.Server.RateLimitRPM))
 
+	// Without an admin listener the metrics are served next to the API
+	if app.Config.Server.AdminAddr == "" {
+		r.Handle("/metrics", metrics.Handler())
+	}
+
+	// Probes for orchestrators and load balancers; see internal/health
+	r.Get("/livez", app.Health.LiveHandler)
+	r.Get("/readyz", app.Health.ReadyHandler)
+
 	r.Route("/v1", func(r chi.Router) {
 		swaggerURL := "http://localhost" + app.Config.Server.Addr + "/v1/swagger/doc.json"
 		r.Get("/swagger/*", httpSwagger.Handler(
 			httpSwagger.URL(swaggerURL),
 			httpSwagger.DeepLinking(true),
 		))
-		r.Get("/health", app.healthCheckHandler)
+		// Kept for probes configured before /readyz existed
+		r.Get("/health", app.Health.ReadyHandler)
+
+		// The GraphQL API sits next to REST on the same services; resolvers
+		// check authentication themselves since one request can mix both
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.OptionalAuth(app.AuthService))
+			r.Get("/graphql", app.GraphQLHandler.ServeHTTP)
+			r.Post("/graphql", app.GraphQLHandler.ServeHTTP)
+		})
 
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/register", app.AuthHandler.Register)
@@ -55,35 +99,117 @@ func (app *Application) Mount() http.Handler {
 			r.Post("/", app.UserHandler.Create)
 			r.Get("/", app.UserHandler.List)
 			r.Get("/{id}", app.UserHandler.Get)
-			r.Put("/{id}", app.UserHandler.Update)
-			r.Delete("/{id}", app.UserHandler.Delete)
-			r.Get("/{userID}/posts", app.PostHandler.GetByUser)
+			r.Get("/{id}/avatar", app.UserHandler.Avatar)
+			r.With(middleware.OptionalAuth(app.AuthService)).Get("/{userID}/posts", app.PostHandler.GetByUser)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.AuthMiddleware(app.AuthService))
+				r.Put("/{id}", app.UserHandler.Update)
+				r.Delete("/{id}", app.UserHandler.Delete)
+				r.Put("/{id}/follow", app.FollowHandler.Follow)
+				r.Delete("/{id}/follow", app.FollowHandler.Unfollow)
+			})
 		})
 
 		r.Route("/posts", func(r chi.Router) {
+			r.Use(middleware.OptionalAuth(app.AuthService))
 			r.Get("/", app.PostHandler.List)
 			r.Get("/search", app.PostHandler.Search)
 			r.Get("/tags", app.PostHandler.GetByTags)
 			r.Get("/{id}", app.PostHandler.Get)
+			r.Get("/{id}/revisions", app.PostHandler.GetRevisions)
+			r.Get("/{id}/revisions/{number}", app.PostHandler.GetRevision)
 
 			r.Route("/{postID}/comments", func(r chi.Router) {
 				r.Get("/", app.CommentHandler.GetByPostID)
 
 				r.Group(func(r chi.Router) {
 					r.Use(middleware.AuthMiddleware(app.AuthService))
-					r.Post("/", app.CommentHandler.Create)
+					r.With(middleware.Idempotency(app.Idempotency)).Post("/", app.CommentHandler.Create)
+				})
+			})
+
+			r.Route("/{postID}/media", func(r chi.Router) {
+				r.Get("/", app.MediaHandler.GetByPostID)
+
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.AuthMiddleware(app.AuthService))
+					r.Post("/", app.MediaHandler.Attach)
+					r.Delete("/{mediaID}", app.MediaHandler.Detach)
+				})
+			})
+
+			r.Route("/{postID}/poll", func(r chi.Router) {
+				r.Get("/", app.PollHandler.Get)
+
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.AuthMiddleware(app.AuthService))
+					r.Put("/", app.PollHandler.Put)
+					r.Delete("/", app.PollHandler.Delete)
+					r.Post("/vote", app.PollHandler.Vote)
+					r.Delete("/vote", app.PollHandler.Retract)
 				})
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Use(middleware.AuthMiddleware(app.AuthService))
-				r.Post("/", app.PostHandler.Create)
+				r.With(middleware.Idempotency(app.Idempotency)).Post("/", app.PostHandler.Create)
 				r.Put("/{id}", app.PostHandler.Update)
 				r.Delete("/{id}", app.PostHandler.Delete)
+				r.Post("/{id}/revisions/{number}/restore", app.PostHandler.RestoreRevision)
+				r.Post("/{id}/repost", app.PostHandler.Repost)
+				r.Delete("/{id}/repost", app.PostHandler.Unrepost)
+				r.Put("/{id}/pin", app.PostHandler.Pin)
+				r.Delete("/{id}/pin", app.PostHandler.Unpin)
+				r.Put("/{id}/bookmark", app.BookmarkHandler.Bookmark)
+				r.Delete("/{id}/bookmark", app.BookmarkHandler.Unbookmark)
+			})
+		})
+
+		// The static /trending route takes precedence over a tag of that name
+		r.Route("/tags", func(r chi.Router) {
+			r.Use(middleware.OptionalAuth(app.AuthService))
+			r.Get("/", app.TagHandler.Autocomplete)
+			r.Get("/trending", app.TagHandler.Trending)
+			r.Get("/{tag}", app.TagHandler.Get)
+		})
+
+		// Downloads are authorized by the signed URL alone, so they work
+		// from <img> and <video> tags
+		r.Route("/media", func(r chi.Router) {
+			r.Get("/{id}/content", app.MediaHandler.Content)
+			r.With(middleware.AuthMiddleware(app.AuthService)).Post("/", app.MediaHandler.Upload)
+		})
+
+		r.Route("/me", func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(app.AuthService))
+			r.Get("/", app.UserHandler.Me)
+			r.Patch("/profile", app.UserHandler.UpdateProfile)
+			r.Get("/drafts", app.PostHandler.GetDrafts)
+			r.Get("/mentions", app.MentionHandler.GetMine)
+			r.Get("/bookmarks", app.BookmarkHandler.GetBookmarks)
+			r.Route("/collections", func(r chi.Router) {
+				r.Get("/", app.BookmarkHandler.GetCollections)
+				r.Post("/", app.BookmarkHandler.CreateCollection)
+				r.Get("/{id}", app.BookmarkHandler.GetCollection)
+				r.Patch("/{id}", app.BookmarkHandler.UpdateCollection)
+				r.Delete("/{id}", app.BookmarkHandler.DeleteCollection)
+				r.Get("/{id}/items", app.BookmarkHandler.GetItems)
+				r.Put("/{id}/items/{postID}", app.BookmarkHandler.PutItem)
+				r.Delete("/{id}/items/{postID}", app.BookmarkHandler.RemoveItem)
 			})
 		})
 
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(app.AuthService))
+			r.Use(middleware.RequireAdmin(app.AuthService))
+			r.Get("/audit", app.AuditHandler.List)
+			r.Get("/audit/export", app.AuditHandler.Export)
+			r.Put("/users/{id}/role", app.UserHandler.SetRole)
+		})
+
 		r.Route("/comments", func(r chi.Router) {
+			r.Use(middleware.OptionalAuth(app.AuthService))
 			r.Get("/", app.CommentHandler.List)
 			r.Get("/{id}", app.CommentHandler.GetByID)
 
@@ -91,15 +217,11 @@ func (app *Application) Mount() http.Handler {
 				r.Use(middleware.AuthMiddleware(app.AuthService))
 				r.Put("/{id}", app.CommentHandler.Update)
 				r.Delete("/{id}", app.CommentHandler.Delete)
+				r.Put("/{id}/pin", app.CommentHandler.Pin)
+				r.Delete("/{id}/pin", app.CommentHandler.Unpin)
 			})
 		})
 	})
 
 	return r
 }
-
-func (app *Application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	if _, err := w.Write([]byte("ok")); err != nil {
-		http.Error(w, "failed to write response", http.StatusInternalServerError)
-	}
-}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores serialized values under string keys with a time to live.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// GetOrLoad returns the value cached under key, or calls load and caches its
+// result. Values are gob encoded so fields hidden from JSON, such as password
+// hashes, survive the round trip. Cache failures are logged and fall back to
+// load, so a broken cache never fails a read.
+func GetOrLoad[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(ctx context.Context) (*T, error)) (*T, error) {
+	data, err := c.Get(ctx, key)
+	if err == nil {
+		var value T
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err == nil {
+			return &value, nil
+		}
+		logger.FromContext(ctx).Warn().Err(err).Str("key", key).Msg("Failed to decode cached value")
+	} else if !errors.Is(err, ErrMiss) {
+		logger.FromContext(ctx).Warn().Err(err).Str("key", key).Msg("Cache read failed")
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		logger.FromContext(ctx).Warn().Err(err).Str("key", key).Msg("Failed to encode value for cache")
+		return value, nil
+	}
+	if err := c.Set(ctx, key, buf.Bytes(), ttl); err != nil {
+		logger.FromContext(ctx).Warn().Err(err).Str("key", key).Msg("Cache write failed")
+	}
+	return value, nil
+}
+
+// Invalidate deletes keys, logging instead of failing since entries also expire
+func Invalidate(ctx context.Context, c Cache, keys ...string) {
+	if err := c.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Warn().Err(err).Strs("keys", keys).Msg("Cache invalidation failed")
+	}
+}
@@ -3,17 +3,39 @@ package di
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/bookmarks"
 	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/follows"
+	"github.com/urdogan0000/social/idempotency"
+	"github.com/urdogan0000/social/internal/cache"
 	"github.com/urdogan0000/social/internal/config"
 	"github.com/urdogan0000/social/internal/db"
 	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/gql"
+	"github.com/urdogan0000/social/internal/health"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/rpc"
+	"github.com/urdogan0000/social/internal/storage"
+	"github.com/urdogan0000/social/internal/tracing"
+	"github.com/urdogan0000/social/media"
+	"github.com/urdogan0000/social/mentions"
+	"github.com/urdogan0000/social/polls"
 	"github.com/urdogan0000/social/posts"
+	"github.com/urdogan0000/social/tags"
 	"github.com/urdogan0000/social/users"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/fx"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
@@ -22,18 +44,64 @@ var Module = fx.Options(
 	fx.Provide(provideDatabase),
 	fx.Provide(provideTransactionManager),
 	fx.Provide(provideEventBus),
+	fx.Provide(provideCache),
 	fx.Provide(provideUserRepository),
 	fx.Provide(providePostRepository),
 	fx.Provide(provideCommentRepository),
+	fx.Provide(provideFollowRepository),
+	fx.Provide(provideMentionRepository),
+	fx.Provide(provideTagRepository),
+	fx.Provide(provideBookmarkRepository),
+	fx.Provide(providePollRepository),
 	fx.Provide(provideDomainUserRepository),
+	fx.Provide(provideDomainPostRepository),
+	fx.Provide(provideDomainCommentRepository),
+	fx.Provide(provideDomainFollowRepository),
+	fx.Provide(provideDomainMentionRepository),
+	fx.Provide(provideDomainTagRepository),
+	fx.Provide(provideDomainBookmarkRepository),
 	fx.Provide(provideUserService),
 	fx.Provide(providePostService),
+	fx.Provide(providePostScheduler),
 	fx.Provide(provideCommentService),
+	fx.Provide(provideFollowService),
+	fx.Provide(provideMentionService),
+	fx.Provide(provideTagService),
+	fx.Provide(provideTagRanker),
+	fx.Provide(provideBookmarkService),
+	fx.Provide(providePollService),
+	fx.Provide(providePollCloser),
 	fx.Provide(provideUserHandler),
 	fx.Provide(providePostHandler),
 	fx.Provide(provideCommentHandler),
+	fx.Provide(provideFollowHandler),
+	fx.Provide(provideMentionHandler),
+	fx.Provide(provideTagHandler),
+	fx.Provide(provideBookmarkHandler),
+	fx.Provide(providePollHandler),
 	fx.Provide(provideAuthService),
 	fx.Provide(provideAuthHandler),
+	fx.Provide(provideHealth),
+	fx.Provide(provideAuditRepository),
+	fx.Provide(provideAuditService),
+	fx.Provide(provideAuditHandler),
+	fx.Provide(provideGraphQLHandler),
+	fx.Provide(provideEventHub),
+	fx.Provide(provideIdempotencyRepository),
+	fx.Provide(provideIdempotencyService),
+	fx.Provide(provideIdempotencySweeper),
+	fx.Provide(provideBlobStore),
+	fx.Provide(provideMediaRepository),
+	fx.Provide(provideMediaService),
+	fx.Provide(provideMediaHandler),
+	fx.Provide(provideMediaCollector),
+	fx.Provide(provideMediaPipeline),
+	fx.Provide(provideMediaProcessor),
+	fx.Provide(provideGRPCServer),
+	fx.Invoke(registerAuditRecorder),
+	fx.Invoke(registerCacheInvalidation),
+	fx.Invoke(registerMediaProcessingTrigger),
+	fx.Invoke(registerTracing),
 )
 
 func provideDatabase(cfg *config.Config) (*gorm.DB, error) {
@@ -41,6 +109,12 @@ func provideDatabase(cfg *config.Config) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := metrics.InstrumentDB(gormDB); err != nil {
+		return nil, err
+	}
+	if err := gormDB.Use(tracing.GORMPlugin{}); err != nil {
+		return nil, err
+	}
 
 	sqlDB, err := gormDB.DB()
 	if err != nil {
@@ -58,6 +132,30 @@ func provideDatabase(cfg *config.Config) (*gorm.DB, error) {
 	return gormDB, nil
 }
 
+// SchemaModels lists the models migrated for this build, in dependency order
+func SchemaModels() []interface{} {
+	return []interface{}{
+		&users.Model{},
+		&posts.Model{},
+		&posts.RevisionModel{},
+		&comments.Model{},
+		&follows.Model{},
+		&mentions.Model{},
+		&tags.Model{},
+		&tags.PostTagModel{},
+		&bookmarks.Model{},
+		&bookmarks.CollectionModel{},
+		&bookmarks.CollectionItemModel{},
+		&polls.Model{},
+		&polls.OptionModel{},
+		&polls.BallotModel{},
+		&polls.VoteModel{},
+		&audit.Model{},
+		&idempotency.Model{},
+		&media.Model{},
+	}
+}
+
 func provideTransactionManager(gormDB *gorm.DB) db.TransactionManager {
 	return db.NewTransactionManager(gormDB)
 }
@@ -65,19 +163,144 @@ func provideTransactionManager(gormDB *gorm.DB) db.TransactionManager {
 func provideEventBus(cfg *config.Config) (events.EventBus, error) {
 	// Currently all event bus types use in-memory implementation
 	// Kafka and NATS implementations can be added when needed
-	return events.NewInMemoryEventBus(), nil
+	bus := metrics.InstrumentEventBus(events.NewInMemoryEventBus())
+	return tracing.InstrumentEventBus(bus), nil
+}
+
+// registerTracing exports spans over OTLP/HTTP when tracing is enabled. The
+// W3C propagator is installed either way so trace context passes through.
+func registerTracing(lc fx.Lifecycle, cfg *config.Config) error {
+	if !cfg.Tracing.Enabled {
+		otel.SetTextMapPropagator(tracing.Propagator)
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+	if cfg.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := tracing.NewProvider(cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio, sdktrace.WithBatcher(exporter))
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// provideHealth registers the readiness checks for every external dependency
+func provideHealth(cfg *config.Config, gormDB *gorm.DB, eventBus events.EventBus, c cacheParams, store storage.BlobStore) (*health.Registry, error) {
+	timeout, err := time.ParseDuration(cfg.Server.ReadinessTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := health.NewRegistry(timeout)
+	registry.Register("database", health.DatabaseCheck(gormDB))
+	registry.Register("migrations", health.SchemaCheck(gormDB, SchemaModels()...))
+	registry.Register("event_bus", func(ctx context.Context) error {
+		return events.Ping(ctx, eventBus)
+	})
+	if pinger, ok := c.Cache.(interface{ Ping(context.Context) error }); ok {
+		registry.Register("cache", pinger.Ping)
+	}
+	if pinger, ok := store.(interface{ Ping(context.Context) error }); ok {
+		registry.Register("blob_store", pinger.Ping)
+	}
+	return registry, nil
+}
+
+// cacheParams carries the optional read-through cache; Cache is nil when
+// CACHE_DRIVER is "none"
+type cacheParams struct {
+	Cache cache.Cache
+	TTL   time.Duration
+}
+
+func provideCache(lc fx.Lifecycle, cfg *config.Config) (cacheParams, error) {
+	ttl, err := time.ParseDuration(cfg.Cache.TTL)
+	if err != nil {
+		return cacheParams{}, err
+	}
+
+	switch cfg.Cache.Driver {
+	case "none", "":
+		return cacheParams{}, nil
+	case "memory":
+		return cacheParams{Cache: cache.NewLRU(cfg.Cache.LRUSize), TTL: ttl}, nil
+	case "redis":
+		redis := cache.NewRedis(cache.RedisOptions{
+			Addr:     cfg.Cache.Redis.Addr,
+			Password: cfg.Cache.Redis.Password,
+			DB:       cfg.Cache.Redis.DB,
+		})
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return redis.Close()
+			},
+		})
+		return cacheParams{Cache: redis, TTL: ttl}, nil
+	default:
+		return cacheParams{}, fmt.Errorf("unknown cache driver %q", cfg.Cache.Driver)
+	}
+}
+
+func registerCacheInvalidation(eventBus events.EventBus, c cacheParams) {
+	if c.Cache == nil {
+		return
+	}
+	posts.RegisterCacheInvalidation(eventBus, c.Cache)
+	users.RegisterCacheInvalidation(eventBus, c.Cache)
+	comments.RegisterCacheInvalidation(eventBus, c.Cache)
 }
 
-func provideUserRepository(db *gorm.DB) users.Repository {
-	return users.NewRepository(db)
+func provideUserRepository(db *gorm.DB, c cacheParams) users.Repository {
+	repo := users.NewRepository(db)
+	if c.Cache != nil {
+		return users.NewCachedRepository(repo, c.Cache, c.TTL)
+	}
+	return repo
 }
 
-func providePostRepository(db *gorm.DB) posts.Repository {
-	return posts.NewRepository(db)
+func providePostRepository(db *gorm.DB, c cacheParams) posts.Repository {
+	repo := posts.NewRepository(db)
+	if c.Cache != nil {
+		return posts.NewCachedRepository(repo, c.Cache, c.TTL)
+	}
+	return repo
 }
 
-func provideCommentRepository(db *gorm.DB) comments.Repository {
-	return comments.NewRepository(db)
+func provideCommentRepository(db *gorm.DB, c cacheParams) comments.Repository {
+	repo := comments.NewRepository(db)
+	if c.Cache != nil {
+		return comments.NewCachedRepository(repo, c.Cache, c.TTL)
+	}
+	return repo
+}
+
+func provideFollowRepository(db *gorm.DB) follows.Repository {
+	return follows.NewRepository(db)
+}
+
+func provideMentionRepository(db *gorm.DB) mentions.Repository {
+	return mentions.NewRepository(db)
+}
+
+func provideTagRepository(db *gorm.DB) tags.Repository {
+	return tags.NewRepository(db)
+}
+
+func provideBookmarkRepository(db *gorm.DB) bookmarks.Repository {
+	return bookmarks.NewRepository(db)
+}
+
+func providePollRepository(db *gorm.DB) polls.Repository {
+	return polls.NewRepository(db)
 }
 
 // provideDomainUserRepository provides domain.UserRepository interface
@@ -86,30 +309,128 @@ func provideDomainUserRepository(userRepo users.Repository) domain.UserRepositor
 	return &domainUserRepositoryAdapter{repo: userRepo}
 }
 
+// provideDomainPostRepository provides domain.PostRepository interface
+func provideDomainPostRepository(postRepo posts.Repository) domain.PostRepository {
+	return &domainPostRepositoryAdapter{repo: postRepo}
+}
+
+// provideDomainCommentRepository provides domain.CommentRepository interface
+func provideDomainCommentRepository(commentRepo comments.Repository) domain.CommentRepository {
+	return &domainCommentRepositoryAdapter{repo: commentRepo}
+}
+
+// provideDomainFollowRepository provides domain.FollowRepository interface
+func provideDomainFollowRepository(followRepo follows.Repository) domain.FollowRepository {
+	return &domainFollowRepositoryAdapter{repo: followRepo}
+}
+
+// provideDomainMentionRepository exposes the mention index to the post and
+// comment services; it already implements the domain interface
+func provideDomainMentionRepository(mentionRepo mentions.Repository) domain.MentionRepository {
+	return mentionRepo
+}
+
+// provideDomainTagRepository lets the post service index posts under their tags
+func provideDomainTagRepository(tagRepo tags.Repository) domain.TagRepository {
+	return tagRepo
+}
+
+// provideDomainBookmarkRepository lets the post service flag bookmarked posts
+func provideDomainBookmarkRepository(bookmarkRepo bookmarks.Repository) domain.BookmarkRepository {
+	return bookmarkRepo
+}
+
 func provideUserService(
 	userRepo users.Repository,
 	eventBus events.EventBus,
 	transactionMgr db.TransactionManager,
+	mediaService *media.Service,
 ) *users.Service {
-	return users.NewService(userRepo, eventBus, transactionMgr)
+	return users.NewService(userRepo, eventBus, transactionMgr, mediaService)
 }
 
 func provideCommentService(
 	commentRepo comments.Repository,
+	domainCommentRepo domain.CommentRepository,
 	userRepo domain.UserRepository,
+	postRepo domain.PostRepository,
+	followRepo domain.FollowRepository,
+	mentionRepo domain.MentionRepository,
 	eventBus events.EventBus,
 	transactionMgr db.TransactionManager,
 ) *comments.Service {
-	return comments.NewService(commentRepo, userRepo, eventBus, transactionMgr)
+	return comments.NewService(commentRepo, domainCommentRepo, userRepo, postRepo, followRepo, mentionRepo, eventBus, transactionMgr)
 }
 
 func providePostService(
 	postRepo posts.Repository,
 	userRepo domain.UserRepository,
+	followRepo domain.FollowRepository,
+	mentionRepo domain.MentionRepository,
+	tagRepo domain.TagRepository,
+	bookmarkRepo domain.BookmarkRepository,
 	eventBus events.EventBus,
 	transactionMgr db.TransactionManager,
 ) *posts.Service {
-	return posts.NewService(postRepo, userRepo, eventBus, transactionMgr)
+	return posts.NewService(postRepo, userRepo, followRepo, mentionRepo, tagRepo, bookmarkRepo, eventBus, transactionMgr)
+}
+
+func providePostScheduler(cfg *config.Config, postService *posts.Service) (*posts.Scheduler, error) {
+	interval, err := time.ParseDuration(cfg.Scheduler.PostPublishInterval)
+	if err != nil {
+		return nil, err
+	}
+	return posts.NewScheduler(postService, interval, cfg.Scheduler.PostPublishBatchSize), nil
+}
+
+func provideFollowService(followRepo follows.Repository, userRepo domain.UserRepository) *follows.Service {
+	return follows.NewService(followRepo, userRepo)
+}
+
+func provideTagService(tagRepo tags.Repository, postService *posts.Service, transactionMgr db.TransactionManager) *tags.Service {
+	return tags.NewService(tagRepo, postService, transactionMgr)
+}
+
+func provideTagRanker(cfg *config.Config, tagService *tags.Service) (*tags.Ranker, error) {
+	interval, err := time.ParseDuration(cfg.Tags.TrendingInterval)
+	if err != nil {
+		return nil, err
+	}
+	halfLife, err := time.ParseDuration(cfg.Tags.TrendingHalfLife)
+	if err != nil {
+		return nil, err
+	}
+	window, err := time.ParseDuration(cfg.Tags.TrendingWindow)
+	if err != nil {
+		return nil, err
+	}
+	return tags.NewRanker(tagService, interval, halfLife, window), nil
+}
+
+func provideBookmarkService(bookmarkRepo bookmarks.Repository, postService *posts.Service, transactionMgr db.TransactionManager) *bookmarks.Service {
+	return bookmarks.NewService(bookmarkRepo, postService, transactionMgr)
+}
+
+func providePollService(
+	pollRepo polls.Repository,
+	postRepo domain.PostRepository,
+	followRepo domain.FollowRepository,
+	eventBus events.EventBus,
+	transactionMgr db.TransactionManager,
+) *polls.Service {
+	return polls.NewService(pollRepo, postRepo, followRepo, eventBus, transactionMgr)
+}
+
+func providePollCloser(cfg *config.Config, pollService *polls.Service) (*polls.Closer, error) {
+	interval, err := time.ParseDuration(cfg.Polls.CloseInterval)
+	if err != nil {
+		return nil, err
+	}
+	return polls.NewCloser(pollService, interval, cfg.Polls.CloseBatchSize), nil
+}
+
+func provideMentionService(mentionRepo mentions.Repository) *mentions.Service {
+	return mentions.NewService(mentionRepo)
 }
 
 func provideUserHandler(userService *users.Service) *users.Handler {
@@ -124,6 +445,26 @@ func provideCommentHandler(commentService *comments.Service) *comments.Handler {
 	return comments.NewHandler(commentService)
 }
 
+func provideFollowHandler(followService *follows.Service) *follows.Handler {
+	return follows.NewHandler(followService)
+}
+
+func provideTagHandler(tagService *tags.Service) *tags.Handler {
+	return tags.NewHandler(tagService)
+}
+
+func provideBookmarkHandler(bookmarkService *bookmarks.Service) *bookmarks.Handler {
+	return bookmarks.NewHandler(bookmarkService)
+}
+
+func providePollHandler(pollService *polls.Service) *polls.Handler {
+	return polls.NewHandler(pollService)
+}
+
+func provideMentionHandler(mentionService *mentions.Service) *mentions.Handler {
+	return mentions.NewHandler(mentionService)
+}
+
 func provideAuthService(userRepo users.Repository, cfg *config.Config) *auth.Service {
 	return auth.NewService(userRepo, cfg.JWT.SecretKey, cfg.JWT.ExpirationHours)
 }
@@ -132,6 +473,170 @@ func provideAuthHandler(authService *auth.Service) *auth.Handler {
 	return auth.NewHandler(authService)
 }
 
+func provideAuditRepository(db *gorm.DB) audit.Repository {
+	return audit.NewRepository(db)
+}
+
+func provideAuditService(repo audit.Repository) *audit.Service {
+	return audit.NewService(repo)
+}
+
+func provideAuditHandler(auditService *audit.Service) *audit.Handler {
+	return audit.NewHandler(auditService)
+}
+
+func provideIdempotencyRepository(db *gorm.DB) idempotency.Repository {
+	return idempotency.NewRepository(db)
+}
+
+func provideIdempotencyService(cfg *config.Config, repo idempotency.Repository) (*idempotency.Service, error) {
+	ttl, err := time.ParseDuration(cfg.Idempotency.TTL)
+	if err != nil {
+		return nil, err
+	}
+	lockTimeout, err := time.ParseDuration(cfg.Idempotency.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return idempotency.NewService(repo, ttl, lockTimeout), nil
+}
+
+func provideIdempotencySweeper(cfg *config.Config, service *idempotency.Service) (*idempotency.Sweeper, error) {
+	interval, err := time.ParseDuration(cfg.Idempotency.SweepInterval)
+	if err != nil {
+		return nil, err
+	}
+	return idempotency.NewSweeper(service, interval), nil
+}
+
+func provideBlobStore(cfg *config.Config) (storage.BlobStore, error) {
+	switch cfg.Media.Storage {
+	case "local", "":
+		return storage.NewLocalStore(cfg.Media.LocalDir)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Media.S3.Endpoint,
+			Bucket:    cfg.Media.S3.Bucket,
+			AccessKey: cfg.Media.S3.AccessKey,
+			SecretKey: cfg.Media.S3.SecretKey,
+			Region:    cfg.Media.S3.Region,
+			UseSSL:    cfg.Media.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", cfg.Media.Storage)
+	}
+}
+
+func provideMediaRepository(db *gorm.DB) media.Repository {
+	return media.NewRepository(db)
+}
+
+func provideMediaService(
+	cfg *config.Config,
+	repo media.Repository,
+	store storage.BlobStore,
+	postRepo domain.PostRepository,
+	followRepo domain.FollowRepository,
+	eventBus events.EventBus,
+) (*media.Service, error) {
+	urlTTL, err := time.ParseDuration(cfg.Media.URLTTL)
+	if err != nil {
+		return nil, err
+	}
+	secret := cfg.Media.URLSecret
+	if secret == "" {
+		secret = cfg.JWT.SecretKey
+	}
+	signer := media.NewSigner([]byte(secret), urlTTL, "/v1/media")
+	limits := media.Limits{
+		MaxSize:      int64(cfg.Media.MaxUploadSize),
+		AllowedTypes: cfg.Media.AllowedTypes,
+	}
+	return media.NewService(repo, store, postRepo, followRepo, eventBus, signer, limits), nil
+}
+
+func provideMediaHandler(mediaService *media.Service) *media.Handler {
+	return media.NewHandler(mediaService)
+}
+
+func provideMediaCollector(cfg *config.Config, service *media.Service) (*media.Collector, error) {
+	interval, err := time.ParseDuration(cfg.Media.GCInterval)
+	if err != nil {
+		return nil, err
+	}
+	orphanTTL, err := time.ParseDuration(cfg.Media.OrphanTTL)
+	if err != nil {
+		return nil, err
+	}
+	return media.NewCollector(service, interval, orphanTTL), nil
+}
+
+func provideMediaPipeline(cfg *config.Config, repo media.Repository, store storage.BlobStore) (*media.Pipeline, error) {
+	sizes := make([]media.Size, 0, len(cfg.Media.ThumbnailSizes))
+	for _, spec := range cfg.Media.ThumbnailSizes {
+		name, edge, ok := strings.Cut(spec, ":")
+		maxEdge, err := strconv.Atoi(edge)
+		if !ok || name == "" || err != nil || maxEdge <= 0 {
+			return nil, fmt.Errorf("invalid thumbnail size %q, want name:maxEdge", spec)
+		}
+		sizes = append(sizes, media.Size{Name: name, MaxEdge: maxEdge})
+	}
+	retryDelay, err := time.ParseDuration(cfg.Media.ProcessRetryDelay)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := time.ParseDuration(cfg.Media.ProcessLease)
+	if err != nil {
+		return nil, err
+	}
+	return media.NewPipeline(repo, store, media.PipelineOptions{
+		Sizes:       sizes,
+		MaxPixels:   cfg.Media.MaxPixels,
+		MaxAttempts: cfg.Media.ProcessMaxAttempts,
+		RetryDelay:  retryDelay,
+		Lease:       lease,
+		BatchSize:   cfg.Media.ProcessBatchSize,
+	}), nil
+}
+
+func provideMediaProcessor(cfg *config.Config, pipeline *media.Pipeline) (*media.Processor, error) {
+	interval, err := time.ParseDuration(cfg.Media.ProcessInterval)
+	if err != nil {
+		return nil, err
+	}
+	return media.NewProcessor(pipeline, interval), nil
+}
+
+// registerMediaProcessingTrigger starts processing as soon as media is uploaded
+func registerMediaProcessingTrigger(eventBus events.EventBus, processor *media.Processor) {
+	media.RegisterProcessingTrigger(eventBus, processor)
+}
+
+// provideGraphQLHandler builds the GraphQL schema over the same services the
+// REST handlers use
+func provideGraphQLHandler(
+	cfg *config.Config,
+	userService *users.Service,
+	postService *posts.Service,
+	commentService *comments.Service,
+	userRepo domain.UserRepository,
+) (*gql.Handler, error) {
+	schema, err := gql.NewSchema(gql.NewResolver(userService, postService, commentService))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build graphql schema: %w", err)
+	}
+	limits := gql.Limits{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+	}
+	return gql.NewHandler(schema, limits, userRepo), nil
+}
+
+// registerAuditRecorder makes audit.Record persist entries through the service
+func registerAuditRecorder(auditService *audit.Service) {
+	audit.SetRecorder(auditService)
+}
+
 // domainUserRepositoryAdapter adapts users.Repository to domain.UserRepository
 type domainUserRepositoryAdapter struct {
 	repo users.Repository
@@ -150,6 +655,27 @@ func (a *domainUserRepositoryAdapter) GetByID(ctx context.Context, id domain.Use
 	}, nil
 }
 
+func (a *domainUserRepositoryAdapter) GetByIDs(ctx context.Context, ids []domain.UserID) ([]*domain.User, error) {
+	rawIDs := make([]uint, len(ids))
+	for i, id := range ids {
+		rawIDs[i] = uint(id)
+	}
+	models, err := a.repo.GetByIDs(ctx, rawIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*domain.User, len(models))
+	for i := range models {
+		result[i] = &domain.User{
+			ID:       domain.UserID(models[i].ID),
+			Username: models[i].Username,
+			Email:    models[i].Email,
+			Password: models[i].Password,
+		}
+	}
+	return result, nil
+}
+
 func (a *domainUserRepositoryAdapter) Exists(ctx context.Context, id domain.UserID) (bool, error) {
 	_, err := a.repo.GetByID(ctx, uint(id))
 	if errors.Is(err, users.ErrNotFound) {
@@ -186,3 +712,123 @@ func (a *domainUserRepositoryAdapter) GetByEmail(ctx context.Context, email stri
 		Password: model.Password,
 	}, nil
 }
+
+// domainPostRepositoryAdapter adapts posts.Repository to domain.PostRepository
+type domainPostRepositoryAdapter struct {
+	repo posts.Repository
+}
+
+func (a *domainPostRepositoryAdapter) GetByID(ctx context.Context, id domain.PostID) (*domain.Post, error) {
+	model, err := a.repo.GetByID(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	return postModelToDomain(model), nil
+}
+
+func (a *domainPostRepositoryAdapter) GetByUserID(ctx context.Context, userID domain.UserID) ([]*domain.Post, error) {
+	// The author sees all of their own posts; a negative limit disables paging
+	models, err := a.repo.GetByUserID(ctx, uint(userID), uint(userID), -1, 0)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*domain.Post, len(models))
+	for i := range models {
+		result[i] = postModelToDomain(&models[i])
+	}
+	return result, nil
+}
+
+func (a *domainPostRepositoryAdapter) Exists(ctx context.Context, id domain.PostID) (bool, error) {
+	_, err := a.repo.GetByID(ctx, uint(id))
+	if errors.Is(err, posts.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func postModelToDomain(model *posts.Model) *domain.Post {
+	return &domain.Post{
+		ID:            domain.PostID(model.ID),
+		Title:         model.Title,
+		Content:       model.Content,
+		UserID:        domain.UserID(model.UserID),
+		Tags:          []string(model.Tags),
+		Mentions:      model.Mentions.Domain(),
+		Visibility:    domain.Visibility(model.Visibility),
+		Status:        domain.PostStatus(model.Status),
+		PublishAt:     model.PublishAt,
+		EditedAt:      model.EditedAt,
+		RepostOfID:    (*domain.PostID)(model.RepostOfID),
+		QuoteOfID:     (*domain.PostID)(model.QuoteOfID),
+		CommentStatus: domain.CommentStatus(model.CommentStatus),
+		ReplyAudience: domain.ReplyAudience(model.ReplyAudience),
+	}
+}
+
+// domainCommentRepositoryAdapter adapts comments.Repository to domain.CommentRepository
+type domainCommentRepositoryAdapter struct {
+	repo comments.Repository
+}
+
+func (a *domainCommentRepositoryAdapter) GetByID(ctx context.Context, id domain.CommentID) (*domain.Comment, error) {
+	model, err := a.repo.GetByID(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Comment{
+		ID:       domain.CommentID(model.ID),
+		PostID:   domain.PostID(model.PostID),
+		UserID:   domain.UserID(model.UserID),
+		Content:  model.Content,
+		Mentions: model.Mentions.Domain(),
+		Pinned:   model.Pinned,
+	}, nil
+}
+
+func (a *domainCommentRepositoryAdapter) Exists(ctx context.Context, id domain.CommentID) (bool, error) {
+	_, err := a.repo.GetByID(ctx, uint(id))
+	if errors.Is(err, comments.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *domainCommentRepositoryAdapter) CountByPostID(ctx context.Context, postID domain.PostID) (int64, error) {
+	return a.repo.CountByPostID(ctx, uint(postID))
+}
+
+// domainFollowRepositoryAdapter adapts follows.Repository to domain.FollowRepository
+type domainFollowRepositoryAdapter struct {
+	repo follows.Repository
+}
+
+func (a *domainFollowRepositoryAdapter) IsFollowing(ctx context.Context, followerID, followeeID domain.UserID) (bool, error) {
+	return a.repo.Exists(ctx, uint(followerID), uint(followeeID))
+}
+
+func provideEventHub(eventBus events.EventBus) *rpc.EventHub {
+	return rpc.NewEventHub(eventBus)
+}
+
+func provideGRPCServer(
+	authService *auth.Service,
+	userService *users.Service,
+	postService *posts.Service,
+	commentService *comments.Service,
+	hub *rpc.EventHub,
+) *grpc.Server {
+	return rpc.NewServer(
+		authService,
+		rpc.NewUserServer(userService, authService),
+		rpc.NewPostServer(postService, authService, hub),
+		rpc.NewCommentServer(commentService, authService, hub),
+		rpc.NewAuthServer(authService),
+	)
+}
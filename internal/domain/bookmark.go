@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxCollectionNameLength = 100
+	// MaxCollections caps the collections of a user and MaxCollectionItems
+	// the posts in one collection, which keeps reordering a single write
+	MaxCollections     = 100
+	MaxCollectionItems = 1000
+)
+
+// BookmarkRepository tells which posts a user bookmarked, so post listings
+// can flag them for the viewer
+type BookmarkRepository interface {
+	// BookmarkedPostIDs returns those of postIDs the user bookmarked
+	BookmarkedPostIDs(ctx context.Context, userID UserID, postIDs []PostID) ([]PostID, error)
+}
+
+// NormalizeCollectionName trims the name and checks it is 1 to
+// MaxCollectionNameLength characters long
+func NormalizeCollectionName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if length := utf8.RuneCountInString(name); length == 0 || length > MaxCollectionNameLength {
+		return "", ErrInvalidCollectionName
+	}
+	return name, nil
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type CommentID uint
+
+// MaxCommentLength bounds the characters of a comment
+const MaxCommentLength = 2000
+
+// Comment is a reply to a post
+type Comment struct {
+	ID       CommentID
+	PostID   PostID
+	UserID   UserID
+	Content  string
+	Mentions []Mention
+	Pinned   bool
+}
+
+// Validate validates comment data. Content must have some text besides
+// whitespace and be at most MaxCommentLength characters long.
+func (c *Comment) Validate() error {
+	return validateCommentContent(c.Content)
+}
+
+// UpdateContent updates the content if valid
+func (c *Comment) UpdateContent(newContent string) error {
+	if err := validateCommentContent(newContent); err != nil {
+		return err
+	}
+	c.Content = newContent
+	return nil
+}
+
+// CanBeEditedBy checks if the comment can be edited by the given user
+func (c *Comment) CanBeEditedBy(userID UserID) bool {
+	return c.UserID == userID
+}
+
+// CanBeDeletedBy checks if the comment can be deleted by the given user
+func (c *Comment) CanBeDeletedBy(userID UserID) bool {
+	return c.UserID == userID
+}
+
+func validateCommentContent(content string) error {
+	if strings.TrimSpace(content) == "" || utf8.RuneCountInString(content) > MaxCommentLength {
+		return ErrInvalidCommentContent
+	}
+	return nil
+}
@@ -16,21 +16,107 @@ var (
 var (
 	ErrUserNotFound      = errors.Join(ErrNotFound, errors.New("user"))
 	ErrUserAlreadyExists = errors.Join(ErrConflict, errors.New("user already exists"))
+	ErrUsernameExists    = errors.Join(ErrConflict, errors.New("username already exists"))
+	ErrEmailExists       = errors.Join(ErrConflict, errors.New("email already exists"))
 	ErrInvalidUsername   = errors.Join(ErrValidation, errors.New("invalid username"))
-	ErrInvalidEmail       = errors.Join(ErrValidation, errors.New("invalid email"))
-	ErrInvalidPassword    = errors.Join(ErrValidation, errors.New("invalid password"))
+	ErrInvalidEmail      = errors.Join(ErrValidation, errors.New("invalid email"))
+	ErrInvalidPassword   = errors.Join(ErrValidation, errors.New("invalid password"))
+	ErrInvalidRole       = errors.Join(ErrValidation, errors.New("invalid role"))
+	ErrInvalidProfile    = errors.Join(ErrValidation, errors.New("invalid profile"))
+	ErrUserForbidden     = errors.Join(ErrForbidden, errors.New("you can only modify your own account"))
 )
 
 // Post specific errors
 var (
-	ErrPostNotFound  = errors.Join(ErrNotFound, errors.New("post"))
-	ErrPostForbidden = errors.Join(ErrForbidden, errors.New("you can only modify your own posts"))
-	ErrInvalidTitle  = errors.Join(ErrValidation, errors.New("invalid title"))
-	ErrInvalidContent = errors.Join(ErrValidation, errors.New("invalid content"))
+	ErrPostNotFound         = errors.Join(ErrNotFound, errors.New("post"))
+	ErrPostForbidden        = errors.Join(ErrForbidden, errors.New("you can only modify your own posts"))
+	ErrInvalidTitle         = errors.Join(ErrValidation, errors.New("invalid title"))
+	ErrInvalidContent       = errors.Join(ErrValidation, errors.New("invalid content"))
+	ErrInvalidVisibility    = errors.Join(ErrValidation, errors.New("invalid visibility"))
+	ErrInvalidPostStatus    = errors.Join(ErrValidation, errors.New("invalid post status"))
+	ErrInvalidPublishAt     = errors.Join(ErrValidation, errors.New("publish_at must be in the future"))
+	ErrPostAlreadyPublished = errors.Join(ErrConflict, errors.New("post is already published"))
+	ErrRevisionNotFound     = errors.Join(ErrNotFound, errors.New("post revision"))
+	ErrRepostNotFound       = errors.Join(ErrNotFound, errors.New("repost"))
+	ErrAlreadyReposted      = errors.Join(ErrConflict, errors.New("post is already reposted"))
+	ErrPostNotShareable     = errors.Join(ErrForbidden, errors.New("post cannot be reposted or quoted"))
+	ErrRepostNotEditable    = errors.Join(ErrValidation, errors.New("reposts have no text of their own"))
+	ErrPostNotPinnable      = errors.Join(ErrValidation, errors.New("only published posts can be pinned"))
+	ErrPinLimit             = errors.Join(ErrConflict, errors.New("pinned post limit reached"))
+	ErrInvalidCommentStatus = errors.Join(ErrValidation, errors.New("invalid comment status"))
+	ErrInvalidReplyAudience = errors.Join(ErrValidation, errors.New("invalid reply audience"))
+)
+
+// Comment specific errors
+var (
+	ErrCommentNotFound       = errors.Join(ErrNotFound, errors.New("comment"))
+	ErrCommentForbidden      = errors.Join(ErrForbidden, errors.New("you can only modify your own comments"))
+	ErrInvalidCommentContent = errors.Join(ErrValidation, errors.New("invalid comment content"))
+	ErrCommentsDisabled      = errors.Join(ErrForbidden, errors.New("comments are disabled on this post"))
+	ErrCommentsLocked        = errors.Join(ErrForbidden, errors.New("comments are locked on this post"))
+	ErrReplyNotAllowed       = errors.Join(ErrForbidden, errors.New("the author limited who can comment on this post"))
+)
+
+// Concurrency errors
+var (
+	ErrVersionMismatch = errors.Join(ErrConflict, errors.New("resource was modified concurrently"))
+)
+
+// Follow specific errors
+var (
+	ErrCannotFollowSelf = errors.Join(ErrValidation, errors.New("cannot follow yourself"))
 )
 
 // Auth specific errors
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidCredentials = errors.Join(ErrUnauthorized, errors.New("invalid email or password"))
 	ErrInvalidToken       = errors.Join(ErrUnauthorized, errors.New("invalid or expired token"))
 )
+
+// Idempotency errors
+var (
+	ErrIdempotencyKeyReused = errors.Join(ErrValidation, errors.New("idempotency key was used for a different request"))
+	ErrIdempotencyKeyInUse  = errors.Join(ErrConflict, errors.New("a request with this idempotency key is in progress"))
+)
+
+// Media specific errors
+var (
+	ErrMediaNotFound         = errors.Join(ErrNotFound, errors.New("media"))
+	ErrMediaTooLarge         = errors.Join(ErrValidation, errors.New("media exceeds the upload size limit"))
+	ErrUnsupportedMediaType  = errors.Join(ErrValidation, errors.New("media type is not allowed"))
+	ErrMediaAlreadyAttached  = errors.Join(ErrConflict, errors.New("media is attached to another post"))
+	ErrInvalidMediaSignature = errors.Join(ErrForbidden, errors.New("media link is invalid or expired"))
+	ErrMediaNotReady         = errors.Join(ErrConflict, errors.New("media has not been processed"))
+)
+
+// Tag specific errors
+var (
+	ErrTagNotFound = errors.Join(ErrNotFound, errors.New("tag"))
+	ErrInvalidTag  = errors.Join(ErrValidation, errors.New("invalid tag"))
+)
+
+// Bookmark specific errors
+var (
+	ErrCollectionNotFound    = errors.Join(ErrNotFound, errors.New("collection"))
+	ErrCollectionExists      = errors.Join(ErrConflict, errors.New("a collection with this name already exists"))
+	ErrCollectionLimit       = errors.Join(ErrConflict, errors.New("collection limit reached"))
+	ErrInvalidCollectionName = errors.Join(ErrValidation, errors.New("invalid collection name"))
+	ErrInvalidPosition       = errors.Join(ErrValidation, errors.New("invalid collection item position"))
+)
+
+// Poll specific errors
+var (
+	ErrPollNotFound = errors.Join(ErrNotFound, errors.New("poll"))
+	ErrPollExists   = errors.Join(ErrConflict, errors.New("post already has a poll"))
+	ErrInvalidPoll  = errors.Join(ErrValidation, errors.New("invalid poll"))
+	ErrPollClosed   = errors.Join(ErrConflict, errors.New("poll is closed"))
+	ErrPollHasVotes = errors.Join(ErrConflict, errors.New("poll options cannot change once votes exist"))
+	ErrAlreadyVoted = errors.Join(ErrConflict, errors.New("you already voted in this poll"))
+	ErrVoteNotFound = errors.Join(ErrNotFound, errors.New("vote"))
+	ErrInvalidVote  = errors.Join(ErrValidation, errors.New("invalid vote"))
+)
+
+// Pagination errors
+var (
+	ErrInvalidCursor = errors.Join(ErrValidation, errors.New("invalid cursor"))
+)
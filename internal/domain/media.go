@@ -0,0 +1,14 @@
+package domain
+
+import "context"
+
+type MediaID uint
+
+// AvatarStore lets users pick one of their uploaded images as avatar
+type AvatarStore interface {
+	// CheckAvatar returns ErrMediaNotFound unless id is an image uploaded by
+	// userID, and ErrUnsupportedMediaType if it cannot be shown as one
+	CheckAvatar(ctx context.Context, id MediaID, userID UserID) error
+	// AvatarURL returns a signed, expiring link to the avatar
+	AvatarURL(ctx context.Context, id MediaID) (string, error)
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxMentions caps how many distinct users one text can mention; further
+// names are left as plain text
+const MaxMentions = 20
+
+// Mention is an @username in a post or comment that names an existing user.
+// Start and End are character (not byte) offsets of the whole "@username".
+type Mention struct {
+	UserID   UserID
+	Username string
+	Start    int
+	End      int
+}
+
+// MentionSource identifies the post or comment a mention was made in.
+// CommentID is zero for mentions in the post itself.
+type MentionSource struct {
+	PostID    PostID
+	CommentID CommentID
+	AuthorID  UserID
+}
+
+// MentionRepository indexes who was mentioned where, so users can list the
+// posts and comments that mention them
+type MentionRepository interface {
+	// Replace stores the users mentioned by the source in place of the ones
+	// stored before
+	Replace(ctx context.Context, source MentionSource, userIDs []UserID) error
+}
+
+// FindMentions returns the @username tokens in text with UserID left zero.
+// A token must not directly follow a letter, digit or another name character,
+// so e-mail addresses are not taken for mentions. Usernames consist of
+// letters, digits, '_', '.' and '-'; trailing dots and hyphens are
+// punctuation.
+func FindMentions(text string) []Mention {
+	var mentions []Mention
+	var prev rune
+	chars := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || isUsernameRune(prev) || prev == '@' {
+			prev = r
+			i += size
+			chars++
+			continue
+		}
+
+		end := i + size
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isUsernameRune(next) {
+				break
+			}
+			end += nextSize
+		}
+		for end > i+size && (text[end-1] == '.' || text[end-1] == '-') {
+			end--
+		}
+
+		username := text[i+size : end]
+		length := 1 + utf8.RuneCountInString(username)
+		if len(username) >= 3 {
+			mentions = append(mentions, Mention{Username: username, Start: chars, End: chars + length})
+		}
+		r, _ = utf8.DecodeLastRuneInString(text[:end])
+		prev = r
+		i = end
+		chars += length
+	}
+	return mentions
+}
+
+func isUsernameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// ResolveMentions finds the mentions in text and looks their usernames up.
+// Names of users that do not exist are plain text and left out, as are
+// names beyond the first MaxMentions distinct ones.
+func ResolveMentions(ctx context.Context, text string, users UserRepository) ([]Mention, error) {
+	found := FindMentions(text)
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	resolved := make(map[string]UserID)
+	var mentions []Mention
+	for _, mention := range found {
+		id, seen := resolved[mention.Username]
+		if !seen {
+			if len(resolved) == MaxMentions {
+				continue
+			}
+			user, err := users.GetByUsername(ctx, mention.Username)
+			if err != nil && !errors.Is(err, ErrUserNotFound) {
+				return nil, err
+			}
+			if user != nil {
+				id = user.ID
+			}
+			resolved[mention.Username] = id
+		}
+		if id != 0 {
+			mention.UserID = id
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions, nil
+}
+
+// MentionedUsers returns the distinct users mentioned, in order of first
+// mention, leaving out the author
+func MentionedUsers(mentions []Mention, authorID UserID) []UserID {
+	var ids []UserID
+	seen := make(map[UserID]bool)
+	for _, mention := range mentions {
+		if mention.UserID != authorID && !seen[mention.UserID] {
+			seen[mention.UserID] = true
+			ids = append(ids, mention.UserID)
+		}
+	}
+	return ids
+}
+
+// NewlyMentioned returns the users mentioned in after but not in before, so
+// an edit only notifies the users it adds
+func NewlyMentioned(before, after []Mention, authorID UserID) []UserID {
+	known := make(map[UserID]bool)
+	for _, id := range MentionedUsers(before, authorID) {
+		known[id] = true
+	}
+	var ids []UserID
+	for _, id := range MentionedUsers(after, authorID) {
+		if !known[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
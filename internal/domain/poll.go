@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type PollID uint
+
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 10
+	MaxPollOptionLength = 100
+	// MaxPollDuration bounds how far in the future a poll may end
+	MaxPollDuration = 30 * 24 * time.Hour
+)
+
+// Poll is a question attached to a post. Voters pick one option, or several
+// when MultipleChoice is set, until EndsAt.
+type Poll struct {
+	ID             PollID
+	PostID         PostID
+	AuthorID       UserID
+	Options        []string
+	MultipleChoice bool
+	EndsAt         time.Time
+}
+
+// Validate normalizes the options and checks the poll ends within
+// MaxPollDuration from now. Options are trimmed and must be 1 to
+// MaxPollOptionLength characters long and distinct.
+func (p *Poll) Validate(now time.Time) error {
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return ErrInvalidPoll
+	}
+	seen := make(map[string]bool, len(p.Options))
+	for i, raw := range p.Options {
+		option := strings.TrimSpace(raw)
+		if length := utf8.RuneCountInString(option); length == 0 || length > MaxPollOptionLength {
+			return ErrInvalidPoll
+		}
+		if seen[option] {
+			return ErrInvalidPoll
+		}
+		seen[option] = true
+		p.Options[i] = option
+	}
+	if !p.EndsAt.After(now) || p.EndsAt.After(now.Add(MaxPollDuration)) {
+		return ErrInvalidPoll
+	}
+	return nil
+}
+
+// IsClosed checks if voting has ended. Polls close at EndsAt whether or not
+// the closing job has run yet.
+func (p *Poll) IsClosed(now time.Time) bool {
+	return !now.Before(p.EndsAt)
+}
+
+// CheckChoice checks that a ballot picks a valid number of options
+func (p *Poll) CheckChoice(count int) error {
+	if count == 0 || (count > 1 && !p.MultipleChoice) || count > len(p.Options) {
+		return ErrInvalidVote
+	}
+	return nil
+}
+
+// CanSeeTallies checks if the viewer may see the vote counts. Voters see live
+// tallies while the poll is open; everyone sees the final ones after it closes.
+func (p *Poll) CanSeeTallies(hasVoted bool, now time.Time) bool {
+	return hasVoted || p.IsClosed(now)
+}
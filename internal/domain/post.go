@@ -1,26 +1,206 @@
 package domain
 
+import (
+	"context"
+	"slices"
+	"time"
+)
+
 type PostID uint
 
+// MaxPinnedPosts caps the posts an author may pin to the top of their profile
+const MaxPinnedPosts = 3
+
+// Visibility controls who can read a post
+type Visibility string
+
+const (
+	VisibilityPublic    Visibility = "public"
+	VisibilityUnlisted  Visibility = "unlisted"
+	VisibilityFollowers Visibility = "followers"
+	VisibilityPrivate   Visibility = "private"
+)
+
+// IsValid checks if the visibility is one of the known levels
+func (v Visibility) IsValid() bool {
+	switch v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityFollowers, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// PostStatus tracks a post through its publishing lifecycle
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "draft"
+	PostStatusScheduled PostStatus = "scheduled"
+	PostStatusPublished PostStatus = "published"
+)
+
+// IsValid checks if the status is one of the known lifecycle states
+func (s PostStatus) IsValid() bool {
+	switch s {
+	case PostStatusDraft, PostStatusScheduled, PostStatusPublished:
+		return true
+	}
+	return false
+}
+
+// CommentStatus controls whether a post takes comments
+type CommentStatus string
+
+const (
+	CommentsOpen CommentStatus = "open"
+	// CommentsLocked keeps existing comments readable but takes no new ones
+	CommentsLocked CommentStatus = "locked"
+	// CommentsDisabled hides the comments and takes no new ones
+	CommentsDisabled CommentStatus = "disabled"
+)
+
+// IsValid checks if the comment status is one of the known states
+func (s CommentStatus) IsValid() bool {
+	switch s {
+	case CommentsOpen, CommentsLocked, CommentsDisabled:
+		return true
+	}
+	return false
+}
+
+// ReplyAudience limits who may comment on a post
+type ReplyAudience string
+
+const (
+	ReplyEveryone  ReplyAudience = "everyone"
+	ReplyFollowers ReplyAudience = "followers"
+	ReplyMentioned ReplyAudience = "mentioned"
+)
+
+// IsValid checks if the reply audience is one of the known audiences
+func (a ReplyAudience) IsValid() bool {
+	switch a {
+	case ReplyEveryone, ReplyFollowers, ReplyMentioned:
+		return true
+	}
+	return false
+}
+
 type Post struct {
-	ID      PostID
-	Title   string
-	Content string
-	UserID  UserID
-	Tags    []string
+	ID         PostID
+	Title      string
+	Content    string
+	UserID     UserID
+	Tags       []string
+	Mentions   []Mention
+	Visibility Visibility
+	Status     PostStatus
+	PublishAt  *time.Time
+	EditedAt   *time.Time
+	// RepostOfID is set on reposts, which share another post as it is and
+	// have no text of their own
+	RepostOfID *PostID
+	// QuoteOfID is set on quotes, which share another post with commentary
+	QuoteOfID     *PostID
+	CommentStatus CommentStatus
+	ReplyAudience ReplyAudience
 }
 
 // Validate validates post data
 func (p *Post) Validate() error {
-	if len(p.Title) == 0 || len(p.Title) > 255 {
-		return ErrInvalidTitle
+	if p.IsRepost() {
+		if len(p.Title) != 0 || len(p.Content) != 0 {
+			return ErrRepostNotEditable
+		}
+	} else {
+		if len(p.Title) == 0 || len(p.Title) > 255 {
+			return ErrInvalidTitle
+		}
+		if len(p.Content) == 0 {
+			return ErrInvalidContent
+		}
 	}
-	if len(p.Content) == 0 {
-		return ErrInvalidContent
+	if p.Visibility != "" && !p.Visibility.IsValid() {
+		return ErrInvalidVisibility
+	}
+	if p.Status != "" && !p.Status.IsValid() {
+		return ErrInvalidPostStatus
+	}
+	if p.Status == PostStatusScheduled && p.PublishAt == nil {
+		return ErrInvalidPublishAt
+	}
+	if p.CommentStatus != "" && !p.CommentStatus.IsValid() {
+		return ErrInvalidCommentStatus
+	}
+	if p.ReplyAudience != "" && !p.ReplyAudience.IsValid() {
+		return ErrInvalidReplyAudience
 	}
 	return nil
 }
 
+// IsPublished checks if the post has been published.
+// Empty status comes from rows written before drafts existed.
+func (p *Post) IsPublished() bool {
+	return p.Status == "" || p.Status == PostStatusPublished
+}
+
+// Publish marks the post as published at the given time
+func (p *Post) Publish(now time.Time) error {
+	if p.IsPublished() {
+		return ErrPostAlreadyPublished
+	}
+	p.Status = PostStatusPublished
+	p.PublishAt = &now
+	return nil
+}
+
+// Schedule marks the post to be published at a future time
+func (p *Post) Schedule(at time.Time, now time.Time) error {
+	if p.IsPublished() {
+		return ErrPostAlreadyPublished
+	}
+	if !at.After(now) {
+		return ErrInvalidPublishAt
+	}
+	p.Status = PostStatusScheduled
+	p.PublishAt = &at
+	return nil
+}
+
+// MarkDraft turns an unpublished post back into a draft
+func (p *Post) MarkDraft() error {
+	if p.IsPublished() {
+		return ErrPostAlreadyPublished
+	}
+	p.Status = PostStatusDraft
+	p.PublishAt = nil
+	return nil
+}
+
+// IsRepost checks if the post is a repost of another post
+func (p *Post) IsRepost() bool {
+	return p.RepostOfID != nil
+}
+
+// CanBeSharedBy checks if the user may repost or quote the post. Sharing
+// shows the post to the sharer's audience, so only public posts can be shared
+// by others; authors may share their own posts with the audience they chose.
+func (p *Post) CanBeSharedBy(userID UserID) bool {
+	if !p.IsPublished() || p.IsRepost() {
+		return false
+	}
+	if p.UserID == userID {
+		return true
+	}
+	return p.Visibility == "" || p.Visibility == VisibilityPublic
+}
+
+// CanBePinned checks if the post may be pinned to its author's profile. Only
+// published posts show up there.
+func (p *Post) CanBePinned() bool {
+	return p.IsPublished()
+}
+
 // CanBeEditedBy checks if the post can be edited by the given user
 func (p *Post) CanBeEditedBy(userID UserID) bool {
 	return p.UserID == userID
@@ -31,6 +211,101 @@ func (p *Post) CanBeDeletedBy(userID UserID) bool {
 	return p.UserID == userID
 }
 
+// CanBeViewedBy checks if the post can be read by the given viewer.
+// A zero viewerID means an anonymous viewer. Unlisted posts are readable by
+// anyone who knows the id; listings exclude them separately. Drafts and
+// scheduled posts are only readable by their author.
+func (p *Post) CanBeViewedBy(viewerID UserID, isFollower bool) bool {
+	if viewerID != 0 && p.UserID == viewerID {
+		return true
+	}
+	if !p.IsPublished() {
+		return false
+	}
+	switch p.Visibility {
+	case "", VisibilityPublic, VisibilityUnlisted:
+		// Empty visibility comes from rows written before the field existed
+		return true
+	case VisibilityFollowers:
+		return viewerID != 0 && isFollower
+	default:
+		return false
+	}
+}
+
+// IsVisibleTo resolves CanBeViewedBy, consulting follows only for
+// followers-only posts read by someone other than the author
+func (p *Post) IsVisibleTo(ctx context.Context, viewerID UserID, follows FollowRepository) (bool, error) {
+	isFollower := false
+	if p.Visibility == VisibilityFollowers && viewerID != 0 && viewerID != p.UserID && follows != nil {
+		following, err := follows.IsFollowing(ctx, viewerID, p.UserID)
+		if err != nil {
+			return false, err
+		}
+		isFollower = following
+	}
+	return p.CanBeViewedBy(viewerID, isFollower), nil
+}
+
+// CommentsVisible checks if the comments of the post may be read. Empty
+// status comes from rows written before comment settings existed.
+func (p *Post) CommentsVisible() bool {
+	return p.CommentStatus != CommentsDisabled
+}
+
+// CanBeCommentedBy checks if the user may add a comment to the post. Closed
+// posts take no comments from anybody; otherwise the author may always reply
+// and others must be in the reply audience.
+func (p *Post) CanBeCommentedBy(userID UserID, isFollower bool) error {
+	switch p.CommentStatus {
+	case CommentsDisabled:
+		return ErrCommentsDisabled
+	case CommentsLocked:
+		return ErrCommentsLocked
+	}
+	if p.UserID == userID {
+		return nil
+	}
+	switch p.ReplyAudience {
+	case ReplyFollowers:
+		if !isFollower {
+			return ErrReplyNotAllowed
+		}
+	case ReplyMentioned:
+		if !slices.ContainsFunc(p.Mentions, func(m Mention) bool { return m.UserID == userID }) {
+			return ErrReplyNotAllowed
+		}
+	}
+	return nil
+}
+
+// CheckCommenter resolves CanBeCommentedBy, consulting follows only when the
+// post takes replies from followers of its author
+func (p *Post) CheckCommenter(ctx context.Context, userID UserID, follows FollowRepository) error {
+	isFollower := false
+	if p.ReplyAudience == ReplyFollowers && userID != p.UserID && follows != nil {
+		following, err := follows.IsFollowing(ctx, userID, p.UserID)
+		if err != nil {
+			return err
+		}
+		isFollower = following
+	}
+	return p.CanBeCommentedBy(userID, isFollower)
+}
+
+// UpdateCommentSettings updates the comment status and reply audience if valid
+func (p *Post) UpdateCommentSettings(status CommentStatus, audience ReplyAudience) error {
+	if !status.IsValid() {
+		return ErrInvalidCommentStatus
+	}
+	if !audience.IsValid() {
+		return ErrInvalidReplyAudience
+	}
+	p.CommentStatus = status
+	p.ReplyAudience = audience
+	return nil
+}
+
 // UpdateTitle updates the title if valid
 func (p *Post) UpdateTitle(newTitle string) error {
 	if len(newTitle) == 0 || len(newTitle) > 255 {
@@ -54,6 +329,20 @@ func (p *Post) UpdateTags(newTags []string) {
 	p.Tags = newTags
 }
 
+// UpdateVisibility updates the visibility if valid
+func (p *Post) UpdateVisibility(newVisibility Visibility) error {
+	if !newVisibility.IsValid() {
+		return ErrInvalidVisibility
+	}
+	p.Visibility = newVisibility
+	return nil
+}
+
+// IsEdited reports whether the published text was changed after publishing
+func (p *Post) IsEdited() bool {
+	return p.EditedAt != nil
+}
+
 // HasTag checks if post has a specific tag
 func (p *Post) HasTag(tag string) bool {
 	for _, t := range p.Tags {
@@ -63,4 +352,3 @@ func (p *Post) HasTag(tag string) bool {
 	}
 	return false
 }
-
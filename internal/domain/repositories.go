@@ -6,6 +6,8 @@ import "context"
 // This allows other modules to depend on the interface rather than concrete implementation
 type UserRepository interface {
 	GetByID(ctx context.Context, id UserID) (*User, error)
+	// GetByIDs loads several users at once; missing IDs are skipped
+	GetByIDs(ctx context.Context, ids []UserID) ([]*User, error)
 	Exists(ctx context.Context, id UserID) (bool, error)
 	GetByUsername(ctx context.Context, username string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
@@ -18,3 +20,14 @@ type PostRepository interface {
 	Exists(ctx context.Context, id PostID) (bool, error)
 }
 
+// CommentRepository defines the interface for comment repository operations
+type CommentRepository interface {
+	GetByID(ctx context.Context, id CommentID) (*Comment, error)
+	Exists(ctx context.Context, id CommentID) (bool, error)
+	CountByPostID(ctx context.Context, postID PostID) (int64, error)
+}
+
+// FollowRepository defines the interface for follower relationship lookups
+type FollowRepository interface {
+	IsFollowing(ctx context.Context, followerID, followeeID UserID) (bool, error)
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	MaxTagLength = 50
+	// MaxTags caps the tags of a post, explicit ones first; further hashtags
+	// in the content are left as plain text
+	MaxTags = 10
+)
+
+// TagRepository indexes posts under their tags
+type TagRepository interface {
+	// SetPostTags replaces the tags the post is indexed under
+	SetPostTags(ctx context.Context, postID PostID, tags []string) error
+}
+
+// foldTag case folds and NFKC-normalizes a tag so "Go", "GO" and the
+// full-width "Ｇｏ" are the same tag
+func foldTag(raw string) string {
+	tag := strings.TrimPrefix(strings.TrimSpace(raw), "#")
+	return norm.NFKC.String(cases.Fold().String(tag))
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_' || r == '-'
+}
+
+// NormalizeTag returns the canonical form of a tag, with or without its
+// leading '#'. Tags consist of letters, digits, '_' and '-', are at most
+// MaxTagLength characters long and are not all digits.
+func NormalizeTag(raw string) (string, error) {
+	tag := foldTag(raw)
+	length := utf8.RuneCountInString(tag)
+	if length == 0 || length > MaxTagLength {
+		return "", fmt.Errorf("%w: %q must be 1 to %d characters", ErrInvalidTag, raw, MaxTagLength)
+	}
+	hasNonDigit := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, '_' and '-'", ErrInvalidTag, raw)
+		}
+		hasNonDigit = hasNonDigit || !unicode.IsDigit(r)
+	}
+	if !hasNonDigit {
+		return "", fmt.Errorf("%w: %q must not be a number", ErrInvalidTag, raw)
+	}
+	return tag, nil
+}
+
+// NormalizeTagPrefix normalizes the start of a tag for autocompletion. Unlike
+// NormalizeTag it accepts what may only be the beginning of a tag.
+func NormalizeTagPrefix(raw string) (string, error) {
+	prefix := foldTag(raw)
+	length := utf8.RuneCountInString(prefix)
+	if length == 0 || length > MaxTagLength {
+		return "", fmt.Errorf("%w: %q must be 1 to %d characters", ErrInvalidTag, raw, MaxTagLength)
+	}
+	for _, r := range prefix {
+		if !isTagRune(r) {
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, '_' and '-'", ErrInvalidTag, raw)
+		}
+	}
+	return prefix, nil
+}
+
+// NormalizeTags normalizes explicitly given tags and drops duplicates
+func NormalizeTags(raw []string) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	tags := make([]string, 0, len(raw))
+	for _, r := range raw {
+		tag, err := NormalizeTag(r)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, MaxTags)
+	}
+	return tags, nil
+}
+
+// FindHashtags returns the normalized #hashtags in text without duplicates.
+// A hashtag must not directly follow a tag character, '#' or '&', so
+// "C#" and HTML entities such as "&#39;" are not taken for one. Trailing
+// hyphens are punctuation.
+func FindHashtags(text string) []string {
+	var tags []string
+	var prev rune
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '#' || isTagRune(prev) || prev == '#' || prev == '&' {
+			prev = r
+			i += size
+			continue
+		}
+
+		end := i + size
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isTagRune(next) {
+				break
+			}
+			end += nextSize
+		}
+		for end > i+size && text[end-1] == '-' {
+			end--
+		}
+
+		if tag, err := NormalizeTag(text[i+size : end]); err == nil && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		prev, _ = utf8.DecodeLastRuneInString(text[:end])
+		i = end
+	}
+	return tags
+}
+
+// PostTags returns the tags a post is indexed under: its explicit tags
+// followed by the hashtags in its content, normalized, without duplicates
+// and at most MaxTags. Explicit tags that are not valid are skipped, which
+// only happens for tags stored before they were normalized.
+func PostTags(explicit []string, content string) []string {
+	var tags []string
+	for _, raw := range explicit {
+		if tag, err := NormalizeTag(raw); err == nil && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range FindHashtags(content) {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTags {
+		tags = tags[:MaxTags]
+	}
+	return tags
+}
@@ -3,6 +3,11 @@ package domain
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,6 +18,90 @@ type User struct {
 	Username string
 	Email    string
 	Password []byte
+	Profile  Profile
+}
+
+// Profile limits, counted in characters
+const (
+	MaxDisplayNameLength       = 100
+	MaxBioLength               = 500
+	MaxWebsiteLength           = 255
+	MaxLocationLength          = 100
+	MaxPronounsLength          = 40
+	MaxProfileFields           = 4
+	MaxProfileFieldNameLength  = 50
+	MaxProfileFieldValueLength = 255
+)
+
+// Profile is what a user tells others about themselves. Every part is
+// optional.
+type Profile struct {
+	DisplayName string
+	Bio         string
+	// AvatarID is one of the user's own image uploads
+	AvatarID *MediaID
+	Website  string
+	Location string
+	Pronouns string
+	// Fields are free-form name/value pairs, shown in order
+	Fields []ProfileField
+}
+
+type ProfileField struct {
+	Name  string
+	Value string
+}
+
+// Validate checks the profile against the limits above
+func (p *Profile) Validate() error {
+	lengths := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"display name", p.DisplayName, MaxDisplayNameLength},
+		{"bio", p.Bio, MaxBioLength},
+		{"website", p.Website, MaxWebsiteLength},
+		{"location", p.Location, MaxLocationLength},
+		{"pronouns", p.Pronouns, MaxPronounsLength},
+	}
+	for _, l := range lengths {
+		if utf8.RuneCountInString(l.value) > l.max {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidProfile, l.name, l.max)
+		}
+	}
+	if strings.ContainsAny(p.DisplayName, "\r\n") || strings.ContainsAny(p.Location, "\r\n") || strings.ContainsAny(p.Pronouns, "\r\n") {
+		return fmt.Errorf("%w: only the bio may span lines", ErrInvalidProfile)
+	}
+	if p.Website != "" {
+		// Anything but http(s) could run script when clicked
+		u, err := url.Parse(p.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: website must be an http or https URL", ErrInvalidProfile)
+		}
+	}
+
+	if len(p.Fields) > MaxProfileFields {
+		return fmt.Errorf("%w: at most %d fields are allowed", ErrInvalidProfile, MaxProfileFields)
+	}
+	seen := make(map[string]bool, len(p.Fields))
+	for _, field := range p.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("%w: field names must not be empty", ErrInvalidProfile)
+		}
+		if utf8.RuneCountInString(field.Name) > MaxProfileFieldNameLength || utf8.RuneCountInString(field.Value) > MaxProfileFieldValueLength {
+			return fmt.Errorf("%w: field %q is too long", ErrInvalidProfile, field.Name)
+		}
+		if strings.ContainsAny(field.Name, "\r\n") || strings.ContainsAny(field.Value, "\r\n") {
+			return fmt.Errorf("%w: field %q spans lines", ErrInvalidProfile, field.Name)
+		}
+		key := strings.ToLower(field.Name)
+		if seen[key] {
+			return fmt.Errorf("%w: field %q is given twice", ErrInvalidProfile, field.Name)
+		}
+		seen[key] = true
+	}
+	return nil
 }
 
 // Validate validates user data
@@ -27,7 +116,7 @@ func (u *User) Validate() error {
 		return ErrInvalidEmail
 	}
 	// Email format validation should be done at DTO level with validator
-	return nil
+	return u.Profile.Validate()
 }
 
 // SetPassword hashes and sets the password
@@ -70,6 +159,15 @@ func (u *User) UpdateEmail(newEmail string) error {
 	return nil
 }
 
+// UpdateProfile replaces the profile if valid
+func (u *User) UpdateProfile(profile Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	u.Profile = profile
+	return nil
+}
+
 type UserExistsChecker interface {
 	UserExists(ctx context.Context, userID UserID) (bool, error)
 }
@@ -0,0 +1,11 @@
+package domain
+
+// CheckVersion compares the stored version of an aggregate with the version a
+// client based its change on. A nil expected version means the client did not
+// ask for a precondition.
+func CheckVersion(current uint, expected *uint) error {
+	if expected != nil && *expected != current {
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -37,6 +37,17 @@ func GetBool(key string, fallback bool) bool {
 	return fallback
 }
 
+func GetFloat(key string, fallback float64) float64 {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	if floatValue, err := strconv.ParseFloat(val, 64); err == nil {
+		return floatValue
+	}
+	return fallback
+}
+
 func GetStringSlice(key string, fallback []string) []string {
 	val, ok := os.LookupEnv(key)
 	if !ok {
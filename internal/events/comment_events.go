@@ -0,0 +1,36 @@
+package events
+
+import "github.com/urdogan0000/social/internal/domain"
+
+// CommentCreated is fired when a comment is created
+type CommentCreated struct {
+	CommentID domain.CommentID
+	PostID    domain.PostID
+	UserID    domain.UserID
+}
+
+func (e CommentCreated) Type() string {
+	return "comment.created"
+}
+
+// CommentUpdated is fired when a comment is updated
+type CommentUpdated struct {
+	CommentID domain.CommentID
+	PostID    domain.PostID
+	UserID    domain.UserID
+}
+
+func (e CommentUpdated) Type() string {
+	return "comment.updated"
+}
+
+// CommentDeleted is fired when a comment is deleted
+type CommentDeleted struct {
+	CommentID domain.CommentID
+	PostID    domain.PostID
+	UserID    domain.UserID
+}
+
+func (e CommentDeleted) Type() string {
+	return "comment.deleted"
+}
@@ -75,3 +75,23 @@ func (bus *InMemoryEventBus) Unsubscribe(eventType string, handler EventHandler)
 	}
 }
 
+
+// Ping reports whether the bus can deliver events. The in-memory bus is always
+// reachable.
+func (bus *InMemoryEventBus) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Pinger is implemented by buses that can check their broker connection
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks bus connectivity when the bus supports it. Decorators forward
+// their Ping here so the check reaches the underlying bus.
+func Ping(ctx context.Context, bus EventBus) error {
+	if p, ok := bus.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
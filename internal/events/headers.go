@@ -0,0 +1,42 @@
+package events
+
+import "context"
+
+// Headers is the envelope metadata that travels with an event, such as W3C
+// trace context. The in-memory bus passes it through the handler context;
+// buses that hand events to other goroutines or processes must copy it into
+// the handler context on the other side.
+type Headers map[string]string
+
+// Get returns the value for key
+func (h Headers) Get(key string) string {
+	return h[key]
+}
+
+// Set stores value under key
+func (h Headers) Set(key, value string) {
+	h[key] = value
+}
+
+// Keys lists the stored keys
+func (h Headers) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type headersKey struct{}
+
+// ContextWithHeaders attaches envelope headers to ctx
+func ContextWithHeaders(ctx context.Context, headers Headers) context.Context {
+	return context.WithValue(ctx, headersKey{}, headers)
+}
+
+// HeadersFromContext returns the envelope headers of the event being
+// published or handled, or nil
+func HeadersFromContext(ctx context.Context) Headers {
+	headers, _ := ctx.Value(headersKey{}).(Headers)
+	return headers
+}
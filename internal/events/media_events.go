@@ -0,0 +1,13 @@
+package events
+
+import "github.com/urdogan0000/social/internal/domain"
+
+// MediaUploaded is fired when a file has been uploaded and awaits processing
+type MediaUploaded struct {
+	MediaID domain.MediaID
+	UserID  domain.UserID
+}
+
+func (e MediaUploaded) Type() string {
+	return "media.uploaded"
+}
@@ -0,0 +1,16 @@
+package events
+
+import "github.com/urdogan0000/social/internal/domain"
+
+// UserMentioned is fired when a user is first mentioned in a published post
+// or in a comment. CommentID is zero for mentions in the post itself.
+type UserMentioned struct {
+	UserID    domain.UserID
+	AuthorID  domain.UserID
+	PostID    domain.PostID
+	CommentID domain.CommentID
+}
+
+func (e UserMentioned) Type() string {
+	return "user.mentioned"
+}
@@ -0,0 +1,15 @@
+package events
+
+import "github.com/urdogan0000/social/internal/domain"
+
+// PollClosed is fired once when a poll's end time has passed, so its author
+// can be notified of the result
+type PollClosed struct {
+	PollID   domain.PollID
+	PostID   domain.PostID
+	AuthorID domain.UserID
+}
+
+func (e PollClosed) Type() string {
+	return "poll.closed"
+}
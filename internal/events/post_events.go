@@ -34,3 +34,26 @@ func (e PostDeleted) Type() string {
 	return "post.deleted"
 }
 
+// PostReposted is fired when a user reposts a post. PostID is the repost and
+// OriginalID the post it shares.
+type PostReposted struct {
+	PostID     domain.PostID
+	OriginalID domain.PostID
+	UserID     domain.UserID
+	AuthorID   domain.UserID
+}
+
+func (e PostReposted) Type() string {
+	return "post.reposted"
+}
+
+// PostUnreposted is fired when a user takes a repost back
+type PostUnreposted struct {
+	PostID     domain.PostID
+	OriginalID domain.PostID
+	UserID     domain.UserID
+}
+
+func (e PostUnreposted) Type() string {
+	return "post.unreposted"
+}
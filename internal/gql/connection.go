@@ -0,0 +1,112 @@
+package gql
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// Connections page over the services' offset pagination. A cursor encodes
+// the absolute offset of its edge, so "after" resumes right behind it. Rows
+// inserted ahead of a cursor shift the window, the same trade-off the REST
+// limit/offset parameters make.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+type page struct {
+	limit  int
+	offset int
+}
+
+// pageArgs reads the first/after arguments of a connection field
+func pageArgs(args map[string]interface{}) (page, error) {
+	p := page{limit: defaultPageSize}
+	if first, ok := args["first"].(int); ok {
+		if first < 0 {
+			return page{}, errInvalidFirst
+		}
+		p.limit = min(first, maxPageSize)
+	}
+	if after, ok := args["after"].(string); ok && after != "" {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return page{}, err
+		}
+		p.offset = offset + 1
+	}
+	return p, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	value, ok := strings.CutPrefix(string(raw), cursorPrefix)
+	if !ok {
+		return 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+type connection struct {
+	Edges      []edge   `json:"edges"`
+	PageInfo   pageInfo `json:"pageInfo"`
+	TotalCount *int64   `json:"totalCount"`
+}
+
+type edge struct {
+	Cursor string      `json:"cursor"`
+	Node   interface{} `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// newConnection wraps one page of nodes. total is nil for lookups the
+// services cannot count.
+func newConnection[T any](nodes []T, p page, hasNext bool, total *int64) *connection {
+	conn := &connection{
+		Edges:      make([]edge, len(nodes)),
+		TotalCount: total,
+		PageInfo:   pageInfo{HasNextPage: hasNext, HasPreviousPage: p.offset > 0},
+	}
+	for i := range nodes {
+		conn.Edges[i] = edge{Cursor: encodeCursor(p.offset + i), Node: &nodes[i]}
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn
+}
+
+// countedConnection builds a connection from a page of a counted list
+func countedConnection[T any](nodes []T, p page, total int64) *connection {
+	return newConnection(nodes, p, int64(p.offset+len(nodes)) < total, &total)
+}
+
+// probedConnection builds a connection from a lookup that fetched one row
+// more than requested to find out whether another page exists
+func probedConnection[T any](nodes []T, p page) *connection {
+	hasNext := len(nodes) > p.limit
+	if hasNext {
+		nodes = nodes[:p.limit]
+	}
+	return newConnection(nodes, p, hasNext, nil)
+}
@@ -0,0 +1,83 @@
+package gql
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/urdogan0000/social/internal/domain"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+var (
+	errInvalidID     = errors.New("invalid id")
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidFirst  = errors.New("first must not be negative")
+)
+
+// Error is a resolver failure with the same stable code the REST API would
+// use. The handler turns it into a localized message and extensions.
+type Error struct {
+	Code   string
+	Status int
+	// Fields is set for validation failures
+	Fields validator.ValidationErrors
+	params map[string]interface{}
+	err    error
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// newError reports a client error identified by code
+func newError(status int, code string) *Error {
+	return &Error{Code: code, Status: status}
+}
+
+// fail maps a service error to its code like httputil.RespondDomainError;
+// fallbackCode names the operation for unexpected errors
+func fail(err error, fallbackCode string) *Error {
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		return &Error{Code: "validation_failed", Status: http.StatusBadRequest, Fields: ve, err: err}
+	}
+	switch {
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return &Error{Code: "edit_conflict", Status: http.StatusConflict, err: err}
+	case errors.Is(err, errInvalidID):
+		return &Error{Code: "invalid_id", Status: http.StatusBadRequest, err: err}
+	case errors.Is(err, errInvalidCursor):
+		return &Error{Code: "invalid_cursor", Status: http.StatusBadRequest, err: err}
+	case errors.Is(err, errInvalidFirst):
+		return &Error{Code: "invalid_page_size", Status: http.StatusBadRequest, err: err}
+	}
+	status, code := httputil.ProblemFor(err, fallbackCode)
+	return &Error{Code: code, Status: status, err: err}
+}
+
+// resolverError digs the *Error out of the wrappers the executor adds. Thunk
+// failures are wrapped twice, which also hides them from ExtendedError.
+func resolverError(err error) *Error {
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			return e
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package gql
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/urdogan0000/social/internal/domain"
+	httputil "github.com/urdogan0000/social/internal/http"
+	appi18n "github.com/urdogan0000/social/internal/i18n"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+type Handler struct {
+	schema   graphql.Schema
+	limits   Limits
+	userRepo domain.UserRepository
+}
+
+func NewHandler(schema graphql.Schema, limits Limits, userRepo domain.UserRepository) *Handler {
+	return &Handler{
+		schema:   schema,
+		limits:   limits,
+		userRepo: userRepo,
+	}
+}
+
+// Request is a GraphQL request as sent in a POST body
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP godoc
+// @Summary GraphQL endpoint
+// @Description Run a GraphQL query or mutation over users, posts, comments and tags. Queries may also be sent as GET with query, operationName and variables parameters. Mutations need a bearer token. Queries deeper or more complex than the configured limits are rejected before they run
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body Request true "GraphQL request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} httputil.Problem
+// @Failure 405 {object} httputil.Problem
+// @Security BearerAuth
+// @Router /graphql [post]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if req.Query == "" {
+		httputil.RespondError(w, r, http.StatusBadRequest, "graphql_query_required")
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		h.respond(w, r, http.StatusBadRequest, &graphql.Result{
+			Errors: withCode(gqlerrors.FormatErrors(err), "graphql_parse_failed"),
+		})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		h.respond(w, r, http.StatusBadRequest, &graphql.Result{
+			Errors: withCode(validation.Errors, "graphql_validation_failed"),
+		})
+		return
+	}
+
+	// GET must stay safe, so it cannot carry mutations
+	operation := selectOperation(doc, req.OperationName)
+	if r.Method == http.MethodGet && operation != nil && operation.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", "POST")
+		httputil.RespondError(w, r, http.StatusMethodNotAllowed, "graphql_mutation_requires_post")
+		return
+	}
+
+	if err := h.limits.Check(&h.schema, doc, req.OperationName, req.Variables); err != nil {
+		h.respond(w, r, http.StatusBadRequest, &graphql.Result{
+			Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)},
+		})
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       WithLoaders(r.Context(), h.userRepo),
+	})
+	h.respond(w, r, http.StatusOK, result)
+}
+
+// respond localizes resolver errors the way problem details are localized and
+// tags them with their stable code. Unexpected errors are logged since the
+// client only sees the code's message.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, status int, result *graphql.Result) {
+	for i := range result.Errors {
+		e := resolverError(result.Errors[i])
+		if e == nil {
+			continue
+		}
+		if e.Status >= http.StatusInternalServerError {
+			logger.FromContext(r.Context()).Error().Err(e).Str("code", e.Code).Msg("GraphQL resolver failed")
+		}
+
+		extensions := map[string]interface{}{"code": e.Code}
+		for k, v := range e.params {
+			extensions[strings.ToLower(k)] = v
+		}
+		if len(e.Fields) > 0 {
+			extensions["errors"] = httputil.FieldErrors(r, e.Fields)
+		}
+		result.Errors[i].Message = appi18n.T(r, e.Code, e.params)
+		result.Errors[i].Extensions = extensions
+	}
+	httputil.RespondJSON(w, status, result)
+}
+
+func withCode(errs []gqlerrors.FormattedError, code string) []gqlerrors.FormattedError {
+	for i := range errs {
+		errs[i].Extensions = map[string]interface{}{"code": code}
+	}
+	return errs
+}
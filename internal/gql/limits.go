@@ -0,0 +1,176 @@
+package gql
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound how much work one query may ask for. They are checked on the
+// validated document, before any resolver runs.
+type Limits struct {
+	// MaxDepth is the deepest allowed field nesting; root fields are depth 1
+	MaxDepth int
+	// MaxComplexity caps the estimated number of resolved fields. Every field
+	// costs one, and the selection under a connection counts once per
+	// requested edge.
+	MaxComplexity int
+}
+
+// Check measures the operation in doc and rejects it when it is over a limit.
+// Zero limits are not enforced.
+func (l Limits) Check(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) *Error {
+	depth, complexity := Measure(schema, doc, operationName, variables)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return limitError("query_too_deep", l.MaxDepth, depth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return limitError("query_too_complex", l.MaxComplexity, complexity)
+	}
+	return nil
+}
+
+func limitError(code string, limit, actual int) *Error {
+	err := newError(http.StatusBadRequest, code)
+	err.params = map[string]interface{}{"Max": limit, "Actual": actual}
+	return err
+}
+
+// Measure returns the depth and complexity of the selected operation.
+// Introspection fields are free since the schema bounds them.
+func Measure(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (depth, complexity int) {
+	m := &measurer{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	operation := selectOperation(doc, operationName)
+	if operation == nil {
+		return 0, 0
+	}
+
+	var root graphql.Type = schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	return m.selectionSet(operation.SelectionSet, root, 0)
+}
+
+// selectOperation finds the operation a request runs: the named one, or the
+// only one in the document
+func selectOperation(doc *ast.Document, operationName string) *ast.OperationDefinition {
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		def, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+			operation = def
+		}
+	}
+	return operation
+}
+
+type measurer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+type fieldsOwner interface {
+	Fields() graphql.FieldDefinitionMap
+}
+
+func (m *measurer) selectionSet(set *ast.SelectionSet, parent graphql.Type, depth int) (maxDepth, cost int) {
+	maxDepth = depth
+	if set == nil {
+		return maxDepth, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = m.field(selection, parent, depth)
+		case *ast.InlineFragment:
+			t := parent
+			if selection.TypeCondition != nil {
+				t = m.schema.Type(selection.TypeCondition.Name.Value)
+			}
+			d, c = m.selectionSet(selection.SelectionSet, t, depth)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+			m.visiting[name] = true
+			d, c = m.selectionSet(fragment.SelectionSet, m.schema.Type(fragment.TypeCondition.Name.Value), depth)
+			m.visiting[name] = false
+		}
+		maxDepth = max(maxDepth, d)
+		cost += c
+	}
+	return maxDepth, cost
+}
+
+func (m *measurer) field(field *ast.Field, parent graphql.Type, depth int) (int, int) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return depth, 0
+	}
+	owner, ok := parent.(fieldsOwner)
+	if !ok {
+		return depth + 1, 1
+	}
+	def, ok := owner.Fields()[name]
+	if !ok {
+		return depth + 1, 1
+	}
+
+	returnType, ok := graphql.GetNamed(def.Type).(graphql.Type)
+	if !ok {
+		return depth + 1, 1
+	}
+	childDepth, childCost := m.selectionSet(field.SelectionSet, returnType, depth+1)
+	if strings.HasSuffix(returnType.Name(), "Connection") {
+		childCost *= m.pageSize(field)
+	}
+	return childDepth, 1 + childCost
+}
+
+// pageSize is the number of edges a connection field asks for
+func (m *measurer) pageSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		var first int
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			first, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			switch v := m.variables[value.Name.Value].(type) {
+			case int:
+				first = v
+			case float64:
+				first = int(v)
+			default:
+				return defaultPageSize
+			}
+		}
+		return min(max(first, 0), maxPageSize)
+	}
+	return defaultPageSize
+}
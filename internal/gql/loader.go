@@ -0,0 +1,91 @@
+package gql
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+// UserLoader batches user lookups made while resolving one request. Resolvers
+// queue IDs with Load and get a thunk back; the executor calls thunks only
+// after a whole level of the response has been resolved, so the first thunk
+// fetches every queued user in a single GetByIDs call.
+type UserLoader struct {
+	repo domain.UserRepository
+
+	mu      sync.Mutex
+	pending []domain.UserID
+	users   map[domain.UserID]*domain.User
+	errs    map[domain.UserID]error
+}
+
+// NewUserLoader returns a loader meant to live for a single request
+func NewUserLoader(repo domain.UserRepository) *UserLoader {
+	return &UserLoader{
+		repo:  repo,
+		users: make(map[domain.UserID]*domain.User),
+		errs:  make(map[domain.UserID]error),
+	}
+}
+
+// Load queues id and returns a thunk resolving to the user, or nil when the
+// user does not exist
+func (l *UserLoader) Load(ctx context.Context, id domain.UserID) func() (*domain.User, error) {
+	l.mu.Lock()
+	if !l.known(id) && !slices.Contains(l.pending, id) {
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (*domain.User, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.known(id) {
+			l.dispatch(ctx)
+		}
+		return l.users[id], l.errs[id]
+	}
+}
+
+// known reports whether id has been fetched, found or not
+func (l *UserLoader) known(id domain.UserID) bool {
+	_, found := l.users[id]
+	_, failed := l.errs[id]
+	return found || failed
+}
+
+// dispatch fetches all pending users; callers hold mu
+func (l *UserLoader) dispatch(ctx context.Context) {
+	ids := l.pending
+	l.pending = nil
+	if len(ids) == 0 {
+		return
+	}
+
+	users, err := l.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		for _, id := range ids {
+			l.errs[id] = err
+		}
+		return
+	}
+	for _, id := range ids {
+		l.users[id] = nil
+	}
+	for _, user := range users {
+		l.users[user.ID] = user
+	}
+}
+
+type loaderKey struct{}
+
+// WithLoaders attaches fresh per-request loaders to ctx
+func WithLoaders(ctx context.Context, userRepo domain.UserRepository) context.Context {
+	return context.WithValue(ctx, loaderKey{}, NewUserLoader(userRepo))
+}
+
+func userLoader(ctx context.Context) *UserLoader {
+	return ctx.Value(loaderKey{}).(*UserLoader)
+}
@@ -0,0 +1,425 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/internal/validator"
+	"github.com/urdogan0000/social/posts"
+	"github.com/urdogan0000/social/users"
+)
+
+// Resolver answers GraphQL fields with the same services the REST handlers
+// use, so visibility rules, validation and auditing stay in one place
+type Resolver struct {
+	userService    *users.Service
+	postService    *posts.Service
+	commentService *comments.Service
+}
+
+func NewResolver(userService *users.Service, postService *posts.Service, commentService *comments.Service) *Resolver {
+	return &Resolver{
+		userService:    userService,
+		postService:    postService,
+		commentService: commentService,
+	}
+}
+
+type tag struct {
+	Name string
+}
+
+// viewerID is the authenticated user, or zero for anonymous requests
+func viewerID(ctx context.Context) uint {
+	userID, _ := middleware.GetUserID(ctx)
+	return userID
+}
+
+// requireViewer is the GraphQL counterpart of AuthMiddleware for mutations
+func requireViewer(ctx context.Context) (uint, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return 0, newError(http.StatusUnauthorized, "authorization_required")
+	}
+	return userID, nil
+}
+
+func parseID(value interface{}) (uint, error) {
+	s, _ := value.(string)
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, errInvalidID
+	}
+	return uint(id), nil
+}
+
+func optionalVersion(args map[string]interface{}) *uint {
+	version, ok := args["expectedVersion"].(int)
+	if !ok || version < 0 {
+		return nil
+	}
+	v := uint(version)
+	return &v
+}
+
+// orNull turns not found into a null field, the GraphQL way of saying so
+func orNull[T any](value *T, err error, fallbackCode string) (interface{}, error) {
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fail(err, fallbackCode)
+	}
+	return value, nil
+}
+
+// loadUser resolves to the user through the request's loader, batching the
+// lookup with every other user requested at the same depth
+func loadUser(ctx context.Context, id uint) interface{} {
+	thunk := userLoader(ctx).Load(ctx, domain.UserID(id))
+	return func() (interface{}, error) {
+		user, err := thunk()
+		if err != nil {
+			return nil, fail(err, "failed_to_get_user")
+		}
+		if user == nil {
+			return nil, nil
+		}
+		return user, nil
+	}
+}
+
+func userResponseToDomain(user *users.Response) *domain.User {
+	return &domain.User{ID: domain.UserID(user.ID), Username: user.Username}
+}
+
+func (r *Resolver) viewer(p graphql.ResolveParams) (interface{}, error) {
+	userID, ok := middleware.GetUserID(p.Context)
+	if !ok {
+		return nil, nil
+	}
+	return loadUser(p.Context, userID), nil
+}
+
+func (r *Resolver) user(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	user, err := r.userService.GetByID(p.Context, id)
+	if err != nil {
+		return orNull(user, err, "failed_to_get_user")
+	}
+	return userResponseToDomain(user), nil
+}
+
+func (r *Resolver) userByUsername(p graphql.ResolveParams) (interface{}, error) {
+	username, _ := p.Args["username"].(string)
+	user, err := r.userService.GetByUsername(p.Context, username)
+	if err != nil {
+		return orNull(user, err, "failed_to_get_user")
+	}
+	return userResponseToDomain(user), nil
+}
+
+func (r *Resolver) users(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	result, err := r.userService.List(p.Context, pg.limit, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_list_users")
+	}
+	nodes := make([]domain.User, len(result.Users))
+	for i := range result.Users {
+		nodes[i] = *userResponseToDomain(&result.Users[i])
+	}
+	return countedConnection(nodes, pg, result.Total), nil
+}
+
+func (r *Resolver) userPosts(p graphql.ResolveParams) (interface{}, error) {
+	user := p.Source.(*domain.User)
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	result, err := r.postService.GetByUserID(p.Context, uint(user.ID), viewerID(p.Context), pg.limit, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_get_user_posts")
+	}
+	return countedConnection(result.Posts, pg, result.Total), nil
+}
+
+func (r *Resolver) post(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	post, err := r.postService.GetByID(p.Context, id, viewerID(p.Context))
+	return orNull(post, err, "failed_to_get_post")
+}
+
+func (r *Resolver) posts(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	result, err := r.postService.List(p.Context, viewerID(p.Context), pg.limit, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_list_posts")
+	}
+	return countedConnection(result.Posts, pg, result.Total), nil
+}
+
+func (r *Resolver) searchPosts(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	title, _ := p.Args["title"].(string)
+	if title == "" {
+		return nil, newError(http.StatusBadRequest, "search_query_required")
+	}
+	result, err := r.postService.SearchByTitle(p.Context, title, viewerID(p.Context), pg.limit+1, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_search_posts")
+	}
+	return probedConnection(result, pg), nil
+}
+
+func (r *Resolver) tag(p graphql.ResolveParams) (interface{}, error) {
+	name, _ := p.Args["name"].(string)
+	if name == "" {
+		return nil, newError(http.StatusBadRequest, "tags_parameter_required")
+	}
+	return &tag{Name: name}, nil
+}
+
+func (r *Resolver) tagPosts(p graphql.ResolveParams) (interface{}, error) {
+	t := p.Source.(*tag)
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	result, err := r.postService.GetByTags(p.Context, []string{t.Name}, viewerID(p.Context), pg.limit+1, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_get_posts_by_tags")
+	}
+	return probedConnection(result, pg), nil
+}
+
+func (r *Resolver) postTags(p graphql.ResolveParams) (interface{}, error) {
+	post := p.Source.(*posts.Response)
+	tags := make([]*tag, len(post.Tags))
+	for i, name := range post.Tags {
+		tags[i] = &tag{Name: name}
+	}
+	return tags, nil
+}
+
+func (r *Resolver) postAuthor(p graphql.ResolveParams) (interface{}, error) {
+	return loadUser(p.Context, p.Source.(*posts.Response).UserID), nil
+}
+
+func (r *Resolver) postComments(p graphql.ResolveParams) (interface{}, error) {
+	post := p.Source.(*posts.Response)
+	pg, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	result, err := r.commentService.GetByPostID(p.Context, post.ID, viewerID(p.Context), pg.limit, pg.offset)
+	if err != nil {
+		return nil, fail(err, "failed_to_get_comments")
+	}
+	return countedConnection(result.Comments, pg, result.Total), nil
+}
+
+func (r *Resolver) comment(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	comment, err := r.commentService.GetByID(p.Context, id, viewerID(p.Context))
+	return orNull(comment, err, "failed_to_get_comment")
+}
+
+func (r *Resolver) commentAuthor(p graphql.ResolveParams) (interface{}, error) {
+	return loadUser(p.Context, p.Source.(*comments.Response).UserID), nil
+}
+
+func (r *Resolver) createPost(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := posts.CreateRequest{
+		Title:      stringArg(input, "title"),
+		Content:    stringArg(input, "content"),
+		Tags:       stringsArg(input, "tags"),
+		Visibility: stringArg(input, "visibility"),
+		Status:     stringArg(input, "status"),
+		PublishAt:  timeArg(input, "publishAt"),
+	}
+	if err := validator.Validate(&req); err != nil {
+		return nil, fail(err, "")
+	}
+	post, err := r.postService.Create(p.Context, userID, req)
+	if err != nil {
+		return nil, fail(err, "failed_to_create_post")
+	}
+	return post, nil
+}
+
+func (r *Resolver) updatePost(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := posts.UpdateRequest{
+		Title:      optionalStringArg(input, "title"),
+		Content:    optionalStringArg(input, "content"),
+		Visibility: optionalStringArg(input, "visibility"),
+		Status:     optionalStringArg(input, "status"),
+		PublishAt:  timeArg(input, "publishAt"),
+	}
+	if _, ok := input["tags"]; ok {
+		tags := stringsArg(input, "tags")
+		req.Tags = &tags
+	}
+	if err := validator.Validate(&req); err != nil {
+		return nil, fail(err, "")
+	}
+	post, err := r.postService.Update(p.Context, id, userID, req, optionalVersion(p.Args))
+	if err != nil {
+		return nil, fail(err, "failed_to_update_post")
+	}
+	return post, nil
+}
+
+func (r *Resolver) deletePost(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	if err := r.postService.Delete(p.Context, id, userID, optionalVersion(p.Args)); err != nil {
+		return nil, fail(err, "failed_to_delete_post")
+	}
+	return true, nil
+}
+
+func (r *Resolver) createComment(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	input, _ := p.Args["input"].(map[string]interface{})
+	postID, err := parseID(input["postId"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	req := comments.CreateRequest{
+		PostID:  postID,
+		Content: stringArg(input, "content"),
+	}
+	if err := validator.Validate(&req); err != nil {
+		return nil, fail(err, "")
+	}
+	comment, err := r.commentService.Create(p.Context, userID, req)
+	if err != nil {
+		return nil, fail(err, "failed_to_create_comment")
+	}
+	return comment, nil
+}
+
+func (r *Resolver) updateComment(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := comments.UpdateRequest{
+		Content: optionalStringArg(input, "content"),
+	}
+	if err := validator.Validate(&req); err != nil {
+		return nil, fail(err, "")
+	}
+	comment, err := r.commentService.Update(p.Context, id, userID, req, optionalVersion(p.Args))
+	if err != nil {
+		return nil, fail(err, "failed_to_update_comment")
+	}
+	return comment, nil
+}
+
+func (r *Resolver) deleteComment(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, fail(err, "")
+	}
+	if err := r.commentService.Delete(p.Context, id, userID, optionalVersion(p.Args)); err != nil {
+		return nil, fail(err, "failed_to_delete_comment")
+	}
+	return true, nil
+}
+
+func stringArg(input map[string]interface{}, name string) string {
+	value, _ := input[name].(string)
+	return value
+}
+
+func optionalStringArg(input map[string]interface{}, name string) *string {
+	value, ok := input[name].(string)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+func stringsArg(input map[string]interface{}, name string) []string {
+	values, ok := input[name].([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func timeArg(input map[string]interface{}, name string) *time.Time {
+	switch value := input[name].(type) {
+	case time.Time:
+		return &value
+	case *time.Time:
+		return value
+	}
+	return nil
+}
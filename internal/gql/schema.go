@@ -0,0 +1,284 @@
+package gql
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// connectionArgs are the forward pagination arguments of every connection
+var connectionArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Page size, at most 100", DefaultValue: defaultPageSize},
+	"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "Cursor of the edge to continue after"},
+}
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"startCursor":     &graphql.Field{Type: graphql.String},
+		"endCursor":       &graphql.Field{Type: graphql.String},
+	},
+})
+
+// connectionType builds the Relay style connection and edge types for node.
+// The name must end in Connection for the complexity limit to see it.
+func connectionType(node *graphql.Object) *graphql.Object {
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(node)},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: node.Name() + "Connection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Total number of nodes, when the lookup can count them",
+			},
+		},
+	})
+}
+
+// NewSchema builds the schema on top of the resolver's services
+func NewSchema(r *Resolver) (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "User",
+		Fields: graphql.Fields{},
+	})
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Post",
+		Fields: graphql.Fields{},
+	})
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Comment",
+		Fields: graphql.Fields{},
+	})
+	tagType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Tag",
+		Fields: graphql.Fields{},
+	})
+	userConnection := connectionType(userType)
+	postConnection := connectionType(postType)
+	commentConnection := connectionType(commentType)
+
+	// The types refer to each other, so their fields are added once all exist
+	userType.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.ID)})
+	userType.AddFieldConfig("username", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	userType.AddFieldConfig("posts", &graphql.Field{
+		Type:    graphql.NewNonNull(postConnection),
+		Args:    connectionArgs,
+		Resolve: r.userPosts,
+	})
+
+	postType.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.ID)})
+	postType.AddFieldConfig("title", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("content", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("tags", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+		Resolve: r.postTags,
+	})
+	postType.AddFieldConfig("visibility", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("status", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("publishAt", &graphql.Field{Type: graphql.String})
+	postType.AddFieldConfig("edited", &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)})
+	postType.AddFieldConfig("editedAt", &graphql.Field{Type: graphql.String})
+	postType.AddFieldConfig("version", &graphql.Field{Type: graphql.NewNonNull(graphql.Int)})
+	postType.AddFieldConfig("createdAt", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("updatedAt", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	postType.AddFieldConfig("author", &graphql.Field{
+		Type:        userType,
+		Description: "Null once the author's account is deleted",
+		Resolve:     r.postAuthor,
+	})
+	postType.AddFieldConfig("comments", &graphql.Field{
+		Type:    graphql.NewNonNull(commentConnection),
+		Args:    connectionArgs,
+		Resolve: r.postComments,
+	})
+
+	commentType.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.ID)})
+	commentType.AddFieldConfig("postId", &graphql.Field{Type: graphql.NewNonNull(graphql.ID)})
+	commentType.AddFieldConfig("content", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	commentType.AddFieldConfig("version", &graphql.Field{Type: graphql.NewNonNull(graphql.Int)})
+	commentType.AddFieldConfig("createdAt", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	commentType.AddFieldConfig("updatedAt", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	commentType.AddFieldConfig("author", &graphql.Field{
+		Type:        userType,
+		Description: "Null once the author's account is deleted",
+		Resolve:     r.commentAuthor,
+	})
+
+	tagType.AddFieldConfig("name", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	tagType.AddFieldConfig("posts", &graphql.Field{
+		Type:    graphql.NewNonNull(postConnection),
+		Args:    connectionArgs,
+		Resolve: r.tagPosts,
+	})
+
+	idArg := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+	versionedIDArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"expectedVersion": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Fail with a conflict unless this is still the current version",
+		},
+	}
+	withArgs := func(args graphql.FieldConfigArgument, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		merged := graphql.FieldConfigArgument{}
+		for name, arg := range args {
+			merged[name] = arg
+		}
+		for name, arg := range extra {
+			merged[name] = arg
+		}
+		return merged
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"viewer": &graphql.Field{
+				Type:        userType,
+				Description: "The user the bearer token belongs to; null for anonymous requests",
+				Resolve:     r.viewer,
+			},
+			"user": &graphql.Field{
+				Type:    userType,
+				Args:    idArg,
+				Resolve: r.user,
+			},
+			"userByUsername": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.userByUsername,
+			},
+			"users": &graphql.Field{
+				Type:    graphql.NewNonNull(userConnection),
+				Args:    connectionArgs,
+				Resolve: r.users,
+			},
+			"post": &graphql.Field{
+				Type:    postType,
+				Args:    idArg,
+				Resolve: r.post,
+			},
+			"posts": &graphql.Field{
+				Type:    graphql.NewNonNull(postConnection),
+				Args:    connectionArgs,
+				Resolve: r.posts,
+			},
+			"searchPosts": &graphql.Field{
+				Type: graphql.NewNonNull(postConnection),
+				Args: withArgs(connectionArgs, graphql.FieldConfigArgument{
+					"title": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				}),
+				Resolve: r.searchPosts,
+			},
+			"tag": &graphql.Field{
+				Type: graphql.NewNonNull(tagType),
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.tag,
+			},
+			"comment": &graphql.Field{
+				Type:    commentType,
+				Args:    idArg,
+				Resolve: r.comment,
+			},
+		},
+	})
+
+	createPostInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreatePostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":       &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"visibility": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"status":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"publishAt":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		},
+	})
+	updatePostInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdatePostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"content":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tags":       &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"visibility": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"status":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"publishAt":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		},
+	})
+	createCommentInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateCommentInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"postId":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"content": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	updateCommentInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateCommentInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"content": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createPostInput)},
+				},
+				Resolve: r.createPost,
+			},
+			"updatePost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: withArgs(versionedIDArgs, graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updatePostInput)},
+				}),
+				Resolve: r.updatePost,
+			},
+			"deletePost": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    versionedIDArgs,
+				Resolve: r.deletePost,
+			},
+			"createComment": &graphql.Field{
+				Type: graphql.NewNonNull(commentType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createCommentInput)},
+				},
+				Resolve: r.createComment,
+			},
+			"updateComment": &graphql.Field{
+				Type: graphql.NewNonNull(commentType),
+				Args: withArgs(versionedIDArgs, graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateCommentInput)},
+				}),
+				Resolve: r.updateComment,
+			},
+			"deleteComment": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    versionedIDArgs,
+				Resolve: r.deleteComment,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck pings the database connection pool
+func DatabaseCheck(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// SchemaCheck verifies that the tables and columns of the given models exist,
+// i.e. that the migrations for this build have been applied. Once the schema
+// has been seen up to date the result is remembered, since migrations are not
+// rolled back under a running process.
+func SchemaCheck(db *gorm.DB, models ...interface{}) Check {
+	var migrated atomic.Bool
+	return func(ctx context.Context) error {
+		if migrated.Load() {
+			return nil
+		}
+
+		tx := db.WithContext(ctx)
+		migrator := tx.Migrator()
+		for _, model := range models {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			table := stmt.Schema.Table
+			if !migrator.HasTable(table) {
+				return fmt.Errorf("table %s is missing", table)
+			}
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName == "" {
+					continue
+				}
+				if !migrator.HasColumn(model, field.DBName) {
+					return fmt.Errorf("column %s.%s is missing", table, field.DBName)
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		migrated.Store(true)
+		return nil
+	}
+}
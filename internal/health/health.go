@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Check reports whether a dependency is usable. It should honour the context
+// deadline.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the readiness response body
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Registry runs the readiness checks and tracks whether the process is
+// draining for shutdown
+type Registry struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// NewRegistry creates a registry whose checks each run with the given timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register adds a named readiness check, replacing any check with that name
+func (reg *Registry) Register(name string, check Check) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.checks[name] = check
+}
+
+// SetDraining makes readiness fail so load balancers stop routing new traffic
+// before the server shuts down
+func (reg *Registry) SetDraining() {
+	reg.draining.Store(true)
+}
+
+// Draining reports whether SetDraining has been called
+func (reg *Registry) Draining() bool {
+	return reg.draining.Load()
+}
+
+// Ready runs all checks concurrently and returns the aggregated report
+func (reg *Registry) Ready(ctx context.Context) Report {
+	reg.mu.RLock()
+	names := make([]string, 0, len(reg.checks))
+	for name := range reg.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = reg.checks[name]
+	}
+	reg.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = reg.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	if reg.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (reg *Registry) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, reg.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LiveHandler reports that the process is up. It checks no dependencies, so
+// an outage of the database does not get the process restarted.
+func (reg *Registry) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadyHandler responds 200 when every check passes and 503 otherwise,
+// including while draining
+func (reg *Registry) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := reg.Ready(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	respond(w, status, report)
+}
+
+func respond(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,86 @@
+package httputil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// NotModified sets the ETag and Last-Modified validators of a representation
+// and evaluates If-None-Match, falling back to If-Modified-Since. When the
+// client's copy is current it writes 304 Not Modified and returns true. A zero
+// lastModified omits the Last-Modified validator.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	header := w.Header()
+	// Responses depend on the viewer, so only private caches may keep them and
+	// they must revalidate before reuse
+	header.Set("Cache-Control", "private, no-cache")
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagListMatches(inm, etag) {
+			return false
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// RespondJSONConditional serializes data once, derives a weak ETag from the
+// body and answers 304 when the client already holds the same body. It suits
+// list responses that have no single version or modification time.
+func RespondJSONConditional(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		logger.FromContext(r.Context()).Error().
+			Err(err).
+			Int("status", status).
+			Msg("Failed to encode JSON response")
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	if NotModified(w, r, `W/"`+hex.EncodeToString(sum[:16])+`"`, time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// etagListMatches applies the weak comparison of RFC 9110 to an If-None-Match list
+func etagListMatches(list, etag string) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
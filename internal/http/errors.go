@@ -0,0 +1,106 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings is checked in order with errors.Is, so specific errors come
+// before the generic categories they are joined with
+var errorMappings = []errorMapping{
+	{domain.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{domain.ErrUserAlreadyExists, http.StatusConflict, "user_already_exists"},
+	{domain.ErrUsernameExists, http.StatusConflict, "username_already_exists"},
+	{domain.ErrEmailExists, http.StatusConflict, "email_already_exists"},
+	{domain.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+	{domain.ErrInvalidProfile, http.StatusBadRequest, "invalid_profile"},
+	{domain.ErrUserForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrPostNotFound, http.StatusNotFound, "post_not_found"},
+	{domain.ErrPostForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrInvalidPublishAt, http.StatusBadRequest, "invalid_publish_at"},
+	{domain.ErrPostAlreadyPublished, http.StatusConflict, "post_already_published"},
+	{domain.ErrRevisionNotFound, http.StatusNotFound, "revision_not_found"},
+	{domain.ErrRepostNotFound, http.StatusNotFound, "repost_not_found"},
+	{domain.ErrAlreadyReposted, http.StatusConflict, "already_reposted"},
+	{domain.ErrPostNotShareable, http.StatusForbidden, "post_not_shareable"},
+	{domain.ErrRepostNotEditable, http.StatusBadRequest, "repost_not_editable"},
+	{domain.ErrPostNotPinnable, http.StatusBadRequest, "post_not_pinnable"},
+	{domain.ErrPinLimit, http.StatusConflict, "pin_limit_reached"},
+	{domain.ErrInvalidCommentStatus, http.StatusBadRequest, "invalid_comment_status"},
+	{domain.ErrInvalidReplyAudience, http.StatusBadRequest, "invalid_reply_audience"},
+	{domain.ErrCommentNotFound, http.StatusNotFound, "comment_not_found"},
+	{domain.ErrCommentForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrInvalidCommentContent, http.StatusBadRequest, "invalid_comment_content"},
+	{domain.ErrCommentsDisabled, http.StatusForbidden, "comments_disabled"},
+	{domain.ErrCommentsLocked, http.StatusForbidden, "comments_locked"},
+	{domain.ErrReplyNotAllowed, http.StatusForbidden, "reply_not_allowed"},
+	{domain.ErrCannotFollowSelf, http.StatusBadRequest, "cannot_follow_self"},
+	{domain.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{domain.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{domain.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{domain.ErrIdempotencyKeyInUse, http.StatusConflict, "idempotency_key_in_use"},
+	{domain.ErrMediaNotFound, http.StatusNotFound, "media_not_found"},
+	{domain.ErrMediaTooLarge, http.StatusRequestEntityTooLarge, "media_too_large"},
+	{domain.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{domain.ErrMediaAlreadyAttached, http.StatusConflict, "media_already_attached"},
+	{domain.ErrInvalidMediaSignature, http.StatusForbidden, "invalid_media_url"},
+	{domain.ErrMediaNotReady, http.StatusConflict, "media_not_ready"},
+	{domain.ErrTagNotFound, http.StatusNotFound, "tag_not_found"},
+	{domain.ErrInvalidTag, http.StatusBadRequest, "invalid_tag"},
+	{domain.ErrCollectionNotFound, http.StatusNotFound, "collection_not_found"},
+	{domain.ErrCollectionExists, http.StatusConflict, "collection_already_exists"},
+	{domain.ErrCollectionLimit, http.StatusConflict, "collection_limit_reached"},
+	{domain.ErrInvalidCollectionName, http.StatusBadRequest, "invalid_collection_name"},
+	{domain.ErrInvalidPosition, http.StatusBadRequest, "invalid_position"},
+	{domain.ErrPollNotFound, http.StatusNotFound, "poll_not_found"},
+	{domain.ErrPollExists, http.StatusConflict, "poll_already_exists"},
+	{domain.ErrInvalidPoll, http.StatusBadRequest, "invalid_poll"},
+	{domain.ErrPollClosed, http.StatusConflict, "poll_closed"},
+	{domain.ErrPollHasVotes, http.StatusConflict, "poll_has_votes"},
+	{domain.ErrAlreadyVoted, http.StatusConflict, "already_voted"},
+	{domain.ErrVoteNotFound, http.StatusNotFound, "vote_not_found"},
+	{domain.ErrInvalidVote, http.StatusBadRequest, "invalid_vote"},
+	{domain.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domain.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+}
+
+// ProblemFor maps err to a status and stable code. Unknown errors map to 500
+// with fallbackCode, which names the operation that failed.
+func ProblemFor(err error, fallbackCode string) (int, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, m.code
+		}
+	}
+	return http.StatusInternalServerError, fallbackCode
+}
+
+// RespondDomainError responds with the problem details for err. Version
+// conflicts honour If-Match as RespondVersionMismatch does, and unexpected
+// errors are logged since the client only sees fallbackCode.
+func RespondDomainError(w http.ResponseWriter, r *http.Request, err error, fallbackCode string) {
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		RespondVersionMismatch(w, r)
+		return
+	}
+
+	status, code := ProblemFor(err, fallbackCode)
+	if status >= http.StatusInternalServerError {
+		logger.FromContext(r.Context()).Error().Err(err).Str("code", code).Msg("Request failed")
+	}
+	RespondProblem(w, r, status, code)
+}
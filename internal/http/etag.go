@@ -0,0 +1,54 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidIfMatch is returned for If-Match values that can never match a
+// strong version tag, such as weak tags, lists or malformed values
+var ErrInvalidIfMatch = errors.New("invalid If-Match header")
+
+// ETag formats a resource version as a strong entity tag
+func ETag(version uint) string {
+	return fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10))
+}
+
+// SetETag exposes the resource version to clients for later If-Match requests
+func SetETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// IfMatch reads the version a client expects from the If-Match header. It
+// returns nil when the header is absent or "*", which match any existing
+// resource.
+func IfMatch(r *http.Request) (*uint, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, ErrInvalidIfMatch
+	}
+	version, err := strconv.ParseUint(value[1:len(value)-1], 10, 32)
+	if err != nil {
+		return nil, ErrInvalidIfMatch
+	}
+
+	expected := uint(version)
+	return &expected, nil
+}
+
+// RespondVersionMismatch answers a failed version check. Clients that sent
+// If-Match get 412; otherwise the write lost a race and gets 409.
+func RespondVersionMismatch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") != "" {
+		RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+	RespondError(w, r, http.StatusConflict, "edit_conflict")
+}
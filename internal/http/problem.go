@@ -0,0 +1,119 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	appi18n "github.com/urdogan0000/social/internal/i18n"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix namespaces problem types; the code makes each one unique
+const problemTypePrefix = "urn:social:problem:"
+
+// Problem is an RFC 9457 problem details body. Code is stable and meant for
+// programs; Title is its localized, human readable form.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewProblem builds a problem for the request with a localized title for code
+func NewProblem(r *http.Request, status int, code string) *Problem {
+	return &Problem{
+		Type:      problemTypePrefix + code,
+		Title:     appi18n.T(r, code),
+		Status:    status,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+}
+
+// WriteProblem writes p as application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logger.FromContext(r.Context()).Error().
+			Err(err).
+			Int("status", p.Status).
+			Msg("Failed to encode problem response")
+	}
+}
+
+// RespondProblem responds with the problem identified by code
+func RespondProblem(w http.ResponseWriter, r *http.Request, status int, code string) {
+	WriteProblem(w, r, NewProblem(r, status, code))
+}
+
+// RespondValidationError responds 400 with one entry per rejected field
+func RespondValidationError(w http.ResponseWriter, r *http.Request, validationErr error) {
+	p := NewProblem(r, http.StatusBadRequest, "validation_failed")
+
+	ve, ok := validationErr.(validator.ValidationErrors)
+	if !ok {
+		p.Detail = validationErr.Error()
+		WriteProblem(w, r, p)
+		return
+	}
+
+	p.Errors = FieldErrors(r, ve)
+	details := make([]string, len(p.Errors))
+	for i, fieldErr := range p.Errors {
+		details[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	p.Detail = strings.Join(details, ", ")
+	WriteProblem(w, r, p)
+}
+
+// FieldErrors describes each rejected field with a localized message
+func FieldErrors(r *http.Request, ve validator.ValidationErrors) []FieldError {
+	fieldErrs := make([]FieldError, len(ve))
+	for i, err := range ve {
+		fieldErrs[i] = FieldError{
+			Field:   err.Field,
+			Code:    err.Tag,
+			Param:   err.Param,
+			Message: fieldMessage(r, err),
+		}
+	}
+	return fieldErrs
+}
+
+// fieldMessage localizes a validation failure, falling back to the
+// validator's English message for tags without a translation
+func fieldMessage(r *http.Request, err validator.ValidationError) string {
+	switch err.Tag {
+	case "required", "email":
+		return appi18n.T(r, "field_"+err.Tag)
+	case "min":
+		return appi18n.T(r, "field_min", map[string]string{"Min": err.Param})
+	case "max":
+		return appi18n.T(r, "field_max", map[string]string{"Max": err.Param})
+	case "oneof":
+		return appi18n.T(r, "field_oneof", map[string]string{"Values": strings.ReplaceAll(err.Param, " ", ", ")})
+	default:
+		return err.Message
+	}
+}
@@ -2,15 +2,10 @@ package httputil
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 
-	"github.com/nicksnyder/go-i18n/v2/i18n"
-	appi18n "github.com/urdogan0000/social/internal/i18n"
 	"github.com/urdogan0000/social/internal/logger"
-	"github.com/urdogan0000/social/internal/validator"
 )
 
 func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -25,88 +20,26 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
+// RespondError responds with problem details for the stable error code
+// messageID, whose localized text becomes the title
 func RespondError(w http.ResponseWriter, r *http.Request, status int, messageID string) {
-	message := appi18n.T(r, messageID)
-	RespondJSON(w, status, map[string]string{
-		"error": message,
-	})
+	RespondProblem(w, r, status, messageID)
 }
 
+// RespondErrorWithMessage responds with problem details carrying an
+// unlocalized message, for callers without a request
 func RespondErrorWithMessage(w http.ResponseWriter, status int, message string) {
-	RespondJSON(w, status, map[string]string{
-		"error": message,
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&Problem{
+		Type:   problemTypePrefix + "error",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: message,
+		Code:   "error",
 	})
 }
 
-// RespondValidationError responds with i18n localized validation errors
-func RespondValidationError(w http.ResponseWriter, r *http.Request, validationErr error) {
-	// Check if it's our structured validation error
-	if ve, ok := validationErr.(validator.ValidationErrors); ok {
-		var localizedErrors []string
-		localizer := appi18n.GetLocalizer(r)
-
-		for _, err := range ve {
-			var message string
-			switch err.Tag {
-			case "required":
-				msg, _ := localizer.Localize(&i18n.LocalizeConfig{
-					MessageID: "field_required",
-				})
-				message = fmt.Sprintf("%s: %s", err.Field, msg)
-			case "email":
-				msg, _ := localizer.Localize(&i18n.LocalizeConfig{
-					MessageID: "field_email",
-				})
-				message = fmt.Sprintf("%s: %s", err.Field, msg)
-			case "min":
-				msg, _ := localizer.Localize(&i18n.LocalizeConfig{
-					MessageID:    "field_min",
-					TemplateData: map[string]string{"Min": err.Param},
-				})
-				message = fmt.Sprintf("%s: %s", err.Field, msg)
-			case "max":
-				msg, _ := localizer.Localize(&i18n.LocalizeConfig{
-					MessageID:    "field_max",
-					TemplateData: map[string]string{"Max": err.Param},
-				})
-				message = fmt.Sprintf("%s: %s", err.Field, msg)
-			default:
-				// Fallback to original message
-				message = fmt.Sprintf("%s: %s", err.Field, err.Message)
-			}
-			localizedErrors = append(localizedErrors, message)
-		}
-
-		errorsStr := strings.Join(localizedErrors, ", ")
-		msg, _ := localizer.Localize(&i18n.LocalizeConfig{
-			MessageID:    "validation_failed",
-			TemplateData: map[string]string{"Errors": errorsStr},
-		})
-
-		RespondJSON(w, http.StatusBadRequest, map[string]string{
-			"error": msg,
-		})
-		return
-	}
-
-	// Fallback for non-structured errors (parse the error message)
-	errorStr := validationErr.Error()
-	if strings.Contains(errorStr, "validation failed:") {
-		// Try to extract and localize
-		msg, _ := appi18n.GetLocalizer(r).Localize(&i18n.LocalizeConfig{
-			MessageID:    "validation_failed",
-			TemplateData: map[string]string{"Errors": errorStr},
-		})
-		RespondJSON(w, http.StatusBadRequest, map[string]string{
-			"error": msg,
-		})
-		return
-	}
-
-	// Last resort: return as-is
-	RespondErrorWithMessage(w, http.StatusBadRequest, errorStr)
-}
-
 func GetPaginationParams(r *http.Request) (limit, offset int) {
 	limit = 20
 	offset = 0
@@ -70,8 +70,13 @@ func parseAcceptLanguage(acceptLang string) string {
 	return "en"
 }
 
+// T localizes messageID for the request. Before Init, or for unknown IDs, it
+// returns the ID itself.
 func T(r *http.Request, messageID string, data ...interface{}) string {
 	localizer := GetLocalizer(r)
+	if localizer == nil {
+		return messageID
+	}
 	
 	var templateData interface{}
 	if len(data) > 0 {
@@ -0,0 +1,104 @@
+// Package imaging decodes, resizes and re-encodes uploaded images in pure Go
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/buckket/go-blurhash"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for data that is not a JPEG, PNG or WebP
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrTooManyPixels guards against decompression bombs: small files that
+	// decode into huge images
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// blurhashSize is the edge the image is shrunk to before computing its
+// blurhash; the placeholder only has a handful of components anyway
+const blurhashSize = 32
+
+// Decode decodes a JPEG, PNG or WebP image, refusing images with more than
+// maxPixels pixels before allocating them
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if format != "jpeg" && format != "png" && format != "webp" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+	return img, nil
+}
+
+// Fit scales img down to fit in a maxEdge square, keeping its aspect ratio.
+// Images that already fit are returned as they are.
+func Fit(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxEdge && height <= maxEdge {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxEdge/width)
+		width = maxEdge
+	} else {
+		width = max(1, width*maxEdge/height)
+		height = maxEdge
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Encode encodes img as a JPEG, or as a PNG when it has transparency that
+// JPEG would lose, and returns the data with its content type
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if opaque(img) {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// Blurhash computes a compact placeholder that clients can render while the
+// image loads, for img displayed with orientation o
+func Blurhash(img image.Image, o Orientation) (string, error) {
+	hash, err := blurhash.Encode(4, 3, Orient(Fit(img, blurhashSize), o))
+	if err != nil {
+		return "", fmt.Errorf("failed to compute blurhash: %w", err)
+	}
+	return hash, nil
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
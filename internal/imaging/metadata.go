@@ -0,0 +1,244 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// ErrMalformed is returned when an image's container structure is broken
+var ErrMalformed = errors.New("malformed image")
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// StripMetadata removes EXIF (including GPS), XMP, IPTC and text metadata
+// from an encoded image without re-encoding its pixels. The orientation is
+// the only EXIF field kept, since dropping it would turn photos sideways; it
+// is returned too so derived images can be rotated.
+func StripMetadata(data []byte, contentType string) ([]byte, Orientation, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	default:
+		return nil, OrientationNormal, fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+}
+
+// JPEG markers
+const (
+	markerSOI  = 0xD8
+	markerSOS  = 0xDA
+	markerAPP0 = 0xE0
+	markerAPP1 = 0xE1
+	// APP2 carries the ICC colour profile and APP14 the Adobe colour
+	// transform; both change how pixels look, so they are kept
+	markerAPP2  = 0xE2
+	markerAPP14 = 0xEE
+	markerCOM   = 0xFE
+)
+
+func stripJPEG(data []byte) ([]byte, Orientation, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != markerSOI {
+		return nil, OrientationNormal, fmt.Errorf("%w: missing JPEG start of image", ErrMalformed)
+	}
+
+	orientation := OrientationNormal
+	var segments [][]byte
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, OrientationNormal, fmt.Errorf("%w: bad JPEG segment at %d", ErrMalformed, pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			pos++
+			continue
+		}
+		if marker == markerSOS {
+			// Entropy-coded data follows; everything from here is pixels
+			segments = append(segments, data[pos:])
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, OrientationNormal, fmt.Errorf("%w: truncated JPEG segment", ErrMalformed)
+		}
+		segment := data[pos:end]
+		payload := segment[4:]
+		pos = end
+
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader):
+			orientation = parseOrientation(payload[len(exifHeader):])
+		case marker == markerAPP0 || marker == markerAPP2 || marker == markerAPP14:
+			segments = append(segments, segment)
+		case marker > markerAPP0 && marker <= 0xEF, marker == markerCOM:
+			// Other application segments and comments are metadata
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, markerSOI})
+	// EXIF belongs right after JFIF, or first when there is none
+	if len(segments) > 0 && segments[0][1] == markerAPP0 {
+		out.Write(segments[0])
+		segments = segments[1:]
+	}
+	if orientation != OrientationNormal {
+		payload := append(append([]byte{}, exifHeader...), orientationOnlyTIFF(orientation)...)
+		out.Write([]byte{0xFF, markerAPP1})
+		_ = binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+		out.Write(payload)
+	}
+	for _, segment := range segments {
+		out.Write(segment)
+	}
+	return out.Bytes(), orientation, nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are the ancillary chunks that can carry personal data
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(data []byte) ([]byte, Orientation, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, OrientationNormal, fmt.Errorf("%w: missing PNG signature", ErrMalformed)
+	}
+
+	orientation := OrientationNormal
+	wroteExif := false
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return nil, OrientationNormal, fmt.Errorf("%w: truncated PNG chunk", ErrMalformed)
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, OrientationNormal, fmt.Errorf("%w: truncated PNG chunk", ErrMalformed)
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if chunkType == "eXIf" {
+			orientation = parseOrientation(data[pos+8 : pos+8+length])
+		}
+		if chunkType == "IDAT" && orientation != OrientationNormal && !wroteExif {
+			// eXIf must come before the image data to be honoured
+			writePNGChunk(&out, "eXIf", orientationOnlyTIFF(orientation))
+			wroteExif = true
+		}
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out.Bytes(), orientation, nil
+}
+
+func writePNGChunk(out *bytes.Buffer, chunkType string, payload []byte) {
+	_ = binary.Write(out, binary.BigEndian, uint32(len(payload)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(payload)
+	out.WriteString(chunkType)
+	out.Write(payload)
+	_ = binary.Write(out, binary.BigEndian, crc.Sum32())
+}
+
+// VP8X feature flags
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func stripWebP(data []byte) ([]byte, Orientation, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, OrientationNormal, fmt.Errorf("%w: missing WebP header", ErrMalformed)
+	}
+
+	orientation := OrientationNormal
+	type chunk struct {
+		fourCC  string
+		payload []byte
+	}
+	var chunks []chunk
+	// Anything after the RIFF size is not part of the image
+	limit := min(len(data), 8+int(binary.LittleEndian.Uint32(data[4:8])))
+	pos := 12
+	for pos < limit {
+		if pos+8 > limit {
+			return nil, OrientationNormal, fmt.Errorf("%w: truncated WebP chunk", ErrMalformed)
+		}
+		fourCC := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if end > limit {
+			return nil, OrientationNormal, fmt.Errorf("%w: truncated WebP chunk", ErrMalformed)
+		}
+		payload := data[pos+8 : end]
+		switch fourCC {
+		case "EXIF":
+			orientation = parseOrientation(bytes.TrimPrefix(payload, exifHeader))
+		case "XMP ":
+			// Dropped
+		default:
+			chunks = append(chunks, chunk{fourCC, payload})
+		}
+		// Chunks are padded to an even length
+		pos = end + length%2
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		payload := c.payload
+		if c.fourCC == "VP8X" && len(payload) > 0 {
+			payload = append([]byte{}, payload...)
+			payload[0] &^= webpFlagEXIF | webpFlagXMP
+			if orientation != OrientationNormal {
+				payload[0] |= webpFlagEXIF
+			}
+		}
+		writeWebPChunk(&body, c.fourCC, payload)
+	}
+	// EXIF may only appear in the extended format, which always has VP8X
+	if orientation != OrientationNormal && len(chunks) > 0 && chunks[0].fourCC == "VP8X" {
+		writeWebPChunk(&body, "EXIF", orientationOnlyTIFF(orientation))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), orientation, nil
+}
+
+func writeWebPChunk(out *bytes.Buffer, fourCC string, payload []byte) {
+	out.WriteString(fourCC)
+	_ = binary.Write(out, binary.LittleEndian, uint32(len(payload)))
+	out.Write(payload)
+	if len(payload)%2 == 1 {
+		out.WriteByte(0)
+	}
+}
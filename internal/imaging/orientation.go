@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// Orientation is the EXIF orientation tag: how the stored pixels must be
+// transformed to display the image upright. 1 means as stored.
+type Orientation int
+
+const OrientationNormal Orientation = 1
+
+// Transposed reports whether the orientation swaps width and height
+func (o Orientation) Transposed() bool {
+	return o >= 5 && o <= 8
+}
+
+// Orient applies the orientation to img. It copies pixel by pixel, so apply
+// it after scaling down.
+func Orient(img image.Image, o Orientation) image.Image {
+	if o <= OrientationNormal || o > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if o.Transposed() {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirrored horizontally
+				dx, dy = width-1-x, y
+			case 3: // rotated 180°
+				dx, dy = width-1-x, height-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, height-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = height-1-y, x
+			case 7: // transversed
+				dx, dy = height-1-y, width-1-x
+			case 8: // rotated 90° counter-clockwise
+				dx, dy = y, width-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// orientationTag is the TIFF tag holding the orientation in IFD0
+const orientationTag = 0x0112
+
+// parseOrientation reads the orientation from a TIFF-structured EXIF payload,
+// defaulting to OrientationNormal when it is missing or malformed
+func parseOrientation(tiff []byte) Orientation {
+	if len(tiff) < 8 {
+		return OrientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return OrientationNormal
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			o := Orientation(order.Uint16(tiff[entry+8:]))
+			if o < 1 || o > 8 {
+				return OrientationNormal
+			}
+			return o
+		}
+	}
+	return OrientationNormal
+}
+
+// orientationOnlyTIFF builds an EXIF payload carrying nothing but the
+// orientation, so stripped images still display upright
+func orientationOnlyTIFF(o Orientation) []byte {
+	tiff := make([]byte, 26)
+	copy(tiff, "II")
+	binary.LittleEndian.PutUint16(tiff[2:], 42)
+	binary.LittleEndian.PutUint32(tiff[4:], 8) // IFD0 follows the header
+	binary.LittleEndian.PutUint16(tiff[8:], 1) // one entry
+	binary.LittleEndian.PutUint16(tiff[10:], orientationTag)
+	binary.LittleEndian.PutUint16(tiff[12:], 3) // SHORT
+	binary.LittleEndian.PutUint32(tiff[14:], 1) // one value
+	binary.LittleEndian.PutUint16(tiff[18:], uint16(o))
+	// The remaining bytes are the value padding and a zero next-IFD offset
+	return tiff
+}
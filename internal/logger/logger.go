@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"io"
 	"os"
 	"time"
 
@@ -8,14 +10,25 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func Init(level string) {
+type contextKey struct{}
+
+// Init configures the global logger. Format "json" writes one JSON object per
+// line for log shippers; anything else uses the human readable console writer.
+func Init(level, format string) {
 	zerolog.TimeFieldFormat = time.RFC3339
 	zerolog.SetGlobalLevel(parseLevel(level))
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stderr,
+	log.Logger = zerolog.New(writer(format, os.Stderr)).With().Timestamp().Logger()
+}
+
+func writer(format string, out io.Writer) io.Writer {
+	if format == "json" {
+		return out
+	}
+	return zerolog.ConsoleWriter{
+		Out:        out,
 		TimeFormat: time.RFC3339,
-	})
+	}
 }
 
 func parseLevel(level string) zerolog.Level {
@@ -39,3 +52,16 @@ func Logger() *zerolog.Logger {
 	return &log.Logger
 }
 
+// WithContext stores a request-scoped logger in the context
+func WithContext(ctx context.Context, l *zerolog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger, or the global logger when the
+// context carries none (background jobs, tests)
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*zerolog.Logger); ok && l != nil {
+		return l
+	}
+	return Logger()
+}
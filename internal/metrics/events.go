@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/urdogan0000/social/internal/events"
+)
+
+// instrumentedEventBus counts published events and times every handler
+type instrumentedEventBus struct {
+	events.EventBus
+
+	mu sync.Mutex
+	// wrappers maps event type and original handler to the handler registered
+	// with the inner bus, so Unsubscribe removes the right one
+	wrappers map[string]map[string]events.EventHandler
+}
+
+// InstrumentEventBus wraps bus with publish and handler metrics
+func InstrumentEventBus(bus events.EventBus) events.EventBus {
+	return &instrumentedEventBus{
+		EventBus: bus,
+		wrappers: make(map[string]map[string]events.EventHandler),
+	}
+}
+
+func (b *instrumentedEventBus) Publish(ctx context.Context, event events.Event) error {
+	EventsPublished.WithLabelValues(event.Type()).Inc()
+	return b.EventBus.Publish(ctx, event)
+}
+
+func (b *instrumentedEventBus) Subscribe(eventType string, handler events.EventHandler) {
+	wrapper := func(ctx context.Context, event events.Event) error {
+		start := time.Now()
+		err := handler(ctx, event)
+		EventHandleDuration.WithLabelValues(eventType).Observe(time.Since(start).Seconds())
+
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		EventsHandled.WithLabelValues(eventType, result).Inc()
+		return err
+	}
+
+	b.mu.Lock()
+	if b.wrappers[eventType] == nil {
+		b.wrappers[eventType] = make(map[string]events.EventHandler)
+	}
+	b.wrappers[eventType][fmt.Sprintf("%p", handler)] = wrapper
+	b.mu.Unlock()
+
+	b.EventBus.Subscribe(eventType, wrapper)
+}
+
+func (b *instrumentedEventBus) Unsubscribe(eventType string, handler events.EventHandler) {
+	key := fmt.Sprintf("%p", handler)
+
+	b.mu.Lock()
+	wrapper, ok := b.wrappers[eventType][key]
+	delete(b.wrappers[eventType], key)
+	b.mu.Unlock()
+
+	if ok {
+		b.EventBus.Unsubscribe(eventType, wrapper)
+	}
+}
+
+func (b *instrumentedEventBus) Ping(ctx context.Context) error {
+	return events.Ping(ctx, b.EventBus)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startedAtKey = "metrics:started_at"
+
+// GORMPlugin records query durations and errors per table and operation
+type GORMPlugin struct{}
+
+func (GORMPlugin) Name() string {
+	return "metrics"
+}
+
+func (GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    error
+		after     error
+	}{
+		{"create",
+			cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+			cb.Create().After("gorm:create").Register("metrics:after_create", after("create"))},
+		{"query",
+			cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+			cb.Query().After("gorm:query").Register("metrics:after_query", after("query"))},
+		{"update",
+			cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+			cb.Update().After("gorm:update").Register("metrics:after_update", after("update"))},
+		{"delete",
+			cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+			cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete"))},
+		{"row",
+			cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+			cb.Row().After("gorm:row").Register("metrics:after_row", after("row"))},
+		{"raw",
+			cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+			cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw"))},
+	}
+
+	for _, hook := range hooks {
+		if err := errors.Join(hook.before, hook.after); err != nil {
+			return fmt.Errorf("failed to register %s callbacks: %w", hook.operation, err)
+		}
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startedAtKey, time.Now())
+}
+
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		observe(db, operation)
+	}
+}
+
+func observe(db *gorm.DB, operation string) {
+	value, ok := db.InstanceGet(startedAtKey)
+	if !ok {
+		return
+	}
+	startedAt, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+
+	table := db.Statement.Table
+	if table == "" {
+		table = "unknown"
+	}
+
+	DBQueryDuration.WithLabelValues(table, operation).Observe(time.Since(startedAt).Seconds())
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		DBQueryErrors.WithLabelValues(table, operation).Inc()
+	}
+}
+
+// InstrumentDB installs the GORM plugin and exports the connection pool
+// statistics of the underlying sql.DB
+func InstrumentDB(db *gorm.DB) error {
+	if err := db.Use(GORMPlugin{}); err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return registerDBStats(sqlDB)
+}
+
+func registerDBStats(sqlDB *sql.DB) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(sqlDB, "postgres"))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/urdogan0000/social/audit"
+)
+
+// AuditContext records the client IP, user agent and request ID in the
+// context for audit entries. It must run after RequestID and RealIP.
+func AuditContext() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := audit.WithRequest(r.Context(), audit.RequestInfo{
+				IP:        r.RemoteAddr,
+				UserAgent: r.UserAgent(),
+				RequestID: middleware.GetReqID(r.Context()),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
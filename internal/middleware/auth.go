@@ -2,65 +2,124 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"strings"
 
-	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/audit"
+	httputil "github.com/urdogan0000/social/internal/http"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
-func AuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
+// Authenticator is the part of auth.Service the middleware needs. Depending
+// on it rather than the service lets feature packages that auth builds on,
+// such as users, use GetUserID.
+type Authenticator interface {
+	ValidateToken(token string) (uint, string, error)
+	IsAdmin(ctx context.Context, userID uint) (bool, error)
+}
+
+func AuthMiddleware(authService Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				respondError(w, http.StatusUnauthorized, "authorization header required")
+				httputil.RespondError(w, r, http.StatusUnauthorized, "authorization_required")
 				return
 			}
 
-			var token string
-			parts := strings.Split(authHeader, " ")
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				token = parts[1]
-			} else if len(parts) == 1 {
-				token = parts[0]
-			} else {
-				respondError(w, http.StatusUnauthorized, "invalid authorization header format")
+			userID, code := authenticate(authService, authHeader)
+			if code != "" {
+				httputil.RespondError(w, r, http.StatusUnauthorized, code)
 				return
 			}
 
-			if token == "" {
-				respondError(w, http.StatusUnauthorized, "token is required")
+			setLogUserID(r, userID)
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = audit.WithActor(ctx, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// OptionalAuth attaches the user ID when a token is present and lets anonymous
+// requests through. A present but invalid token is still rejected so clients
+// notice expired sessions instead of silently seeing less data.
+func OptionalAuth(authService Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			userID, _, err := authService.ValidateToken(token)
-			if err != nil {
-				respondError(w, http.StatusUnauthorized, "invalid or expired token")
+			userID, code := authenticate(authService, authHeader)
+			if code != "" {
+				httputil.RespondError(w, r, http.StatusUnauthorized, code)
 				return
 			}
 
+			setLogUserID(r, userID)
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = audit.WithActor(ctx, userID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func GetUserID(ctx context.Context) (uint, bool) {
-	userID, ok := ctx.Value(UserIDKey).(uint)
-	return userID, ok
+// RequireAdmin rejects requests from users without the admin role. It must
+// run after AuthMiddleware.
+func RequireAdmin(authService Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserID(r.Context())
+			if !ok {
+				httputil.RespondError(w, r, http.StatusUnauthorized, "authorization_required")
+				return
+			}
+
+			isAdmin, err := authService.IsAdmin(r.Context(), userID)
+			if err != nil {
+				httputil.RespondDomainError(w, r, err, "failed_to_check_permissions")
+				return
+			}
+			if !isAdmin {
+				httputil.RespondError(w, r, http.StatusForbidden, "admin_required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"error": message,
-	}); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+// authenticate validates the Authorization header and returns the user ID,
+// or the error code for a 401 response
+func authenticate(authService Authenticator, authHeader string) (uint, string) {
+	var token string
+	parts := strings.Split(authHeader, " ")
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		token = parts[1]
+	} else if len(parts) == 1 {
+		token = parts[0]
+	} else {
+		return 0, "invalid_authorization_header"
 	}
+
+	if token == "" {
+		return 0, "authorization_required"
+	}
+
+	userID, _, err := authService.ValidateToken(token)
+	if err != nil {
+		return 0, "invalid_token"
+	}
+	return userID, ""
+}
+
+func GetUserID(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(UserIDKey).(uint)
+	return userID, ok
 }
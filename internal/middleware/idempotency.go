@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/urdogan0000/social/idempotency"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders are the response headers stored with a key. Everything else
+// is set again by the middleware chain of the retry.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified"}
+
+// Idempotency makes retries of a request that carries an Idempotency-Key safe.
+// The first request runs and its response is stored per user and key; retries
+// with the same method, path and body get that response back, marked with
+// Idempotent-Replayed. A key reused for a different request is rejected with
+// 422, and a retry that arrives while the first request is still running with
+// 409. Server errors are not stored, so they can be retried. It must run
+// after AuthMiddleware.
+func Idempotency(service *idempotency.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				httputil.RespondError(w, r, http.StatusBadRequest, "invalid_idempotency_key")
+				return
+			}
+			userID, ok := GetUserID(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+			entry, err := service.Begin(r.Context(), userID, key, fingerprint)
+			if err != nil {
+				if errors.Is(err, idempotency.ErrKeyInUse) {
+					w.Header().Set("Retry-After", "1")
+				}
+				httputil.RespondDomainError(w, r, err, "idempotency_check_failed")
+				return
+			}
+			if entry.Completed() {
+				replay(w, entry)
+				return
+			}
+
+			// The outcome is stored even if the client has gone away, since
+			// that is exactly when it will retry
+			storeCtx := context.WithoutCancel(r.Context())
+			defer func() {
+				if rec := recover(); rec != nil {
+					releaseKey(storeCtx, service, entry)
+					panic(rec)
+				}
+			}()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var buf bytes.Buffer
+			ww.Tee(&buf)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				releaseKey(storeCtx, service, entry)
+				return
+			}
+
+			header := make(http.Header)
+			for _, name := range replayedHeaders {
+				if values := ww.Header().Values(name); len(values) > 0 {
+					header[http.CanonicalHeaderKey(name)] = values
+				}
+			}
+			if err := service.Complete(storeCtx, entry, status, header, buf.Bytes()); err != nil {
+				logger.FromContext(r.Context()).Error().Err(err).Str("idempotency_key", key).Msg("Failed to store idempotent response")
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, entry *idempotency.Model) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(entry.StatusCode)
+	_, _ = w.Write(entry.Body)
+}
+
+func releaseKey(ctx context.Context, service *idempotency.Service, entry *idempotency.Model) {
+	if err := service.Release(ctx, entry); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("idempotency_key", entry.Key).Msg("Failed to release idempotency key")
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// RequestLogger stores a per-request logger in the context, tagged with the
+// request ID, method and client IP, and writes one access-log line when the
+// request completes. It must run after RequestID and RealIP.
+func RequestLogger() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := logger.Logger().With().
+				Str("request_id", middleware.GetReqID(r.Context())).
+				Str("method", r.Method).
+				Str("ip", r.RemoteAddr).
+				Logger()
+			// The route pattern is only known once chi has routed the request,
+			// so it is read from the shared route context at log time
+			l = l.Hook(routeHook{rctx: chi.RouteContext(r.Context())})
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			next.ServeHTTP(ww, r.WithContext(logger.WithContext(r.Context(), &l)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			event := l.Info()
+			switch {
+			case status >= http.StatusInternalServerError:
+				event = l.Error()
+			case status >= http.StatusBadRequest:
+				event = l.Warn()
+			}
+			event.
+				Str("path", r.URL.Path).
+				Int("status", status).
+				Int("bytes", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Msg("Request completed")
+		})
+	}
+}
+
+type routeHook struct {
+	rctx *chi.Context
+}
+
+func (h routeHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if h.rctx != nil && h.rctx.RoutePattern() != "" {
+		e.Str("route", h.rctx.RoutePattern())
+	}
+}
+
+// setLogUserID adds the authenticated user to the request logger so that
+// handler logs and the access log carry it. The global logger is never touched.
+func setLogUserID(r *http.Request, userID uint) {
+	l := logger.FromContext(r.Context())
+	if l == logger.Logger() {
+		return
+	}
+	l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Uint("user_id", userID)
+	})
+}
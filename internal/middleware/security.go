@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/httprate"
 	"github.com/rs/cors"
 	"github.com/unrolled/secure"
+	httputil "github.com/urdogan0000/social/internal/http"
 )
 
 func SecurityHeaders(isDevelopment bool) func(http.Handler) http.Handler {
@@ -53,10 +54,11 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 			"Accept",
 			"Authorization",
 			"Content-Type",
+			"Idempotency-Key",
 			"X-CSRF-Token",
 			"X-Requested-With",
 		},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300, // 5 minutes
 	})
@@ -69,6 +71,9 @@ func RateLimit(requestsPerMinute int) func(http.Handler) http.Handler {
 		requestsPerMinute,
 		time.Minute,
 		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			httputil.RespondError(w, r, http.StatusTooManyRequests, "rate_limited")
+		}),
 	)
 }
 
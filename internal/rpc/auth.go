@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthServer lets other services check bearer tokens issued by this one
+type AuthServer struct {
+	socialv1.UnimplementedAuthServiceServer
+	service *auth.Service
+}
+
+func NewAuthServer(service *auth.Service) *AuthServer {
+	return &AuthServer{service: service}
+}
+
+func (s *AuthServer) ValidateToken(ctx context.Context, req *socialv1.ValidateTokenRequest) (*socialv1.ValidateTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token_required")
+	}
+	userID, email, err := s.service.ValidateToken(req.GetToken())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid_token")
+	}
+	return &socialv1.ValidateTokenResponse{
+		UserId: uint64(userID),
+		Email:  email,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"github.com/urdogan0000/social/internal/validator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// CommentServer serves socialv1.CommentService from comments.Service
+type CommentServer struct {
+	socialv1.UnimplementedCommentServiceServer
+	service     *comments.Service
+	authService *auth.Service
+	hub         *EventHub
+}
+
+func NewCommentServer(service *comments.Service, authService *auth.Service, hub *EventHub) *CommentServer {
+	return &CommentServer{
+		service:     service,
+		authService: authService,
+		hub:         hub,
+	}
+}
+
+func (s *CommentServer) CreateComment(ctx context.Context, req *socialv1.CreateCommentRequest) (*socialv1.Comment, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	create := comments.CreateRequest{
+		PostID:  uint(req.GetPostId()),
+		Content: req.GetContent(),
+	}
+	if err := validator.Validate(&create); err != nil {
+		return nil, err
+	}
+	comment, err := s.service.Create(ctx, userID, create)
+	if err != nil {
+		return nil, err
+	}
+	return toComment(comment), nil
+}
+
+func (s *CommentServer) GetComment(ctx context.Context, req *socialv1.GetCommentRequest) (*socialv1.Comment, error) {
+	viewerID, _ := requireUser(ctx)
+	comment, err := s.service.GetByID(ctx, uint(req.GetId()), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return toComment(comment), nil
+}
+
+func (s *CommentServer) ListPostComments(ctx context.Context, req *socialv1.ListPostCommentsRequest) (*socialv1.ListCommentsResponse, error) {
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.GetByPostID(ctx, uint(req.GetPostId()), viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toCommentList(result), nil
+}
+
+func (s *CommentServer) UpdateComment(ctx context.Context, req *socialv1.UpdateCommentRequest) (*socialv1.Comment, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	update := comments.UpdateRequest{Content: req.Content}
+	if err := validator.Validate(&update); err != nil {
+		return nil, err
+	}
+	comment, err := s.service.Update(ctx, uint(req.GetId()), userID, update, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, err
+	}
+	return toComment(comment), nil
+}
+
+func (s *CommentServer) DeleteComment(ctx context.Context, req *socialv1.DeleteCommentRequest) (*emptypb.Empty, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.Delete(ctx, uint(req.GetId()), userID, expectedVersion(req.ExpectedVersion)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *CommentServer) ListComments(ctx context.Context, req *socialv1.ListCommentsRequest) (*socialv1.ListCommentsResponse, error) {
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.List(ctx, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toCommentList(result), nil
+}
+
+// SubscribeCommentEvents streams comment events until the client goes away or
+// the server stops
+func (s *CommentServer) SubscribeCommentEvents(req *socialv1.SubscribeCommentEventsRequest, stream grpc.ServerStreamingServer[socialv1.CommentEvent]) error {
+	ctx := stream.Context()
+	if err := requireAdmin(ctx, s.authService); err != nil {
+		return err
+	}
+
+	filter := CommentEventFilter{Types: req.GetTypes(), PostID: uint(req.GetPostId())}
+	events, unsubscribe := s.hub.Subscribe(filter.Accept)
+	defer unsubscribe()
+
+	// Headers tell the client the subscription is live, so events published
+	// after it receives them are delivered
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toCommentEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toComment(comment *comments.Response) *socialv1.Comment {
+	return &socialv1.Comment{
+		Id:        uint64(comment.ID),
+		PostId:    uint64(comment.PostID),
+		Content:   comment.Content,
+		UserId:    uint64(comment.UserID),
+		Version:   uint64(comment.Version),
+		CreatedAt: timestamp(comment.CreatedAt),
+		UpdatedAt: timestamp(comment.UpdatedAt),
+	}
+}
+
+func toCommentList(result *comments.ListResponse) *socialv1.ListCommentsResponse {
+	resp := &socialv1.ListCommentsResponse{
+		Comments: make([]*socialv1.Comment, len(result.Comments)),
+		Total:    result.Total,
+		Limit:    int32(result.Limit),
+		Offset:   int32(result.Offset),
+	}
+	for i := range result.Comments {
+		resp.Comments[i] = toComment(&result.Comments[i])
+	}
+	return resp
+}
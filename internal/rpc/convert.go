@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// page applies the REST pagination defaults to request values
+func page(limit, offset int32) (int, int) {
+	l := int(limit)
+	if l <= 0 {
+		l = defaultPageSize
+	}
+	l = min(l, maxPageSize)
+	return l, max(int(offset), 0)
+}
+
+// requireUser returns the authenticated caller, like AuthMiddleware does for HTTP
+func requireUser(ctx context.Context) (uint, error) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "authorization_required")
+	}
+	return userID, nil
+}
+
+// requireAdmin is the RequireAdmin counterpart for RPCs
+func requireAdmin(ctx context.Context, authService *auth.Service) error {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := authService.IsAdmin(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return status.Error(codes.PermissionDenied, "admin_required")
+	}
+	return nil
+}
+
+func expectedVersion(v *uint64) *uint {
+	if v == nil {
+		return nil
+	}
+	version := uint(*v)
+	return &version
+}
+
+// timestamp converts the RFC3339 strings of service responses
+func timestamp(value string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func optionalTimestamp(value *string) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamp(*value)
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
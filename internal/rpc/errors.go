@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/urdogan0000/social/internal/domain"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain names this service in ErrorInfo details
+const errorDomain = "social"
+
+// codeMappings turns the domain error categories into gRPC codes. Like the
+// REST mappings they are checked in order, specific errors first.
+var codeMappings = []struct {
+	err  error
+	code codes.Code
+}{
+	{domain.ErrVersionMismatch, codes.Aborted},
+	{domain.ErrPostAlreadyPublished, codes.FailedPrecondition},
+	{domain.ErrNotFound, codes.NotFound},
+	{domain.ErrForbidden, codes.PermissionDenied},
+	{domain.ErrUnauthorized, codes.Unauthenticated},
+	{domain.ErrValidation, codes.InvalidArgument},
+	{domain.ErrConflict, codes.AlreadyExists},
+}
+
+// internalCode is reported for errors that are not domain errors
+const internalCode = "internal_error"
+
+// toStatus converts a handler error into a gRPC status. The message is the
+// same stable code the REST API uses, repeated as the ErrorInfo reason;
+// validation failures also list the rejected fields.
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(ve))
+		for i, fieldErr := range ve {
+			violations[i] = &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Message,
+			}
+		}
+		return withDetails(codes.InvalidArgument, "validation_failed", &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	grpcCode := codes.Internal
+	for _, m := range codeMappings {
+		if errors.Is(err, m.err) {
+			grpcCode = m.code
+			break
+		}
+	}
+	_, code := httputil.ProblemFor(err, internalCode)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		code = "edit_conflict"
+	}
+	if grpcCode == codes.Internal {
+		logger.FromContext(ctx).Error().Err(err).Str("code", code).Msg("RPC failed")
+	}
+	return withDetails(grpcCode, code, nil)
+}
+
+func withDetails(grpcCode codes.Code, code string, badRequest *errdetails.BadRequest) error {
+	st := status.New(grpcCode, code)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: code, Domain: errorDomain}}
+	if badRequest != nil {
+		details = append(details, badRequest)
+	}
+	if withInfo, err := st.WithDetails(details...); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
@@ -0,0 +1,190 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// streamedEventTypes are the bus events the subscription RPCs can deliver
+var streamedEventTypes = []string{
+	events.PostCreated{}.Type(),
+	events.PostUpdated{}.Type(),
+	events.PostDeleted{}.Type(),
+	events.CommentCreated{}.Type(),
+	events.CommentUpdated{}.Type(),
+	events.CommentDeleted{}.Type(),
+}
+
+// subscriberBuffer is how many events a slow stream may fall behind before
+// further events are dropped for it
+const subscriberBuffer = 64
+
+// Event is a bus event with the time the hub received it
+type Event struct {
+	events.Event
+	OccurredAt time.Time
+}
+
+type subscriber struct {
+	ch     chan Event
+	accept func(Event) bool
+}
+
+// EventHub subscribes to the bus once and fans events out to the open
+// streams. Publishing never waits on a stream: a subscriber whose buffer is
+// full misses the event.
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func NewEventHub(bus events.EventBus) *EventHub {
+	hub := &EventHub{subscribers: make(map[*subscriber]struct{})}
+	for _, eventType := range streamedEventTypes {
+		bus.Subscribe(eventType, hub.publish)
+	}
+	return hub
+}
+
+func (h *EventHub) publish(ctx context.Context, event events.Event) error {
+	received := Event{Event: event, OccurredAt: time.Now().UTC()}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if !sub.accept(received) {
+			continue
+		}
+		select {
+		case sub.ch <- received:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of the events accept lets through and a
+// function that ends the subscription. The channel is closed when the hub is.
+func (h *EventHub) Subscribe(accept func(Event) bool) (<-chan Event, func()) {
+	sub := &subscriber{ch: make(chan Event, subscriberBuffer), accept: accept}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub.ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[sub]; ok {
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close ends every subscription so open streams return and a graceful stop
+// does not wait on them
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// PostEventFilter selects the post events a subscription receives
+type PostEventFilter struct {
+	// Types limits delivery to these event types; empty means all
+	Types []string
+	// UserID limits delivery to posts by this author; zero means any
+	UserID uint
+}
+
+func (f PostEventFilter) Accept(event Event) bool {
+	var userID uint
+	switch e := event.Event.(type) {
+	case events.PostCreated:
+		userID = uint(e.UserID)
+	case events.PostUpdated:
+		userID = uint(e.UserID)
+	case events.PostDeleted:
+		userID = uint(e.UserID)
+	default:
+		return false
+	}
+	return acceptType(f.Types, event.Type()) && (f.UserID == 0 || f.UserID == userID)
+}
+
+// CommentEventFilter selects the comment events a subscription receives
+type CommentEventFilter struct {
+	// Types limits delivery to these event types; empty means all
+	Types []string
+	// PostID limits delivery to comments on this post; zero means any
+	PostID uint
+}
+
+func (f CommentEventFilter) Accept(event Event) bool {
+	var postID uint
+	switch e := event.Event.(type) {
+	case events.CommentCreated:
+		postID = uint(e.PostID)
+	case events.CommentUpdated:
+		postID = uint(e.PostID)
+	case events.CommentDeleted:
+		postID = uint(e.PostID)
+	default:
+		return false
+	}
+	return acceptType(f.Types, event.Type()) && (f.PostID == 0 || f.PostID == postID)
+}
+
+func acceptType(types []string, eventType string) bool {
+	return len(types) == 0 || slices.Contains(types, eventType)
+}
+
+func toPostEvent(event Event) *socialv1.PostEvent {
+	resp := &socialv1.PostEvent{
+		Type:       event.Type(),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	switch e := event.Event.(type) {
+	case events.PostCreated:
+		resp.PostId, resp.UserId, resp.Title = uint64(e.PostID), uint64(e.UserID), e.Title
+	case events.PostUpdated:
+		resp.PostId, resp.UserId, resp.Title = uint64(e.PostID), uint64(e.UserID), e.Title
+	case events.PostDeleted:
+		resp.PostId, resp.UserId = uint64(e.PostID), uint64(e.UserID)
+	}
+	return resp
+}
+
+func toCommentEvent(event Event) *socialv1.CommentEvent {
+	resp := &socialv1.CommentEvent{
+		Type:       event.Type(),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	var commentID, postID, userID uint
+	switch e := event.Event.(type) {
+	case events.CommentCreated:
+		commentID, postID, userID = uint(e.CommentID), uint(e.PostID), uint(e.UserID)
+	case events.CommentUpdated:
+		commentID, postID, userID = uint(e.CommentID), uint(e.PostID), uint(e.UserID)
+	case events.CommentDeleted:
+		commentID, postID, userID = uint(e.CommentID), uint(e.PostID), uint(e.UserID)
+	}
+	resp.CommentId, resp.PostId, resp.UserId = uint64(commentID), uint64(postID), uint64(userID)
+	return resp
+}
@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/urdogan0000/social/audit"
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDHeader is the metadata key carrying the caller's request ID
+const requestIDHeader = "x-request-id"
+
+// UnaryInterceptors returns the unary chain, outermost first: logging sees
+// the final status, recovery turns panics into INTERNAL, errors maps domain
+// errors to codes and auth attaches the caller.
+func UnaryInterceptors(authService *auth.Service) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		unaryLogging,
+		unaryRecovery,
+		unaryErrors,
+		unaryAuth(authService),
+	}
+}
+
+// StreamInterceptors mirrors UnaryInterceptors for streaming calls
+func StreamInterceptors(authService *auth.Service) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		streamLogging,
+		streamRecovery,
+		streamErrors,
+		streamAuth(authService),
+	}
+}
+
+// contextStream lets stream interceptors replace the stream's context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func withStreamContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ServerStream: ss, ctx: ctx}
+}
+
+// callContext stores a call-scoped logger and the audit request details, the
+// gRPC counterpart of RequestLogger and AuditContext
+func callContext(ctx context.Context, method string) context.Context {
+	requestID := firstMetadata(ctx, requestIDHeader)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+	}
+
+	l := logger.Logger().With().
+		Str("request_id", requestID).
+		Str("rpc_method", method).
+		Str("ip", ip).
+		Logger()
+	ctx = logger.WithContext(ctx, &l)
+	return audit.WithRequest(ctx, audit.RequestInfo{
+		IP:        ip,
+		UserAgent: firstMetadata(ctx, "user-agent"),
+		RequestID: requestID,
+	})
+}
+
+func logCall(ctx context.Context, start time.Time, err error) {
+	l := logger.FromContext(ctx)
+	code := status.Code(err)
+	event := l.Info()
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		event = l.Error()
+	default:
+		event = l.Warn()
+	}
+	event.
+		Str("code", code.String()).
+		Dur("duration", time.Since(start)).
+		Msg("RPC completed")
+}
+
+func unaryLogging(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = callContext(ctx, info.FullMethod)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, start, err)
+	return resp, err
+}
+
+func streamLogging(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := callContext(ss.Context(), info.FullMethod)
+	start := time.Now()
+	err := handler(srv, withStreamContext(ss, ctx))
+	logCall(ctx, start, err)
+	return err
+}
+
+func recovered(ctx context.Context, r interface{}) error {
+	logger.FromContext(ctx).Error().
+		Interface("panic", r).
+		Bytes("stack", debug.Stack()).
+		Msg("RPC panicked")
+	return status.Error(codes.Internal, internalCode)
+}
+
+func unaryRecovery(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(ctx, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func streamRecovery(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(ss.Context(), r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func unaryErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+func streamErrors(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatus(ss.Context(), err)
+	}
+	return nil
+}
+
+// authenticate attaches the caller named by the bearer token in the
+// authorization metadata, like OptionalAuth does for HTTP. Calls without a
+// token go through anonymously; methods that need a caller check for one.
+func authenticate(ctx context.Context, authService *auth.Service) (context.Context, error) {
+	header := firstMetadata(ctx, "authorization")
+	if header == "" {
+		return ctx, nil
+	}
+	// Like the HTTP header, the bearer scheme is optional
+	var token string
+	parts := strings.Split(header, " ")
+	switch {
+	case len(parts) == 2 && parts[0] == "Bearer":
+		token = parts[1]
+	case len(parts) == 1:
+		token = parts[0]
+	default:
+		return nil, status.Error(codes.Unauthenticated, "invalid_authorization_header")
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization_required")
+	}
+	userID, _, err := authService.ValidateToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid_token")
+	}
+
+	// The global logger is never touched, as in setLogUserID
+	if l := logger.FromContext(ctx); l != logger.Logger() {
+		l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Uint("user_id", userID)
+		})
+	}
+	ctx = context.WithValue(ctx, middleware.UserIDKey, userID)
+	return audit.WithActor(ctx, userID), nil
+}
+
+func unaryAuth(authService *auth.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, authService)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(authService *auth.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authService)
+		if err != nil {
+			return err
+		}
+		return handler(srv, withStreamContext(ss, ctx))
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
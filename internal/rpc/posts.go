@@ -0,0 +1,303 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"github.com/urdogan0000/social/internal/validator"
+	"github.com/urdogan0000/social/posts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// PostServer serves socialv1.PostService from posts.Service
+type PostServer struct {
+	socialv1.UnimplementedPostServiceServer
+	service     *posts.Service
+	authService *auth.Service
+	hub         *EventHub
+}
+
+func NewPostServer(service *posts.Service, authService *auth.Service, hub *EventHub) *PostServer {
+	return &PostServer{
+		service:     service,
+		authService: authService,
+		hub:         hub,
+	}
+}
+
+func (s *PostServer) CreatePost(ctx context.Context, req *socialv1.CreatePostRequest) (*socialv1.Post, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	create := posts.CreateRequest{
+		Title:      req.GetTitle(),
+		Content:    req.GetContent(),
+		Tags:       req.GetTags(),
+		Visibility: req.GetVisibility(),
+		Status:     req.GetStatus(),
+		PublishAt:  optionalTime(req.GetPublishAt()),
+	}
+	if err := validator.Validate(&create); err != nil {
+		return nil, err
+	}
+	post, err := s.service.Create(ctx, userID, create)
+	if err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) GetPost(ctx context.Context, req *socialv1.GetPostRequest) (*socialv1.Post, error) {
+	viewerID, _ := requireUser(ctx)
+	post, err := s.service.GetByID(ctx, uint(req.GetId()), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) ListUserPosts(ctx context.Context, req *socialv1.ListUserPostsRequest) (*socialv1.ListPostsResponse, error) {
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.GetByUserID(ctx, uint(req.GetUserId()), viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toPostList(result), nil
+}
+
+func (s *PostServer) UpdatePost(ctx context.Context, req *socialv1.UpdatePostRequest) (*socialv1.Post, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	update := posts.UpdateRequest{
+		Title:      req.Title,
+		Content:    req.Content,
+		Visibility: req.Visibility,
+		Status:     req.Status,
+		PublishAt:  optionalTime(req.GetPublishAt()),
+	}
+	if req.Tags != nil {
+		tags := req.Tags.GetValues()
+		if tags == nil {
+			tags = []string{}
+		}
+		update.Tags = &tags
+	}
+	if err := validator.Validate(&update); err != nil {
+		return nil, err
+	}
+	post, err := s.service.Update(ctx, uint(req.GetId()), userID, update, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) DeletePost(ctx context.Context, req *socialv1.DeletePostRequest) (*emptypb.Empty, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.Delete(ctx, uint(req.GetId()), userID, expectedVersion(req.ExpectedVersion)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *PostServer) ListPosts(ctx context.Context, req *socialv1.ListPostsRequest) (*socialv1.ListPostsResponse, error) {
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.List(ctx, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toPostList(result), nil
+}
+
+func (s *PostServer) SearchPosts(ctx context.Context, req *socialv1.SearchPostsRequest) (*socialv1.ListPostsResponse, error) {
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "search_query_required")
+	}
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.SearchByTitle(ctx, req.GetTitle(), viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toPostList(&posts.ListResponse{Posts: result, Total: int64(len(result)), Limit: limit, Offset: offset}), nil
+}
+
+func (s *PostServer) ListPostsByTags(ctx context.Context, req *socialv1.ListPostsByTagsRequest) (*socialv1.ListPostsResponse, error) {
+	if len(req.GetTags()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "tags_parameter_required")
+	}
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.GetByTags(ctx, req.GetTags(), viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toPostList(&posts.ListResponse{Posts: result, Total: int64(len(result)), Limit: limit, Offset: offset}), nil
+}
+
+func (s *PostServer) ListDrafts(ctx context.Context, req *socialv1.ListDraftsRequest) (*socialv1.ListPostsResponse, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.GetDrafts(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toPostList(result), nil
+}
+
+func (s *PostServer) ListRevisions(ctx context.Context, req *socialv1.ListRevisionsRequest) (*socialv1.ListRevisionsResponse, error) {
+	viewerID, _ := requireUser(ctx)
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.GetRevisions(ctx, uint(req.GetPostId()), viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &socialv1.ListRevisionsResponse{
+		Revisions: make([]*socialv1.Revision, len(result.Revisions)),
+		Total:     result.Total,
+		Limit:     int32(result.Limit),
+		Offset:    int32(result.Offset),
+	}
+	for i := range result.Revisions {
+		resp.Revisions[i] = toRevision(&result.Revisions[i])
+	}
+	return resp, nil
+}
+
+func (s *PostServer) GetRevision(ctx context.Context, req *socialv1.GetRevisionRequest) (*socialv1.RevisionDetail, error) {
+	if req.GetNumber() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "invalid_revision_number")
+	}
+	viewerID, _ := requireUser(ctx)
+	result, err := s.service.GetRevision(ctx, uint(req.GetPostId()), int(req.GetNumber()), viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &socialv1.RevisionDetail{
+		Revision:    toRevision(&result.RevisionResponse),
+		TitleDiff:   toDiff(result.TitleDiff),
+		ContentDiff: toDiff(result.ContentDiff),
+	}
+	if result.PreviousNumber != nil {
+		previous := int32(*result.PreviousNumber)
+		resp.PreviousNumber = &previous
+	}
+	return resp, nil
+}
+
+func (s *PostServer) RestoreRevision(ctx context.Context, req *socialv1.RestoreRevisionRequest) (*socialv1.Post, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetNumber() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "invalid_revision_number")
+	}
+	post, err := s.service.RestoreRevision(ctx, uint(req.GetPostId()), int(req.GetNumber()), userID, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+// SubscribePostEvents streams post events until the client goes away or the
+// server stops
+func (s *PostServer) SubscribePostEvents(req *socialv1.SubscribePostEventsRequest, stream grpc.ServerStreamingServer[socialv1.PostEvent]) error {
+	ctx := stream.Context()
+	if err := requireAdmin(ctx, s.authService); err != nil {
+		return err
+	}
+
+	filter := PostEventFilter{Types: req.GetTypes(), UserID: uint(req.GetUserId())}
+	events, unsubscribe := s.hub.Subscribe(filter.Accept)
+	defer unsubscribe()
+
+	// Headers tell the client the subscription is live, so events published
+	// after it receives them are delivered
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toPostEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toPost(post *posts.Response) *socialv1.Post {
+	return &socialv1.Post{
+		Id:         uint64(post.ID),
+		Title:      post.Title,
+		Content:    post.Content,
+		UserId:     uint64(post.UserID),
+		Tags:       post.Tags,
+		Visibility: post.Visibility,
+		Status:     post.Status,
+		PublishAt:  optionalTimestamp(post.PublishAt),
+		Edited:     post.Edited,
+		EditedAt:   optionalTimestamp(post.EditedAt),
+		Version:    uint64(post.Version),
+		CreatedAt:  timestamp(post.CreatedAt),
+		UpdatedAt:  timestamp(post.UpdatedAt),
+	}
+}
+
+func toPostList(result *posts.ListResponse) *socialv1.ListPostsResponse {
+	resp := &socialv1.ListPostsResponse{
+		Posts:  make([]*socialv1.Post, len(result.Posts)),
+		Total:  result.Total,
+		Limit:  int32(result.Limit),
+		Offset: int32(result.Offset),
+	}
+	for i := range result.Posts {
+		resp.Posts[i] = toPost(&result.Posts[i])
+	}
+	return resp
+}
+
+func toRevision(revision *posts.RevisionResponse) *socialv1.Revision {
+	return &socialv1.Revision{
+		Number:    int32(revision.Number),
+		PostId:    uint64(revision.PostID),
+		Title:     revision.Title,
+		Content:   revision.Content,
+		Tags:      revision.Tags,
+		EditorId:  uint64(revision.EditorID),
+		CreatedAt: timestamp(revision.CreatedAt),
+	}
+}
+
+func toDiff(lines []posts.DiffLine) []*socialv1.DiffLine {
+	diff := make([]*socialv1.DiffLine, len(lines))
+	for i, line := range lines {
+		diff[i] = &socialv1.DiffLine{Op: line.Op, Text: line.Text}
+	}
+	return diff
+}
@@ -0,0 +1,27 @@
+package rpc
+
+import (
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"google.golang.org/grpc"
+)
+
+// NewServer registers the social.v1 services on a gRPC server behind the
+// logging, recovery, error-mapping and auth interceptors
+func NewServer(
+	authService *auth.Service,
+	userServer *UserServer,
+	postServer *PostServer,
+	commentServer *CommentServer,
+	authServer *AuthServer,
+) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryInterceptors(authService)...),
+		grpc.ChainStreamInterceptor(StreamInterceptors(authService)...),
+	)
+	socialv1.RegisterUserServiceServer(srv, userServer)
+	socialv1.RegisterPostServiceServer(srv, postServer)
+	socialv1.RegisterCommentServiceServer(srv, commentServer)
+	socialv1.RegisterAuthServiceServer(srv, authServer)
+	return srv
+}
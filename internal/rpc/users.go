@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"github.com/urdogan0000/social/internal/validator"
+	"github.com/urdogan0000/social/users"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// UserServer serves socialv1.UserService from users.Service
+type UserServer struct {
+	socialv1.UnimplementedUserServiceServer
+	service     *users.Service
+	authService *auth.Service
+}
+
+func NewUserServer(service *users.Service, authService *auth.Service) *UserServer {
+	return &UserServer{
+		service:     service,
+		authService: authService,
+	}
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *socialv1.CreateUserRequest) (*socialv1.User, error) {
+	create := users.CreateRequest{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := validator.Validate(&create); err != nil {
+		return nil, err
+	}
+	user, err := s.service.Create(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	created := toUser(&user.Response)
+	created.Email = user.Email
+	return created, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *socialv1.GetUserRequest) (*socialv1.User, error) {
+	user, err := s.service.GetByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *UserServer) GetUserByUsername(ctx context.Context, req *socialv1.GetUserByUsernameRequest) (*socialv1.User, error) {
+	user, err := s.service.GetByUsername(ctx, req.GetUsername())
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *socialv1.UpdateUserRequest) (*socialv1.User, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	update := users.UpdateRequest{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+	if err := validator.Validate(&update); err != nil {
+		return nil, err
+	}
+	user, err := s.service.Update(ctx, uint(req.GetId()), userID, update, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *socialv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.Delete(ctx, uint(req.GetId()), userID, expectedVersion(req.ExpectedVersion)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *socialv1.ListUsersRequest) (*socialv1.ListUsersResponse, error) {
+	limit, offset := page(req.GetLimit(), req.GetOffset())
+	result, err := s.service.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &socialv1.ListUsersResponse{
+		Users:  make([]*socialv1.User, len(result.Users)),
+		Total:  result.Total,
+		Limit:  int32(result.Limit),
+		Offset: int32(result.Offset),
+	}
+	for i := range result.Users {
+		resp.Users[i] = toUser(&result.Users[i])
+	}
+	return resp, nil
+}
+
+func (s *UserServer) SetUserRole(ctx context.Context, req *socialv1.SetUserRoleRequest) (*socialv1.User, error) {
+	if err := requireAdmin(ctx, s.authService); err != nil {
+		return nil, err
+	}
+	setRole := users.SetRoleRequest{Role: req.GetRole()}
+	if err := validator.Validate(&setRole); err != nil {
+		return nil, err
+	}
+	user, err := s.service.SetRole(ctx, uint(req.GetId()), setRole.Role, expectedVersion(req.ExpectedVersion))
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+// toUser converts the public view of a user, which has no email
+func toUser(user *users.Response) *socialv1.User {
+	return &socialv1.User{
+		Id:        uint64(user.ID),
+		Username:  user.Username,
+		Role:      user.Role,
+		Version:   uint64(user.Version),
+		CreatedAt: timestamp(user.CreatedAt),
+		UpdatedAt: timestamp(user.UpdatedAt),
+	}
+}
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/urdogan0000/social/internal/events"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const eventTypeKey = attribute.Key("event.type")
+
+// tracedEventBus starts a producer span per publish and injects its context
+// into the event headers; handlers continue the trace from those headers, so
+// they join the originating trace even when run outside the publisher's context
+type tracedEventBus struct {
+	events.EventBus
+
+	mu       sync.Mutex
+	wrappers map[string]map[string]events.EventHandler
+}
+
+// InstrumentEventBus wraps bus with publish and handler spans
+func InstrumentEventBus(bus events.EventBus) events.EventBus {
+	return &tracedEventBus{
+		EventBus: bus,
+		wrappers: make(map[string]map[string]events.EventHandler),
+	}
+}
+
+func (b *tracedEventBus) Publish(ctx context.Context, event events.Event) error {
+	ctx, span := Tracer().Start(ctx, "publish "+event.Type(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(eventTypeKey.String(event.Type())),
+	)
+	defer span.End()
+
+	headers := events.Headers{}
+	for key, value := range events.HeadersFromContext(ctx) {
+		headers[key] = value
+	}
+	Propagator.Inject(ctx, headers)
+
+	err := b.EventBus.Publish(events.ContextWithHeaders(ctx, headers), event)
+	RecordError(span, err)
+	return err
+}
+
+func (b *tracedEventBus) Subscribe(eventType string, handler events.EventHandler) {
+	wrapper := func(ctx context.Context, event events.Event) error {
+		if headers := events.HeadersFromContext(ctx); headers != nil {
+			ctx = Propagator.Extract(ctx, headers)
+		}
+		ctx, span := Tracer().Start(ctx, "handle "+eventType,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(eventTypeKey.String(eventType)),
+		)
+		defer span.End()
+
+		err := handler(ctx, event)
+		RecordError(span, err)
+		return err
+	}
+
+	b.mu.Lock()
+	if b.wrappers[eventType] == nil {
+		b.wrappers[eventType] = make(map[string]events.EventHandler)
+	}
+	b.wrappers[eventType][fmt.Sprintf("%p", handler)] = wrapper
+	b.mu.Unlock()
+
+	b.EventBus.Subscribe(eventType, wrapper)
+}
+
+func (b *tracedEventBus) Unsubscribe(eventType string, handler events.EventHandler) {
+	key := fmt.Sprintf("%p", handler)
+
+	b.mu.Lock()
+	wrapper, ok := b.wrappers[eventType][key]
+	delete(b.wrappers[eventType], key)
+	b.mu.Unlock()
+
+	if ok {
+		b.EventBus.Unsubscribe(eventType, wrapper)
+	}
+}
+
+func (b *tracedEventBus) Ping(ctx context.Context) error {
+	return events.Ping(ctx, b.EventBus)
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// GORMPlugin wraps every query in a client span that is a child of the span
+// in the statement context
+type GORMPlugin struct{}
+
+func (GORMPlugin) Name() string {
+	return "tracing"
+}
+
+func (GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    error
+		after     error
+	}{
+		{"create",
+			cb.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create")),
+			cb.Create().After("gorm:create").Register("tracing:after_create", endSpan)},
+		{"query",
+			cb.Query().Before("gorm:query").Register("tracing:before_query", startSpan("query")),
+			cb.Query().After("gorm:query").Register("tracing:after_query", endSpan)},
+		{"update",
+			cb.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update")),
+			cb.Update().After("gorm:update").Register("tracing:after_update", endSpan)},
+		{"delete",
+			cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete")),
+			cb.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan)},
+		{"row",
+			cb.Row().Before("gorm:row").Register("tracing:before_row", startSpan("row")),
+			cb.Row().After("gorm:row").Register("tracing:after_row", endSpan)},
+		{"raw",
+			cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan("raw")),
+			cb.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan)},
+	}
+
+	for _, hook := range hooks {
+		if err := errors.Join(hook.before, hook.after); err != nil {
+			return fmt.Errorf("failed to register %s callbacks: %w", hook.operation, err)
+		}
+	}
+	return nil
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		_, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBCollectionName(db.Statement.Table),
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+}
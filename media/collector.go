@@ -0,0 +1,76 @@
+package media
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Collector periodically deletes uploads that were never attached, or whose
+// post was deleted, once they are older than the orphan TTL
+type Collector struct {
+	service   *Service
+	interval  time.Duration
+	orphanTTL time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewCollector(service *Service, interval, orphanTTL time.Duration) *Collector {
+	return &Collector{
+		service:   service,
+		interval:  interval,
+		orphanTTL: orphanTTL,
+	}
+}
+
+// Start launches the collection loop in the background
+func (c *Collector) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.run()
+}
+
+// Stop signals the loop to exit and waits for the current collection to finish
+func (c *Collector) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	close(c.stop)
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Collector) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.collect()
+		}
+	}
+}
+
+func (c *Collector) collect() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+	defer cancel()
+
+	count, err := c.service.CollectOrphans(ctx, time.Now().Add(-c.orphanTTL))
+	if err != nil {
+		logger.Logger().Error().Err(err).Msg("Failed to collect orphaned media")
+	}
+	if count > 0 {
+		logger.Logger().Info().Int("count", count).Msg("Collected orphaned media")
+	}
+}
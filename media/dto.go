@@ -0,0 +1,62 @@
+package media
+
+import (
+	"io"
+	"time"
+)
+
+// UploadRequest carries a file from a multipart upload. Size is the length
+// the client declared; the service still stops reading past the limit.
+type UploadRequest struct {
+	File    io.Reader
+	Size    int64
+	AltText string `validate:"max=1000"`
+}
+
+type AttachRequest struct {
+	MediaID uint   `json:"media_id" validate:"required"`
+	AltText string `json:"alt_text" validate:"max=1000"`
+}
+
+type Response struct {
+	ID          uint   `json:"id"`
+	PostID      *uint  `json:"post_id,omitempty"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	AltText     string `json:"alt_text"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Blurhash    string `json:"blurhash,omitempty"`
+	// ProcessingStatus is pending or processing while the image pipeline
+	// has not finished, then ready, failed or, for other types, skipped
+	ProcessingStatus string `json:"processing_status"`
+	// URL is a signed download link that stops working at URLExpiresAt. It
+	// is only set once the original may be served.
+	URL          string            `json:"url,omitempty"`
+	URLExpiresAt string            `json:"url_expires_at,omitempty"`
+	Variants     []VariantResponse `json:"variants,omitempty"`
+	CreatedAt    string            `json:"created_at"`
+}
+
+// VariantResponse is a resized copy of an image, with a signed URL that
+// expires with the original's
+type VariantResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+type ListResponse struct {
+	Media []Response `json:"media"`
+}
+
+// Content is an opened blob ready to be streamed to a client
+type Content struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+	ExpiresAt   time.Time
+}
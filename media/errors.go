@@ -0,0 +1,12 @@
+package media
+
+import "github.com/urdogan0000/social/internal/domain"
+
+var (
+	ErrNotFound         = domain.ErrMediaNotFound
+	ErrTooLarge         = domain.ErrMediaTooLarge
+	ErrUnsupportedType  = domain.ErrUnsupportedMediaType
+	ErrAlreadyAttached  = domain.ErrMediaAlreadyAttached
+	ErrInvalidSignature = domain.ErrInvalidMediaSignature
+	ErrNotReady         = domain.ErrMediaNotReady
+)
@@ -0,0 +1,262 @@
+package media
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+const (
+	// multipartOverhead allows for the form fields and part headers that
+	// come with the file in an upload request
+	multipartOverhead = 1 << 20
+	// multipartMemory is how much of an upload is buffered in memory before
+	// the rest spills to a temporary file
+	multipartMemory = 1 << 20
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Upload godoc
+// @Summary Upload media
+// @Description Upload an image or video to attach to a post later. The type is detected from the content and must be on the allowed list. Images are processed in the background: metadata is stripped and resized variants are added, and processing_status tells when that is done. Uploads that are not attached within the orphan TTL are deleted.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Media file"
+// @Param alt_text formData string false "Alternative text"
+// @Success 201 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 413 {object} httputil.Problem
+// @Failure 415 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /media [post]
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.service.limits.MaxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			httputil.RespondError(w, r, http.StatusRequestEntityTooLarge, "media_too_large")
+			return
+		}
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "media_file_required")
+		return
+	}
+	defer file.Close()
+
+	req := UploadRequest{
+		File:    file,
+		Size:    header.Size,
+		AltText: r.FormValue("alt_text"),
+	}
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	media, err := h.service.Upload(r.Context(), userID, req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_upload_media")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("media_id", media.ID).
+		Uint("user_id", userID).
+		Str("content_type", media.ContentType).
+		Int64("size", media.Size).
+		Msg("Media uploaded successfully")
+	httputil.RespondJSON(w, http.StatusCreated, media)
+}
+
+// Attach godoc
+// @Summary Attach media to a post
+// @Description Attach one of your uploads to one of your posts. Attaching it again updates the alt text.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Param media body AttachRequest true "Media to attach"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/media [post]
+func (h *Handler) Attach(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	var req AttachRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	media, err := h.service.Attach(r.Context(), userID, uint(postID), req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_attach_media")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, media)
+}
+
+// Detach godoc
+// @Summary Detach media from a post
+// @Description Detach media from one of your posts. It is deleted unless attached again within the orphan TTL.
+// @Tags media
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Param mediaID path int true "Media ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/media/{mediaID} [delete]
+func (h *Handler) Detach(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+	mediaID, err := strconv.ParseUint(chi.URLParam(r, "mediaID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_media_id")
+		return
+	}
+
+	if err := h.service.Detach(r.Context(), userID, uint(postID), uint(mediaID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_detach_media")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetByPostID godoc
+// @Summary Get media of a post
+// @Description Get the media attached to a post, with signed download URLs
+// @Tags media
+// @Produce json
+// @Param postID path int true "Post ID"
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/media [get]
+func (h *Handler) GetByPostID(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	viewerID, _ := middleware.GetUserID(r.Context())
+	result, err := h.service.GetByPostID(r.Context(), uint(postID), viewerID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_media")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, result)
+}
+
+// Content godoc
+// @Summary Download media
+// @Description Download media through a signed URL as returned in the url field of a media response
+// @Tags media
+// @Produce octet-stream
+// @Param id path int true "Media ID"
+// @Param variant query string false "Resized variant; the original when omitted"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param sig query string true "Signature"
+// @Success 200 {file} file
+// @Failure 400 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /media/{id}/content [get]
+func (h *Handler) Content(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_media_id")
+		return
+	}
+
+	query := r.URL.Query()
+	content, err := h.service.Open(r.Context(), uint(id), query.Get("variant"), query.Get("expires"), query.Get("sig"))
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_media")
+		return
+	}
+	defer content.Close()
+
+	// The link may be cached until it expires, but only by the client
+	maxAge := int(time.Until(content.ExpiresAt).Seconds())
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(content.Size, 10))
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(max(maxAge, 0)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logger.FromContext(r.Context()).Warn().Err(err).Uint64("media_id", id).Msg("Failed to stream media")
+	}
+}
@@ -0,0 +1,102 @@
+package media
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Processing states of an upload. Images start pending and are stripped of
+// metadata and resized by the pipeline; other types are not processed.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+	StatusSkipped    = "skipped"
+)
+
+// Model is an uploaded file. PostID stays nil until the uploader attaches it;
+// unattached uploads are collected once they are older than the orphan TTL.
+type Model struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	UserID      uint     `gorm:"not null;index" json:"user_id"`
+	PostID      *uint    `gorm:"index" json:"post_id,omitempty"`
+	Key         string   `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	ContentType string   `gorm:"size:100;not null" json:"content_type"`
+	Size        int64    `gorm:"not null" json:"size"`
+	AltText     string   `gorm:"type:text" json:"alt_text"`
+	Width       int      `gorm:"not null;default:0" json:"width"`
+	Height      int      `gorm:"not null;default:0" json:"height"`
+	Blurhash    string   `gorm:"size:100" json:"blurhash"`
+	Variants    Variants `gorm:"type:jsonb" json:"variants"`
+	// ProcessingStatus is one of the Status constants. It defaults to pending
+	// so uploads from before the pipeline existed are processed too.
+	ProcessingStatus   string `gorm:"size:20;not null;default:pending;index" json:"processing_status"`
+	ProcessingAttempts int    `gorm:"not null;default:0" json:"processing_attempts"`
+	ProcessingError    string `gorm:"type:text" json:"-"`
+	// ProcessAfter is when a pending upload is next due, or until when a
+	// processing one is leased to a worker
+	ProcessAfter *time.Time `gorm:"index" json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"index" json:"updated_at"`
+}
+
+func (Model) TableName() string {
+	return "media"
+}
+
+// Servable reports whether the original may be downloaded. Images are held
+// back until their metadata has been stripped.
+func (m *Model) Servable() bool {
+	return m.ProcessingStatus == StatusReady || m.ProcessingStatus == StatusSkipped
+}
+
+// Variant returns the derived image with the given name
+func (m *Model) Variant(name string) (Variant, bool) {
+	for _, v := range m.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Variant is a resized copy of an image
+type Variant struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+// Variants is stored as a JSON array
+type Variants []Variant
+
+func (v Variants) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (v *Variants) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return errors.New("unsupported type for Variants")
+	}
+}
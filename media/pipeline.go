@@ -0,0 +1,204 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urdogan0000/social/internal/imaging"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/storage"
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+// processableTypes are the uploads the pipeline can decode
+var processableTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// errUnprocessable marks failures that retrying cannot fix, such as a
+// corrupt file
+var errUnprocessable = errors.New("image cannot be processed")
+
+// Size is a variant to derive: the image scaled down to fit a MaxEdge square
+type Size struct {
+	Name    string
+	MaxEdge int
+}
+
+type PipelineOptions struct {
+	Sizes []Size
+	// MaxPixels rejects images that would take too much memory to decode
+	MaxPixels   int
+	MaxAttempts int
+	// RetryDelay is the wait after the first failure; it doubles with
+	// every further attempt
+	RetryDelay time.Duration
+	// Lease is how long a worker may hold a claimed upload before another
+	// worker takes it over
+	Lease     time.Duration
+	BatchSize int
+}
+
+// Pipeline derives what clients need from uploaded images: the original
+// without EXIF/GPS metadata, resized variants, dimensions and a blurhash
+// placeholder. Uploads are claimed from the database, so work survives
+// restarts and can be shared by several instances.
+type Pipeline struct {
+	repo  Repository
+	store storage.BlobStore
+	opts  PipelineOptions
+}
+
+func NewPipeline(repo Repository, store storage.BlobStore, opts PipelineOptions) *Pipeline {
+	return &Pipeline{
+		repo:  repo,
+		store: store,
+		opts:  opts,
+	}
+}
+
+// ProcessPending processes one batch of due uploads and returns how many it
+// claimed
+func (p *Pipeline) ProcessPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	claimed, err := p.repo.ClaimForProcessing(ctx, now, now.Add(p.opts.Lease), p.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range claimed {
+		p.processOne(ctx, &claimed[i])
+	}
+	return len(claimed), nil
+}
+
+func (p *Pipeline) processOne(ctx context.Context, media *Model) {
+	ctx, span := tracing.Start(ctx, "media.Pipeline.Process")
+	defer span.End()
+
+	log := logger.FromContext(ctx).With().Uint("media_id", media.ID).Logger()
+	original := media.Key
+	written, err := p.process(ctx, media)
+	if err != nil {
+		media.ProcessingAttempts++
+		media.ProcessingError = err.Error()
+		if errors.Is(err, errUnprocessable) || media.ProcessingAttempts >= p.opts.MaxAttempts {
+			media.ProcessingStatus = StatusFailed
+			media.ProcessAfter = nil
+			log.Error().Err(err).Int("attempts", media.ProcessingAttempts).Msg("Media processing failed")
+		} else {
+			retryAt := time.Now().Add(p.opts.RetryDelay << (media.ProcessingAttempts - 1))
+			media.ProcessingStatus = StatusPending
+			media.ProcessAfter = &retryAt
+			log.Warn().Err(err).Int("attempts", media.ProcessingAttempts).Time("retry_at", retryAt).Msg("Media processing failed, will retry")
+		}
+	}
+
+	saved, err := p.repo.SaveProcessing(ctx, media)
+	if err != nil || !saved {
+		// Nothing refers to the new blobs; the lease runs out and the
+		// upload is processed again unless it was deleted
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to save media processing")
+		}
+		p.deleteBlobs(ctx, written)
+		return
+	}
+	if media.Key != original {
+		p.deleteBlobs(ctx, []string{original})
+	}
+}
+
+// process updates media in place and returns the keys of the blobs it wrote
+func (p *Pipeline) process(ctx context.Context, media *Model) ([]string, error) {
+	if !slices.Contains(processableTypes, media.ContentType) {
+		media.ProcessingStatus = StatusSkipped
+		media.ProcessAfter = nil
+		return nil, nil
+	}
+
+	blob, err := p.store.Get(ctx, media.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open original: %w", err)
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original: %w", err)
+	}
+
+	stripped, orientation, err := imaging.StripMetadata(data, media.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnprocessable, err)
+	}
+	img, err := imaging.Decode(stripped, p.opts.MaxPixels)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnprocessable, err)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if orientation.Transposed() {
+		width, height = height, width
+	}
+	blurhash, err := imaging.Blurhash(img, orientation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnprocessable, err)
+	}
+
+	// The stripped original gets a new key, so links to the old blob never
+	// serve a mix of both
+	key := fmt.Sprintf("media/%d/%s", media.UserID, uuid.NewString())
+	if err := p.store.Put(ctx, key, bytes.NewReader(stripped), int64(len(stripped)), media.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store stripped original: %w", err)
+	}
+	written := []string{key}
+
+	variants := Variants{}
+	for _, size := range p.opts.Sizes {
+		// Never upscale
+		if size.MaxEdge >= max(width, height) {
+			continue
+		}
+		thumb := imaging.Orient(imaging.Fit(img, size.MaxEdge), orientation)
+		encoded, contentType, err := imaging.Encode(thumb)
+		if err != nil {
+			p.deleteBlobs(ctx, written)
+			return nil, fmt.Errorf("%w: %w", errUnprocessable, err)
+		}
+		variantKey := key + "_" + size.Name
+		if err := p.store.Put(ctx, variantKey, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+			p.deleteBlobs(ctx, written)
+			return nil, fmt.Errorf("failed to store %s variant: %w", size.Name, err)
+		}
+		written = append(written, variantKey)
+		variants = append(variants, Variant{
+			Name:        size.Name,
+			Key:         variantKey,
+			ContentType: contentType,
+			Width:       thumb.Bounds().Dx(),
+			Height:      thumb.Bounds().Dy(),
+			Size:        int64(len(encoded)),
+		})
+	}
+
+	media.Key = key
+	media.Size = int64(len(stripped))
+	media.Width = width
+	media.Height = height
+	media.Blurhash = blurhash
+	media.Variants = variants
+	media.ProcessingStatus = StatusReady
+	media.ProcessingError = ""
+	media.ProcessAfter = nil
+	return written, nil
+}
+
+func (p *Pipeline) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := p.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Str("key", key).Msg("Failed to delete media blob")
+		}
+	}
+}
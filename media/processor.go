@@ -0,0 +1,105 @@
+package media
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Processor runs the pipeline in the background. It wakes up on every upload
+// and polls on an interval for retries and uploads a crashed worker left.
+type Processor struct {
+	pipeline *Pipeline
+	interval time.Duration
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewProcessor(pipeline *Pipeline, interval time.Duration) *Processor {
+	return &Processor{
+		pipeline: pipeline,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Notify makes the loop look for work now. It never blocks, so it is safe to
+// call from the synchronous event bus.
+func (p *Processor) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the processing loop in the background
+func (p *Processor) Start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.run()
+}
+
+// Stop signals the loop to exit and waits for the current batch to finish
+func (p *Processor) Stop(ctx context.Context) error {
+	if p.stop == nil {
+		return nil
+	}
+	close(p.stop)
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Processor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.drain()
+		case <-p.wake:
+			p.drain()
+		}
+	}
+}
+
+// drain processes batches until no upload is due
+func (p *Processor) drain() {
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.pipeline.opts.Lease)
+		count, err := p.pipeline.ProcessPending(ctx)
+		cancel()
+		if err != nil {
+			logger.Logger().Error().Err(err).Msg("Failed to process media")
+			return
+		}
+		if count < p.pipeline.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// RegisterProcessingTrigger wakes the processor whenever media is uploaded
+func RegisterProcessingTrigger(bus events.EventBus, processor *Processor) {
+	bus.Subscribe(events.MediaUploaded{}.Type(), func(ctx context.Context, event events.Event) error {
+		processor.Notify()
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, media *Model) error
+	GetByID(ctx context.Context, id uint) (*Model, error)
+	GetByPostID(ctx context.Context, postID uint) ([]Model, error)
+	// Attach links the media to postID unless it is already attached to
+	// another post, and reports whether it did
+	Attach(ctx context.Context, id, postID uint, altText string) (bool, error)
+	// Detach unlinks the media from postID and reports whether it was linked
+	Detach(ctx context.Context, id, postID uint) (bool, error)
+	// ListOrphans returns up to limit media that were never attached, or
+	// whose post was deleted, before the given time
+	ListOrphans(ctx context.Context, before time.Time, limit int) ([]Model, error)
+	// DeleteOrphan deletes the media only if it is still an orphan by the
+	// same rules, so an attach that raced the collector wins
+	DeleteOrphan(ctx context.Context, id uint, before time.Time) (bool, error)
+	// ClaimForProcessing leases up to limit media that are due for
+	// processing, or whose lease has run out, until leaseUntil
+	ClaimForProcessing(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Model, error)
+	// SaveProcessing stores the outcome of processing a claimed media and
+	// reports false when it has been deleted in the meantime
+	SaveProcessing(ctx context.Context, media *Model) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+// orphaned scopes a query to media no live post refers to since before.
+// Posts are soft-deleted, so their media is found through deleted_at.
+// Avatars are never attached to a post, so they are kept while in use.
+func orphaned(before time.Time) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(
+			"((post_id IS NULL AND updated_at < ?) OR post_id IN (SELECT id FROM posts WHERE deleted_at IS NOT NULL AND deleted_at < ?))",
+			before, before,
+		).Where("id NOT IN (SELECT avatar_media_id FROM users WHERE avatar_media_id IS NOT NULL AND deleted_at IS NULL)")
+	}
+}
+
+func (r *repository) Create(ctx context.Context, media *Model) error {
+	if err := r.getDB(ctx).WithContext(ctx).Create(media).Error; err != nil {
+		return fmt.Errorf("failed to create media: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id uint) (*Model, error) {
+	var media Model
+	if err := r.getDB(ctx).WithContext(ctx).Where("id = ?", id).First(&media).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get media by id: %w", err)
+	}
+	return &media, nil
+}
+
+func (r *repository) GetByPostID(ctx context.Context, postID uint) ([]Model, error) {
+	var media []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("id ASC").
+		Find(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to get media by post id: %w", err)
+	}
+	return media, nil
+}
+
+func (r *repository) Attach(ctx context.Context, id, postID uint, altText string) (bool, error) {
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ? AND (post_id IS NULL OR post_id = ?)", id, postID).
+		Updates(map[string]interface{}{
+			"post_id":    postID,
+			"alt_text":   altText,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to attach media: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) Detach(ctx context.Context, id, postID uint) (bool, error) {
+	// Bumping updated_at gives the user the full orphan TTL to reattach it
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ? AND post_id = ?", id, postID).
+		Updates(map[string]interface{}{
+			"post_id":    nil,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to detach media: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) ListOrphans(ctx context.Context, before time.Time, limit int) ([]Model, error) {
+	var media []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Scopes(orphaned(before)).
+		Order("id ASC").
+		Limit(limit).
+		Find(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to list orphaned media: %w", err)
+	}
+	return media, nil
+}
+
+func (r *repository) DeleteOrphan(ctx context.Context, id uint, before time.Time) (bool, error) {
+	result := r.getDB(ctx).WithContext(ctx).
+		Where("id = ?", id).
+		Scopes(orphaned(before)).
+		Delete(&Model{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete orphaned media: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) ClaimForProcessing(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Model, error) {
+	// SKIP LOCKED lets several workers claim disjoint batches; updated_at is
+	// left alone since it times orphans
+	var media []Model
+	if err := r.getDB(ctx).WithContext(ctx).Raw(`
+		UPDATE media SET processing_status = ?, process_after = ?
+		WHERE id IN (
+			SELECT id FROM media
+			WHERE processing_status IN (?, ?) AND (process_after IS NULL OR process_after <= ?)
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		StatusProcessing, leaseUntil, StatusPending, StatusProcessing, now, limit,
+	).Scan(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim media for processing: %w", err)
+	}
+	return media, nil
+}
+
+func (r *repository) SaveProcessing(ctx context.Context, media *Model) (bool, error) {
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ?", media.ID).
+		UpdateColumns(map[string]interface{}{
+			"key":                 media.Key,
+			"size":                media.Size,
+			"width":               media.Width,
+			"height":              media.Height,
+			"blurhash":            media.Blurhash,
+			"variants":            media.Variants,
+			"processing_status":   media.ProcessingStatus,
+			"processing_attempts": media.ProcessingAttempts,
+			"processing_error":    media.ProcessingError,
+			"process_after":       media.ProcessAfter,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to save media processing: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,411 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/storage"
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+// sniffLen is how much of an upload http.DetectContentType looks at
+const sniffLen = 512
+
+// collectBatchSize bounds how many orphans are loaded at once
+const collectBatchSize = 100
+
+// Limits restricts what can be uploaded
+type Limits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+type Service struct {
+	repo       Repository
+	store      storage.BlobStore
+	postRepo   domain.PostRepository
+	followRepo domain.FollowRepository
+	eventBus   events.EventBus
+	signer     *Signer
+	limits     Limits
+}
+
+func NewService(repo Repository, store storage.BlobStore, postRepo domain.PostRepository, followRepo domain.FollowRepository, eventBus events.EventBus, signer *Signer, limits Limits) *Service {
+	return &Service{
+		repo:       repo,
+		store:      store,
+		postRepo:   postRepo,
+		followRepo: followRepo,
+		eventBus:   eventBus,
+		signer:     signer,
+		limits:     limits,
+	}
+}
+
+// Upload stores a file for userID. The content type is sniffed from the file
+// itself, since the client's Content-Type cannot be trusted; the upload stays
+// unattached until Attach links it to a post. Images are queued for the
+// pipeline and cannot be downloaded until it has stripped their metadata.
+func (s *Service) Upload(ctx context.Context, userID uint, req UploadRequest) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "media.Service.Upload")
+	defer span.End()
+
+	if req.Size > s.limits.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(req.File, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+	contentType := sniff(head)
+	if !slices.Contains(s.limits.AllowedTypes, contentType) {
+		return nil, ErrUnsupportedType
+	}
+
+	size := req.Size
+	if size <= 0 {
+		size = -1
+	}
+	body := &limitedReader{r: io.MultiReader(bytes.NewReader(head), req.File), remaining: s.limits.MaxSize}
+	key := fmt.Sprintf("media/%d/%s", userID, uuid.NewString())
+	if err := s.store.Put(ctx, key, body, size, contentType); err != nil {
+		if body.exceeded {
+			return nil, ErrTooLarge
+		}
+		return nil, fmt.Errorf("failed to store media: %w", err)
+	}
+
+	media := &Model{
+		UserID:           userID,
+		Key:              key,
+		ContentType:      contentType,
+		Size:             s.limits.MaxSize - body.remaining,
+		AltText:          req.AltText,
+		ProcessingStatus: StatusSkipped,
+	}
+	if slices.Contains(processableTypes, contentType) {
+		now := time.Now()
+		media.ProcessingStatus = StatusPending
+		media.ProcessAfter = &now
+	}
+	if err := s.repo.Create(ctx, media); err != nil {
+		// Without a row nothing would ever collect the blob
+		if delErr := s.store.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			logger.FromContext(ctx).Error().Err(delErr).Str("key", key).Msg("Failed to delete blob of failed upload")
+		}
+		return nil, fmt.Errorf("failed to create media: %w", err)
+	}
+	if s.eventBus != nil && media.ProcessingStatus == StatusPending {
+		_ = s.eventBus.Publish(ctx, events.MediaUploaded{
+			MediaID: domain.MediaID(media.ID),
+			UserID:  domain.UserID(userID),
+		})
+	}
+
+	response := s.toResponse(media)
+	return &response, nil
+}
+
+// Attach links userID's upload to their post, or updates the alt text when
+// it is already attached to that post
+func (s *Service) Attach(ctx context.Context, userID, postID uint, req AttachRequest) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "media.Service.Attach")
+	defer span.End()
+
+	if err := s.checkPostOwner(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+	media, err := s.repo.GetByID(ctx, req.MediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media by id: %w", err)
+	}
+	// Unattached uploads are private, so other users' media does not exist
+	if media.UserID != userID {
+		return nil, ErrNotFound
+	}
+
+	attached, err := s.repo.Attach(ctx, media.ID, postID, req.AltText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach media: %w", err)
+	}
+	if !attached {
+		return nil, ErrAlreadyAttached
+	}
+	media.PostID = &postID
+	media.AltText = req.AltText
+
+	response := s.toResponse(media)
+	return &response, nil
+}
+
+// Detach unlinks media from userID's post. The upload becomes an orphan and
+// is collected unless it is attached again within the orphan TTL.
+func (s *Service) Detach(ctx context.Context, userID, postID, mediaID uint) error {
+	ctx, span := tracing.Start(ctx, "media.Service.Detach")
+	defer span.End()
+
+	if err := s.checkPostOwner(ctx, postID, userID); err != nil {
+		return err
+	}
+	detached, err := s.repo.Detach(ctx, mediaID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to detach media: %w", err)
+	}
+	if !detached {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetByPostID lists the media of a post the viewer may read
+func (s *Service) GetByPostID(ctx context.Context, postID, viewerID uint) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "media.Service.GetByPostID")
+	defer span.End()
+
+	if err := s.checkPostVisible(ctx, postID, viewerID); err != nil {
+		return nil, err
+	}
+	media, err := s.repo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media by post id: %w", err)
+	}
+	responses := make([]Response, len(media))
+	for i := range media {
+		responses[i] = s.toResponse(&media[i])
+	}
+	return &ListResponse{Media: responses}, nil
+}
+
+// Open checks a signed link and opens the content of the media or one of its
+// variants. The link is the only credential, so anyone holding an unexpired
+// one may download.
+func (s *Service) Open(ctx context.Context, id uint, variant, expires, sig string) (*Content, error) {
+	ctx, span := tracing.Start(ctx, "media.Service.Open")
+	defer span.End()
+
+	expiresAt, err := s.signer.Verify(id, variant, expires, sig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	media, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media by id: %w", err)
+	}
+
+	key, contentType, size := media.Key, media.ContentType, media.Size
+	if variant == "" {
+		if !media.Servable() {
+			return nil, ErrNotReady
+		}
+	} else {
+		v, ok := media.Variant(variant)
+		if !ok {
+			return nil, ErrNotFound
+		}
+		key, contentType, size = v.Key, v.ContentType, v.Size
+	}
+
+	blob, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media: %w", err)
+	}
+	return &Content{
+		ReadCloser:  blob,
+		ContentType: contentType,
+		Size:        size,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// CheckAvatar implements domain.AvatarStore
+func (s *Service) CheckAvatar(ctx context.Context, id domain.MediaID, userID domain.UserID) error {
+	media, err := s.repo.GetByID(ctx, uint(id))
+	if err != nil {
+		return fmt.Errorf("failed to get media by id: %w", err)
+	}
+	if media.UserID != uint(userID) {
+		return ErrNotFound
+	}
+	if !strings.HasPrefix(media.ContentType, "image/") || media.ProcessingStatus == StatusFailed {
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// AvatarURL implements domain.AvatarStore. Avatars are small on screen, so
+// the smallest variant is linked when there is one.
+func (s *Service) AvatarURL(ctx context.Context, id domain.MediaID) (string, error) {
+	media, err := s.repo.GetByID(ctx, uint(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to get media by id: %w", err)
+	}
+	if len(media.Variants) > 0 {
+		smallest := slices.MinFunc(media.Variants, func(a, b Variant) int {
+			return max(a.Width, a.Height) - max(b.Width, b.Height)
+		})
+		url, _ := s.signer.URL(media.ID, smallest.Name, time.Now())
+		return url, nil
+	}
+	if !media.Servable() {
+		return "", ErrNotReady
+	}
+	url, _ := s.signer.URL(media.ID, "", time.Now())
+	return url, nil
+}
+
+// CollectOrphans deletes media that has been unattached, or whose post has
+// been deleted, since before, and returns how many it deleted
+func (s *Service) CollectOrphans(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "media.Service.CollectOrphans")
+	defer span.End()
+
+	count := 0
+	for {
+		orphans, err := s.repo.ListOrphans(ctx, before, collectBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("failed to list orphaned media: %w", err)
+		}
+		for _, media := range orphans {
+			// The row goes first: a leftover blob only wastes space, while a
+			// row without its blob would be a broken attachment
+			deleted, err := s.repo.DeleteOrphan(ctx, media.ID, before)
+			if err != nil {
+				return count, fmt.Errorf("failed to delete orphaned media %d: %w", media.ID, err)
+			}
+			if !deleted {
+				continue
+			}
+			count++
+			keys := []string{media.Key}
+			for _, v := range media.Variants {
+				keys = append(keys, v.Key)
+			}
+			for _, key := range keys {
+				if err := s.store.Delete(ctx, key); err != nil {
+					logger.FromContext(ctx).Error().Err(err).Str("key", key).Msg("Failed to delete blob of orphaned media")
+				}
+			}
+		}
+		if len(orphans) < collectBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// checkPostOwner returns domain.ErrPostForbidden unless userID wrote the post
+func (s *Service) checkPostOwner(ctx context.Context, postID, userID uint) error {
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(postID))
+	if err != nil {
+		return fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+	if !post.CanBeEditedBy(domain.UserID(userID)) {
+		return domain.ErrPostForbidden
+	}
+	return nil
+}
+
+// checkPostVisible returns domain.ErrPostNotFound when the post does not
+// exist or the viewer is not allowed to read it
+func (s *Service) checkPostVisible(ctx context.Context, postID, viewerID uint) error {
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(postID))
+	if err != nil {
+		return fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+
+	visible, err := post.IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+	if err != nil {
+		return fmt.Errorf("failed to check post visibility: %w", err)
+	}
+	if !visible {
+		return domain.ErrPostNotFound
+	}
+	return nil
+}
+
+func (s *Service) toResponse(media *Model) Response {
+	now := time.Now()
+	response := Response{
+		ID:               media.ID,
+		PostID:           media.PostID,
+		ContentType:      media.ContentType,
+		Size:             media.Size,
+		AltText:          media.AltText,
+		Width:            media.Width,
+		Height:           media.Height,
+		Blurhash:         media.Blurhash,
+		ProcessingStatus: media.ProcessingStatus,
+		CreatedAt:        media.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if media.Servable() {
+		url, expiresAt := s.signer.URL(media.ID, "", now)
+		response.URL = url
+		response.URLExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+	for _, v := range media.Variants {
+		url, _ := s.signer.URL(media.ID, v.Name, now)
+		response.Variants = append(response.Variants, VariantResponse{
+			Name:        v.Name,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        v.Size,
+			URL:         url,
+		})
+	}
+	return response
+}
+
+// sniff detects the media type of an upload from its first bytes, without
+// parameters such as charset
+func sniff(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// limitedReader fails once more than remaining bytes are read, unlike
+// io.LimitReader which would silently truncate the upload
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, ErrTooLarge
+	}
+	// Read one byte past the limit to tell a file of exactly the limit
+	// from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,15 @@
+package mentions
+
+type Response struct {
+	PostID    uint   `json:"post_id"`
+	CommentID *uint  `json:"comment_id,omitempty"`
+	AuthorID  uint   `json:"author_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListResponse struct {
+	Mentions []Response `json:"mentions"`
+	Total    int64      `json:"total"`
+	Limit    int        `json:"limit"`
+	Offset   int        `json:"offset"`
+}
@@ -0,0 +1,48 @@
+package mentions
+
+import (
+	"net/http"
+
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetMine godoc
+// @Summary Get my mentions
+// @Description Get the posts and comments that mention the authenticated user, newest first. Mentions in posts and comments the user may no longer read are left out.
+// @Tags mentions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} ListResponse
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/mentions [get]
+func (h *Handler) GetMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit, offset := httputil.GetPaginationParams(r)
+	result, err := h.service.GetByUserID(r.Context(), userID, limit, offset)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_mentions")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,17 @@
+package mentions
+
+import "time"
+
+// Model records that a post or comment mentions a user. CommentID is zero
+// for mentions in the post itself.
+type Model struct {
+	PostID    uint      `gorm:"primaryKey" json:"post_id"`
+	CommentID uint      `gorm:"primaryKey" json:"comment_id"`
+	UserID    uint      `gorm:"primaryKey;index" json:"user_id"`
+	AuthorID  uint      `gorm:"not null" json:"author_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "mentions"
+}
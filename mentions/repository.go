@@ -0,0 +1,100 @@
+package mentions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/posts"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	domain.MentionRepository
+	GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+// Replace keeps the rows of users still mentioned, so their place in the
+// mention list does not change when the text is edited
+func (r *repository) Replace(ctx context.Context, source domain.MentionSource, userIDs []domain.UserID) error {
+	stale := r.getDB(ctx).WithContext(ctx).
+		Where("post_id = ? AND comment_id = ?", source.PostID, source.CommentID)
+	if len(userIDs) > 0 {
+		stale = stale.Where("user_id NOT IN ?", userIDs)
+	}
+	if err := stale.Delete(&Model{}).Error; err != nil {
+		return fmt.Errorf("failed to delete mentions of post %d comment %d: %w", source.PostID, source.CommentID, err)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]Model, len(userIDs))
+	for i, userID := range userIDs {
+		rows[i] = Model{
+			PostID:    uint(source.PostID),
+			CommentID: uint(source.CommentID),
+			UserID:    uint(userID),
+			AuthorID:  uint(source.AuthorID),
+		}
+	}
+	if err := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to create mentions of post %d comment %d: %w", source.PostID, source.CommentID, err)
+	}
+	return nil
+}
+
+// readableBy restricts mentions of the user to those in posts and comments
+// that still exist and that the user may read. Comments of posts that
+// disabled them are hidden.
+func (r *repository) readableBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("mentions.user_id = ?", userID).
+			Where("mentions.post_id IN (?)", r.db.Model(&posts.Model{}).Select("id").Scopes(posts.ReadableBy(userID))).
+			Where("(mentions.comment_id = 0 OR mentions.comment_id IN (?))", r.db.Model(&comments.Model{}).Select("id").
+				Where("comments.post_id IN (?)", r.db.Model(&posts.Model{}).Select("id").Scopes(posts.TakesComments)))
+	}
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error) {
+	var mentions []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Scopes(r.readableBy(userID)).
+		Limit(limit).
+		Offset(offset).
+		Order("created_at DESC").
+		Find(&mentions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get mentions of user %d: %w", userID, err)
+	}
+	return mentions, nil
+}
+
+func (r *repository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Scopes(r.readableBy(userID)).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count mentions of user %d: %w", userID, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,59 @@
+package mentions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetByUserID lists the posts and comments that mention the user, newest first
+func (s *Service) GetByUserID(ctx context.Context, userID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "mentions.Service.GetByUserID")
+	defer span.End()
+
+	mentions, err := s.repo.GetByUserID(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions of user %d: %w", userID, err)
+	}
+
+	total, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count mentions of user %d: %w", userID, err)
+	}
+
+	responses := make([]Response, len(mentions))
+	for i, mention := range mentions {
+		responses[i] = s.toResponse(&mention)
+	}
+
+	return &ListResponse{
+		Mentions: responses,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+func (s *Service) toResponse(mention *Model) Response {
+	response := Response{
+		PostID:    mention.PostID,
+		AuthorID:  mention.AuthorID,
+		CreatedAt: mention.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if mention.CommentID != 0 {
+		commentID := mention.CommentID
+		response.CommentID = &commentID
+	}
+	return response
+}
@@ -0,0 +1,83 @@
+package polls
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Closer periodically closes polls whose end time has passed so their authors
+// are notified. Every API replica can run one; CloseDue locks rows so each
+// poll is closed once.
+type Closer struct {
+	service   *Service
+	interval  time.Duration
+	batchSize int
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewCloser(service *Service, interval time.Duration, batchSize int) *Closer {
+	return &Closer{
+		service:   service,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start launches the closing loop in the background
+func (c *Closer) Start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.run()
+}
+
+// Stop signals the loop to exit and waits for the current tick to finish
+func (c *Closer) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	close(c.stop)
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Closer) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.tick()
+		}
+	}
+}
+
+func (c *Closer) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+	defer cancel()
+
+	for {
+		count, err := c.service.CloseDuePolls(ctx, time.Now(), c.batchSize)
+		if err != nil {
+			logger.Logger().Error().Err(err).Msg("Failed to close due polls")
+			return
+		}
+		if count > 0 {
+			logger.Logger().Info().Int("count", count).Msg("Closed due polls")
+		}
+		if count < c.batchSize {
+			return
+		}
+	}
+}
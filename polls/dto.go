@@ -0,0 +1,37 @@
+package polls
+
+import "time"
+
+// PutRequest creates the poll of a post or replaces it. Once votes exist
+// only EndsAt may change.
+type PutRequest struct {
+	Options        []string  `json:"options" validate:"required,min=2,max=10,dive,required,max=100"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	EndsAt         time.Time `json:"ends_at" validate:"required"`
+}
+
+type VoteRequest struct {
+	OptionIDs []uint `json:"option_ids" validate:"required,min=1,max=10"`
+}
+
+type Response struct {
+	ID             uint             `json:"id"`
+	PostID         uint             `json:"post_id"`
+	Options        []OptionResponse `json:"options"`
+	MultipleChoice bool             `json:"multiple_choice"`
+	EndsAt         string           `json:"ends_at"`
+	Closed         bool             `json:"closed"`
+	// TotalVoters and the option votes are only set for viewers who may see
+	// the tallies: voters while the poll is open and everyone after it closes
+	TotalVoters *int `json:"total_voters,omitempty"`
+	// Voted lists the options the viewer picked, empty if they did not vote
+	Voted     []uint `json:"voted"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type OptionResponse struct {
+	ID    uint   `json:"id"`
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
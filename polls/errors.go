@@ -0,0 +1,14 @@
+package polls
+
+import "github.com/urdogan0000/social/internal/domain"
+
+var (
+	ErrNotFound     = domain.ErrPollNotFound
+	ErrExists       = domain.ErrPollExists
+	ErrInvalid      = domain.ErrInvalidPoll
+	ErrClosed       = domain.ErrPollClosed
+	ErrHasVotes     = domain.ErrPollHasVotes
+	ErrAlreadyVoted = domain.ErrAlreadyVoted
+	ErrVoteNotFound = domain.ErrVoteNotFound
+	ErrInvalidVote  = domain.ErrInvalidVote
+)
@@ -0,0 +1,225 @@
+package polls
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Get godoc
+// @Summary Get the poll of a post
+// @Description Get the poll attached to a post. Vote counts are only included for viewers who voted while the poll is open, and for everyone once it has closed
+// @Tags polls
+// @Produce json
+// @Param postID path int true "Post ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/poll [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	viewerID, _ := middleware.GetUserID(r.Context())
+	poll, err := h.service.Get(r.Context(), uint(postID), viewerID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_poll")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, poll)
+}
+
+// Put godoc
+// @Summary Attach or replace the poll of a post
+// @Description Attach a poll with 2 to 10 options to one of your posts, or replace its poll. Once somebody voted only ends_at can change, and closed polls cannot be edited
+// @Tags polls
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Param poll body PutRequest true "Poll"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/poll [put]
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	var req PutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	poll, err := h.service.Put(r.Context(), userID, uint(postID), req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_save_poll")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("poll_id", poll.ID).
+		Uint("post_id", poll.PostID).
+		Uint("user_id", userID).
+		Msg("Poll saved")
+	httputil.RespondJSON(w, http.StatusOK, poll)
+}
+
+// Delete godoc
+// @Summary Remove the poll of a post
+// @Description Remove the poll from one of your posts together with its votes
+// @Tags polls
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/poll [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, uint(postID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_delete_poll")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Vote godoc
+// @Summary Vote in a poll
+// @Description Vote in the poll of a post. Single choice polls take exactly one option id. Each user votes once; retract the vote to change it
+// @Tags polls
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Param vote body VoteRequest true "Picked options"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/poll/vote [post]
+func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	var req VoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	poll, err := h.service.Vote(r.Context(), userID, uint(postID), req)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_vote")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, poll)
+}
+
+// Retract godoc
+// @Summary Retract a vote
+// @Description Withdraw your vote from a poll that is still open
+// @Tags polls
+// @Security BearerAuth
+// @Param postID path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{postID}/poll/vote [delete]
+func (h *Handler) Retract(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	postID, err := strconv.ParseUint(chi.URLParam(r, "postID"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Retract(r.Context(), userID, uint(postID)); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_retract_vote")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
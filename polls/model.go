@@ -0,0 +1,54 @@
+package polls
+
+import "time"
+
+// Model is the poll attached to a post. ClosedAt is set by the closing job
+// once EndsAt has passed; voting stops at EndsAt either way.
+type Model struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	PostID         uint       `gorm:"not null;uniqueIndex" json:"post_id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	MultipleChoice bool       `gorm:"not null;default:false" json:"multiple_choice"`
+	EndsAt         time.Time  `gorm:"not null;index" json:"ends_at"`
+	ClosedAt       *time.Time `gorm:"index" json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (Model) TableName() string {
+	return "polls"
+}
+
+type OptionModel struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	PollID   uint   `gorm:"not null;index" json:"poll_id"`
+	Position int    `gorm:"not null" json:"position"`
+	Text     string `gorm:"size:100;not null" json:"text"`
+}
+
+func (OptionModel) TableName() string {
+	return "poll_options"
+}
+
+// BallotModel records that a user voted. Its primary key is what allows one
+// vote per user, however many options a multiple choice ballot picks.
+type BallotModel struct {
+	PollID    uint      `gorm:"primaryKey" json:"poll_id"`
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (BallotModel) TableName() string {
+	return "poll_ballots"
+}
+
+// VoteModel is one option picked on a ballot
+type VoteModel struct {
+	OptionID uint `gorm:"primaryKey" json:"option_id"`
+	UserID   uint `gorm:"primaryKey" json:"user_id"`
+	PollID   uint `gorm:"not null;index" json:"poll_id"`
+}
+
+func (VoteModel) TableName() string {
+	return "poll_votes"
+}
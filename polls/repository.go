@@ -0,0 +1,257 @@
+package polls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Create stores the poll with its options, or returns ErrExists when the
+	// post already has one
+	Create(ctx context.Context, poll *Model, options []OptionModel) error
+	GetByPostID(ctx context.Context, postID uint) (*Model, error)
+	// LockByPostID is GetByPostID that also locks the poll until the
+	// transaction ends. An exclusive lock keeps votes out while the poll is
+	// edited; votes take a shared one so they do not queue behind each other.
+	LockByPostID(ctx context.Context, postID uint, exclusive bool) (*Model, error)
+	GetOptions(ctx context.Context, pollID uint) ([]OptionModel, error)
+	Update(ctx context.Context, poll *Model) error
+	// ReplaceOptions swaps the options of a poll nobody voted in yet
+	ReplaceOptions(ctx context.Context, pollID uint, options []OptionModel) error
+	// Delete removes the poll with its options and votes
+	Delete(ctx context.Context, pollID uint) error
+	CountBallots(ctx context.Context, pollID uint) (int64, error)
+	// AddBallot records the user's vote for the options, or returns
+	// ErrAlreadyVoted when the user voted before
+	AddBallot(ctx context.Context, pollID, userID uint, optionIDs []uint) error
+	// RemoveBallot retracts the user's vote, or returns ErrVoteNotFound
+	RemoveBallot(ctx context.Context, pollID, userID uint) error
+	// GetVotes returns the options the user picked, if any
+	GetVotes(ctx context.Context, pollID, userID uint) ([]uint, error)
+	// Tally counts the votes of each option that has any
+	Tally(ctx context.Context, pollID uint) (map[uint]int, error)
+	// CloseDue marks polls of live posts whose end time has passed as closed
+	// and returns them
+	CloseDue(ctx context.Context, now time.Time, batchSize int) ([]Model, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+func (r *repository) Create(ctx context.Context, poll *Model, options []OptionModel) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(poll)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create poll: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrExists
+	}
+	return r.createOptions(tx, poll.ID, options)
+}
+
+func (r *repository) createOptions(tx *gorm.DB, pollID uint, options []OptionModel) error {
+	for i := range options {
+		options[i].PollID = pollID
+		options[i].Position = i
+	}
+	if err := tx.Create(&options).Error; err != nil {
+		return fmt.Errorf("failed to create options of poll %d: %w", pollID, err)
+	}
+	return nil
+}
+
+func (r *repository) GetByPostID(ctx context.Context, postID uint) (*Model, error) {
+	return r.getByPostID(r.getDB(ctx).WithContext(ctx), postID)
+}
+
+func (r *repository) LockByPostID(ctx context.Context, postID uint, exclusive bool) (*Model, error) {
+	strength := "SHARE"
+	if exclusive {
+		strength = "UPDATE"
+	}
+	return r.getByPostID(r.getDB(ctx).WithContext(ctx).Clauses(clause.Locking{Strength: strength}), postID)
+}
+
+func (r *repository) getByPostID(tx *gorm.DB, postID uint) (*Model, error) {
+	var poll Model
+	if err := tx.Where("post_id = ?", postID).First(&poll).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get poll of post %d: %w", postID, err)
+	}
+	return &poll, nil
+}
+
+func (r *repository) GetOptions(ctx context.Context, pollID uint) ([]OptionModel, error) {
+	var options []OptionModel
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("poll_id = ?", pollID).
+		Order("position").
+		Find(&options).Error; err != nil {
+		return nil, fmt.Errorf("failed to get options of poll %d: %w", pollID, err)
+	}
+	return options, nil
+}
+
+func (r *repository) Update(ctx context.Context, poll *Model) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(poll).
+		Select("multiple_choice", "ends_at").
+		Updates(poll).Error; err != nil {
+		return fmt.Errorf("failed to update poll %d: %w", poll.ID, err)
+	}
+	return nil
+}
+
+func (r *repository) ReplaceOptions(ctx context.Context, pollID uint, options []OptionModel) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.Where("poll_id = ?", pollID).Delete(&OptionModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete options of poll %d: %w", pollID, err)
+	}
+	return r.createOptions(tx, pollID, options)
+}
+
+func (r *repository) Delete(ctx context.Context, pollID uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	for _, model := range []interface{}{&VoteModel{}, &BallotModel{}, &OptionModel{}} {
+		if err := tx.Where("poll_id = ?", pollID).Delete(model).Error; err != nil {
+			return fmt.Errorf("failed to delete poll %d: %w", pollID, err)
+		}
+	}
+	if err := tx.Delete(&Model{}, pollID).Error; err != nil {
+		return fmt.Errorf("failed to delete poll %d: %w", pollID, err)
+	}
+	return nil
+}
+
+func (r *repository) CountBallots(ctx context.Context, pollID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&BallotModel{}).
+		Where("poll_id = ?", pollID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count votes of poll %d: %w", pollID, err)
+	}
+	return count, nil
+}
+
+// AddBallot relies on the ballot's primary key to settle concurrent votes of
+// the same user
+func (r *repository) AddBallot(ctx context.Context, pollID, userID uint, optionIDs []uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&BallotModel{PollID: pollID, UserID: userID})
+	if result.Error != nil {
+		return fmt.Errorf("failed to vote in poll %d: %w", pollID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlreadyVoted
+	}
+
+	votes := make([]VoteModel, len(optionIDs))
+	for i, optionID := range optionIDs {
+		votes[i] = VoteModel{OptionID: optionID, UserID: userID, PollID: pollID}
+	}
+	if err := tx.Create(&votes).Error; err != nil {
+		return fmt.Errorf("failed to vote in poll %d: %w", pollID, err)
+	}
+	return nil
+}
+
+func (r *repository) RemoveBallot(ctx context.Context, pollID, userID uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	result := tx.Where("poll_id = ? AND user_id = ?", pollID, userID).Delete(&BallotModel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to retract vote in poll %d: %w", pollID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrVoteNotFound
+	}
+	if err := tx.Where("poll_id = ? AND user_id = ?", pollID, userID).Delete(&VoteModel{}).Error; err != nil {
+		return fmt.Errorf("failed to retract vote in poll %d: %w", pollID, err)
+	}
+	return nil
+}
+
+func (r *repository) GetVotes(ctx context.Context, pollID, userID uint) ([]uint, error) {
+	var optionIDs []uint
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&VoteModel{}).
+		Where("poll_id = ? AND user_id = ?", pollID, userID).
+		Order("option_id").
+		Pluck("option_id", &optionIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get votes of user %d in poll %d: %w", userID, pollID, err)
+	}
+	return optionIDs, nil
+}
+
+func (r *repository) Tally(ctx context.Context, pollID uint) (map[uint]int, error) {
+	var rows []struct {
+		OptionID uint
+		Votes    int
+	}
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&VoteModel{}).
+		Select("option_id, COUNT(*) AS votes").
+		Where("poll_id = ?", pollID).
+		Group("option_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to tally poll %d: %w", pollID, err)
+	}
+
+	tally := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		tally[row.OptionID] = row.Votes
+	}
+	return tally, nil
+}
+
+// CloseDue must run inside a transaction: rows are locked with FOR UPDATE
+// SKIP LOCKED so concurrent replicas never close the same poll twice. Polls
+// of deleted posts are left alone, so nobody is notified about them.
+func (r *repository) CloseDue(ctx context.Context, now time.Time, batchSize int) ([]Model, error) {
+	var due []Model
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("closed_at IS NULL AND ends_at <= ?", now).
+		Where("post_id IN (SELECT id FROM posts WHERE deleted_at IS NULL)").
+		Order("ends_at").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("failed to select due polls: %w", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(due))
+	for i := range due {
+		ids[i] = due[i].ID
+		due[i].ClosedAt = &now
+	}
+	if err := tx.Model(&Model{}).
+		Where("id IN ?", ids).
+		UpdateColumn("closed_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to close due polls: %w", err)
+	}
+	return due, nil
+}
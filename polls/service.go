@@ -0,0 +1,357 @@
+package polls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/tracing"
+)
+
+type Service struct {
+	repo           Repository
+	postRepo       domain.PostRepository
+	followRepo     domain.FollowRepository
+	eventBus       events.EventBus
+	transactionMgr db.TransactionManager
+}
+
+func NewService(repo Repository, postRepo domain.PostRepository, followRepo domain.FollowRepository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
+	return &Service{
+		repo:           repo,
+		postRepo:       postRepo,
+		followRepo:     followRepo,
+		eventBus:       eventBus,
+		transactionMgr: transactionMgr,
+	}
+}
+
+// Get returns the poll of a post the viewer may read
+func (s *Service) Get(ctx context.Context, postID, viewerID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "polls.Service.Get")
+	defer span.End()
+
+	if err := s.checkPostVisible(ctx, postID, viewerID); err != nil {
+		return nil, err
+	}
+	poll, err := s.repo.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, poll, viewerID, time.Now())
+}
+
+// Put attaches a poll to the user's post or replaces the one it has. Once
+// somebody voted, the options and the choice mode are fixed and only the end
+// time may change; closed polls cannot be edited at all.
+func (s *Service) Put(ctx context.Context, userID, postID uint, req PutRequest) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "polls.Service.Put")
+	defer span.End()
+
+	post, err := s.checkPostOwner(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if post.IsRepost() {
+		return nil, domain.ErrRepostNotEditable
+	}
+
+	now := time.Now()
+	candidate := &domain.Poll{
+		PostID:         post.ID,
+		AuthorID:       post.UserID,
+		Options:        slices.Clone(req.Options),
+		MultipleChoice: req.MultipleChoice,
+		EndsAt:         req.EndsAt,
+	}
+	if err := candidate.Validate(now); err != nil {
+		return nil, err
+	}
+
+	var poll *Model
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.LockByPostID(ctx, postID, true)
+		if errors.Is(err, ErrNotFound) {
+			poll = &Model{
+				PostID:         postID,
+				UserID:         userID,
+				MultipleChoice: candidate.MultipleChoice,
+				EndsAt:         candidate.EndsAt,
+			}
+			return s.repo.Create(ctx, poll, toOptionModels(candidate.Options))
+		}
+		if err != nil {
+			return err
+		}
+		poll = existing
+
+		options, err := s.repo.GetOptions(ctx, poll.ID)
+		if err != nil {
+			return err
+		}
+		if s.toDomain(poll, options).IsClosed(now) {
+			return ErrClosed
+		}
+
+		sameOptions := slices.Equal(optionTexts(options), candidate.Options)
+		if !sameOptions || poll.MultipleChoice != candidate.MultipleChoice {
+			ballots, err := s.repo.CountBallots(ctx, poll.ID)
+			if err != nil {
+				return err
+			}
+			if ballots > 0 {
+				return ErrHasVotes
+			}
+		}
+
+		poll.MultipleChoice = candidate.MultipleChoice
+		poll.EndsAt = candidate.EndsAt
+		if err := s.repo.Update(ctx, poll); err != nil {
+			return err
+		}
+		if sameOptions {
+			return nil
+		}
+		return s.repo.ReplaceOptions(ctx, poll.ID, toOptionModels(candidate.Options))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put poll of post %d: %w", postID, err)
+	}
+
+	return s.toResponse(ctx, poll, userID, now)
+}
+
+// Delete removes the poll from the user's post along with its votes
+func (s *Service) Delete(ctx context.Context, userID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "polls.Service.Delete")
+	defer span.End()
+
+	if _, err := s.checkPostOwner(ctx, postID, userID); err != nil {
+		return err
+	}
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		poll, err := s.repo.LockByPostID(ctx, postID, true)
+		if err != nil {
+			return err
+		}
+		return s.repo.Delete(ctx, poll.ID)
+	})
+}
+
+// Vote casts the user's one vote in an open poll. Single choice polls take
+// exactly one option; multiple choice polls take any number of them.
+func (s *Service) Vote(ctx context.Context, userID, postID uint, req VoteRequest) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "polls.Service.Vote")
+	defer span.End()
+
+	if err := s.checkPostVisible(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+
+	optionIDs := slices.Compact(slices.Sorted(slices.Values(req.OptionIDs)))
+	now := time.Now()
+
+	var poll *Model
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if poll, err = s.repo.LockByPostID(ctx, postID, false); err != nil {
+			return err
+		}
+		options, err := s.repo.GetOptions(ctx, poll.ID)
+		if err != nil {
+			return err
+		}
+
+		domainPoll := s.toDomain(poll, options)
+		if domainPoll.IsClosed(now) {
+			return ErrClosed
+		}
+		if err := domainPoll.CheckChoice(len(optionIDs)); err != nil {
+			return err
+		}
+		for _, id := range optionIDs {
+			if !slices.ContainsFunc(options, func(option OptionModel) bool { return option.ID == id }) {
+				return ErrInvalidVote
+			}
+		}
+		return s.repo.AddBallot(ctx, poll.ID, userID, optionIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to vote in poll of post %d: %w", postID, err)
+	}
+
+	return s.toResponse(ctx, poll, userID, now)
+}
+
+// Retract withdraws the user's vote while the poll is open, so they can vote
+// again
+func (s *Service) Retract(ctx context.Context, userID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "polls.Service.Retract")
+	defer span.End()
+
+	if err := s.checkPostVisible(ctx, postID, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		poll, err := s.repo.LockByPostID(ctx, postID, false)
+		if err != nil {
+			return err
+		}
+		if s.toDomain(poll, nil).IsClosed(now) {
+			return ErrClosed
+		}
+		return s.repo.RemoveBallot(ctx, poll.ID, userID)
+	})
+}
+
+// CloseDuePolls marks polls whose end time has passed as closed and tells
+// their authors with a PollClosed event. It returns how many were closed.
+func (s *Service) CloseDuePolls(ctx context.Context, now time.Time, batchSize int) (int, error) {
+	ctx, span := tracing.Start(ctx, "polls.Service.CloseDuePolls")
+	defer span.End()
+
+	var closed []Model
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		closed, err = s.repo.CloseDue(ctx, now, batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to close due polls: %w", err)
+	}
+
+	// Events go out only after the transaction committed
+	if s.eventBus != nil {
+		for _, poll := range closed {
+			_ = s.eventBus.Publish(ctx, events.PollClosed{
+				PollID:   domain.PollID(poll.ID),
+				PostID:   domain.PostID(poll.PostID),
+				AuthorID: domain.UserID(poll.UserID),
+			})
+		}
+	}
+
+	return len(closed), nil
+}
+
+// inTransaction runs fn in a transaction if a manager is available
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactionMgr != nil {
+		return s.transactionMgr.WithTransaction(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+func (s *Service) checkPostOwner(ctx context.Context, postID, userID uint) (*domain.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(postID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+	if !post.CanBeEditedBy(domain.UserID(userID)) {
+		return nil, domain.ErrPostForbidden
+	}
+	return post, nil
+}
+
+// checkPostVisible returns domain.ErrPostNotFound when the post does not
+// exist or the viewer is not allowed to read it
+func (s *Service) checkPostVisible(ctx context.Context, postID, viewerID uint) error {
+	post, err := s.postRepo.GetByID(ctx, domain.PostID(postID))
+	if err != nil {
+		return fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+
+	visible, err := post.IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+	if err != nil {
+		return fmt.Errorf("failed to check post visibility: %w", err)
+	}
+	if !visible {
+		return domain.ErrPostNotFound
+	}
+	return nil
+}
+
+func (s *Service) toDomain(poll *Model, options []OptionModel) *domain.Poll {
+	return &domain.Poll{
+		ID:             domain.PollID(poll.ID),
+		PostID:         domain.PostID(poll.PostID),
+		AuthorID:       domain.UserID(poll.UserID),
+		Options:        optionTexts(options),
+		MultipleChoice: poll.MultipleChoice,
+		EndsAt:         poll.EndsAt,
+	}
+}
+
+func (s *Service) toResponse(ctx context.Context, poll *Model, viewerID uint, now time.Time) (*Response, error) {
+	options, err := s.repo.GetOptions(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	voted := []uint{}
+	if viewerID != 0 {
+		picked, err := s.repo.GetVotes(ctx, poll.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		voted = append(voted, picked...)
+	}
+
+	domainPoll := s.toDomain(poll, options)
+	response := &Response{
+		ID:             poll.ID,
+		PostID:         poll.PostID,
+		Options:        make([]OptionResponse, len(options)),
+		MultipleChoice: poll.MultipleChoice,
+		EndsAt:         poll.EndsAt.Format("2006-01-02T15:04:05Z07:00"),
+		Closed:         domainPoll.IsClosed(now),
+		Voted:          voted,
+		CreatedAt:      poll.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      poll.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for i, option := range options {
+		response.Options[i] = OptionResponse{ID: option.ID, Text: option.Text}
+	}
+	if !domainPoll.CanSeeTallies(len(voted) > 0, now) {
+		return response, nil
+	}
+
+	tally, err := s.repo.Tally(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	voters, err := s.repo.CountBallots(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range response.Options {
+		votes := tally[response.Options[i].ID]
+		response.Options[i].Votes = &votes
+	}
+	total := int(voters)
+	response.TotalVoters = &total
+	return response, nil
+}
+
+func toOptionModels(texts []string) []OptionModel {
+	options := make([]OptionModel, len(texts))
+	for i, text := range texts {
+		options[i] = OptionModel{Text: text}
+	}
+	return options
+}
+
+func optionTexts(options []OptionModel) []string {
+	texts := make([]string, len(options))
+	for i, option := range options {
+		texts[i] = option.Text
+	}
+	return texts
+}
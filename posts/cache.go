@@ -0,0 +1,114 @@
+package posts
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/internal/events"
+)
+
+// CacheKey is the cache key of a single post
+func CacheKey(id uint) string {
+	return "posts:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// cachedRepository serves GetByID from a read-through cache and drops entries
+// on every write it performs
+type cachedRepository struct {
+	Repository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedRepository wraps repo with a read-through cache for post lookups
+func NewCachedRepository(repo Repository, c cache.Cache, ttl time.Duration) Repository {
+	return &cachedRepository{Repository: repo, cache: c, ttl: ttl}
+}
+
+func (r *cachedRepository) GetByID(ctx context.Context, id uint) (*Model, error) {
+	return cache.GetOrLoad(ctx, r.cache, CacheKey(id), r.ttl, func(ctx context.Context) (*Model, error) {
+		return r.Repository.GetByID(ctx, id)
+	})
+}
+
+func (r *cachedRepository) Update(ctx context.Context, post *Model) error {
+	err := r.Repository.Update(ctx, post)
+	cache.Invalidate(ctx, r.cache, CacheKey(post.ID))
+	return err
+}
+
+func (r *cachedRepository) Delete(ctx context.Context, id uint, version *uint) error {
+	err := r.Repository.Delete(ctx, id, version)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+func (r *cachedRepository) AdjustShareCounts(ctx context.Context, id uint, reposts, quotes int) error {
+	err := r.Repository.AdjustShareCounts(ctx, id, reposts, quotes)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+func (r *cachedRepository) DeleteReposts(ctx context.Context, postID uint) ([]uint, error) {
+	ids, err := r.Repository.DeleteReposts(ctx, postID)
+	if len(ids) > 0 {
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = CacheKey(id)
+		}
+		cache.Invalidate(ctx, r.cache, keys...)
+	}
+	return ids, err
+}
+
+func (r *cachedRepository) Pin(ctx context.Context, id, userID uint, now time.Time, limit int) error {
+	err := r.Repository.Pin(ctx, id, userID, now, limit)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+func (r *cachedRepository) Unpin(ctx context.Context, id uint) error {
+	err := r.Repository.Unpin(ctx, id)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+func (r *cachedRepository) PublishDue(ctx context.Context, now time.Time, batchSize int) ([]Model, error) {
+	due, err := r.Repository.PublishDue(ctx, now, batchSize)
+	if len(due) > 0 {
+		keys := make([]string, len(due))
+		for i := range due {
+			keys[i] = CacheKey(due[i].ID)
+		}
+		cache.Invalidate(ctx, r.cache, keys...)
+	}
+	return due, err
+}
+
+// RegisterCacheInvalidation drops cached posts once post events are published.
+// Writes already invalidate inside the transaction, but a concurrent read can
+// repopulate the old row before commit; the events arrive after commit.
+func RegisterCacheInvalidation(bus events.EventBus, c cache.Cache) {
+	invalidate := func(ctx context.Context, id uint) error {
+		cache.Invalidate(ctx, c, CacheKey(id))
+		return nil
+	}
+	bus.Subscribe(events.PostCreated{}.Type(), func(ctx context.Context, event events.Event) error {
+		return invalidate(ctx, uint(event.(events.PostCreated).PostID))
+	})
+	bus.Subscribe(events.PostUpdated{}.Type(), func(ctx context.Context, event events.Event) error {
+		return invalidate(ctx, uint(event.(events.PostUpdated).PostID))
+	})
+	bus.Subscribe(events.PostDeleted{}.Type(), func(ctx context.Context, event events.Event) error {
+		return invalidate(ctx, uint(event.(events.PostDeleted).PostID))
+	})
+	// Reposts change the share counts of the original
+	bus.Subscribe(events.PostReposted{}.Type(), func(ctx context.Context, event events.Event) error {
+		return invalidate(ctx, uint(event.(events.PostReposted).OriginalID))
+	})
+	bus.Subscribe(events.PostUnreposted{}.Type(), func(ctx context.Context, event events.Event) error {
+		return invalidate(ctx, uint(event.(events.PostUnreposted).OriginalID))
+	})
+}
@@ -1,31 +1,105 @@
 package posts
 
+import "time"
+
 type CreateRequest struct {
-	Title   string   `json:"title" validate:"required,min=1,max=255"`
-	Content string   `json:"content" validate:"required,min=1"`
-	Tags    []string `json:"tags,omitempty"`
+	Title      string     `json:"title" validate:"required,min=1,max=255"`
+	Content    string     `json:"content" validate:"required,min=1"`
+	Tags       []string   `json:"tags,omitempty"`
+	Visibility string     `json:"visibility,omitempty" validate:"omitempty,oneof=public unlisted followers private"`
+	Status     string     `json:"status,omitempty" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	QuoteOfID  *uint      `json:"quote_of_id,omitempty"`
+	// CommentStatus defaults to open and ReplyAudience to everyone
+	CommentStatus string `json:"comment_status,omitempty" validate:"omitempty,oneof=open locked disabled"`
+	ReplyAudience string `json:"reply_audience,omitempty" validate:"omitempty,oneof=everyone followers mentioned"`
 }
 
 type UpdateRequest struct {
-	Title   *string   `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
-	Content *string   `json:"content,omitempty" validate:"omitempty,min=1"`
-	Tags    *[]string `json:"tags,omitempty"`
+	Title      *string    `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Content    *string    `json:"content,omitempty" validate:"omitempty,min=1"`
+	Tags       *[]string  `json:"tags,omitempty"`
+	Visibility *string    `json:"visibility,omitempty" validate:"omitempty,oneof=public unlisted followers private"`
+	Status     *string    `json:"status,omitempty" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	// CommentStatus locks or disables comments; ReplyAudience limits who may
+	// comment
+	CommentStatus *string `json:"comment_status,omitempty" validate:"omitempty,oneof=open locked disabled"`
+	ReplyAudience *string `json:"reply_audience,omitempty" validate:"omitempty,oneof=everyone followers mentioned"`
 }
 
 type Response struct {
-	ID        uint     `json:"id"`
+	ID            uint          `json:"id"`
+	Title         string        `json:"title"`
+	Content       string        `json:"content"`
+	UserID        uint          `json:"user_id"`
+	Tags          []string      `json:"tags"`
+	Mentions      []Mention     `json:"mentions"`
+	Visibility    string        `json:"visibility"`
+	Status        string        `json:"status"`
+	PublishAt     *string       `json:"publish_at,omitempty"`
+	Edited        bool          `json:"edited"`
+	EditedAt      *string       `json:"edited_at,omitempty"`
+	RepostOf      *EmbeddedPost `json:"repost_of,omitempty"`
+	QuoteOf       *EmbeddedPost `json:"quote_of,omitempty"`
+	RepostCount   int           `json:"repost_count"`
+	QuoteCount    int           `json:"quote_count"`
+	Bookmarked    bool          `json:"bookmarked"`
+	PinnedAt      *string       `json:"pinned_at,omitempty"`
+	CommentStatus string        `json:"comment_status"`
+	ReplyAudience string        `json:"reply_audience"`
+	Version       uint          `json:"version"`
+	CreatedAt     string        `json:"created_at"`
+	UpdatedAt     string        `json:"updated_at"`
+}
+
+// EmbeddedPost is the post a repost or quote shares. Posts that were deleted
+// or that the viewer may not read are unavailable stubs with only their id.
+type EmbeddedPost struct {
+	ID          uint      `json:"id"`
+	Unavailable bool      `json:"unavailable"`
+	Post        *Response `json:"post,omitempty"`
+}
+
+// LastModified returns UpdatedAt as a time for the Last-Modified header
+func (r *Response) LastModified() time.Time {
+	updatedAt, _ := time.Parse(time.RFC3339, r.UpdatedAt)
+	return updatedAt
+}
+
+type ListResponse struct {
+	Posts  []Response `json:"posts"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+type RevisionResponse struct {
+	Number    int      `json:"number"`
+	PostID    uint     `json:"post_id"`
 	Title     string   `json:"title"`
 	Content   string   `json:"content"`
-	UserID    uint     `json:"user_id"`
 	Tags      []string `json:"tags"`
+	EditorID  uint     `json:"editor_id"`
 	CreatedAt string   `json:"created_at"`
-	UpdatedAt string   `json:"updated_at"`
 }
 
-type ListResponse struct {
-	Posts []Response `json:"posts"`
-	Total int64      `json:"total"`
-	Limit int        `json:"limit"`
-	Offset int       `json:"offset"`
+type RevisionListResponse struct {
+	Revisions []RevisionResponse `json:"revisions"`
+	Total     int64              `json:"total"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
 }
 
+// RevisionDetailResponse carries a revision with line diffs against the previous revision
+type RevisionDetailResponse struct {
+	RevisionResponse
+	PreviousNumber *int       `json:"previous_number,omitempty"`
+	TitleDiff      []DiffLine `json:"title_diff"`
+	ContentDiff    []DiffLine `json:"content_diff"`
+}
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
@@ -5,5 +5,14 @@ import "github.com/urdogan0000/social/internal/domain"
 var (
 	ErrNotFound  = domain.ErrPostNotFound
 	ErrForbidden = domain.ErrPostForbidden
-)
 
+	ErrRevisionNotFound = domain.ErrRevisionNotFound
+
+	ErrRepostNotFound    = domain.ErrRepostNotFound
+	ErrAlreadyReposted   = domain.ErrAlreadyReposted
+	ErrNotShareable      = domain.ErrPostNotShareable
+	ErrRepostNotEditable = domain.ErrRepostNotEditable
+
+	ErrNotPinnable = domain.ErrPostNotPinnable
+	ErrPinLimit    = domain.ErrPinLimit
+)
@@ -25,17 +25,21 @@ func NewHandler(service *Service) *Handler {
 
 // CreatePost godoc
 // @Summary Create a new post
-// @Description Create a new post with title, content, user_id and optional tags
+// @Description Create a new post with title, content, user_id and optional tags. Use status "draft" or a future publish_at to hold it back. Set quote_of_id to quote another post. comment_status and reply_audience control who may comment
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param post body CreateRequest true "Post creation request"
+// @Param Idempotency-Key header string false "Makes retries safe: a retry with the same key and body replays the first response"
 // @Success 201 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} Idempotent-Replayed "true when the response is a replay"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 422 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -57,24 +61,11 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.service.Create(r.Context(), userID, req)
 	if err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().
-				Uint("user_id", userID).
-				Str("title", req.Title).
-				Msg("Post creation failed: user not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "user_not_found")
-			return
-		}
-		logger.Logger().Error().
-			Err(err).
-			Uint("user_id", userID).
-			Str("title", req.Title).
-			Msg("Failed to create post")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_create_post")
-		return
-	}
-
-	logger.Logger().Info().
+		httputil.RespondDomainError(w, r, err, "failed_to_create_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
 		Uint("post_id", post.ID).
 		Uint("user_id", post.UserID).
 		Str("title", post.Title).
@@ -84,15 +75,19 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 // GetPost godoc
 // @Summary Get post by ID
-// @Description Get a post by its ID
+// @Description Get a post by its ID. Followers-only and private posts require authentication
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Header 200 {string} Last-Modified "Time of the last change"
+// @Success 304 "Not modified"
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/{id} [get]
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
@@ -101,34 +96,38 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.service.GetByID(r.Context(), uint(id))
+	viewerID, _ := middleware.GetUserID(r.Context())
+	post, err := h.service.GetByID(r.Context(), uint(id), viewerID)
 	if err != nil {
-		if err == ErrNotFound {
-			httputil.RespondError(w, r, http.StatusNotFound, "post_not_found")
-			return
-		}
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_post")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_post")
 		return
 	}
 
+	if httputil.NotModified(w, r, httputil.ETag(post.Version), post.LastModified()) {
+		return
+	}
 	httputil.RespondJSON(w, http.StatusOK, post)
 }
 
 // UpdatePost godoc
 // @Summary Update post
-// @Description Update an existing post
+// @Description Update an existing post. Set comment_status to lock or disable comments and reply_audience to limit who may comment
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Post ID"
 // @Param post body UpdateRequest true "Post update request"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -143,6 +142,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
 	var req UpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
@@ -154,31 +159,18 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.service.Update(r.Context(), uint(id), userID, req)
+	post, err := h.service.Update(r.Context(), uint(id), userID, req, expectedVersion)
 	if err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("post_id", uint(id)).Msg("Post update failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "post_not_found")
-			return
-		}
-		if err == ErrForbidden {
-			logger.Logger().Warn().
-				Uint("post_id", uint(id)).
-				Uint("user_id", userID).
-				Msg("Post update failed: forbidden")
-			httputil.RespondError(w, r, http.StatusForbidden, "forbidden")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("post_id", uint(id)).Msg("Failed to update post")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_update_post")
-		return
-	}
-
-	logger.Logger().Info().
+		httputil.RespondDomainError(w, r, err, "failed_to_update_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
 		Uint("post_id", post.ID).
 		Uint("user_id", post.UserID).
 		Str("title", post.Title).
 		Msg("Post updated successfully")
+	httputil.SetETag(w, post.Version)
 	httputil.RespondJSON(w, http.StatusOK, post)
 }
 
@@ -190,12 +182,15 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 204
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
@@ -210,26 +205,173 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(r.Context(), uint(id), userID); err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("post_id", uint(id)).Msg("Post delete failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "post_not_found")
-			return
-		}
-		if err == ErrForbidden {
-			logger.Logger().Warn().
-				Uint("post_id", uint(id)).
-				Uint("user_id", userID).
-				Msg("Post delete failed: forbidden")
-			httputil.RespondError(w, r, http.StatusForbidden, "forbidden")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("post_id", uint(id)).Msg("Failed to delete post")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_delete_post")
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), uint(id), userID, expectedVersion); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_delete_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("post_id", uint(id)).Msg("Post deleted successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RepostPost godoc
+// @Summary Repost a post
+// @Description Share a post with your followers as it is. Reposting a repost shares its original. Others' posts can only be reposted while they are public
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 201 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/repost [post]
+func (h *Handler) Repost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	repost, err := h.service.Repost(r.Context(), uint(id), userID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_repost_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("post_id", repost.ID).
+		Uint("repost_of", repost.RepostOf.ID).
+		Uint("user_id", userID).
+		Msg("Post reposted")
+	httputil.RespondJSON(w, http.StatusCreated, repost)
+}
+
+// UnrepostPost godoc
+// @Summary Undo a repost
+// @Description Remove your repost of a post
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/repost [delete]
+func (h *Handler) Unrepost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Unrepost(r.Context(), uint(id), userID); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_unrepost_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("post_id", uint(id)).Uint("user_id", userID).Msg("Repost removed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PinPost godoc
+// @Summary Pin a post
+// @Description Pin one of your published posts to the top of your profile. Up to three posts can be pinned
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/pin [put]
+func (h *Handler) Pin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	post, err := h.service.Pin(r.Context(), uint(id), userID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_pin_post")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("post_id", post.ID).Uint("user_id", userID).Msg("Post pinned")
+	httputil.RespondJSON(w, http.StatusOK, post)
+}
+
+// UnpinPost godoc
+// @Summary Unpin a post
+// @Description Take one of your posts off the top of your profile
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 204
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/pin [delete]
+func (h *Handler) Unpin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	if err := h.service.Unpin(r.Context(), uint(id), userID); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_unpin_post")
 		return
 	}
 
-	logger.Logger().Info().Uint("post_id", uint(id)).Msg("Post deleted successfully")
+	logger.FromContext(r.Context()).Info().Uint("post_id", uint(id)).Uint("user_id", userID).Msg("Post unpinned")
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -242,23 +384,24 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListResponse
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} httputil.Problem
 // @Router /posts [get]
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	limit, offset := httputil.GetPaginationParams(r)
+	viewerID, _ := middleware.GetUserID(r.Context())
 
-	result, err := h.service.List(r.Context(), limit, offset)
+	result, err := h.service.List(r.Context(), viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_list_posts")
+		httputil.RespondDomainError(w, r, err, "failed_to_list_posts")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, result)
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
 }
 
 // GetPostsByUser godoc
 // @Summary Get posts by user ID
-// @Description Get all posts created by a specific user
+// @Description Get all posts created by a specific user. Pinned posts come first, most recently pinned first
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -266,8 +409,8 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/{userID}/posts [get]
 func (h *Handler) GetByUser(w http.ResponseWriter, r *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(r, "userID"), 10, 32)
@@ -277,13 +420,14 @@ func (h *Handler) GetByUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	limit, offset := httputil.GetPaginationParams(r)
-	result, err := h.service.GetByUserID(r.Context(), uint(userID), limit, offset)
+	viewerID, _ := middleware.GetUserID(r.Context())
+	result, err := h.service.GetByUserID(r.Context(), uint(userID), viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_user_posts")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_user_posts")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, result)
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
 }
 
 // SearchPosts godoc
@@ -296,8 +440,8 @@ func (h *Handler) GetByUser(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/search [get]
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
@@ -307,13 +451,14 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	}
 
 	limit, offset := httputil.GetPaginationParams(r)
-	posts, err := h.service.SearchByTitle(r.Context(), query, limit, offset)
+	viewerID, _ := middleware.GetUserID(r.Context())
+	posts, err := h.service.SearchByTitle(r.Context(), query, viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_search_posts")
+		httputil.RespondDomainError(w, r, err, "failed_to_search_posts")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+	httputil.RespondJSONConditional(w, r, http.StatusOK, map[string]interface{}{
 		"posts": posts,
 		"query": query,
 	})
@@ -329,8 +474,8 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /posts/tags [get]
 func (h *Handler) GetByTags(w http.ResponseWriter, r *http.Request) {
 	tagsParam := r.URL.Query().Get("tags")
@@ -345,14 +490,173 @@ func (h *Handler) GetByTags(w http.ResponseWriter, r *http.Request) {
 	}
 
 	limit, offset := httputil.GetPaginationParams(r)
-	posts, err := h.service.GetByTags(r.Context(), tags, limit, offset)
+	viewerID, _ := middleware.GetUserID(r.Context())
+	posts, err := h.service.GetByTags(r.Context(), tags, viewerID, limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_posts_by_tags")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_posts_by_tags")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+	httputil.RespondJSONConditional(w, r, http.StatusOK, map[string]interface{}{
 		"posts": posts,
 		"tags":  tags,
 	})
 }
+
+// GetDrafts godoc
+// @Summary Get my drafts
+// @Description Get the authenticated user's drafts and scheduled posts
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} ListResponse
+// @Failure 401 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/drafts [get]
+func (h *Handler) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit, offset := httputil.GetPaginationParams(r)
+	result, err := h.service.GetDrafts(r.Context(), userID, limit, offset)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_drafts")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// GetRevisions godoc
+// @Summary Get post revisions
+// @Description Get the edit history of a post, newest first
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} RevisionListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/revisions [get]
+func (h *Handler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	viewerID, _ := middleware.GetUserID(r.Context())
+	limit, offset := httputil.GetPaginationParams(r)
+	result, err := h.service.GetRevisions(r.Context(), uint(id), viewerID, limit, offset)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_revisions")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// GetRevision godoc
+// @Summary Get post revision
+// @Description Get a single revision with title and content diffs against the previous revision
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param number path int true "Revision number"
+// @Success 200 {object} RevisionDetailResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/revisions/{number} [get]
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil || number < 1 {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_revision_number")
+		return
+	}
+
+	viewerID, _ := middleware.GetUserID(r.Context())
+	result, err := h.service.GetRevision(r.Context(), uint(id), number, viewerID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_revisions")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// RestoreRevision godoc
+// @Summary Restore post revision
+// @Description Restore the title, content and tags of an earlier revision. The restore is recorded as a new revision
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param number path int true "Revision number"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} Response
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /posts/{id}/revisions/{number}/restore [post]
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_post_id")
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil || number < 1 {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_revision_number")
+		return
+	}
+
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	post, err := h.service.RestoreRevision(r.Context(), uint(id), number, userID, expectedVersion)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_restore_revision")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("post_id", post.ID).
+		Uint("user_id", userID).
+		Int("number", number).
+		Msg("Post revision restored")
+	httputil.SetETag(w, post.Version)
+	httputil.RespondJSON(w, http.StatusOK, post)
+}
@@ -2,10 +2,12 @@ package posts
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/urdogan0000/social/internal/domain"
 	"gorm.io/gorm"
 )
 
@@ -48,17 +50,113 @@ func (a *StringArray) Scan(value interface{}) error {
 }
 
 type Model struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Title     string         `gorm:"not null;size:255" json:"title"`
-	Content   string         `gorm:"type:text;not null" json:"content"`
-	UserID    uint           `gorm:"not null;index" json:"user_id"`
-	Tags      StringArray    `gorm:"type:text[]" json:"tags"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	Title       string      `gorm:"not null;size:255" json:"title"`
+	Content     string      `gorm:"type:text;not null" json:"content"`
+	UserID      uint        `gorm:"not null;index;uniqueIndex:idx_posts_repost_user,priority:2" json:"user_id"`
+	Tags        StringArray `gorm:"type:text[]" json:"tags"`
+	Mentions    Mentions    `gorm:"type:jsonb" json:"mentions"`
+	Visibility  string      `gorm:"not null;size:20;default:public;index" json:"visibility"`
+	Status      string      `gorm:"not null;size:20;default:published;index" json:"status"`
+	PublishAt   *time.Time  `gorm:"index" json:"publish_at"`
+	EditedAt    *time.Time  `json:"edited_at"`
+	Version     uint        `gorm:"not null;default:1" json:"version"`
+	RepostOfID  *uint       `gorm:"uniqueIndex:idx_posts_repost_user,priority:1,where:repost_of_id IS NOT NULL AND deleted_at IS NULL" json:"repost_of_id"`
+	QuoteOfID   *uint       `gorm:"index" json:"quote_of_id"`
+	RepostCount int         `gorm:"not null;default:0" json:"repost_count"`
+	QuoteCount  int         `gorm:"not null;default:0" json:"quote_count"`
+	PinnedAt    *time.Time  `gorm:"index" json:"pinned_at"`
+	// CommentStatus and ReplyAudience are the author's controls over the
+	// discussion under the post
+	CommentStatus string         `gorm:"not null;size:20;default:open" json:"comment_status"`
+	ReplyAudience string         `gorm:"not null;size:20;default:everyone" json:"reply_audience"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (Model) TableName() string {
 	return "posts"
 }
 
+// Mention is a resolved @username in a post or comment. Start and End are
+// character offsets of the whole "@username" in the content.
+type Mention struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// Mentions is stored as a JSON array with the text it was found in, so
+// listings need no lookups to render it
+type Mentions []Mention
+
+func (m Mentions) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *Mentions) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	default:
+		return errors.New("unsupported type for Mentions")
+	}
+}
+
+// NewMentions converts resolved domain mentions for storage
+func NewMentions(mentions []domain.Mention) Mentions {
+	result := make(Mentions, len(mentions))
+	for i, mention := range mentions {
+		result[i] = Mention{
+			UserID:   uint(mention.UserID),
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		}
+	}
+	return result
+}
+
+// Domain converts stored mentions back to domain mentions
+func (m Mentions) Domain() []domain.Mention {
+	result := make([]domain.Mention, len(m))
+	for i, mention := range m {
+		result[i] = domain.Mention{
+			UserID:   domain.UserID(mention.UserID),
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		}
+	}
+	return result
+}
+
+// RevisionModel is an immutable snapshot of a post's text after an edit
+type RevisionModel struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	PostID    uint        `gorm:"not null;uniqueIndex:idx_post_revisions_post_number" json:"post_id"`
+	Number    int         `gorm:"not null;uniqueIndex:idx_post_revisions_post_number" json:"number"`
+	Title     string      `gorm:"not null;size:255" json:"title"`
+	Content   string      `gorm:"type:text;not null" json:"content"`
+	Tags      StringArray `gorm:"type:text[]" json:"tags"`
+	EditorID  uint        `gorm:"not null" json:"editor_id"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (RevisionModel) TableName() string {
+	return "post_revisions"
+}
@@ -4,22 +4,83 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, post *Model) error
 	GetByID(ctx context.Context, id uint) (*Model, error)
-	GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error)
+	GetByUserID(ctx context.Context, userID, viewerID uint, limit, offset int) ([]Model, error)
 	Update(ctx context.Context, post *Model) error
-	Delete(ctx context.Context, id uint) error
-	List(ctx context.Context, limit, offset int) ([]Model, error)
-	Count(ctx context.Context) (int64, error)
-	CountByUserID(ctx context.Context, userID uint) (int64, error)
-	SearchByTitle(ctx context.Context, title string, limit, offset int) ([]Model, error)
-	GetByTags(ctx context.Context, tags []string, limit, offset int) ([]Model, error)
+	// Delete removes the post. A non-nil version makes the delete conditional
+	// on the stored version, failing with domain.ErrVersionMismatch otherwise.
+	Delete(ctx context.Context, id uint, version *uint) error
+	List(ctx context.Context, viewerID uint, limit, offset int) ([]Model, error)
+	Count(ctx context.Context, viewerID uint) (int64, error)
+	CountByUserID(ctx context.Context, userID, viewerID uint) (int64, error)
+	SearchByTitle(ctx context.Context, title string, viewerID uint, limit, offset int) ([]Model, error)
+	GetByTags(ctx context.Context, tags []string, viewerID uint, limit, offset int) ([]Model, error)
+	CountByTags(ctx context.Context, tags []string, viewerID uint) (int64, error)
+	GetDraftsByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error)
+	CountDraftsByUserID(ctx context.Context, userID uint) (int64, error)
+	PublishDue(ctx context.Context, now time.Time, batchSize int) ([]Model, error)
+	CreateRevision(ctx context.Context, revision *RevisionModel) error
+	GetRevisions(ctx context.Context, postID uint, limit, offset int) ([]RevisionModel, error)
+	CountRevisions(ctx context.Context, postID uint) (int64, error)
+	GetRevision(ctx context.Context, postID uint, number int) (*RevisionModel, error)
+	CreateRepost(ctx context.Context, repost *Model) error
+	GetRepost(ctx context.Context, userID, postID uint) (*Model, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]Model, error)
+	AdjustShareCounts(ctx context.Context, id uint, reposts, quotes int) error
+	DeleteReposts(ctx context.Context, postID uint) ([]uint, error)
+	Pin(ctx context.Context, id, userID uint, now time.Time, limit int) error
+	Unpin(ctx context.Context, id uint) error
+}
+
+// ListableBy restricts a query to published posts that may appear in listings
+// for the viewer. A zero viewerID means an anonymous viewer who only sees public
+// posts; authors always see their own posts, and followers see followers-only
+// posts. Unlisted posts never show up in other users' listings, and drafts are
+// only reachable through GetDraftsByUserID.
+func ListableBy(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.status = ?", string(domain.PostStatusPublished))
+		if viewerID == 0 {
+			return db.Where("posts.visibility = ?", string(domain.VisibilityPublic))
+		}
+		return db.Where(
+			"posts.visibility = ? OR posts.user_id = ? OR (posts.visibility = ? AND posts.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?))",
+			string(domain.VisibilityPublic), viewerID, string(domain.VisibilityFollowers), viewerID,
+		)
+	}
+}
+
+// ReadableBy restricts a query to published posts the viewer may open. Unlike
+// ListableBy it includes other users' unlisted posts, which anyone with the
+// link may read.
+func ReadableBy(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.status = ?", string(domain.PostStatusPublished))
+		open := []string{string(domain.VisibilityPublic), string(domain.VisibilityUnlisted)}
+		if viewerID == 0 {
+			return db.Where("posts.visibility IN ?", open)
+		}
+		return db.Where(
+			"posts.visibility IN ? OR posts.user_id = ? OR (posts.visibility = ? AND posts.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?))",
+			open, viewerID, string(domain.VisibilityFollowers), viewerID,
+		)
+	}
+}
+
+// TakesComments restricts a query to posts whose comments are not disabled,
+// so their comments may show up outside the post
+func TakesComments(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.comment_status <> ?", string(domain.CommentsDisabled))
 }
 
 type repository struct {
@@ -36,6 +97,9 @@ func (r *repository) getDB(ctx context.Context) *gorm.DB {
 }
 
 func (r *repository) Create(ctx context.Context, post *Model) error {
+	if post.Version == 0 {
+		post.Version = 1
+	}
 	if err := r.getDB(ctx).WithContext(ctx).Create(post).Error; err != nil {
 		return fmt.Errorf("failed to create post: %w", err)
 	}
@@ -53,40 +117,74 @@ func (r *repository) GetByID(ctx context.Context, id uint) (*Model, error) {
 	return &post, nil
 }
 
-func (r *repository) GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error) {
+// GetByUserID lists the user's posts with the pinned ones first, most recently
+// pinned first, followed by the rest newest first
+func (r *repository) GetByUserID(ctx context.Context, userID, viewerID uint, limit, offset int) ([]Model, error) {
 	var posts []Model
 	if err := r.getDB(ctx).WithContext(ctx).
 		Where("user_id = ?", userID).
+		Scopes(ListableBy(viewerID)).
 		Limit(limit).
 		Offset(offset).
-		Order("created_at DESC").
+		Order("pinned_at IS NULL, pinned_at DESC, created_at DESC").
 		Find(&posts).Error; err != nil {
 		return nil, fmt.Errorf("failed to get posts by user id %d: %w", userID, err)
 	}
 	return posts, nil
 }
 
+// Update saves the post only if the stored version is still the one it was
+// read with, and bumps the version. A concurrent write in between makes it fail
+// with domain.ErrVersionMismatch instead of being silently overwritten. Share
+// counts and pins are left alone; AdjustShareCounts and Pin maintain them.
 func (r *repository) Update(ctx context.Context, post *Model) error {
-	if err := r.getDB(ctx).WithContext(ctx).Save(post).Error; err != nil {
-		return fmt.Errorf("failed to update post %d: %w", post.ID, err)
+	version := post.Version
+	post.Version++
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(post).
+		Where("version = ?", version).
+		Select("*").
+		Omit("created_at", "repost_count", "quote_count", "pinned_at").
+		Updates(post)
+	if result.Error != nil {
+		post.Version = version
+		return fmt.Errorf("failed to update post %d: %w", post.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		post.Version = version
+		return domain.ErrVersionMismatch
 	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id uint) error {
-	result := r.getDB(ctx).WithContext(ctx).Delete(&Model{}, id)
+// Delete soft-deletes the post and unpins it, so it stops counting against
+// the author's pins
+func (r *repository) Delete(ctx context.Context, id uint, version *uint) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	query := tx.Where("id = ?", id)
+	if version != nil {
+		query = query.Where("version = ?", *version)
+	}
+	result := query.Delete(&Model{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete post %d: %w", id, result.Error)
 	}
 	if result.RowsAffected == 0 {
+		if version != nil {
+			return domain.ErrVersionMismatch
+		}
 		return ErrNotFound
 	}
+	if err := tx.Unscoped().Model(&Model{}).Where("id = ?", id).UpdateColumn("pinned_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to unpin post %d: %w", id, err)
+	}
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, limit, offset int) ([]Model, error) {
+func (r *repository) List(ctx context.Context, viewerID uint, limit, offset int) ([]Model, error) {
 	var posts []Model
 	if err := r.getDB(ctx).WithContext(ctx).
+		Scopes(ListableBy(viewerID)).
 		Limit(limit).
 		Offset(offset).
 		Order("created_at DESC").
@@ -96,29 +194,34 @@ func (r *repository) List(ctx context.Context, limit, offset int) ([]Model, erro
 	return posts, nil
 }
 
-func (r *repository) Count(ctx context.Context) (int64, error) {
+func (r *repository) Count(ctx context.Context, viewerID uint) (int64, error) {
 	var count int64
-	if err := r.getDB(ctx).WithContext(ctx).Model(&Model{}).Count(&count).Error; err != nil {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Scopes(ListableBy(viewerID)).
+		Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count posts: %w", err)
 	}
 	return count, nil
 }
 
-func (r *repository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+func (r *repository) CountByUserID(ctx context.Context, userID, viewerID uint) (int64, error) {
 	var count int64
 	if err := r.getDB(ctx).WithContext(ctx).
 		Model(&Model{}).
 		Where("user_id = ?", userID).
+		Scopes(ListableBy(viewerID)).
 		Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count posts by user id %d: %w", userID, err)
 	}
 	return count, nil
 }
 
-func (r *repository) SearchByTitle(ctx context.Context, title string, limit, offset int) ([]Model, error) {
+func (r *repository) SearchByTitle(ctx context.Context, title string, viewerID uint, limit, offset int) ([]Model, error) {
 	var posts []Model
 	if err := r.getDB(ctx).WithContext(ctx).
 		Where("LOWER(title) LIKE LOWER(?)", "%"+title+"%").
+		Scopes(ListableBy(viewerID)).
 		Limit(limit).
 		Offset(offset).
 		Order("created_at DESC").
@@ -128,15 +231,18 @@ func (r *repository) SearchByTitle(ctx context.Context, title string, limit, off
 	return posts, nil
 }
 
-func (r *repository) GetByTags(ctx context.Context, tags []string, limit, offset int) ([]Model, error) {
-	var posts []Model
-	query := r.getDB(ctx).WithContext(ctx)
-
-	for _, tag := range tags {
-		query = query.Or("? = ANY(tags)", tag)
+// taggedWith restricts a query to posts indexed under any of the normalized
+// tags, explicitly or through a hashtag
+func taggedWith(tags []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.id IN (SELECT post_tags.post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.name IN ?)", tags)
 	}
+}
 
-	if err := query.
+func (r *repository) GetByTags(ctx context.Context, tags []string, viewerID uint, limit, offset int) ([]Model, error) {
+	var posts []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Scopes(taggedWith(tags), ListableBy(viewerID)).
 		Limit(limit).
 		Offset(offset).
 		Order("created_at DESC").
@@ -145,3 +251,256 @@ func (r *repository) GetByTags(ctx context.Context, tags []string, limit, offset
 	}
 	return posts, nil
 }
+
+func (r *repository) CountByTags(ctx context.Context, tags []string, viewerID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Scopes(taggedWith(tags), ListableBy(viewerID)).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count posts by tags: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) GetDraftsByUserID(ctx context.Context, userID uint, limit, offset int) ([]Model, error) {
+	var posts []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ? AND status <> ?", userID, string(domain.PostStatusPublished)).
+		Limit(limit).
+		Offset(offset).
+		Order("updated_at DESC").
+		Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get drafts by user id %d: %w", userID, err)
+	}
+	return posts, nil
+}
+
+func (r *repository) CountDraftsByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("user_id = ? AND status <> ?", userID, string(domain.PostStatusPublished)).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count drafts by user id %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+// PublishDue flips scheduled posts whose publish time has passed to published
+// and returns them. It must run inside a transaction: rows are locked with
+// FOR UPDATE SKIP LOCKED so concurrent replicas never claim the same post.
+func (r *repository) PublishDue(ctx context.Context, now time.Time, batchSize int) ([]Model, error) {
+	var due []Model
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND publish_at <= ?", string(domain.PostStatusScheduled), now).
+		Order("publish_at").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("failed to select due posts: %w", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(due))
+	for i := range due {
+		ids[i] = due[i].ID
+		due[i].Status = string(domain.PostStatusPublished)
+		due[i].Version++
+	}
+
+	if err := tx.Model(&Model{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":  string(domain.PostStatusPublished),
+			"version": gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to publish due posts: %w", err)
+	}
+	return due, nil
+}
+
+// CreateRevision stores the revision under the next number for its post. It
+// must run in the transaction that updated the post, after the update, so the
+// post's row lock keeps concurrent edits from picking the same number.
+func (r *repository) CreateRevision(ctx context.Context, revision *RevisionModel) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+
+	var last int
+	if err := tx.Model(&RevisionModel{}).
+		Where("post_id = ?", revision.PostID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&last).Error; err != nil {
+		return fmt.Errorf("failed to get last revision of post %d: %w", revision.PostID, err)
+	}
+
+	revision.Number = last + 1
+	if err := tx.Create(revision).Error; err != nil {
+		return fmt.Errorf("failed to create revision of post %d: %w", revision.PostID, err)
+	}
+	return nil
+}
+
+func (r *repository) GetRevisions(ctx context.Context, postID uint, limit, offset int) ([]RevisionModel, error) {
+	var revisions []RevisionModel
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("post_id = ?", postID).
+		Limit(limit).
+		Offset(offset).
+		Order("number DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revisions of post %d: %w", postID, err)
+	}
+	return revisions, nil
+}
+
+func (r *repository) CountRevisions(ctx context.Context, postID uint) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&RevisionModel{}).
+		Where("post_id = ?", postID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count revisions of post %d: %w", postID, err)
+	}
+	return count, nil
+}
+
+func (r *repository) GetRevision(ctx context.Context, postID uint, number int) (*RevisionModel, error) {
+	var revision RevisionModel
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("post_id = ? AND number = ?", postID, number).
+		First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to get revision %d of post %d: %w", number, postID, err)
+	}
+	return &revision, nil
+}
+
+// CreateRepost stores a repost unless the user already reposted the post. The
+// partial unique index on (repost_of_id, user_id) settles concurrent reposts.
+func (r *repository) CreateRepost(ctx context.Context, repost *Model) error {
+	if repost.Version == 0 {
+		repost.Version = 1
+	}
+	result := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(repost)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create repost: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlreadyReposted
+	}
+	return nil
+}
+
+func (r *repository) GetRepost(ctx context.Context, userID, postID uint) (*Model, error) {
+	var repost Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("repost_of_id = ? AND user_id = ?", postID, userID).
+		First(&repost).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepostNotFound
+		}
+		return nil, fmt.Errorf("failed to get repost of post %d by user %d: %w", postID, userID, err)
+	}
+	return &repost, nil
+}
+
+// GetByIDs returns the posts that exist among ids, in no particular order
+func (r *repository) GetByIDs(ctx context.Context, ids []uint) ([]Model, error) {
+	var posts []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("id IN ?", ids).
+		Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get posts by ids: %w", err)
+	}
+	return posts, nil
+}
+
+// AdjustShareCounts adds to the repost and quote counts of a post. Counts do
+// not bump the version, so being shared never conflicts with the author's
+// edits.
+func (r *repository) AdjustShareCounts(ctx context.Context, id uint, reposts, quotes int) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"repost_count": gorm.Expr("GREATEST(repost_count + ?, 0)", reposts),
+			"quote_count":  gorm.Expr("GREATEST(quote_count + ?, 0)", quotes),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to adjust share counts of post %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteReposts deletes the reposts of a post and returns their ids
+func (r *repository) DeleteReposts(ctx context.Context, postID uint) ([]uint, error) {
+	tx := r.getDB(ctx).WithContext(ctx)
+
+	var ids []uint
+	if err := tx.Model(&Model{}).
+		Where("repost_of_id = ?", postID).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reposts of post %d: %w", postID, err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := tx.Model(&Model{}).Where("id IN ?", ids).UpdateColumn("pinned_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to unpin reposts of post %d: %w", postID, err)
+	}
+	if err := tx.Delete(&Model{}, ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete reposts of post %d: %w", postID, err)
+	}
+	return ids, nil
+}
+
+// Pin pins the post to the top of its author's profile unless the author
+// already has limit pinned posts. It must run inside a transaction: the
+// author's user row is locked so concurrent pins are counted one at a time.
+// Pinning does not bump the version, so it never conflicts with edits.
+func (r *repository) Pin(ctx context.Context, id, userID uint, now time.Time, limit int) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+
+	var locked []uint
+	if err := tx.Table("users").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", userID).
+		Pluck("id", &locked).Error; err != nil {
+		return fmt.Errorf("failed to lock pins of user %d: %w", userID, err)
+	}
+
+	var pinned int64
+	if err := tx.Model(&Model{}).
+		Where("user_id = ? AND pinned_at IS NOT NULL AND id <> ?", userID, id).
+		Count(&pinned).Error; err != nil {
+		return fmt.Errorf("failed to count pinned posts of user %d: %w", userID, err)
+	}
+	if pinned >= int64(limit) {
+		return ErrPinLimit
+	}
+
+	if err := tx.Model(&Model{}).
+		Where("id = ? AND pinned_at IS NULL", id).
+		UpdateColumn("pinned_at", now).Error; err != nil {
+		return fmt.Errorf("failed to pin post %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *repository) Unpin(ctx context.Context, id uint) error {
+	if err := r.getDB(ctx).WithContext(ctx).
+		Model(&Model{}).
+		Where("id = ?", id).
+		UpdateColumn("pinned_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to unpin post %d: %w", id, err)
+	}
+	return nil
+}
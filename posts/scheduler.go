@@ -0,0 +1,83 @@
+package posts
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Scheduler periodically publishes scheduled posts whose publish time has passed.
+// Every API replica can run one; PublishDue locks rows so each post is claimed once.
+type Scheduler struct {
+	service   *Service
+	interval  time.Duration
+	batchSize int
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewScheduler(service *Service, interval time.Duration, batchSize int) *Scheduler {
+	return &Scheduler{
+		service:   service,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start launches the publishing loop in the background
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// Stop signals the loop to exit and waits for the current tick to finish
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.tick()
+		}
+	}
+}
+
+func (s *Scheduler) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+
+	// Drain in batches so a backlog of due posts doesn't wait for the next tick
+	for {
+		count, err := s.service.PublishDuePosts(ctx, time.Now(), s.batchSize)
+		if err != nil {
+			logger.Logger().Error().Err(err).Msg("Failed to publish scheduled posts")
+			return
+		}
+		if count > 0 {
+			logger.Logger().Info().Int("count", count).Msg("Published scheduled posts")
+		}
+		if count < s.batchSize {
+			return
+		}
+	}
+}
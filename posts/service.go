@@ -3,41 +3,95 @@ package posts
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/internal/db"
 	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/tracing"
 )
 
 type Service struct {
 	repo           Repository
 	userRepo       domain.UserRepository
+	followRepo     domain.FollowRepository
+	mentionRepo    domain.MentionRepository
+	tagRepo        domain.TagRepository
+	bookmarkRepo   domain.BookmarkRepository
 	eventBus       events.EventBus
 	transactionMgr db.TransactionManager
 }
 
-func NewService(repo Repository, userRepo domain.UserRepository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
+func NewService(repo Repository, userRepo domain.UserRepository, followRepo domain.FollowRepository, mentionRepo domain.MentionRepository, tagRepo domain.TagRepository, bookmarkRepo domain.BookmarkRepository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
 	return &Service{
 		repo:           repo,
 		userRepo:       userRepo,
+		followRepo:     followRepo,
+		mentionRepo:    mentionRepo,
+		tagRepo:        tagRepo,
+		bookmarkRepo:   bookmarkRepo,
 		eventBus:       eventBus,
 		transactionMgr: transactionMgr,
 	}
 }
 
 func (s *Service) Create(ctx context.Context, userID uint, req CreateRequest) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.Create")
+	defer span.End()
+
 	// Check if user exists using domain repository
 	_, err := s.userRepo.GetByID(ctx, domain.UserID(userID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to check user existence: %w", err)
 	}
 
+	tags, err := domain.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	// Create domain post
 	post := &domain.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  domain.UserID(userID),
-		Tags:    req.Tags,
+		Title:         req.Title,
+		Content:       req.Content,
+		UserID:        domain.UserID(userID),
+		Tags:          tags,
+		Visibility:    domain.VisibilityPublic,
+		Status:        domain.PostStatusDraft,
+		CommentStatus: domain.CommentsOpen,
+		ReplyAudience: domain.ReplyEveryone,
+	}
+	if req.Visibility != "" {
+		post.Visibility = domain.Visibility(req.Visibility)
+	}
+	if req.CommentStatus != "" {
+		post.CommentStatus = domain.CommentStatus(req.CommentStatus)
+	}
+	if req.ReplyAudience != "" {
+		post.ReplyAudience = domain.ReplyAudience(req.ReplyAudience)
+	}
+	if req.QuoteOfID != nil {
+		quoted, err := s.shareable(ctx, *req.QuoteOfID, userID)
+		if err != nil {
+			return nil, err
+		}
+		quotedID := domain.PostID(quoted.ID)
+		post.QuoteOfID = &quotedID
+	}
+
+	// Posts are published immediately unless the author asks for a draft or a publish time
+	status := domain.PostStatus(req.Status)
+	if status == "" {
+		status = domain.PostStatusPublished
+		if req.PublishAt != nil {
+			status = domain.PostStatusScheduled
+		}
+	}
+	if err := s.transition(post, status, req.PublishAt, time.Now()); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	// Validate domain model
@@ -45,17 +99,33 @@ func (s *Service) Create(ctx context.Context, userID uint, req CreateRequest) (*
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	post.Mentions, err = domain.ResolveMentions(ctx, post.Content, s.userRepo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+	}
+
 	// Convert to model
 	model := s.domainToModel(post)
 
+	create := func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, model); err != nil {
+			return err
+		}
+		if err := s.indexTags(ctx, model); err != nil {
+			return err
+		}
+		if err := s.indexMentions(ctx, model); err != nil {
+			return err
+		}
+		return s.countShare(ctx, model, 1)
+	}
+
 	// Use transaction if available
 	var createErr error
 	if s.transactionMgr != nil {
-		createErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
-			return s.repo.Create(txCtx, model)
-		})
+		createErr = s.transactionMgr.WithTransaction(ctx, create)
 	} else {
-		createErr = s.repo.Create(ctx, model)
+		createErr = create(ctx)
 	}
 
 	if createErr != nil {
@@ -65,40 +135,57 @@ func (s *Service) Create(ctx context.Context, userID uint, req CreateRequest) (*
 	// Update domain post with generated ID
 	post.ID = domain.PostID(model.ID)
 
-	// Publish event
-	if s.eventBus != nil {
-		_ = s.eventBus.Publish(ctx, events.PostCreated{
-			PostID: post.ID,
-			UserID: post.UserID,
-			Title:  post.Title,
-		})
+	// Drafts and scheduled posts are counted and announced when they get published
+	if post.IsPublished() {
+		metrics.PostsCreated.Inc()
+		if s.eventBus != nil {
+			_ = s.eventBus.Publish(ctx, events.PostCreated{
+				PostID: post.ID,
+				UserID: post.UserID,
+				Title:  post.Title,
+			})
+		}
 	}
+	s.notifyMentions(ctx, post, nil)
 
-	return s.toResponse(model), nil
+	return s.toViewerResponse(ctx, model, userID)
 }
 
-func (s *Service) GetByID(ctx context.Context, id uint) (*Response, error) {
+// GetByID returns the post if the viewer may read it. A zero viewerID means
+// an anonymous viewer; hidden posts are reported as not found.
+func (s *Service) GetByID(ctx context.Context, id uint, viewerID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetByID")
+	defer span.End()
+
 	post, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get post by id %d: %w", id, err)
 	}
-	return s.toResponse(post), nil
+
+	if err := s.checkVisible(ctx, s.modelToDomain(post), viewerID); err != nil {
+		return nil, err
+	}
+
+	return s.toViewerResponse(ctx, post, viewerID)
 }
 
-func (s *Service) GetByUserID(ctx context.Context, userID uint, limit, offset int) (*ListResponse, error) {
-	posts, err := s.repo.GetByUserID(ctx, userID, limit, offset)
+func (s *Service) GetByUserID(ctx context.Context, userID, viewerID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetByUserID")
+	defer span.End()
+
+	posts, err := s.repo.GetByUserID(ctx, userID, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get posts by user id %d: %w", userID, err)
 	}
 
-	total, err := s.repo.CountByUserID(ctx, userID)
+	total, err := s.repo.CountByUserID(ctx, userID, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count posts by user id %d: %w", userID, err)
 	}
 
-	responses := make([]Response, len(posts))
-	for i, post := range posts {
-		responses[i] = *s.toResponse(&post)
+	responses, err := s.toResponses(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ListResponse{
@@ -109,7 +196,49 @@ func (s *Service) GetByUserID(ctx context.Context, userID uint, limit, offset in
 	}, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRequest) (*Response, error) {
+// GetByIDs returns the posts in the order of ids, leaving out those that do
+// not exist or that the viewer may not read
+func (s *Service) GetByIDs(ctx context.Context, ids []uint, viewerID uint) ([]Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetByIDs")
+	defer span.End()
+
+	if len(ids) == 0 {
+		return []Response{}, nil
+	}
+
+	found, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts by ids: %w", err)
+	}
+
+	byID := make(map[uint]*Model, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	readable := make([]Model, 0, len(found))
+	for _, id := range ids {
+		post, ok := byID[id]
+		if !ok {
+			continue
+		}
+		visible, err := s.modelToDomain(post).IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post visibility: %w", err)
+		}
+		if visible {
+			readable = append(readable, *post)
+		}
+	}
+
+	return s.toResponses(ctx, readable, viewerID)
+}
+
+// Update applies the request to the post. A non-nil expectedVersion makes the
+// update conditional on the version the client last saw.
+func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRequest, expectedVersion *uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.Update")
+	defer span.End()
+
 	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get post by id %d: %w", id, err)
@@ -117,11 +246,18 @@ func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRe
 
 	// Convert to domain model
 	post := s.modelToDomain(model)
+	wasPublished := post.IsPublished()
 
 	// Check permission using domain method
 	if !post.CanBeEditedBy(domain.UserID(userID)) {
 		return nil, ErrForbidden
 	}
+	if post.IsRepost() {
+		return nil, ErrRepostNotEditable
+	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Update fields using domain methods
 	if req.Title != nil {
@@ -134,10 +270,50 @@ func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRe
 		if err := post.UpdateContent(*req.Content); err != nil {
 			return nil, fmt.Errorf("failed to update content: %w", err)
 		}
+		post.Mentions, err = domain.ResolveMentions(ctx, post.Content, s.userRepo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve mentions: %w", err)
+		}
 	}
 
 	if req.Tags != nil {
-		post.UpdateTags(*req.Tags)
+		tags, err := domain.NormalizeTags(*req.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update tags: %w", err)
+		}
+		post.UpdateTags(tags)
+	}
+
+	if req.Visibility != nil {
+		if err := post.UpdateVisibility(domain.Visibility(*req.Visibility)); err != nil {
+			return nil, fmt.Errorf("failed to update visibility: %w", err)
+		}
+	}
+
+	if req.CommentStatus != nil || req.ReplyAudience != nil {
+		status, audience := post.CommentStatus, post.ReplyAudience
+		if req.CommentStatus != nil {
+			status = domain.CommentStatus(*req.CommentStatus)
+		}
+		if req.ReplyAudience != nil {
+			audience = domain.ReplyAudience(*req.ReplyAudience)
+		}
+		if err := post.UpdateCommentSettings(status, audience); err != nil {
+			return nil, fmt.Errorf("failed to update comment settings: %w", err)
+		}
+	}
+
+	// A bare publish_at reschedules the post
+	if req.Status != nil || req.PublishAt != nil {
+		status := domain.PostStatusScheduled
+		if req.Status != nil {
+			status = domain.PostStatus(*req.Status)
+		}
+		if !(wasPublished && status == domain.PostStatusPublished) {
+			if err := s.transition(post, status, req.PublishAt, time.Now()); err != nil {
+				return nil, fmt.Errorf("failed to update status: %w", err)
+			}
+		}
 	}
 
 	// Validate updated post
@@ -145,38 +321,93 @@ func (s *Service) Update(ctx context.Context, id uint, userID uint, req UpdateRe
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Text edits of a published post are kept as revisions; drafts change freely
+	textChanged := wasPublished && (post.Title != model.Title ||
+		post.Content != model.Content ||
+		!slices.Equal(post.Tags, []string(model.Tags)))
+	if textChanged {
+		now := time.Now()
+		post.EditedAt = &now
+	}
+
 	// Convert back to model
 	updatedModel := s.domainToModel(post)
 	updatedModel.ID = model.ID
 	updatedModel.CreatedAt = model.CreatedAt
+	updatedModel.Version = model.Version
+	updatedModel.RepostCount = model.RepostCount
+	updatedModel.QuoteCount = model.QuoteCount
+	updatedModel.PinnedAt = model.PinnedAt
+
+	save := func(ctx context.Context) error {
+		// The version-checked update goes first: its row lock makes concurrent
+		// edits take turns, so the loser gets a version conflict instead of
+		// racing for the next revision number
+		if err := s.repo.Update(ctx, updatedModel); err != nil {
+			return err
+		}
+		if textChanged {
+			if err := s.recordRevision(ctx, model, updatedModel, userID); err != nil {
+				return err
+			}
+		}
+		if req.Tags != nil || req.Content != nil {
+			if err := s.indexTags(ctx, updatedModel); err != nil {
+				return err
+			}
+		}
+		if req.Content != nil {
+			return s.indexMentions(ctx, updatedModel)
+		}
+		return nil
+	}
 
 	// Use transaction if available
 	var updateErr error
 	if s.transactionMgr != nil {
-		updateErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
-			return s.repo.Update(txCtx, updatedModel)
-		})
+		updateErr = s.transactionMgr.WithTransaction(ctx, save)
 	} else {
-		updateErr = s.repo.Update(ctx, updatedModel)
+		updateErr = save(ctx)
 	}
 
 	if updateErr != nil {
 		return nil, fmt.Errorf("failed to update post %d: %w", id, updateErr)
 	}
 
-	// Publish event
+	// Publish event; a draft published by this update is announced as new
+	if !wasPublished && post.IsPublished() {
+		metrics.PostsCreated.Inc()
+	}
 	if s.eventBus != nil {
-		_ = s.eventBus.Publish(ctx, events.PostUpdated{
-			PostID: post.ID,
-			UserID: post.UserID,
-			Title:  post.Title,
-		})
+		if wasPublished {
+			_ = s.eventBus.Publish(ctx, events.PostUpdated{
+				PostID: post.ID,
+				UserID: post.UserID,
+				Title:  post.Title,
+			})
+		} else if post.IsPublished() {
+			_ = s.eventBus.Publish(ctx, events.PostCreated{
+				PostID: post.ID,
+				UserID: post.UserID,
+				Title:  post.Title,
+			})
+		}
 	}
 
-	return s.toResponse(updatedModel), nil
+	// Users mentioned before were notified when the post was published
+	var notified []domain.Mention
+	if wasPublished {
+		notified = model.Mentions.Domain()
+	}
+	s.notifyMentions(ctx, post, notified)
+
+	return s.toViewerResponse(ctx, updatedModel, userID)
 }
 
-func (s *Service) Delete(ctx context.Context, id uint, userID uint) error {
+func (s *Service) Delete(ctx context.Context, id uint, userID uint, expectedVersion *uint) error {
+	ctx, span := tracing.Start(ctx, "posts.Service.Delete")
+	defer span.End()
+
 	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get post by id %d: %w", id, err)
@@ -189,23 +420,49 @@ func (s *Service) Delete(ctx context.Context, id uint, userID uint) error {
 	if !post.CanBeDeletedBy(domain.UserID(userID)) {
 		return ErrForbidden
 	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	// Reposts go with the post they share; quotes stay and show it as unavailable
+	remove := func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, expectedVersion); err != nil {
+			return err
+		}
+		if err := s.countShare(ctx, model, -1); err != nil {
+			return err
+		}
+		_, err := s.repo.DeleteReposts(ctx, id)
+		return err
+	}
 
 	// Use transaction if available
 	var deleteErr error
 	if s.transactionMgr != nil {
-		deleteErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
-			return s.repo.Delete(txCtx, id)
-		})
+		deleteErr = s.transactionMgr.WithTransaction(ctx, remove)
 	} else {
-		deleteErr = s.repo.Delete(ctx, id)
+		deleteErr = remove(ctx)
 	}
 
 	if deleteErr != nil {
 		return fmt.Errorf("failed to delete post %d: %w", id, deleteErr)
 	}
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &userID,
+		Action:     audit.ActionPostDeleted,
+		TargetType: audit.TargetPost,
+		TargetID:   id,
+		Changes:    audit.Diff(model, nil),
+	})
 
-	// Publish event
-	if s.eventBus != nil {
+	// Publish event; subscribers never heard of unpublished posts
+	if s.eventBus != nil && post.IsRepost() {
+		_ = s.eventBus.Publish(ctx, events.PostUnreposted{
+			PostID:     post.ID,
+			OriginalID: *post.RepostOfID,
+			UserID:     post.UserID,
+		})
+	} else if s.eventBus != nil && post.IsPublished() {
 		_ = s.eventBus.Publish(ctx, events.PostDeleted{
 			PostID: post.ID,
 			UserID: post.UserID,
@@ -215,20 +472,222 @@ func (s *Service) Delete(ctx context.Context, id uint, userID uint) error {
 	return nil
 }
 
-func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, error) {
-	posts, err := s.repo.List(ctx, limit, offset)
+// Repost shares a post with the user's audience as it is; reposting a repost
+// shares its original. The repost takes the original's visibility, so it
+// never reaches readers the original is hidden from.
+func (s *Service) Repost(ctx context.Context, id, userID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.Repost")
+	defer span.End()
+
+	original, err := s.shareable(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalID := domain.PostID(original.ID)
+	repost := &domain.Post{
+		UserID:     domain.UserID(userID),
+		Visibility: domain.Visibility(original.Visibility),
+		Status:     domain.PostStatusDraft,
+		RepostOfID: &originalID,
+	}
+	if err := repost.Publish(time.Now()); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := repost.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	model := s.domainToModel(repost)
+	create := func(ctx context.Context) error {
+		if err := s.repo.CreateRepost(ctx, model); err != nil {
+			return err
+		}
+		return s.countShare(ctx, model, 1)
+	}
+
+	// Use transaction if available
+	var createErr error
+	if s.transactionMgr != nil {
+		createErr = s.transactionMgr.WithTransaction(ctx, create)
+	} else {
+		createErr = create(ctx)
+	}
+
+	if createErr != nil {
+		return nil, fmt.Errorf("failed to repost post %d: %w", original.ID, createErr)
+	}
+
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.PostReposted{
+			PostID:     domain.PostID(model.ID),
+			OriginalID: originalID,
+			UserID:     domain.UserID(userID),
+			AuthorID:   domain.UserID(original.UserID),
+		})
+	}
+
+	return s.toViewerResponse(ctx, model, userID)
+}
+
+// Unrepost takes the user's repost of a post back. id may also be a repost of
+// the post, as Repost accepts.
+func (s *Service) Unrepost(ctx context.Context, id, userID uint) error {
+	ctx, span := tracing.Start(ctx, "posts.Service.Unrepost")
+	defer span.End()
+
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get post by id %d: %w", id, err)
+	}
+	if post.RepostOfID != nil {
+		id = *post.RepostOfID
+	}
+
+	repost, err := s.repo.GetRepost(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get repost of post %d: %w", id, err)
+	}
+
+	remove := func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, repost.ID, nil); err != nil {
+			return err
+		}
+		return s.countShare(ctx, repost, -1)
+	}
+
+	// Use transaction if available
+	var deleteErr error
+	if s.transactionMgr != nil {
+		deleteErr = s.transactionMgr.WithTransaction(ctx, remove)
+	} else {
+		deleteErr = remove(ctx)
+	}
+
+	if deleteErr != nil {
+		return fmt.Errorf("failed to delete repost of post %d: %w", id, deleteErr)
+	}
+
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.PostUnreposted{
+			PostID:     domain.PostID(repost.ID),
+			OriginalID: domain.PostID(id),
+			UserID:     domain.UserID(userID),
+		})
+	}
+
+	return nil
+}
+
+// Pin pins one of the user's published posts to the top of their profile.
+// Pinning a pinned post keeps its place.
+func (s *Service) Pin(ctx context.Context, id, userID uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.Pin")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", id, err)
+	}
+	post := s.modelToDomain(model)
+	if !post.CanBeEditedBy(domain.UserID(userID)) {
+		return nil, ErrForbidden
+	}
+	if !post.CanBePinned() {
+		return nil, ErrNotPinnable
+	}
+
+	if model.PinnedAt == nil {
+		now := time.Now()
+		pin := func(ctx context.Context) error {
+			return s.repo.Pin(ctx, id, userID, now, domain.MaxPinnedPosts)
+		}
+
+		var pinErr error
+		if s.transactionMgr != nil {
+			pinErr = s.transactionMgr.WithTransaction(ctx, pin)
+		} else {
+			pinErr = pin(ctx)
+		}
+		if pinErr != nil {
+			return nil, fmt.Errorf("failed to pin post %d: %w", id, pinErr)
+		}
+		model.PinnedAt = &now
+	}
+
+	return s.toViewerResponse(ctx, model, userID)
+}
+
+// Unpin takes one of the user's posts off the top of their profile
+func (s *Service) Unpin(ctx context.Context, id, userID uint) error {
+	ctx, span := tracing.Start(ctx, "posts.Service.Unpin")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get post by id %d: %w", id, err)
+	}
+	if !s.modelToDomain(model).CanBeEditedBy(domain.UserID(userID)) {
+		return ErrForbidden
+	}
+	if err := s.repo.Unpin(ctx, id); err != nil {
+		return fmt.Errorf("failed to unpin post %d: %w", id, err)
+	}
+	return nil
+}
+
+// shareable returns the post that reposting or quoting id shares: reposts
+// stand for their original. Posts the user may not read are not found.
+func (s *Service) shareable(ctx context.Context, id, userID uint) (*Model, error) {
+	original, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", id, err)
+	}
+	if original.RepostOfID != nil {
+		originalID := *original.RepostOfID
+		if original, err = s.repo.GetByID(ctx, originalID); err != nil {
+			return nil, fmt.Errorf("failed to get post by id %d: %w", originalID, err)
+		}
+	}
+
+	post := s.modelToDomain(original)
+	if err := s.checkVisible(ctx, post, userID); err != nil {
+		return nil, err
+	}
+	if !post.CanBeSharedBy(domain.UserID(userID)) {
+		return nil, ErrNotShareable
+	}
+	return original, nil
+}
+
+// countShare adds delta to the repost or quote count of the post that post shares
+func (s *Service) countShare(ctx context.Context, post *Model, delta int) error {
+	switch {
+	case post.RepostOfID != nil:
+		return s.repo.AdjustShareCounts(ctx, *post.RepostOfID, delta, 0)
+	case post.QuoteOfID != nil:
+		return s.repo.AdjustShareCounts(ctx, *post.QuoteOfID, 0, delta)
+	}
+	return nil
+}
+
+func (s *Service) List(ctx context.Context, viewerID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.List")
+	defer span.End()
+
+	posts, err := s.repo.List(ctx, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list posts: %w", err)
 	}
 
-	total, err := s.repo.Count(ctx)
+	total, err := s.repo.Count(ctx, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count posts: %w", err)
 	}
 
-	responses := make([]Response, len(posts))
-	for i, post := range posts {
-		responses[i] = *s.toResponse(&post)
+	responses, err := s.toResponses(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ListResponse{
@@ -239,63 +698,550 @@ func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, e
 	}, nil
 }
 
-func (s *Service) SearchByTitle(ctx context.Context, title string, limit, offset int) ([]Response, error) {
-	posts, err := s.repo.SearchByTitle(ctx, title, limit, offset)
+func (s *Service) SearchByTitle(ctx context.Context, title string, viewerID uint, limit, offset int) ([]Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.SearchByTitle")
+	defer span.End()
+
+	posts, err := s.repo.SearchByTitle(ctx, title, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search posts by title %q: %w", title, err)
 	}
 
-	responses := make([]Response, len(posts))
-	for i, post := range posts {
-		responses[i] = *s.toResponse(&post)
+	responses, err := s.toResponses(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
 	return responses, nil
 }
 
-func (s *Service) GetByTags(ctx context.Context, tags []string, limit, offset int) ([]Response, error) {
-	posts, err := s.repo.GetByTags(ctx, tags, limit, offset)
+// GetByTags returns posts with any of the tags. Tags are matched in their
+// normalized form, and tags that cannot be valid match nothing.
+func (s *Service) GetByTags(ctx context.Context, tags []string, viewerID uint, limit, offset int) ([]Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetByTags")
+	defer span.End()
+
+	tags = normalizeQueryTags(tags)
+	if len(tags) == 0 {
+		return []Response{}, nil
+	}
+
+	posts, err := s.repo.GetByTags(ctx, tags, viewerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get posts by tags: %w", err)
 	}
 
-	responses := make([]Response, len(posts))
-	for i, post := range posts {
-		responses[i] = *s.toResponse(&post)
+	responses, err := s.toResponses(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
 	}
 
 	return responses, nil
 }
 
+// GetByTag returns the posts with a normalized tag along with their total
+func (s *Service) GetByTag(ctx context.Context, tag string, viewerID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetByTag")
+	defer span.End()
+
+	tags := []string{tag}
+	posts, err := s.repo.GetByTags(ctx, tags, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts by tag %q: %w", tag, err)
+	}
+
+	total, err := s.repo.CountByTags(ctx, tags, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count posts by tag %q: %w", tag, err)
+	}
+
+	responses, err := s.toResponses(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Posts:  responses,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// normalizeQueryTags normalizes tags to search for, dropping invalid ones
+func normalizeQueryTags(raw []string) []string {
+	var tags []string
+	for _, r := range raw {
+		if tag, err := domain.NormalizeTag(r); err == nil && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// GetDrafts returns the author's drafts and scheduled posts
+func (s *Service) GetDrafts(ctx context.Context, userID uint, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetDrafts")
+	defer span.End()
+
+	posts, err := s.repo.GetDraftsByUserID(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drafts by user id %d: %w", userID, err)
+	}
+
+	total, err := s.repo.CountDraftsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count drafts by user id %d: %w", userID, err)
+	}
+
+	responses, err := s.toResponses(ctx, posts, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResponse{
+		Posts:  responses,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// PublishDuePosts publishes scheduled posts whose publish time has passed and
+// fires PostCreated for each of them. It returns the number of posts published.
+func (s *Service) PublishDuePosts(ctx context.Context, now time.Time, batchSize int) (int, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.PublishDuePosts")
+	defer span.End()
+
+	var published []Model
+	var publishErr error
+	if s.transactionMgr != nil {
+		publishErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
+			var err error
+			published, err = s.repo.PublishDue(txCtx, now, batchSize)
+			return err
+		})
+	} else {
+		published, publishErr = s.repo.PublishDue(ctx, now, batchSize)
+	}
+
+	if publishErr != nil {
+		return 0, fmt.Errorf("failed to publish due posts: %w", publishErr)
+	}
+
+	metrics.PostsCreated.Add(float64(len(published)))
+
+	// Events go out only after the transaction committed
+	if s.eventBus != nil {
+		for _, post := range published {
+			_ = s.eventBus.Publish(ctx, events.PostCreated{
+				PostID: domain.PostID(post.ID),
+				UserID: domain.UserID(post.UserID),
+				Title:  post.Title,
+			})
+			s.notifyMentions(ctx, s.modelToDomain(&post), nil)
+		}
+	}
+
+	return len(published), nil
+}
+
+// GetRevisions lists the edit history of a post the viewer may read, newest first
+func (s *Service) GetRevisions(ctx context.Context, postID, viewerID uint, limit, offset int) (*RevisionListResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetRevisions")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+	if err := s.checkVisible(ctx, s.modelToDomain(model), viewerID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.GetRevisions(ctx, postID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions of post %d: %w", postID, err)
+	}
+
+	total, err := s.repo.CountRevisions(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count revisions of post %d: %w", postID, err)
+	}
+
+	responses := make([]RevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		responses[i] = s.toRevisionResponse(&revision)
+	}
+
+	return &RevisionListResponse{
+		Revisions: responses,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
+
+// GetRevision returns a single revision with line diffs against the previous one
+func (s *Service) GetRevision(ctx context.Context, postID uint, number int, viewerID uint) (*RevisionDetailResponse, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.GetRevision")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+	if err := s.checkVisible(ctx, s.modelToDomain(model), viewerID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.repo.GetRevision(ctx, postID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision %d of post %d: %w", number, postID, err)
+	}
+
+	response := &RevisionDetailResponse{RevisionResponse: s.toRevisionResponse(revision)}
+
+	var previousTitle, previousContent string
+	if number > 1 {
+		previous, err := s.repo.GetRevision(ctx, postID, number-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get revision %d of post %d: %w", number-1, postID, err)
+		}
+		previousTitle, previousContent = previous.Title, previous.Content
+		response.PreviousNumber = &previous.Number
+	}
+
+	response.TitleDiff = diffLines(previousTitle, revision.Title)
+	response.ContentDiff = diffLines(previousContent, revision.Content)
+	return response, nil
+}
+
+// RestoreRevision brings back the text of an old revision. The restore is an
+// ordinary update, so it is recorded as a new revision itself.
+func (s *Service) RestoreRevision(ctx context.Context, postID uint, number int, userID uint, expectedVersion *uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "posts.Service.RestoreRevision")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post by id %d: %w", postID, err)
+	}
+	if !s.modelToDomain(model).CanBeEditedBy(domain.UserID(userID)) {
+		return nil, ErrForbidden
+	}
+
+	revision, err := s.repo.GetRevision(ctx, postID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision %d of post %d: %w", number, postID, err)
+	}
+
+	tags := []string(revision.Tags)
+	return s.Update(ctx, postID, userID, UpdateRequest{
+		Title:   &revision.Title,
+		Content: &revision.Content,
+		Tags:    &tags,
+	}, expectedVersion)
+}
+
+// recordRevision stores the new text of an edited post. The first edit also
+// stores the originally published text so every revision has a predecessor.
+func (s *Service) recordRevision(ctx context.Context, before, after *Model, editorID uint) error {
+	if before.EditedAt == nil {
+		original := &RevisionModel{
+			PostID:    before.ID,
+			Title:     before.Title,
+			Content:   before.Content,
+			Tags:      before.Tags,
+			EditorID:  before.UserID,
+			CreatedAt: before.CreatedAt,
+		}
+		if before.PublishAt != nil {
+			original.CreatedAt = *before.PublishAt
+		}
+		if err := s.repo.CreateRevision(ctx, original); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.CreateRevision(ctx, &RevisionModel{
+		PostID:    after.ID,
+		Title:     after.Title,
+		Content:   after.Content,
+		Tags:      after.Tags,
+		EditorID:  editorID,
+		CreatedAt: *after.EditedAt,
+	})
+}
+
+func (s *Service) toRevisionResponse(revision *RevisionModel) RevisionResponse {
+	return RevisionResponse{
+		Number:    revision.Number,
+		PostID:    revision.PostID,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		Tags:      []string(revision.Tags),
+		EditorID:  revision.EditorID,
+		CreatedAt: revision.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// indexTags indexes the post under its explicit tags and hashtags
+func (s *Service) indexTags(ctx context.Context, post *Model) error {
+	if s.tagRepo == nil {
+		return nil
+	}
+	if err := s.tagRepo.SetPostTags(ctx, domain.PostID(post.ID), domain.PostTags(post.Tags, post.Content)); err != nil {
+		return fmt.Errorf("failed to store tags: %w", err)
+	}
+	return nil
+}
+
+// indexMentions stores who the post mentions for their mention lists
+func (s *Service) indexMentions(ctx context.Context, post *Model) error {
+	if s.mentionRepo == nil {
+		return nil
+	}
+	source := domain.MentionSource{PostID: domain.PostID(post.ID), AuthorID: domain.UserID(post.UserID)}
+	userIDs := domain.MentionedUsers(post.Mentions.Domain(), source.AuthorID)
+	if err := s.mentionRepo.Replace(ctx, source, userIDs); err != nil {
+		return fmt.Errorf("failed to store mentions: %w", err)
+	}
+	return nil
+}
+
+// notifyMentions fires UserMentioned for the users a published post mentions
+// that are not in notified and may read the post
+func (s *Service) notifyMentions(ctx context.Context, post *domain.Post, notified []domain.Mention) {
+	if s.eventBus == nil || !post.IsPublished() {
+		return
+	}
+	for _, userID := range domain.NewlyMentioned(notified, post.Mentions, post.UserID) {
+		if visible, err := post.IsVisibleTo(ctx, userID, s.followRepo); err != nil || !visible {
+			continue
+		}
+		_ = s.eventBus.Publish(ctx, events.UserMentioned{
+			UserID:   userID,
+			AuthorID: post.UserID,
+			PostID:   post.ID,
+		})
+	}
+}
+
+// transition moves the post to the requested lifecycle status
+func (s *Service) transition(post *domain.Post, status domain.PostStatus, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case domain.PostStatusDraft:
+		return post.MarkDraft()
+	case domain.PostStatusScheduled:
+		if publishAt == nil {
+			publishAt = post.PublishAt
+		}
+		if publishAt == nil {
+			return domain.ErrInvalidPublishAt
+		}
+		return post.Schedule(*publishAt, now)
+	case domain.PostStatusPublished:
+		return post.Publish(now)
+	default:
+		return domain.ErrInvalidPostStatus
+	}
+}
+
+// checkVisible returns ErrNotFound when the viewer is not allowed to read the post
+func (s *Service) checkVisible(ctx context.Context, post *domain.Post, viewerID uint) error {
+	visible, err := post.IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+	if err != nil {
+		return fmt.Errorf("failed to check post visibility: %w", err)
+	}
+	if !visible {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (s *Service) toResponse(post *Model) *Response {
-	return &Response{
-		ID:        post.ID,
-		Title:     post.Title,
-		Content:   post.Content,
-		UserID:    post.UserID,
-		Tags:      []string(post.Tags),
-		CreatedAt: post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	response := &Response{
+		ID:            post.ID,
+		Title:         post.Title,
+		Content:       post.Content,
+		UserID:        post.UserID,
+		Tags:          domain.PostTags(post.Tags, post.Content),
+		Mentions:      append([]Mention{}, post.Mentions...),
+		Visibility:    post.Visibility,
+		Status:        post.Status,
+		RepostCount:   post.RepostCount,
+		QuoteCount:    post.QuoteCount,
+		CommentStatus: post.CommentStatus,
+		ReplyAudience: post.ReplyAudience,
+		Version:       post.Version,
+		CreatedAt:     post.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     post.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if response.Status == "" {
+		response.Status = string(domain.PostStatusPublished)
 	}
+	if response.CommentStatus == "" {
+		response.CommentStatus = string(domain.CommentsOpen)
+	}
+	if response.ReplyAudience == "" {
+		response.ReplyAudience = string(domain.ReplyEveryone)
+	}
+	if post.PublishAt != nil {
+		publishAt := post.PublishAt.Format("2006-01-02T15:04:05Z07:00")
+		response.PublishAt = &publishAt
+	}
+	if post.EditedAt != nil {
+		editedAt := post.EditedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.Edited = true
+		response.EditedAt = &editedAt
+	}
+	if post.PinnedAt != nil {
+		pinnedAt := post.PinnedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.PinnedAt = &pinnedAt
+	}
+	return response
+}
+
+// toResponses converts posts for the viewer, embedding the posts they repost
+// or quote and flagging the ones the viewer bookmarked. Shared posts that were
+// deleted or that the viewer may not read are embedded as unavailable stubs.
+func (s *Service) toResponses(ctx context.Context, posts []Model, viewerID uint) ([]Response, error) {
+	var sharedIDs []uint
+	for _, post := range posts {
+		for _, id := range []*uint{post.RepostOfID, post.QuoteOfID} {
+			if id != nil && !slices.Contains(sharedIDs, *id) {
+				sharedIDs = append(sharedIDs, *id)
+			}
+		}
+	}
+
+	readable := make(map[uint]*Response)
+	if len(sharedIDs) > 0 {
+		shared, err := s.repo.GetByIDs(ctx, sharedIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shared posts: %w", err)
+		}
+		for i := range shared {
+			visible, err := s.modelToDomain(&shared[i]).IsVisibleTo(ctx, domain.UserID(viewerID), s.followRepo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check post visibility: %w", err)
+			}
+			if visible {
+				readable[shared[i].ID] = s.toResponse(&shared[i])
+			}
+		}
+	}
+
+	embed := func(id *uint) *EmbeddedPost {
+		if id == nil {
+			return nil
+		}
+		if post, ok := readable[*id]; ok {
+			return &EmbeddedPost{ID: *id, Post: post}
+		}
+		return &EmbeddedPost{ID: *id, Unavailable: true}
+	}
+
+	responses := make([]Response, len(posts))
+	for i := range posts {
+		responses[i] = *s.toResponse(&posts[i])
+		responses[i].RepostOf = embed(posts[i].RepostOfID)
+		responses[i].QuoteOf = embed(posts[i].QuoteOfID)
+	}
+	if err := s.flagBookmarks(ctx, responses, viewerID); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// flagBookmarks marks the posts, and the posts they embed, that the viewer
+// bookmarked
+func (s *Service) flagBookmarks(ctx context.Context, responses []Response, viewerID uint) error {
+	if s.bookmarkRepo == nil || viewerID == 0 || len(responses) == 0 {
+		return nil
+	}
+
+	var targets []*Response
+	for i := range responses {
+		targets = append(targets, &responses[i])
+		for _, embedded := range []*EmbeddedPost{responses[i].RepostOf, responses[i].QuoteOf} {
+			if embedded != nil && embedded.Post != nil {
+				targets = append(targets, embedded.Post)
+			}
+		}
+	}
+
+	ids := make([]domain.PostID, len(targets))
+	for i, target := range targets {
+		ids[i] = domain.PostID(target.ID)
+	}
+	bookmarked, err := s.bookmarkRepo.BookmarkedPostIDs(ctx, domain.UserID(viewerID), ids)
+	if err != nil {
+		return fmt.Errorf("failed to get bookmarks: %w", err)
+	}
+	for _, target := range targets {
+		target.Bookmarked = slices.Contains(bookmarked, domain.PostID(target.ID))
+	}
+	return nil
+}
+
+// toViewerResponse converts a single post for the viewer like toResponses
+func (s *Service) toViewerResponse(ctx context.Context, post *Model, viewerID uint) (*Response, error) {
+	responses, err := s.toResponses(ctx, []Model{*post}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
 }
 
 // domainToModel converts domain Post to repository Model
 func (s *Service) domainToModel(post *domain.Post) *Model {
+	status := post.Status
+	if status == "" {
+		status = domain.PostStatusPublished
+	}
+	commentStatus := post.CommentStatus
+	if commentStatus == "" {
+		commentStatus = domain.CommentsOpen
+	}
+	replyAudience := post.ReplyAudience
+	if replyAudience == "" {
+		replyAudience = domain.ReplyEveryone
+	}
 	return &Model{
-		Title:   post.Title,
-		Content: post.Content,
-		UserID:  uint(post.UserID),
-		Tags:    StringArray(post.Tags),
+		Title:         post.Title,
+		Content:       post.Content,
+		UserID:        uint(post.UserID),
+		Tags:          StringArray(post.Tags),
+		Mentions:      NewMentions(post.Mentions),
+		Visibility:    string(post.Visibility),
+		Status:        string(status),
+		PublishAt:     post.PublishAt,
+		EditedAt:      post.EditedAt,
+		RepostOfID:    (*uint)(post.RepostOfID),
+		QuoteOfID:     (*uint)(post.QuoteOfID),
+		CommentStatus: string(commentStatus),
+		ReplyAudience: string(replyAudience),
 	}
 }
 
 // modelToDomain converts repository Model to domain Post
 func (s *Service) modelToDomain(model *Model) *domain.Post {
 	return &domain.Post{
-		ID:      domain.PostID(model.ID),
-		Title:   model.Title,
-		Content: model.Content,
-		UserID:  domain.UserID(model.UserID),
-		Tags:    []string(model.Tags),
+		ID:            domain.PostID(model.ID),
+		Title:         model.Title,
+		Content:       model.Content,
+		UserID:        domain.UserID(model.UserID),
+		Tags:          []string(model.Tags),
+		Mentions:      model.Mentions.Domain(),
+		Visibility:    domain.Visibility(model.Visibility),
+		Status:        domain.PostStatus(model.Status),
+		PublishAt:     model.PublishAt,
+		EditedAt:      model.EditedAt,
+		RepostOfID:    (*domain.PostID)(model.RepostOfID),
+		QuoteOfID:     (*domain.PostID)(model.QuoteOfID),
+		CommentStatus: domain.CommentStatus(model.CommentStatus),
+		ReplyAudience: domain.ReplyAudience(model.ReplyAudience),
 	}
 }
@@ -0,0 +1,22 @@
+package tags
+
+import "github.com/urdogan0000/social/posts"
+
+type Response struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+type ListResponse struct {
+	Tags []Response `json:"tags"`
+}
+
+// PageResponse is a tag with the posts under it the viewer may see.
+// PostCount counts those posts.
+type PageResponse struct {
+	Name      string           `json:"name"`
+	PostCount int64            `json:"post_count"`
+	Posts     []posts.Response `json:"posts"`
+	Limit     int              `json:"limit"`
+	Offset    int              `json:"offset"`
+}
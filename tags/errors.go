@@ -0,0 +1,8 @@
+package tags
+
+import "github.com/urdogan0000/social/internal/domain"
+
+var (
+	ErrNotFound   = domain.ErrTagNotFound
+	ErrInvalidTag = domain.ErrInvalidTag
+)
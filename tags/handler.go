@@ -0,0 +1,93 @@
+package tags
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Get godoc
+// @Summary Get tag
+// @Description Get a tag with the posts under it, tagged explicitly or through a #hashtag in the content. The tag is matched case-insensitively and may be given with its '#'; post_count counts the posts the viewer may see.
+// @Tags tags
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} PageResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /tags/{tag} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_tag")
+		return
+	}
+
+	viewerID, _ := middleware.GetUserID(r.Context())
+	limit, offset := httputil.GetPaginationParams(r)
+	result, err := h.service.GetByName(r.Context(), tag, viewerID, limit, offset)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_tag")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// Autocomplete godoc
+// @Summary Autocomplete tags
+// @Description Get the most used tags starting with a prefix
+// @Tags tags
+// @Produce json
+// @Param q query string true "Tag prefix"
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /tags [get]
+func (h *Handler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	limit, _ := httputil.GetPaginationParams(r)
+	result, err := h.service.Autocomplete(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_tags")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
+
+// Trending godoc
+// @Summary Get trending tags
+// @Description Get the tags used most by recent public posts. Scores decay over time and are recomputed periodically.
+// @Tags tags
+// @Produce json
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} ListResponse
+// @Failure 500 {object} httputil.Problem
+// @Router /tags/trending [get]
+func (h *Handler) Trending(w http.ResponseWriter, r *http.Request) {
+	limit, _ := httputil.GetPaginationParams(r)
+	result, err := h.service.GetTrending(r.Context(), limit)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_tags")
+		return
+	}
+
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
+}
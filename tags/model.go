@@ -0,0 +1,30 @@
+package tags
+
+import "time"
+
+// Model is a normalized tag. PostCount and Score are recomputed by the
+// Ranker from published public posts: PostCount counts all of them, Score
+// weighs recent ones more for trending.
+type Model struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:200;not null;uniqueIndex;index:idx_tags_name_pattern,expression:name text_pattern_ops" json:"name"`
+	PostCount int64     `gorm:"not null;default:0" json:"post_count"`
+	Score     float64   `gorm:"not null;default:0;index" json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Model) TableName() string {
+	return "tags"
+}
+
+// PostTagModel indexes a post under a tag, from its explicit tags or a
+// hashtag in its content
+type PostTagModel struct {
+	PostID    uint      `gorm:"primaryKey" json:"post_id"`
+	TagID     uint      `gorm:"primaryKey;index" json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PostTagModel) TableName() string {
+	return "post_tags"
+}
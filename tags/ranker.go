@@ -0,0 +1,77 @@
+package tags
+
+import (
+	"context"
+	"time"
+
+	"github.com/urdogan0000/social/internal/logger"
+)
+
+// Ranker periodically recomputes tag post counts and trending scores. Every
+// API replica can run one; each run replaces the stats as a whole.
+type Ranker struct {
+	service  *Service
+	interval time.Duration
+	halfLife time.Duration
+	window   time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewRanker(service *Service, interval, halfLife, window time.Duration) *Ranker {
+	return &Ranker{
+		service:  service,
+		interval: interval,
+		halfLife: halfLife,
+		window:   window,
+	}
+}
+
+// Start launches the ranking loop in the background
+func (r *Ranker) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.run()
+}
+
+// Stop signals the loop to exit and waits for the current run to finish
+func (r *Ranker) Stop(ctx context.Context) error {
+	if r.stop == nil {
+		return nil
+	}
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Ranker) run() {
+	defer close(r.done)
+
+	// Rank right away so trending is not empty until the first tick
+	r.tick()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.tick()
+		}
+	}
+}
+
+func (r *Ranker) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+
+	if err := r.service.RefreshStats(ctx, time.Now(), r.halfLife, r.window); err != nil {
+		logger.Logger().Error().Err(err).Msg("Failed to refresh tag stats")
+	}
+}
@@ -0,0 +1,150 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	domain.TagRepository
+	GetByName(ctx context.Context, name string) (*Model, error)
+	SearchByPrefix(ctx context.Context, prefix string, limit int) ([]Model, error)
+	GetTrending(ctx context.Context, limit int) ([]Model, error)
+	RefreshStats(ctx context.Context, now time.Time, halfLife, window time.Duration) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// getDB retrieves the database connection from context or uses default
+func (r *repository) getDB(ctx context.Context) *gorm.DB {
+	return db.GetDBFromContext(ctx, r.db)
+}
+
+// SetPostTags creates tags seen for the first time and keeps the rows of
+// tags the post already had
+func (r *repository) SetPostTags(ctx context.Context, postID domain.PostID, names []string) error {
+	var ids []uint
+	if len(names) > 0 {
+		tags := make([]Model, len(names))
+		for i, name := range names {
+			tags[i] = Model{Name: name}
+		}
+		if err := r.getDB(ctx).WithContext(ctx).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+			Create(&tags).Error; err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+		if err := r.getDB(ctx).WithContext(ctx).
+			Model(&Model{}).
+			Where("name IN ?", names).
+			Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to get tag ids: %w", err)
+		}
+	}
+
+	stale := r.getDB(ctx).WithContext(ctx).Where("post_id = ?", postID)
+	if len(ids) > 0 {
+		stale = stale.Where("tag_id NOT IN ?", ids)
+	}
+	if err := stale.Delete(&PostTagModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete tags of post %d: %w", postID, err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows := make([]PostTagModel, len(ids))
+	for i, id := range ids {
+		rows[i] = PostTagModel{PostID: uint(postID), TagID: id}
+	}
+	if err := r.getDB(ctx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to tag post %d: %w", postID, err)
+	}
+	return nil
+}
+
+func (r *repository) GetByName(ctx context.Context, name string) (*Model, error) {
+	var tag Model
+	if err := r.getDB(ctx).WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag %q: %w", name, err)
+	}
+	return &tag, nil
+}
+
+// likeEscaper escapes the LIKE wildcards, '_' being common in tags
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchByPrefix returns tags in use starting with prefix, most used first
+func (r *repository) SearchByPrefix(ctx context.Context, prefix string, limit int) ([]Model, error) {
+	var tags []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("name LIKE ? AND post_count > 0", likeEscaper.Replace(prefix)+"%").
+		Limit(limit).
+		Order("post_count DESC, name").
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to search tags by prefix %q: %w", prefix, err)
+	}
+	return tags, nil
+}
+
+func (r *repository) GetTrending(ctx context.Context, limit int) ([]Model, error) {
+	var tags []Model
+	if err := r.getDB(ctx).WithContext(ctx).
+		Where("score > 0").
+		Limit(limit).
+		Order("score DESC, name").
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to get trending tags: %w", err)
+	}
+	return tags, nil
+}
+
+// RefreshStats recomputes every tag's post count and trending score from
+// published public posts. A post adds to the score with a weight that halves
+// every halfLife since it was published, and not at all once it is older
+// than window. It should run inside a transaction so readers never see the
+// counts reset.
+func (r *repository) RefreshStats(ctx context.Context, now time.Time, halfLife, window time.Duration) error {
+	tx := r.getDB(ctx).WithContext(ctx)
+	if err := tx.Exec("UPDATE tags SET post_count = 0, score = 0 WHERE post_count <> 0 OR score <> 0").Error; err != nil {
+		return fmt.Errorf("failed to reset tag stats: %w", err)
+	}
+
+	if err := tx.Exec(`UPDATE tags SET post_count = stats.post_count, score = stats.score
+FROM (
+	SELECT post_tags.tag_id,
+		COUNT(*) AS post_count,
+		COALESCE(SUM(POWER(0.5, EXTRACT(EPOCH FROM CAST(? AS timestamptz) - COALESCE(posts.publish_at, posts.created_at)) / ?))
+			FILTER (WHERE COALESCE(posts.publish_at, posts.created_at) > ?), 0) AS score
+	FROM post_tags
+	JOIN posts ON posts.id = post_tags.post_id
+	WHERE posts.deleted_at IS NULL AND posts.status = ? AND posts.visibility = ?
+	GROUP BY post_tags.tag_id
+) AS stats
+WHERE tags.id = stats.tag_id`,
+		now, halfLife.Seconds(), now.Add(-window),
+		string(domain.PostStatusPublished), string(domain.VisibilityPublic),
+	).Error; err != nil {
+		return fmt.Errorf("failed to compute tag stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/tracing"
+	"github.com/urdogan0000/social/posts"
+)
+
+type Service struct {
+	repo           Repository
+	postService    *posts.Service
+	transactionMgr db.TransactionManager
+}
+
+func NewService(repo Repository, postService *posts.Service, transactionMgr db.TransactionManager) *Service {
+	return &Service{
+		repo:           repo,
+		postService:    postService,
+		transactionMgr: transactionMgr,
+	}
+}
+
+// GetByName returns the tag page: the tag and the posts under it that the
+// viewer may see. The name may be given in any case and with its '#'.
+func (s *Service) GetByName(ctx context.Context, name string, viewerID uint, limit, offset int) (*PageResponse, error) {
+	ctx, span := tracing.Start(ctx, "tags.Service.GetByName")
+	defer span.End()
+
+	normalized, err := domain.NormalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.repo.GetByName(ctx, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag %q: %w", normalized, err)
+	}
+
+	list, err := s.postService.GetByTag(ctx, tag.Name, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PageResponse{
+		Name:      tag.Name,
+		PostCount: list.Total,
+		Posts:     list.Posts,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
+
+// Autocomplete returns the most used tags starting with prefix
+func (s *Service) Autocomplete(ctx context.Context, prefix string, limit int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "tags.Service.Autocomplete")
+	defer span.End()
+
+	normalized, err := domain.NormalizeTagPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.repo.SearchByPrefix(ctx, normalized, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tags: %w", err)
+	}
+	return s.toListResponse(tags), nil
+}
+
+// GetTrending returns the tags with the highest trending score
+func (s *Service) GetTrending(ctx context.Context, limit int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "tags.Service.GetTrending")
+	defer span.End()
+
+	tags, err := s.repo.GetTrending(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending tags: %w", err)
+	}
+	return s.toListResponse(tags), nil
+}
+
+// RefreshStats recomputes post counts and trending scores of all tags
+func (s *Service) RefreshStats(ctx context.Context, now time.Time, halfLife, window time.Duration) error {
+	ctx, span := tracing.Start(ctx, "tags.Service.RefreshStats")
+	defer span.End()
+
+	var refreshErr error
+	if s.transactionMgr != nil {
+		refreshErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
+			return s.repo.RefreshStats(txCtx, now, halfLife, window)
+		})
+	} else {
+		refreshErr = s.repo.RefreshStats(ctx, now, halfLife, window)
+	}
+
+	if refreshErr != nil {
+		return fmt.Errorf("failed to refresh tag stats: %w", refreshErr)
+	}
+	return nil
+}
+
+func (s *Service) toListResponse(tags []Model) *ListResponse {
+	responses := make([]Response, len(tags))
+	for i, tag := range tags {
+		responses[i] = Response{
+			Name:      tag.Name,
+			PostCount: tag.PostCount,
+		}
+	}
+	return &ListResponse{Tags: responses}
+}
@@ -0,0 +1,126 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/urdogan0000/social/audit"
+	"github.com/urdogan0000/social/users"
+)
+
+type mockRepository struct {
+	entries []audit.Model
+}
+
+func (m *mockRepository) Create(ctx context.Context, entry *audit.Model) error {
+	entry.ID = uint(len(m.entries) + 1)
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *mockRepository) List(ctx context.Context, filter audit.Filter, limit, offset int) ([]audit.Model, error) {
+	return m.entries, nil
+}
+
+func (m *mockRepository) Count(ctx context.Context, filter audit.Filter) (int64, error) {
+	return int64(len(m.entries)), nil
+}
+
+func (m *mockRepository) ListAfter(ctx context.Context, filter audit.Filter, afterID uint, limit int) ([]audit.Model, error) {
+	var result []audit.Model
+	for _, entry := range m.entries {
+		if entry.ID > afterID && len(result) < limit {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func TestDiff_ReportsChangedFieldsAndRedactsSecrets(t *testing.T) {
+	before := &users.Model{ID: 1, Username: "alice", Email: "old@example.com", Password: []byte("hash-1"), Version: 1}
+	after := &users.Model{ID: 1, Username: "alice", Email: "new@example.com", Password: []byte("hash-2"), Version: 2}
+
+	changes := audit.Diff(before, after)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected email and password changes, got %v", changes)
+	}
+	if c := changes["email"]; c.Before != "old@example.com" || c.After != "new@example.com" {
+		t.Errorf("unexpected email change %+v", c)
+	}
+	if c := changes["password"]; c.Before != audit.Redacted || c.After != audit.Redacted {
+		t.Errorf("expected redacted password change, got %+v", c)
+	}
+	if _, ok := changes["version"]; ok {
+		t.Error("expected bookkeeping fields to be ignored")
+	}
+}
+
+func TestDiff_DeletionSnapshotsBefore(t *testing.T) {
+	before := &users.Model{ID: 3, Username: "bob", Password: []byte("hash")}
+
+	changes := audit.Diff(before, nil)
+
+	if c := changes["username"]; c.Before != "bob" || c.After != nil {
+		t.Errorf("unexpected username change %+v", c)
+	}
+	if c := changes["password"]; c.Before != audit.Redacted {
+		t.Errorf("expected redacted password, got %+v", c)
+	}
+}
+
+func TestService_Record_UsesRequestContext(t *testing.T) {
+	repo := &mockRepository{}
+	service := audit.NewService(repo)
+
+	ctx := audit.WithRequest(context.Background(), audit.RequestInfo{
+		IP:        "203.0.113.7",
+		UserAgent: "curl/8.0",
+		RequestID: "req-1",
+	})
+	ctx = audit.WithActor(ctx, 42)
+
+	err := service.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserDeleted,
+		TargetType: audit.TargetUser,
+		TargetID:   7,
+		Metadata:   map[string]string{"reason": "spam", "api_token": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry := repo.entries[0]
+	if entry.ActorID == nil || *entry.ActorID != 42 {
+		t.Errorf("expected actor 42 from context, got %v", entry.ActorID)
+	}
+	if entry.TargetID == nil || *entry.TargetID != 7 {
+		t.Errorf("expected target 7, got %v", entry.TargetID)
+	}
+	if entry.IP != "203.0.113.7" || entry.UserAgent != "curl/8.0" || entry.RequestID != "req-1" {
+		t.Errorf("expected request details, got %+v", entry)
+	}
+	if entry.Metadata["api_token"] != audit.Redacted || entry.Metadata["reason"] != "spam" {
+		t.Errorf("expected only sensitive metadata redacted, got %v", entry.Metadata)
+	}
+}
+
+func TestService_Export_ReadsAllBatches(t *testing.T) {
+	repo := &mockRepository{}
+	service := audit.NewService(repo)
+	for i := 0; i < 1200; i++ {
+		_ = service.Record(context.Background(), audit.Entry{Action: audit.ActionLogin})
+	}
+
+	var ids []uint
+	err := service.Export(context.Background(), audit.Filter{}, func(entry *audit.Response) error {
+		ids = append(ids, entry.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 1200 || ids[0] != 1 || ids[len(ids)-1] != 1200 {
+		t.Errorf("expected all 1200 entries in order, got %d", len(ids))
+	}
+}
@@ -3,6 +3,7 @@ package auth_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/urdogan0000/social/auth"
@@ -36,6 +37,16 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id uint) (*users.Model
 	return nil, domain.ErrUserNotFound
 }
 
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []uint) ([]users.Model, error) {
+	var result []users.Model
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			result = append(result, *user)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockUserRepository) GetByUsername(ctx context.Context, username string) (*users.Model, error) {
 	for _, user := range m.users {
 		if user.Username == username {
@@ -61,7 +72,7 @@ func (m *mockUserRepository) Update(ctx context.Context, user *users.Model) erro
 	return nil
 }
 
-func (m *mockUserRepository) Delete(ctx context.Context, id uint) error {
+func (m *mockUserRepository) Delete(ctx context.Context, id uint, version *uint) error {
 	return nil
 }
 
@@ -254,3 +265,37 @@ func TestService_ValidateToken(t *testing.T) {
 	}
 }
 
+
+// wrappingUserRepository wraps lookup errors the way repository decorators do
+type wrappingUserRepository struct {
+	*mockUserRepository
+}
+
+func (r *wrappingUserRepository) GetByUsername(ctx context.Context, username string) (*users.Model, error) {
+	user, err := r.mockUserRepository.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	return user, nil
+}
+
+func (r *wrappingUserRepository) GetByEmail(ctx context.Context, email string) (*users.Model, error) {
+	user, err := r.mockUserRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return user, nil
+}
+
+func TestService_WrappedNotFound(t *testing.T) {
+	repo := &wrappingUserRepository{&mockUserRepository{users: make(map[uint]*users.Model)}}
+	service := auth.NewService(repo, "test-secret", 24)
+	ctx := context.Background()
+
+	if _, err := service.Register(ctx, auth.RegisterRequest{Username: "newuser", Email: "new@example.com", Password: "password123"}); err != nil {
+		t.Fatalf("expected registration despite wrapped not found errors, got %v", err)
+	}
+	if _, err := service.Login(ctx, auth.LoginRequest{Email: "missing@example.com", Password: "password123"}); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
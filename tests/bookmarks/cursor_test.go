@@ -0,0 +1,33 @@
+package bookmarks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/urdogan0000/social/bookmarks"
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := bookmarks.Cursor{Key: 1767225600123456, ID: 42}
+
+	parsed, err := bookmarks.ParseCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *parsed != cursor {
+		t.Errorf("expected %+v, got %+v", cursor, *parsed)
+	}
+
+	if parsed, err := bookmarks.ParseCursor(""); parsed != nil || err != nil {
+		t.Errorf("expected no cursor for the first page, got %v, %v", parsed, err)
+	}
+}
+
+func TestParseCursor_Invalid(t *testing.T) {
+	for _, raw := range []string{"%%%", "MTIz", "YTpi", "MTotMQ"} {
+		if _, err := bookmarks.ParseCursor(raw); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", raw, err)
+		}
+	}
+}
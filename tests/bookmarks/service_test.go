@@ -0,0 +1,138 @@
+package bookmarks_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/urdogan0000/social/bookmarks"
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+// mockRepository keeps collections in memory. Methods the tests do not reach
+// are left to the embedded nil interface.
+type mockRepository struct {
+	bookmarks.Repository
+	collections []*bookmarks.CollectionModel
+}
+
+func (m *mockRepository) CreateCollection(ctx context.Context, collection *bookmarks.CollectionModel) error {
+	for _, existing := range m.collections {
+		if existing.UserID == collection.UserID && existing.Name == collection.Name {
+			return bookmarks.ErrCollectionExists
+		}
+	}
+	collection.ID = uint(len(m.collections) + 1)
+	m.collections = append(m.collections, collection)
+	return nil
+}
+
+func (m *mockRepository) GetCollection(ctx context.Context, userID, id uint) (*bookmarks.CollectionModel, error) {
+	for _, collection := range m.collections {
+		if collection.ID == id && collection.UserID == userID {
+			copied := *collection
+			return &copied, nil
+		}
+	}
+	return nil, bookmarks.ErrCollectionNotFound
+}
+
+func (m *mockRepository) CountCollections(ctx context.Context, userID uint) (int64, error) {
+	count := int64(0)
+	for _, collection := range m.collections {
+		if collection.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockRepository) NameTaken(ctx context.Context, userID uint, name string, exceptID uint) (bool, error) {
+	for _, collection := range m.collections {
+		if collection.UserID == userID && collection.Name == name && collection.ID != exceptID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) UpdateCollection(ctx context.Context, collection *bookmarks.CollectionModel) error {
+	for i, existing := range m.collections {
+		if existing.ID == collection.ID {
+			m.collections[i] = collection
+		}
+	}
+	return nil
+}
+
+func TestService_CreateCollection(t *testing.T) {
+	repo := &mockRepository{}
+	service := bookmarks.NewService(repo, nil, nil)
+	ctx := context.Background()
+
+	collection, err := service.CreateCollection(ctx, 1, bookmarks.CreateCollectionRequest{Name: "  Recipes "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if collection.Name != "Recipes" {
+		t.Errorf("expected the name trimmed to Recipes, got %q", collection.Name)
+	}
+
+	if _, err := service.CreateCollection(ctx, 1, bookmarks.CreateCollectionRequest{Name: "Recipes"}); !errors.Is(err, domain.ErrCollectionExists) {
+		t.Errorf("expected ErrCollectionExists, got %v", err)
+	}
+	if _, err := service.CreateCollection(ctx, 2, bookmarks.CreateCollectionRequest{Name: "Recipes"}); err != nil {
+		t.Errorf("expected other users to use the same name, got %v", err)
+	}
+	if _, err := service.CreateCollection(ctx, 1, bookmarks.CreateCollectionRequest{Name: "   "}); !errors.Is(err, domain.ErrInvalidCollectionName) {
+		t.Errorf("expected ErrInvalidCollectionName, got %v", err)
+	}
+}
+
+func TestService_CreateCollection_Limit(t *testing.T) {
+	repo := &mockRepository{}
+	for i := range domain.MaxCollections {
+		repo.collections = append(repo.collections, &bookmarks.CollectionModel{ID: uint(i + 1), UserID: 1})
+	}
+	service := bookmarks.NewService(repo, nil, nil)
+
+	if _, err := service.CreateCollection(context.Background(), 1, bookmarks.CreateCollectionRequest{Name: "One more"}); !errors.Is(err, domain.ErrCollectionLimit) {
+		t.Errorf("expected ErrCollectionLimit, got %v", err)
+	}
+}
+
+func TestService_UpdateCollection(t *testing.T) {
+	repo := &mockRepository{}
+	service := bookmarks.NewService(repo, nil, nil)
+	ctx := context.Background()
+
+	first, _ := service.CreateCollection(ctx, 1, bookmarks.CreateCollectionRequest{Name: "Recipes"})
+	second, _ := service.CreateCollection(ctx, 1, bookmarks.CreateCollectionRequest{Name: "Travel"})
+
+	taken := "Recipes"
+	if _, err := service.UpdateCollection(ctx, 1, second.ID, bookmarks.UpdateCollectionRequest{Name: &taken}); !errors.Is(err, domain.ErrCollectionExists) {
+		t.Errorf("expected ErrCollectionExists, got %v", err)
+	}
+
+	// Keeping the own name is not a conflict
+	description := "Things to cook"
+	updated, err := service.UpdateCollection(ctx, 1, first.ID, bookmarks.UpdateCollectionRequest{Name: &taken, Description: &description})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Description != description {
+		t.Errorf("expected the description updated, got %q", updated.Description)
+	}
+
+	if _, err := service.UpdateCollection(ctx, 2, first.ID, bookmarks.UpdateCollectionRequest{Description: &description}); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("expected other users' collections to be not found, got %v", err)
+	}
+}
+
+func TestService_GetBookmarks_InvalidCursor(t *testing.T) {
+	service := bookmarks.NewService(&mockRepository{}, nil, nil)
+
+	if _, err := service.GetBookmarks(context.Background(), 1, "not a cursor", 20); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
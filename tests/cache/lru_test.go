@@ -0,0 +1,91 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(2)
+
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	// Touch a so b becomes the eviction candidate
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected b to be evicted, got %v", err)
+	}
+	if value, err := c.Get(ctx, "a"); err != nil || string(value) != "1" {
+		t.Errorf("expected a to stay cached, got %q, %v", value, err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRU_ExpiryAndDelete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+
+	_ = c.Set(ctx, "short", []byte("x"), time.Millisecond)
+	_ = c.Set(ctx, "long", []byte("y"), time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected expired entry to miss, got %v", err)
+	}
+
+	_ = c.Delete(ctx, "long", "missing")
+	if _, err := c.Get(ctx, "long"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected deleted entry to miss, got %v", err)
+	}
+}
+
+type record struct {
+	ID     uint
+	Secret []byte `json:"-"`
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+
+	loads := 0
+	load := func(ctx context.Context) (*record, error) {
+		loads++
+		return &record{ID: 1, Secret: []byte("hash")}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := cache.GetOrLoad(ctx, c, "records:1", time.Minute, load)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Fields hidden from JSON must survive the cache round trip
+		if got.ID != 1 || string(got.Secret) != "hash" {
+			t.Errorf("unexpected record %+v", got)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected a single load, got %d", loads)
+	}
+
+	_, err := cache.GetOrLoad(ctx, c, "records:2", time.Minute, func(ctx context.Context) (*record, error) {
+		return nil, errors.New("not found")
+	})
+	if err == nil {
+		t.Errorf("expected load error to be returned")
+	}
+	if _, err := c.Get(ctx, "records:2"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected failed loads not to be cached, got %v", err)
+	}
+}
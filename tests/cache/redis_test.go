@@ -0,0 +1,225 @@
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+)
+
+// fakeRedis is a local stand-in speaking just enough RESP for the cache client
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu     sync.Mutex
+	values map[string]string
+	expiry map[string]time.Time
+}
+
+func startFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fakeRedis{
+		listener: listener,
+		password: password,
+		values:   make(map[string]string),
+		expiry:   make(map[string]time.Time),
+	}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(args[0])
+		if !authenticated && command != "AUTH" {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		switch command {
+		case "AUTH":
+			if args[1] != s.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authenticated = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "GET":
+			value, ok := s.get(args[1])
+			if !ok {
+				fmt.Fprint(conn, "$-1\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+		case "SET":
+			s.set(args[1], args[2], args[3:])
+			fmt.Fprint(conn, "+OK\r\n")
+		case "DEL":
+			fmt.Fprintf(conn, ":%d\r\n", s.del(args[1:]))
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func (s *fakeRedis) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expiresAt, ok := s.expiry[key]; ok && time.Now().After(expiresAt) {
+		delete(s.values, key)
+		delete(s.expiry, key)
+	}
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *fakeRedis) set(key, value string, options []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	delete(s.expiry, key)
+	if len(options) == 2 && strings.ToUpper(options[0]) == "PX" {
+		ms, _ := strconv.Atoi(options[1])
+		s.expiry[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+	}
+}
+
+func (s *fakeRedis) del(keys []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := s.values[key]; ok {
+			deleted++
+		}
+		delete(s.values, key)
+		delete(s.expiry, key)
+	}
+	return deleted
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedis_GetSetDelete(t *testing.T) {
+	server := startFakeRedis(t, "secret")
+	c := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String(), Password: "secret"})
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("unexpected ping error: %v", err)
+	}
+	if _, err := c.Get(ctx, "posts:1"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected miss, got %v", err)
+	}
+
+	// Binary values including CRLF must round trip unchanged
+	value := []byte("line\r\nbinary\x00value")
+	if err := c.Set(ctx, "posts:1", value, time.Minute); err != nil {
+		t.Fatalf("unexpected set error: %v", err)
+	}
+	got, err := c.Get(ctx, "posts:1")
+	if err != nil || string(got) != string(value) {
+		t.Errorf("expected %q, got %q, %v", value, got, err)
+	}
+
+	if err := c.Delete(ctx, "posts:1"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, err := c.Get(ctx, "posts:1"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected miss after delete, got %v", err)
+	}
+}
+
+func TestRedis_TTL(t *testing.T) {
+	server := startFakeRedis(t, "")
+	c := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String()})
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "users:1", []byte("x"), 10*time.Millisecond); err != nil {
+		t.Fatalf("unexpected set error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := c.Get(ctx, "users:1"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected expired key to miss, got %v", err)
+	}
+}
+
+func TestRedis_Errors(t *testing.T) {
+	server := startFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	wrong := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String(), Password: "wrong"})
+	defer wrong.Close()
+	if err := wrong.Ping(ctx); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("expected authentication error, got %v", err)
+	}
+
+	unreachable := cache.NewRedis(cache.RedisOptions{Addr: "127.0.0.1:1", Timeout: 100 * time.Millisecond})
+	if _, err := unreachable.Get(ctx, "posts:1"); err == nil || errors.Is(err, cache.ErrMiss) {
+		t.Errorf("expected connection error, got %v", err)
+	}
+}
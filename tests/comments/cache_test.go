@@ -0,0 +1,44 @@
+package comments_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/internal/events"
+)
+
+func TestCachedRepository_InvalidatedByEvents(t *testing.T) {
+	inner := newMockRepository()
+	inner.comments[1] = &comments.Model{ID: 1, PostID: postID, Content: "Original", UserID: otherID, Version: 1}
+	c := cache.NewLRU(10)
+	repo := comments.NewCachedRepository(inner, c, time.Minute)
+	eventBus := events.NewInMemoryEventBus()
+	comments.RegisterCacheInvalidation(eventBus, c)
+	ctx := context.Background()
+
+	if _, err := repo.GetByID(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A write that bypasses the cached repository is not seen until invalidated
+	inner.comments[1] = &comments.Model{ID: 1, PostID: postID, Content: "Changed", UserID: otherID, Version: 2}
+	comment, _ := repo.GetByID(ctx, 1)
+	if comment.Content != "Original" {
+		t.Fatalf("expected cached content, got %q", comment.Content)
+	}
+
+	_ = eventBus.Publish(ctx, events.CommentUpdated{CommentID: 1, PostID: postID, UserID: otherID})
+	comment, _ = repo.GetByID(ctx, 1)
+	if comment.Content != "Changed" {
+		t.Errorf("expected fresh content after CommentUpdated, got %q", comment.Content)
+	}
+
+	delete(inner.comments, 1)
+	_ = eventBus.Publish(ctx, events.CommentDeleted{CommentID: 1, PostID: postID, UserID: otherID})
+	if _, err := repo.GetByID(ctx, 1); err == nil {
+		t.Errorf("expected deleted comment not to be served from cache")
+	}
+}
@@ -0,0 +1,59 @@
+package comments_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/domain"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB builds statements without a database so tests can check the SQL
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run db: %v", err)
+	}
+	return db
+}
+
+func TestRepository_List_SkipsDisabledComments(t *testing.T) {
+	db := newDryRunDB(t)
+	var statement string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statement = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	repo := comments.NewRepository(db)
+	if _, err := repo.List(context.Background(), 1, 10, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(statement, "posts.comment_status <> $") {
+		t.Errorf("expected comments of posts with disabled comments excluded, got %s", statement)
+	}
+}
+
+func TestRepository_Delete_NoRows(t *testing.T) {
+	// Dry runs affect no rows, like deleting a comment that is already gone
+	db := newDryRunDB(t).Session(&gorm.Session{SkipDefaultTransaction: true})
+	repo := comments.NewRepository(db)
+	ctx := context.Background()
+
+	if err := repo.Delete(ctx, 1, nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unversioned delete, got %v", err)
+	}
+	version := uint(1)
+	if err := repo.Delete(ctx, 1, &version); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for a versioned delete, got %v", err)
+	}
+}
@@ -0,0 +1,386 @@
+package comments_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/comments"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+)
+
+type mockRepository struct {
+	nextID   uint
+	comments map[uint]*comments.Model
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{comments: make(map[uint]*comments.Model)}
+}
+
+func (m *mockRepository) Create(ctx context.Context, comment *comments.Model) error {
+	m.nextID++
+	comment.ID = m.nextID
+	comment.Version = 1
+	stored := *comment
+	m.comments[comment.ID] = &stored
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uint) (*comments.Model, error) {
+	if comment, ok := m.comments[id]; ok {
+		copied := *comment
+		return &copied, nil
+	}
+	return nil, comments.ErrNotFound
+}
+
+func (m *mockRepository) GetByPostID(ctx context.Context, postID uint, limit, offset int) ([]comments.Model, error) {
+	var result []comments.Model
+	for _, comment := range m.comments {
+		if comment.PostID == postID {
+			result = append(result, *comment)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) Update(ctx context.Context, comment *comments.Model) error {
+	stored, ok := m.comments[comment.ID]
+	if !ok {
+		return comments.ErrNotFound
+	}
+	if stored.Version != comment.Version {
+		return domain.ErrVersionMismatch
+	}
+	comment.Version++
+	comment.Pinned = stored.Pinned
+	updated := *comment
+	m.comments[comment.ID] = &updated
+	return nil
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id uint, version *uint) error {
+	if comment, ok := m.comments[id]; ok && version != nil && comment.Version != *version {
+		return domain.ErrVersionMismatch
+	}
+	delete(m.comments, id)
+	return nil
+}
+
+func (m *mockRepository) List(ctx context.Context, viewerID uint, limit, offset int) ([]comments.Model, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) Count(ctx context.Context, viewerID uint) (int64, error) {
+	return int64(len(m.comments)), nil
+}
+
+func (m *mockRepository) CountByPostID(ctx context.Context, postID uint) (int64, error) {
+	list, _ := m.GetByPostID(ctx, postID, 0, 0)
+	return int64(len(list)), nil
+}
+
+func (m *mockRepository) Pin(ctx context.Context, postID, id uint) ([]uint, error) {
+	var unpinned []uint
+	for _, comment := range m.comments {
+		if comment.PostID == postID && comment.Pinned && comment.ID != id {
+			comment.Pinned = false
+			unpinned = append(unpinned, comment.ID)
+		}
+	}
+	m.comments[id].Pinned = true
+	return unpinned, nil
+}
+
+func (m *mockRepository) Unpin(ctx context.Context, id uint) error {
+	if comment, ok := m.comments[id]; ok {
+		comment.Pinned = false
+	}
+	return nil
+}
+
+// mockCommentRepository is the domain view of a mockRepository
+type mockCommentRepository struct {
+	repo *mockRepository
+}
+
+func (m *mockCommentRepository) GetByID(ctx context.Context, id domain.CommentID) (*domain.Comment, error) {
+	comment, err := m.repo.GetByID(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Comment{
+		ID:      domain.CommentID(comment.ID),
+		PostID:  domain.PostID(comment.PostID),
+		UserID:  domain.UserID(comment.UserID),
+		Content: comment.Content,
+		Pinned:  comment.Pinned,
+	}, nil
+}
+
+func (m *mockCommentRepository) Exists(ctx context.Context, id domain.CommentID) (bool, error) {
+	_, ok := m.repo.comments[uint(id)]
+	return ok, nil
+}
+
+func (m *mockCommentRepository) CountByPostID(ctx context.Context, postID domain.PostID) (int64, error) {
+	return m.repo.CountByPostID(ctx, uint(postID))
+}
+
+type mockPostRepository struct {
+	posts map[domain.PostID]*domain.Post
+}
+
+func (m *mockPostRepository) GetByID(ctx context.Context, id domain.PostID) (*domain.Post, error) {
+	if post, ok := m.posts[id]; ok {
+		return post, nil
+	}
+	return nil, domain.ErrPostNotFound
+}
+
+func (m *mockPostRepository) GetByUserID(ctx context.Context, userID domain.UserID) ([]*domain.Post, error) {
+	return nil, nil
+}
+
+func (m *mockPostRepository) Exists(ctx context.Context, id domain.PostID) (bool, error) {
+	_, ok := m.posts[id]
+	return ok, nil
+}
+
+type mockFollowRepository struct {
+	follows map[[2]domain.UserID]bool
+}
+
+func (m *mockFollowRepository) IsFollowing(ctx context.Context, followerID, followeeID domain.UserID) (bool, error) {
+	return m.follows[[2]domain.UserID{followerID, followeeID}], nil
+}
+
+// mockTransactionManager counts the transactions the service opens
+type mockTransactionManager struct {
+	transactions int
+}
+
+func (m *mockTransactionManager) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.transactions++
+	return fn(ctx)
+}
+
+const (
+	authorID   = 1
+	followerID = 2
+	otherID    = 3
+	postID     = 10
+)
+
+type fixture struct {
+	service  *comments.Service
+	repo     *mockRepository
+	posts    *mockPostRepository
+	tx       *mockTransactionManager
+	received []events.Event
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		repo: newMockRepository(),
+		posts: &mockPostRepository{posts: map[domain.PostID]*domain.Post{
+			postID: {ID: postID, UserID: authorID, Visibility: domain.VisibilityPublic, Status: domain.PostStatusPublished},
+		}},
+		tx: &mockTransactionManager{},
+	}
+	follows := &mockFollowRepository{follows: map[[2]domain.UserID]bool{{followerID, authorID}: true}}
+	bus := events.NewInMemoryEventBus()
+	for _, eventType := range []string{"comment.created", "comment.updated", "comment.deleted"} {
+		bus.Subscribe(eventType, func(ctx context.Context, event events.Event) error {
+			f.received = append(f.received, event)
+			return nil
+		})
+	}
+	f.service = comments.NewService(f.repo, &mockCommentRepository{f.repo}, nil, f.posts, follows, nil, bus, f.tx)
+	return f
+}
+
+func TestService_Create(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	comment, err := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Nice post"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comment.PostID != postID || comment.UserID != otherID || comment.Version != 1 {
+		t.Errorf("unexpected comment %+v", comment)
+	}
+	if f.tx.transactions != 1 {
+		t.Errorf("expected the comment written in a transaction, got %d", f.tx.transactions)
+	}
+	if len(f.received) != 1 {
+		t.Fatalf("expected one event, got %d", len(f.received))
+	}
+	created, ok := f.received[0].(events.CommentCreated)
+	if !ok || created.CommentID != domain.CommentID(comment.ID) || created.PostID != postID {
+		t.Errorf("unexpected event %+v", f.received[0])
+	}
+}
+
+func TestService_Create_MissingPost(t *testing.T) {
+	f := newFixture()
+
+	_, err := f.service.Create(context.Background(), otherID, comments.CreateRequest{PostID: 99, Content: "Hello?"})
+	if !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+	if len(f.repo.comments) != 0 || len(f.received) != 0 {
+		t.Error("expected nothing stored or published")
+	}
+}
+
+func TestService_Create_InvalidContent(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	for _, content := range []string{"   ", strings.Repeat("a", domain.MaxCommentLength+1)} {
+		_, err := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: content})
+		if !errors.Is(err, domain.ErrInvalidCommentContent) {
+			t.Errorf("expected ErrInvalidCommentContent, got %v", err)
+		}
+	}
+}
+
+func TestService_Create_CommentSettings(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	post := f.posts.posts[postID]
+
+	post.CommentStatus = domain.CommentsLocked
+	if _, err := f.service.Create(ctx, authorID, comments.CreateRequest{PostID: postID, Content: "Hi"}); !errors.Is(err, domain.ErrCommentsLocked) {
+		t.Errorf("expected ErrCommentsLocked, got %v", err)
+	}
+
+	post.CommentStatus = domain.CommentsOpen
+	post.ReplyAudience = domain.ReplyFollowers
+	if _, err := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Hi"}); !errors.Is(err, domain.ErrReplyNotAllowed) {
+		t.Errorf("expected ErrReplyNotAllowed, got %v", err)
+	}
+	if _, err := f.service.Create(ctx, followerID, comments.CreateRequest{PostID: postID, Content: "Hi"}); err != nil {
+		t.Errorf("expected followers to comment, got %v", err)
+	}
+
+	post.CommentStatus = domain.CommentsDisabled
+	if _, err := f.service.GetByPostID(ctx, postID, otherID, 10, 0); !errors.Is(err, domain.ErrCommentsDisabled) {
+		t.Errorf("expected ErrCommentsDisabled, got %v", err)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	comment, _ := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Nice post"})
+
+	content := "Great post"
+	if _, err := f.service.Update(ctx, comment.ID, authorID, comments.UpdateRequest{Content: &content}, nil); !errors.Is(err, domain.ErrCommentForbidden) {
+		t.Errorf("expected ErrCommentForbidden, got %v", err)
+	}
+
+	blank := " "
+	if _, err := f.service.Update(ctx, comment.ID, otherID, comments.UpdateRequest{Content: &blank}, nil); !errors.Is(err, domain.ErrInvalidCommentContent) {
+		t.Errorf("expected ErrInvalidCommentContent, got %v", err)
+	}
+
+	updated, err := f.service.Update(ctx, comment.ID, otherID, comments.UpdateRequest{Content: &content}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Content != content || updated.Version != 2 {
+		t.Errorf("unexpected comment %+v", updated)
+	}
+	if f.tx.transactions != 2 {
+		t.Errorf("expected create and update in transactions, got %d", f.tx.transactions)
+	}
+	if _, ok := f.received[len(f.received)-1].(events.CommentUpdated); !ok {
+		t.Errorf("expected CommentUpdated, got %+v", f.received[len(f.received)-1])
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	comment, _ := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Nice post"})
+
+	if err := f.service.Delete(ctx, comment.ID, authorID, nil); !errors.Is(err, domain.ErrCommentForbidden) {
+		t.Errorf("expected ErrCommentForbidden, got %v", err)
+	}
+	if err := f.service.Delete(ctx, comment.ID, otherID, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.service.GetByID(ctx, comment.ID, otherID); !errors.Is(err, domain.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+	deleted, ok := f.received[len(f.received)-1].(events.CommentDeleted)
+	if !ok || deleted.CommentID != domain.CommentID(comment.ID) {
+		t.Errorf("expected CommentDeleted, got %+v", f.received[len(f.received)-1])
+	}
+}
+
+func TestService_PinAndCount(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	comment, _ := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Nice post"})
+
+	if _, err := f.service.Pin(ctx, comment.ID, otherID); !errors.Is(err, domain.ErrPostForbidden) {
+		t.Errorf("expected ErrPostForbidden for the comment author, got %v", err)
+	}
+	if _, err := f.service.Pin(ctx, 99, authorID); !errors.Is(err, domain.ErrCommentNotFound) {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+
+	pinned, err := f.service.Pin(ctx, comment.ID, authorID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pinned.Pinned || pinned.Version != comment.Version {
+		t.Errorf("expected the comment pinned without a new version, got %+v", pinned)
+	}
+	if err := f.service.Unpin(ctx, comment.ID, authorID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	list, err := f.service.GetByPostID(ctx, postID, otherID, 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Total != 1 || list.Comments[0].Pinned {
+		t.Errorf("expected 1 unpinned comment, got %+v", list)
+	}
+}
+
+// racingRepository lets another write land right after every read
+type racingRepository struct {
+	*mockRepository
+}
+
+func (r *racingRepository) GetByID(ctx context.Context, id uint) (*comments.Model, error) {
+	comment, err := r.mockRepository.GetByID(ctx, id)
+	if err == nil {
+		r.comments[id].Version++
+	}
+	return comment, err
+}
+
+func TestService_Delete_ConcurrentWrite(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	comment, _ := f.service.Create(ctx, otherID, comments.CreateRequest{PostID: postID, Content: "Nice post"})
+
+	service := comments.NewService(&racingRepository{f.repo}, &mockCommentRepository{f.repo}, nil, f.posts, nil, nil, nil, f.tx)
+	version := comment.Version
+	if err := service.Delete(ctx, comment.ID, otherID, &version); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+	if _, ok := f.repo.comments[comment.ID]; !ok {
+		t.Error("expected the comment kept")
+	}
+}
@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+func TestComment_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"valid comment", "Nice post", false},
+		{"empty content", "", true},
+		{"only whitespace", " \n\t", true},
+		{"at the limit", strings.Repeat("ç", domain.MaxCommentLength), false},
+		{"too long", strings.Repeat("a", domain.MaxCommentLength+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &domain.Comment{PostID: 1, UserID: 1, Content: tt.content}
+			err := comment.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidCommentContent) {
+				t.Errorf("Validate() expected ErrInvalidCommentContent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestComment_UpdateContent(t *testing.T) {
+	comment := &domain.Comment{Content: "Original"}
+
+	if err := comment.UpdateContent("Edited"); err != nil {
+		t.Errorf("UpdateContent() error = %v", err)
+	}
+	if comment.Content != "Edited" {
+		t.Errorf("UpdateContent() content = %q, want %q", comment.Content, "Edited")
+	}
+
+	if err := comment.UpdateContent("   "); !errors.Is(err, domain.ErrInvalidCommentContent) {
+		t.Errorf("UpdateContent() expected ErrInvalidCommentContent, got %v", err)
+	}
+	if comment.Content != "Edited" {
+		t.Errorf("UpdateContent() should keep the content on error, got %q", comment.Content)
+	}
+}
+
+func TestComment_CanBeEditedBy(t *testing.T) {
+	comment := &domain.Comment{UserID: domain.UserID(1)}
+
+	if !comment.CanBeEditedBy(domain.UserID(1)) {
+		t.Errorf("CanBeEditedBy() should return true for the author")
+	}
+	if comment.CanBeEditedBy(domain.UserID(2)) {
+		t.Errorf("CanBeEditedBy() should return false for other users")
+	}
+	if comment.CanBeDeletedBy(domain.UserID(2)) {
+		t.Errorf("CanBeDeletedBy() should return false for other users")
+	}
+}
@@ -0,0 +1,98 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+func TestFindMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []domain.Mention
+	}{
+		{"start of text", "@ada hi", []domain.Mention{{Username: "ada", Start: 0, End: 4}}},
+		{"trailing punctuation", "thanks @ada.lovelace.", []domain.Mention{{Username: "ada.lovelace", Start: 7, End: 20}}},
+		{"character offsets", "çok güzel @bob!", []domain.Mention{{Username: "bob", Start: 10, End: 14}}},
+		{"several", "@ada and @bob_2", []domain.Mention{{Username: "ada", Start: 0, End: 4}, {Username: "bob_2", Start: 9, End: 15}}},
+		{"e-mail address", "mail ada@example.com", nil},
+		{"too short", "@ab @", nil},
+		{"doubled at sign", "@@ada", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.FindMentions(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+type usernameLookup map[string]domain.UserID
+
+func (u usernameLookup) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (u usernameLookup) GetByIDs(ctx context.Context, ids []domain.UserID) ([]*domain.User, error) {
+	return nil, nil
+}
+
+func (u usernameLookup) Exists(ctx context.Context, id domain.UserID) (bool, error) {
+	return false, nil
+}
+
+func (u usernameLookup) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	if username == "broken" {
+		return nil, errors.New("database error")
+	}
+	if id, ok := u[username]; ok {
+		return &domain.User{ID: id, Username: username}, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (u usernameLookup) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func TestResolveMentions(t *testing.T) {
+	users := usernameLookup{"ada": 1, "bob": 2}
+	ctx := context.Background()
+
+	mentions, err := domain.ResolveMentions(ctx, "@ada meet @nobody and @bob, cc @ada", users)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.Mention{
+		{UserID: 1, Username: "ada", Start: 0, End: 4},
+		{UserID: 2, Username: "bob", Start: 22, End: 26},
+		{UserID: 1, Username: "ada", Start: 31, End: 35},
+	}
+	if !slices.Equal(mentions, want) {
+		t.Errorf("expected %+v, got %+v", want, mentions)
+	}
+	if got := domain.MentionedUsers(mentions, 2); !slices.Equal(got, []domain.UserID{1}) {
+		t.Errorf("expected only ada once without the author, got %v", got)
+	}
+
+	if _, err := domain.ResolveMentions(ctx, "@broken", users); err == nil {
+		t.Error("expected lookup errors to be returned")
+	}
+}
+
+func TestNewlyMentioned(t *testing.T) {
+	before := []domain.Mention{{UserID: 1}, {UserID: 2}}
+	after := []domain.Mention{{UserID: 2}, {UserID: 3}, {UserID: 4}, {UserID: 3}}
+
+	if got := domain.NewlyMentioned(before, after, 4); !slices.Equal(got, []domain.UserID{3}) {
+		t.Errorf("expected only user 3 as new, got %v", got)
+	}
+	if got := domain.NewlyMentioned(after, after, 4); len(got) != 0 {
+		t.Errorf("expected nobody new for an unchanged text, got %v", got)
+	}
+}
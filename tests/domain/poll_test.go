@@ -0,0 +1,88 @@
+package domain_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+func TestPoll_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		options []string
+		endsAt  time.Time
+		wantErr bool
+	}{
+		{"valid", []string{"Yes", "No"}, now.Add(time.Hour), false},
+		{"ten options", strings.Split("a b c d e f g h i j", " "), now.Add(time.Hour), false},
+		{"one option", []string{"Yes"}, now.Add(time.Hour), true},
+		{"eleven options", strings.Split("a b c d e f g h i j k", " "), now.Add(time.Hour), true},
+		{"blank option", []string{"Yes", "  "}, now.Add(time.Hour), true},
+		{"duplicate after trimming", []string{"Yes", " Yes "}, now.Add(time.Hour), true},
+		{"option too long", []string{"Yes", strings.Repeat("n", domain.MaxPollOptionLength+1)}, now.Add(time.Hour), true},
+		{"ended", []string{"Yes", "No"}, now, true},
+		{"too far ahead", []string{"Yes", "No"}, now.Add(domain.MaxPollDuration + time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := &domain.Poll{Options: tt.options, EndsAt: tt.endsAt}
+			err := poll.Validate(now)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidPoll) {
+				t.Errorf("expected ErrInvalidPoll, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPoll_Validate_TrimsOptions(t *testing.T) {
+	now := time.Now()
+	poll := &domain.Poll{Options: []string{" Yes ", "No\n"}, EndsAt: now.Add(time.Hour)}
+	if err := poll.Validate(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"Yes", "No"}; !slices.Equal(poll.Options, want) {
+		t.Errorf("expected %v, got %v", want, poll.Options)
+	}
+}
+
+func TestPoll_CheckChoice(t *testing.T) {
+	single := &domain.Poll{Options: []string{"a", "b", "c"}}
+	multiple := &domain.Poll{Options: []string{"a", "b", "c"}, MultipleChoice: true}
+
+	if err := single.CheckChoice(1); err != nil {
+		t.Errorf("expected one option to be valid, got %v", err)
+	}
+	if err := single.CheckChoice(2); !errors.Is(err, domain.ErrInvalidVote) {
+		t.Errorf("expected ErrInvalidVote for two options of a single choice poll, got %v", err)
+	}
+	if err := multiple.CheckChoice(3); err != nil {
+		t.Errorf("expected all options to be valid, got %v", err)
+	}
+	if err := multiple.CheckChoice(0); !errors.Is(err, domain.ErrInvalidVote) {
+		t.Errorf("expected ErrInvalidVote for no options, got %v", err)
+	}
+}
+
+func TestPoll_CanSeeTallies(t *testing.T) {
+	now := time.Now()
+	open := &domain.Poll{EndsAt: now.Add(time.Hour)}
+	closed := &domain.Poll{EndsAt: now}
+
+	if open.CanSeeTallies(false, now) {
+		t.Error("expected open tallies hidden from non-voters")
+	}
+	if !open.CanSeeTallies(true, now) {
+		t.Error("expected open tallies shown to voters")
+	}
+	if !closed.CanSeeTallies(false, now) {
+		t.Error("expected final tallies shown to everyone")
+	}
+}
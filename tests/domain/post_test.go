@@ -1,7 +1,9 @@
 package domain_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/urdogan0000/social/internal/domain"
 )
@@ -135,3 +137,137 @@ func TestPost_UpdateTags(t *testing.T) {
 	}
 }
 
+
+func TestPost_CanBeViewedBy(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility domain.Visibility
+		viewerID   domain.UserID
+		isFollower bool
+		want       bool
+	}{
+		{"public anonymous", domain.VisibilityPublic, 0, false, true},
+		{"unlisted anonymous", domain.VisibilityUnlisted, 0, false, true},
+		{"followers anonymous", domain.VisibilityFollowers, 0, false, false},
+		{"followers follower", domain.VisibilityFollowers, 2, true, true},
+		{"followers stranger", domain.VisibilityFollowers, 2, false, false},
+		{"private author", domain.VisibilityPrivate, 1, false, true},
+		{"private follower", domain.VisibilityPrivate, 2, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &domain.Post{UserID: domain.UserID(1), Visibility: tt.visibility}
+			if got := post.CanBeViewedBy(tt.viewerID, tt.isFollower); got != tt.want {
+				t.Errorf("CanBeViewedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPost_UpdateVisibility(t *testing.T) {
+	post := &domain.Post{Visibility: domain.VisibilityPublic}
+
+	if err := post.UpdateVisibility(domain.VisibilityPrivate); err != nil {
+		t.Errorf("UpdateVisibility() error = %v", err)
+	}
+	if post.Visibility != domain.VisibilityPrivate {
+		t.Errorf("UpdateVisibility() visibility = %q, want %q", post.Visibility, domain.VisibilityPrivate)
+	}
+
+	if err := post.UpdateVisibility("secret"); err == nil {
+		t.Errorf("UpdateVisibility() expected error for unknown visibility")
+	}
+}
+
+func TestPost_Schedule(t *testing.T) {
+	now := time.Now()
+	post := &domain.Post{Status: domain.PostStatusDraft}
+
+	if err := post.Schedule(now.Add(-time.Minute), now); !errors.Is(err, domain.ErrInvalidPublishAt) {
+		t.Errorf("Schedule() expected ErrInvalidPublishAt for past time, got %v", err)
+	}
+
+	if err := post.Schedule(now.Add(time.Hour), now); err != nil {
+		t.Errorf("Schedule() error = %v", err)
+	}
+	if post.Status != domain.PostStatusScheduled {
+		t.Errorf("Schedule() status = %q, want %q", post.Status, domain.PostStatusScheduled)
+	}
+
+	if err := post.Publish(now); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if err := post.MarkDraft(); !errors.Is(err, domain.ErrPostAlreadyPublished) {
+		t.Errorf("MarkDraft() expected ErrPostAlreadyPublished, got %v", err)
+	}
+}
+
+func TestPost_CanBeViewedBy_Draft(t *testing.T) {
+	post := &domain.Post{UserID: domain.UserID(1), Status: domain.PostStatusDraft, Visibility: domain.VisibilityPublic}
+
+	if !post.CanBeViewedBy(domain.UserID(1), false) {
+		t.Errorf("CanBeViewedBy() should return true for the author of a draft")
+	}
+	if post.CanBeViewedBy(domain.UserID(2), true) {
+		t.Errorf("CanBeViewedBy() should return false for other users on a draft")
+	}
+}
+
+func TestPost_CanBeCommentedBy(t *testing.T) {
+	mentioned := []domain.Mention{{UserID: domain.UserID(3), Username: "carol"}}
+	tests := []struct {
+		name       string
+		status     domain.CommentStatus
+		audience   domain.ReplyAudience
+		userID     domain.UserID
+		isFollower bool
+		want       error
+	}{
+		{"legacy row", "", "", 2, false, nil},
+		{"open everyone", domain.CommentsOpen, domain.ReplyEveryone, 2, false, nil},
+		{"locked", domain.CommentsLocked, domain.ReplyEveryone, 2, true, domain.ErrCommentsLocked},
+		{"locked author", domain.CommentsLocked, domain.ReplyEveryone, 1, false, domain.ErrCommentsLocked},
+		{"disabled", domain.CommentsDisabled, domain.ReplyEveryone, 2, false, domain.ErrCommentsDisabled},
+		{"followers follower", domain.CommentsOpen, domain.ReplyFollowers, 2, true, nil},
+		{"followers stranger", domain.CommentsOpen, domain.ReplyFollowers, 2, false, domain.ErrReplyNotAllowed},
+		{"followers author", domain.CommentsOpen, domain.ReplyFollowers, 1, false, nil},
+		{"mentioned user", domain.CommentsOpen, domain.ReplyMentioned, 3, false, nil},
+		{"mentioned follower", domain.CommentsOpen, domain.ReplyMentioned, 2, true, domain.ErrReplyNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &domain.Post{
+				UserID:        domain.UserID(1),
+				Mentions:      mentioned,
+				CommentStatus: tt.status,
+				ReplyAudience: tt.audience,
+			}
+			if err := post.CanBeCommentedBy(tt.userID, tt.isFollower); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("CanBeCommentedBy() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPost_UpdateCommentSettings(t *testing.T) {
+	post := &domain.Post{CommentStatus: domain.CommentsOpen, ReplyAudience: domain.ReplyEveryone}
+
+	if err := post.UpdateCommentSettings(domain.CommentsLocked, domain.ReplyFollowers); err != nil {
+		t.Errorf("UpdateCommentSettings() error = %v", err)
+	}
+	if post.CommentStatus != domain.CommentsLocked || post.ReplyAudience != domain.ReplyFollowers {
+		t.Errorf("UpdateCommentSettings() = %q/%q, want locked/followers", post.CommentStatus, post.ReplyAudience)
+	}
+	if !post.CommentsVisible() {
+		t.Errorf("CommentsVisible() should return true for locked comments")
+	}
+
+	if err := post.UpdateCommentSettings("closed", domain.ReplyEveryone); !errors.Is(err, domain.ErrInvalidCommentStatus) {
+		t.Errorf("UpdateCommentSettings() expected ErrInvalidCommentStatus, got %v", err)
+	}
+	if err := post.UpdateCommentSettings(domain.CommentsOpen, "friends"); !errors.Is(err, domain.ErrInvalidReplyAudience) {
+		t.Errorf("UpdateCommentSettings() expected ErrInvalidReplyAudience, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package domain_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "Go", want: "go"},
+		{raw: " go ", want: "go"},
+		{raw: "#GO", want: "go"},
+		{raw: "Ｇｏ", want: "go"},
+		{raw: "Straße", want: "strasse"},
+		{raw: "best-practices", want: "best-practices"},
+		{raw: "2024wrapped", want: "2024wrapped"},
+		{raw: "", wantErr: true},
+		{raw: "#", wantErr: true},
+		{raw: "hello world", wantErr: true},
+		{raw: "2024", wantErr: true},
+		{raw: strings.Repeat("a", domain.MaxTagLength+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := domain.NormalizeTag(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidTag) {
+					t.Errorf("expected ErrInvalidTag, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expected %q, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := domain.NormalizeTags([]string{"Go", " go", "#GO", "Rust"})
+	if err != nil || !slices.Equal(tags, []string{"go", "rust"}) {
+		t.Errorf("expected go and rust once, got %v, %v", tags, err)
+	}
+
+	tooMany := make([]string, domain.MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = "tag" + strings.Repeat("x", i)
+	}
+	if _, err := domain.NormalizeTags(tooMany); !errors.Is(err, domain.ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag for too many tags, got %v", err)
+	}
+}
+
+func TestFindHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"normalized and deduplicated", "#Go is great. #go #GoLang!", []string{"go", "golang"}},
+		{"trailing hyphen", "learning #go-", []string{"go"}},
+		{"unicode", "#Çay time", []string{"çay"}},
+		{"not after a word", "C# and a#b", nil},
+		{"not an html entity", "it&#39;s", nil},
+		{"not a number", "issue #42", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.FindHashtags(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPostTags(t *testing.T) {
+	got := domain.PostTags([]string{"Go", "not valid"}, "more #go and #Rust")
+	if !slices.Equal(got, []string{"go", "rust"}) {
+		t.Errorf("expected explicit tags then hashtags, got %v", got)
+	}
+
+	var content strings.Builder
+	for i := range domain.MaxTags + 5 {
+		content.WriteString(" #tag" + strings.Repeat("x", i))
+	}
+	if got := domain.PostTags(nil, content.String()); len(got) != domain.MaxTags {
+		t.Errorf("expected at most %d tags, got %d", domain.MaxTags, len(got))
+	}
+}
@@ -1,6 +1,8 @@
 package domain_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/urdogan0000/social/internal/domain"
@@ -121,3 +123,57 @@ func TestUser_UpdateEmail(t *testing.T) {
 	}
 }
 
+
+func TestProfile_Validate(t *testing.T) {
+	fields := func(n int) []domain.ProfileField {
+		result := make([]domain.ProfileField, n)
+		for i := range result {
+			result[i] = domain.ProfileField{Name: strings.Repeat("f", i+1), Value: "value"}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		profile domain.Profile
+		wantErr bool
+	}{
+		{"empty", domain.Profile{}, false},
+		{"complete", domain.Profile{DisplayName: "Ada", Bio: "line one\nline two", Website: "https://example.com", Location: "London", Pronouns: "she/her", Fields: fields(domain.MaxProfileFields)}, false},
+		{"long display name counted in characters", domain.Profile{DisplayName: strings.Repeat("ü", domain.MaxDisplayNameLength)}, false},
+		{"display name too long", domain.Profile{DisplayName: strings.Repeat("a", domain.MaxDisplayNameLength+1)}, true},
+		{"bio too long", domain.Profile{Bio: strings.Repeat("a", domain.MaxBioLength+1)}, true},
+		{"multi-line display name", domain.Profile{DisplayName: "Ada\nLovelace"}, true},
+		{"javascript website", domain.Profile{Website: "javascript:alert(1)"}, true},
+		{"website without host", domain.Profile{Website: "https://"}, true},
+		{"too many fields", domain.Profile{Fields: fields(domain.MaxProfileFields + 1)}, true},
+		{"empty field name", domain.Profile{Fields: []domain.ProfileField{{Name: "", Value: "x"}}}, true},
+		{"duplicate field name", domain.Profile{Fields: []domain.ProfileField{{Name: "Blog", Value: "a"}, {Name: "blog", Value: "b"}}}, true},
+		{"field value too long", domain.Profile{Fields: []domain.ProfileField{{Name: "Blog", Value: strings.Repeat("a", domain.MaxProfileFieldValueLength+1)}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidProfile) {
+				t.Errorf("Validate() error = %v, want ErrInvalidProfile", err)
+			}
+		})
+	}
+}
+
+func TestUser_UpdateProfile(t *testing.T) {
+	user := &domain.User{Username: "ada", Email: "ada@example.com"}
+	if err := user.UpdateProfile(domain.Profile{DisplayName: "Ada"}); err != nil || user.Profile.DisplayName != "Ada" {
+		t.Fatalf("UpdateProfile() = %v, profile %+v", err, user.Profile)
+	}
+	if err := user.UpdateProfile(domain.Profile{Website: "ftp://example.com"}); err == nil {
+		t.Error("UpdateProfile() expected error for an ftp website")
+	}
+	if user.Profile.DisplayName != "Ada" {
+		t.Errorf("UpdateProfile() changed the profile on error: %+v", user.Profile)
+	}
+}
@@ -0,0 +1,233 @@
+package gql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/gql"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+type mockUserRepository struct {
+	users      map[domain.UserID]*domain.User
+	batchCalls [][]domain.UserID
+}
+
+func (m *mockUserRepository) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []domain.UserID) ([]*domain.User, error) {
+	m.batchCalls = append(m.batchCalls, ids)
+	var result []*domain.User
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			result = append(result, user)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockUserRepository) Exists(ctx context.Context, id domain.UserID) (bool, error) {
+	_, ok := m.users[id]
+	return ok, nil
+}
+
+func (m *mockUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *mockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func newRepo() *mockUserRepository {
+	return &mockUserRepository{users: map[domain.UserID]*domain.User{
+		1: {ID: 1, Username: "alice"},
+		2: {ID: 2, Username: "bob"},
+	}}
+}
+
+// newHandler builds the real schema without services; the tests only reach
+// resolvers that fail or answer before calling one
+func newHandler(t *testing.T, repo domain.UserRepository, limits gql.Limits) *gql.Handler {
+	t.Helper()
+	schema, err := gql.NewSchema(gql.NewResolver(nil, nil, nil))
+	if err != nil {
+		t.Fatalf("failed to build schema: %v", err)
+	}
+	return gql.NewHandler(schema, limits, repo)
+}
+
+type gqlResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, h *gql.Handler, ctx context.Context, query string) (int, gqlResponse) {
+	t.Helper()
+	body, _ := json.Marshal(gql.Request{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "/v1/graphql", bytes.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp gqlResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+func errorCode(resp gqlResponse) string {
+	if len(resp.Errors) == 0 {
+		return ""
+	}
+	code, _ := resp.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestUserLoader_BatchesAndDeduplicates(t *testing.T) {
+	repo := newRepo()
+	loader := gql.NewUserLoader(repo)
+	ctx := context.Background()
+
+	first := loader.Load(ctx, 1)
+	second := loader.Load(ctx, 2)
+	again := loader.Load(ctx, 1)
+	missing := loader.Load(ctx, 99)
+
+	for _, thunk := range []func() (*domain.User, error){first, second, again} {
+		if user, err := thunk(); err != nil || user == nil {
+			t.Fatalf("expected a user, got %v, %v", user, err)
+		}
+	}
+	if user, err := missing(); err != nil || user != nil {
+		t.Errorf("expected nil for a missing user, got %v, %v", user, err)
+	}
+	if len(repo.batchCalls) != 1 || len(repo.batchCalls[0]) != 3 {
+		t.Fatalf("expected one batch of 3 distinct IDs, got %v", repo.batchCalls)
+	}
+
+	// Users fetched once are served from the loader afterwards
+	if user, _ := loader.Load(ctx, 2)(); user.Username != "bob" {
+		t.Errorf("expected bob, got %v", user)
+	}
+	if len(repo.batchCalls) != 1 {
+		t.Errorf("expected no further lookups, got %v", repo.batchCalls)
+	}
+}
+
+func TestViewer_UsesBearerIdentity(t *testing.T) {
+	repo := newRepo()
+	h := newHandler(t, repo, gql.Limits{})
+
+	ctx := context.WithValue(context.Background(), middleware.UserIDKey, uint(2))
+	code, resp := post(t, h, ctx, `{ viewer { id username } }`)
+	if code != http.StatusOK || len(resp.Errors) != 0 {
+		t.Fatalf("expected success, got %d %+v", code, resp.Errors)
+	}
+	viewer := resp.Data["viewer"].(map[string]interface{})
+	if viewer["username"] != "bob" || viewer["id"] != "2" {
+		t.Errorf("unexpected viewer %v", viewer)
+	}
+
+	_, resp = post(t, h, context.Background(), `{ viewer { id } }`)
+	if resp.Data["viewer"] != nil {
+		t.Errorf("expected a null viewer for anonymous requests, got %v", resp.Data["viewer"])
+	}
+}
+
+func TestMutation_RequiresAuthentication(t *testing.T) {
+	h := newHandler(t, newRepo(), gql.Limits{})
+
+	_, resp := post(t, h, context.Background(), `mutation { deletePost(id: "1") }`)
+	if errorCode(resp) != "authorization_required" {
+		t.Errorf("expected authorization_required, got %+v", resp.Errors)
+	}
+}
+
+func TestMutation_RejectedOverGET(t *testing.T) {
+	h := newHandler(t, newRepo(), gql.Limits{})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/graphql?query="+url.QueryEscape(`mutation { deletePost(id: "1") }`), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestConnection_InvalidCursor(t *testing.T) {
+	h := newHandler(t, newRepo(), gql.Limits{})
+
+	_, resp := post(t, h, context.Background(), `{ posts(after: "not-a-cursor") { totalCount } }`)
+	if errorCode(resp) != "invalid_cursor" {
+		t.Errorf("expected invalid_cursor, got %+v", resp.Errors)
+	}
+}
+
+func TestLimits_RejectDeepQueries(t *testing.T) {
+	repo := newRepo()
+	h := newHandler(t, repo, gql.Limits{MaxDepth: 4})
+
+	code, resp := post(t, h, context.Background(),
+		`{ viewer { posts { edges { node { author { username } } } } } }`)
+	if code != http.StatusBadRequest || errorCode(resp) != "query_too_deep" {
+		t.Fatalf("expected 400 query_too_deep, got %d %+v", code, resp.Errors)
+	}
+	if resp.Errors[0].Extensions["max"] != float64(4) {
+		t.Errorf("expected the limit in extensions, got %v", resp.Errors[0].Extensions)
+	}
+	if len(repo.batchCalls) != 0 {
+		t.Error("expected nothing to run for a rejected query")
+	}
+}
+
+func TestLimits_RejectComplexQueries(t *testing.T) {
+	h := newHandler(t, newRepo(), gql.Limits{MaxComplexity: 500})
+
+	code, resp := post(t, h, context.Background(),
+		`{ posts(first: 100) { edges { node { comments(first: 100) { edges { node { id } } } } } } }`)
+	if code != http.StatusBadRequest || errorCode(resp) != "query_too_complex" {
+		t.Errorf("expected 400 query_too_complex, got %d %+v", code, resp.Errors)
+	}
+}
+
+func TestMeasure_CountsConnectionsAndFragments(t *testing.T) {
+	schema, err := gql.NewSchema(gql.NewResolver(nil, nil, nil))
+	if err != nil {
+		t.Fatalf("failed to build schema: %v", err)
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: `
+		query Feed($n: Int) {
+			posts(first: $n) { edges { node { ...PostFields } } }
+			__typename
+		}
+		fragment PostFields on Post { title author { username } }
+	`})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	depth, complexity := gql.Measure(&schema, doc, "Feed", map[string]interface{}{"n": float64(10)})
+	if depth != 5 {
+		t.Errorf("expected depth 5, got %d", depth)
+	}
+	// posts + 10 * (edges + node + title + author + username)
+	if complexity != 51 {
+		t.Errorf("expected complexity 51, got %d", complexity)
+	}
+}
@@ -0,0 +1,97 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/health"
+)
+
+func ready(t *testing.T, reg *health.Registry) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	reg.ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReady_AllChecksPass(t *testing.T) {
+	reg := health.NewRegistry(time.Second)
+	reg.Register("database", func(ctx context.Context) error { return nil })
+	reg.Register("cache", func(ctx context.Context) error { return nil })
+
+	code, report := ready(t, reg)
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if report.Status != health.StatusOK || len(report.Checks) != 2 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestReady_FailingCheckReportsDetails(t *testing.T) {
+	reg := health.NewRegistry(time.Second)
+	reg.Register("database", func(ctx context.Context) error { return errors.New("connection refused") })
+	reg.Register("event_bus", func(ctx context.Context) error { return nil })
+
+	code, report := ready(t, reg)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", code)
+	}
+	if report.Status != health.StatusUnavailable {
+		t.Errorf("expected unavailable, got %s", report.Status)
+	}
+	db := report.Checks["database"]
+	if db.Status != health.StatusUnavailable || db.Error != "connection refused" {
+		t.Errorf("unexpected database result %+v", db)
+	}
+	if report.Checks["event_bus"].Status != health.StatusOK {
+		t.Errorf("expected event_bus ok, got %+v", report.Checks["event_bus"])
+	}
+}
+
+func TestReady_CheckTimesOut(t *testing.T) {
+	reg := health.NewRegistry(20 * time.Millisecond)
+	reg.Register("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := ready(t, reg)
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the check to be cut off by the timeout")
+	}
+	if code != http.StatusServiceUnavailable || report.Checks["database"].Error == "" {
+		t.Errorf("expected timed out check to fail, got %d %+v", code, report)
+	}
+}
+
+func TestReady_FailsWhileDraining(t *testing.T) {
+	reg := health.NewRegistry(time.Second)
+	reg.Register("database", func(ctx context.Context) error { return nil })
+	reg.SetDraining()
+
+	code, report := ready(t, reg)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while draining, got %d", code)
+	}
+	if report.Status != health.StatusDraining {
+		t.Errorf("expected draining, got %s", report.Status)
+	}
+
+	rec := httptest.NewRecorder()
+	reg.LiveHandler(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness to stay 200 while draining, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,229 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/imaging"
+)
+
+// secret stands in for GPS coordinates and other private metadata
+const secret = "41.0082N28.9784E"
+
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 16), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// exifTIFF builds a little-endian TIFF with an orientation and a GPS IFD
+// holding secret
+func exifTIFF(orientation uint16) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("II")
+	_ = binary.Write(&buf, le, uint16(42))
+	_ = binary.Write(&buf, le, uint32(8))
+
+	// IFD0 at 8: orientation and a pointer to the GPS IFD
+	_ = binary.Write(&buf, le, uint16(2))
+	_ = binary.Write(&buf, le, []uint16{0x0112, 3})
+	_ = binary.Write(&buf, le, uint32(1))
+	_ = binary.Write(&buf, le, []uint16{orientation, 0})
+	_ = binary.Write(&buf, le, []uint16{0x8825, 4})
+	_ = binary.Write(&buf, le, uint32(1))
+	_ = binary.Write(&buf, le, uint32(38))
+	_ = binary.Write(&buf, le, uint32(0))
+
+	// GPS IFD at 38 with one ASCII entry stored after it at 56
+	_ = binary.Write(&buf, le, uint16(1))
+	_ = binary.Write(&buf, le, []uint16{0x0002, 2})
+	_ = binary.Write(&buf, le, uint32(len(secret)+1))
+	_ = binary.Write(&buf, le, uint32(56))
+	_ = binary.Write(&buf, le, uint32(0))
+	buf.WriteString(secret + "\x00")
+	return buf.Bytes()
+}
+
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+	payload := append([]byte("Exif\x00\x00"), exifTIFF(orientation)...)
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func pngWithText(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	data := encoded.Bytes()
+	const headerEnd = 8 + 25 // signature and IHDR chunk
+	payload := []byte("tEXtLocation\x00" + secret)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)-4))
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(payload))
+
+	out := append([]byte{}, data[:headerEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[headerEnd:]...)
+}
+
+func webpChunk(fourCC string, payload []byte) []byte {
+	chunk := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func TestStripMetadata_JPEG(t *testing.T) {
+	data := jpegWithExif(t, testImage(8, 4), 6)
+
+	stripped, orientation, err := imaging.StripMetadata(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("expected stripping to succeed, got %v", err)
+	}
+	if orientation != 6 {
+		t.Errorf("expected orientation 6, got %d", orientation)
+	}
+	if bytes.Contains(stripped, []byte(secret)) {
+		t.Error("expected the GPS data stripped")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Fatalf("expected the stripped jpeg to decode, got %v", err)
+	}
+
+	// The orientation survives, so viewers still display the image upright
+	_, again, err := imaging.StripMetadata(stripped, "image/jpeg")
+	if err != nil || again != 6 {
+		t.Errorf("expected the orientation kept, got %d, %v", again, err)
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	data := pngWithText(t, testImage(4, 4))
+
+	stripped, orientation, err := imaging.StripMetadata(data, "image/png")
+	if err != nil {
+		t.Fatalf("expected stripping to succeed, got %v", err)
+	}
+	if orientation != imaging.OrientationNormal {
+		t.Errorf("expected the normal orientation, got %d", orientation)
+	}
+	if bytes.Contains(stripped, []byte(secret)) {
+		t.Error("expected the text chunk stripped")
+	}
+	if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("expected the stripped png to decode, got %v", err)
+	}
+}
+
+func TestStripMetadata_WebP(t *testing.T) {
+	// VP8X with the EXIF flag set; the image data is never decoded here
+	vp8x := []byte{0x08, 0, 0, 0, 3, 0, 0, 3, 0, 0}
+	body := append([]byte("WEBP"), webpChunk("VP8X", vp8x)...)
+	body = append(body, webpChunk("VP8L", []byte{0x2f, 1, 2, 3, 4})...)
+	body = append(body, webpChunk("EXIF", exifTIFF(1))...)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	data = append(data, body...)
+
+	stripped, _, err := imaging.StripMetadata(data, "image/webp")
+	if err != nil {
+		t.Fatalf("expected stripping to succeed, got %v", err)
+	}
+	if bytes.Contains(stripped, []byte(secret)) || bytes.Contains(stripped, []byte("EXIF")) {
+		t.Error("expected the EXIF chunk stripped")
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:8]); int(size) != len(stripped)-8 {
+		t.Errorf("expected the RIFF size %d, got %d", len(stripped)-8, size)
+	}
+	if flags := stripped[20]; flags&0x08 != 0 {
+		t.Errorf("expected the EXIF flag cleared, got %#x", flags)
+	}
+}
+
+func TestStripMetadata_RejectsMalformed(t *testing.T) {
+	if _, _, err := imaging.StripMetadata([]byte("\xFF\xD8\xFF\xE1\xFF"), "image/jpeg"); !errors.Is(err, imaging.ErrMalformed) {
+		t.Errorf("expected ErrMalformed for a truncated jpeg, got %v", err)
+	}
+	if _, _, err := imaging.StripMetadata([]byte("GIF89a"), "image/gif"); !errors.Is(err, imaging.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for a gif, got %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	data := jpegWithExif(t, testImage(8, 4), 1)
+	img, err := imaging.Decode(data, 100)
+	if err != nil {
+		t.Fatalf("expected decode to succeed, got %v", err)
+	}
+	if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 4 {
+		t.Errorf("expected 8x4, got %v", img.Bounds())
+	}
+	if _, err := imaging.Decode(data, 31); !errors.Is(err, imaging.ErrTooManyPixels) {
+		t.Errorf("expected ErrTooManyPixels, got %v", err)
+	}
+	if _, err := imaging.Decode([]byte("not an image"), 100); !errors.Is(err, imaging.ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestFitAndOrient(t *testing.T) {
+	img := testImage(16, 8)
+
+	fitted := imaging.Fit(img, 4)
+	if fitted.Bounds().Dx() != 4 || fitted.Bounds().Dy() != 2 {
+		t.Errorf("expected 4x2, got %v", fitted.Bounds())
+	}
+	if imaging.Fit(img, 32) != image.Image(img) {
+		t.Error("expected an image that fits to be returned as is")
+	}
+
+	rotated := imaging.Orient(img, 6)
+	if rotated.Bounds().Dx() != 8 || rotated.Bounds().Dy() != 16 {
+		t.Fatalf("expected 8x16, got %v", rotated.Bounds())
+	}
+	// Rotating clockwise moves the top-left pixel to the top-right
+	if rotated.At(7, 0) != img.At(0, 0) {
+		t.Errorf("expected the top-left pixel at the top-right, got %v", rotated.At(7, 0))
+	}
+}
+
+func TestEncodeAndBlurhash(t *testing.T) {
+	_, contentType, err := imaging.Encode(testImage(4, 4))
+	if err != nil || contentType != "image/jpeg" {
+		t.Errorf("expected an opaque image as jpeg, got %s, %v", contentType, err)
+	}
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	if _, contentType, err := imaging.Encode(transparent); err != nil || contentType != "image/png" {
+		t.Errorf("expected a transparent image as png, got %s, %v", contentType, err)
+	}
+
+	hash, err := imaging.Blurhash(testImage(16, 8), imaging.OrientationNormal)
+	if err != nil || hash == "" {
+		t.Errorf("expected a blurhash, got %q, %v", hash, err)
+	}
+}
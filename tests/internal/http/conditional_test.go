@@ -0,0 +1,89 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httputil "github.com/urdogan0000/social/internal/http"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	etag := httputil.ETag(3)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "no validators", method: http.MethodGet},
+		{name: "matching etag", method: http.MethodGet, headers: map[string]string{"If-None-Match": etag}, want: true},
+		{name: "weak match", method: http.MethodGet, headers: map[string]string{"If-None-Match": `"1", W/"3"`}, want: true},
+		{name: "wildcard", method: http.MethodGet, headers: map[string]string{"If-None-Match": "*"}, want: true},
+		{name: "stale etag", method: http.MethodGet, headers: map[string]string{"If-None-Match": `"2"`}},
+		{name: "not modified since", method: http.MethodGet, headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, want: true},
+		{name: "modified since", method: http.MethodGet, headers: map[string]string{"If-Modified-Since": modified.Add(-time.Minute).Format(http.TimeFormat)}},
+		{
+			name:    "etag takes precedence over date",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"2"`, "If-Modified-Since": modified.Format(http.TimeFormat)},
+		},
+		{name: "unsafe method", method: http.MethodPut, headers: map[string]string{"If-None-Match": etag}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/posts/1", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+
+			got := httputil.NotModified(rr, req, etag, modified)
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			if got && rr.Code != http.StatusNotModified {
+				t.Errorf("expected 304, got %d", rr.Code)
+			}
+			if rr.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %q", etag, rr.Header().Get("ETag"))
+			}
+			if rr.Header().Get("Last-Modified") != modified.Format(http.TimeFormat) {
+				t.Errorf("unexpected Last-Modified %q", rr.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
+
+func TestRespondJSONConditional(t *testing.T) {
+	payload := map[string]interface{}{"posts": []string{"a", "b"}, "total": 2}
+
+	rr := httptest.NewRecorder()
+	httputil.RespondJSONConditional(rr, httptest.NewRequest(http.MethodGet, "/v1/posts", nil), http.StatusOK, payload)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected an ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	httputil.RespondJSONConditional(rr, req, http.StatusOK, payload)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d with %q", rr.Code, rr.Body.String())
+	}
+
+	payload["total"] = 3
+	rr = httptest.NewRecorder()
+	httputil.RespondJSONConditional(rr, req, http.StatusOK, payload)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 after the list changed, got %d", rr.Code)
+	}
+}
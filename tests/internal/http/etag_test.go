@@ -0,0 +1,45 @@
+package httputil_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	httputil "github.com/urdogan0000/social/internal/http"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    *uint
+		wantErr bool
+	}{
+		{name: "absent", header: ""},
+		{name: "wildcard", header: "*"},
+		{name: "strong tag", header: httputil.ETag(7), want: ptr(7)},
+		{name: "weak tag", header: `W/"7"`, wantErr: true},
+		{name: "unquoted", header: "7", wantErr: true},
+		{name: "list", header: `"7", "8"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := httputil.IfMatch(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("expected version %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func ptr(v uint) *uint {
+	return &v
+}
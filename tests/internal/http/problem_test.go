@@ -0,0 +1,123 @@
+package httputil_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/urdogan0000/social/internal/domain"
+	httputil "github.com/urdogan0000/social/internal/http"
+	"github.com/urdogan0000/social/internal/validator"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) httputil.Problem {
+	t.Helper()
+	if got := rr.Header().Get("Content-Type"); got != httputil.ProblemContentType {
+		t.Fatalf("expected content-type %s, got %s", httputil.ProblemContentType, got)
+	}
+	var p httputil.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	return p
+}
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"specific error", domain.ErrPostNotFound, http.StatusNotFound, "post_not_found"},
+		{"wrapped specific error", fmt.Errorf("loading: %w", domain.ErrUserNotFound), http.StatusNotFound, "user_not_found"},
+		{"category only", errors.Join(domain.ErrConflict, errors.New("dup")), http.StatusConflict, "conflict"},
+		{"validation category", domain.ErrInvalidTitle, http.StatusBadRequest, "validation_failed"},
+		{"credentials", domain.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError, "failed_to_get_post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := httputil.ProblemFor(tt.err, "failed_to_get_post")
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("expected (%d, %s), got (%d, %s)", tt.wantStatus, tt.wantCode, status, code)
+			}
+		})
+	}
+}
+
+func TestRespondDomainError(t *testing.T) {
+	initI18N(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts/7", nil)
+	var rr *httptest.ResponseRecorder
+	middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rr = httptest.NewRecorder()
+		httputil.RespondDomainError(rr, r, fmt.Errorf("get: %w", domain.ErrPostNotFound), "failed_to_get_post")
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	p := decodeProblem(t, rr)
+	if p.Code != "post_not_found" || p.Title != "Post not found" || p.Status != http.StatusNotFound {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if p.Type != "urn:social:problem:post_not_found" || p.Instance != "/v1/posts/7" {
+		t.Errorf("unexpected type or instance %+v", p)
+	}
+	if p.RequestID == "" {
+		t.Error("expected request id in problem")
+	}
+}
+
+func TestRespondDomainError_VersionMismatchHonoursIfMatch(t *testing.T) {
+	initI18N(t)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/v1/posts/7", nil)
+	req.Header.Set("If-Match", `"3"`)
+	httputil.RespondDomainError(rr, req, domain.ErrVersionMismatch, "failed_to_update_post")
+
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", rr.Code)
+	}
+	if p := decodeProblem(t, rr); p.Code != "precondition_failed" {
+		t.Errorf("expected precondition_failed, got %s", p.Code)
+	}
+}
+
+func TestRespondValidationError_FieldErrors(t *testing.T) {
+	initI18N(t)
+
+	type request struct {
+		Email string `json:"email" validate:"required,email"`
+		Name  string `json:"name" validate:"min=3"`
+	}
+	err := validator.Validate(&request{Email: "not-an-email", Name: "ab"})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	rr := httptest.NewRecorder()
+	httputil.RespondValidationError(rr, httptest.NewRequest(http.MethodPost, "/?lang=tr", nil), err)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	p := decodeProblem(t, rr)
+	if p.Code != "validation_failed" || len(p.Errors) != 2 {
+		t.Fatalf("expected 2 field errors, got %+v", p)
+	}
+	if p.Errors[0].Field != "email" || p.Errors[0].Code != "email" {
+		t.Errorf("unexpected first field error %+v", p.Errors[0])
+	}
+	if p.Errors[1].Field != "name" || p.Errors[1].Param != "3" || p.Errors[1].Message != "en az 3 karakter olmalıdır" {
+		t.Errorf("unexpected second field error %+v", p.Errors[1])
+	}
+}
@@ -67,12 +67,12 @@ func TestRespondErrorWithMessage(t *testing.T) {
 		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
 	}
 
-	var body map[string]string
+	var body httputil.Problem
 	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
-	if body["error"] != message {
-		t.Fatalf("expected error message %q, got %q", message, body["error"])
+	if body.Detail != message {
+		t.Fatalf("expected error message %q, got %q", message, body.Detail)
 	}
 }
 
@@ -88,13 +88,16 @@ func TestRespondError_Localized(t *testing.T) {
 		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
 	}
 
-	var body map[string]string
+	var body httputil.Problem
 	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 	expected := "Kullanıcı bulunamadı"
-	if body["error"] != expected {
-		t.Fatalf("expected localized message %q, got %q", expected, body["error"])
+	if body.Title != expected {
+		t.Fatalf("expected localized message %q, got %q", expected, body.Title)
+	}
+	if body.Code != "user_not_found" {
+		t.Fatalf("expected stable code user_not_found, got %q", body.Code)
 	}
 }
 
@@ -0,0 +1,109 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/media"
+)
+
+func newRouter(f *fixture) http.Handler {
+	handler := media.NewHandler(f.service)
+	r := chi.NewRouter()
+	r.Get("/v1/media/{id}/content", handler.Content)
+	r.Post("/v1/media", handler.Upload)
+	return r
+}
+
+func uploadRequest(t *testing.T, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "pixel.png")
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	_, _ = part.Write(data)
+	_ = form.WriteField("alt_text", "a pixel")
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/media", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, uint(1)))
+}
+
+func TestHandler_UploadAndDownload(t *testing.T) {
+	f := newFixture(t)
+	router := newRouter(f)
+	data := pngBytes(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, data))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp media.Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.AltText != "a pixel" {
+		t.Errorf("expected the alt text stored, got %q", resp.AltText)
+	}
+
+	contentURL, _ := f.signer.URL(resp.ID, "", time.Now())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, contentURL, nil))
+	if rec.Code != http.StatusConflict || problemCode(rec) != "media_not_ready" {
+		t.Errorf("expected 409 media_not_ready before processing, got %d", rec.Code)
+	}
+
+	f.process(t)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, contentURL, nil))
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("expected the png downloaded, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected png served with nosniff, got %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/media/1/content?expires=1&sig=00", nil))
+	if rec.Code != http.StatusForbidden || problemCode(rec) != "invalid_media_url" {
+		t.Errorf("expected 403 invalid_media_url for a bad signature, got %d", rec.Code)
+	}
+}
+
+func TestHandler_UploadErrors(t *testing.T) {
+	f := newFixture(t)
+	router := newRouter(f)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, []byte("just some text")))
+	if rec.Code != http.StatusUnsupportedMediaType || problemCode(rec) != "unsupported_media_type" {
+		t.Errorf("expected 415 unsupported_media_type, got %d", rec.Code)
+	}
+
+	// Well past the limit plus the multipart allowance
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, append(pngBytes(t), make([]byte, 2<<20)...)))
+	if rec.Code != http.StatusRequestEntityTooLarge || problemCode(rec) != "media_too_large" {
+		t.Errorf("expected 413 media_too_large, got %d", rec.Code)
+	}
+}
+
+func problemCode(rec *httptest.ResponseRecorder) string {
+	var problem struct {
+		Code string `json:"code"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&problem)
+	return problem.Code
+}
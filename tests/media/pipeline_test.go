@@ -0,0 +1,165 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hash/crc32"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/storage"
+	"github.com/urdogan0000/social/media"
+)
+
+// withTextChunk inserts a tEXt chunk, where encoders put metadata such as
+// the author or location, right after the PNG header
+func withTextChunk(t *testing.T, data []byte, text string) []byte {
+	t.Helper()
+	const headerEnd = 8 + 25 // signature and IHDR chunk
+	payload := append([]byte("tEXt"), text...)
+	var chunk bytes.Buffer
+	chunk.Write([]byte{0, 0, 0, byte(len(text))})
+	chunk.Write(payload)
+	crc := crc32.ChecksumIEEE(payload)
+	chunk.Write([]byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)})
+
+	out := append([]byte{}, data[:headerEnd]...)
+	out = append(out, chunk.Bytes()...)
+	return append(out, data[headerEnd:]...)
+}
+
+// read downloads a variant of the media, failing the test when it cannot
+func (f *fixture) read(t *testing.T, id uint, variant string) []byte {
+	t.Helper()
+	content, err := f.open(id, variant)
+	if err != nil {
+		t.Fatalf("expected content to open, got %v", err)
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("failed to read content: %v", err)
+	}
+	return data
+}
+
+func TestPipeline_ProcessesImages(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	data := withTextChunk(t, pngBytes(t), "Comment\x00taken at home")
+	resp, err := f.service.Upload(ctx, 1, media.UploadRequest{File: bytes.NewReader(data), Size: int64(len(data))})
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+	uploadedKey := f.repo.get(t, resp.ID).Key
+
+	f.process(t)
+	processed := f.repo.get(t, resp.ID)
+	if processed.ProcessingStatus != media.StatusReady {
+		t.Fatalf("expected ready, got %s: %s", processed.ProcessingStatus, processed.ProcessingError)
+	}
+	if processed.Width != 8 || processed.Height != 6 || processed.Blurhash == "" {
+		t.Errorf("expected 8x6 with a blurhash, got %dx%d %q", processed.Width, processed.Height, processed.Blurhash)
+	}
+	// The uploaded blob is replaced by the stripped one
+	if _, err := f.store.Get(ctx, uploadedKey); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the uploaded blob deleted, got %v", err)
+	}
+
+	original := f.read(t, resp.ID, "")
+	if bytes.Contains(original, []byte("taken at home")) {
+		t.Error("expected the text chunk stripped from the original")
+	}
+	if _, err := png.Decode(bytes.NewReader(original)); err != nil {
+		t.Errorf("expected the stripped original to decode, got %v", err)
+	}
+
+	// Only sizes smaller than the image are derived
+	if len(processed.Variants) != 1 || processed.Variants[0].Name != "small" {
+		t.Fatalf("expected only the small variant, got %+v", processed.Variants)
+	}
+	small := processed.Variants[0]
+	if small.Width != 4 || small.Height != 3 {
+		t.Errorf("expected the small variant to keep the aspect ratio, got %dx%d", small.Width, small.Height)
+	}
+	thumb := f.read(t, resp.ID, "small")
+	if len(thumb) != int(small.Size) {
+		t.Errorf("expected %d bytes for the small variant, got %d", small.Size, len(thumb))
+	}
+	if _, err := f.open(resp.ID, "large"); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a variant that was not derived, got %v", err)
+	}
+}
+
+func TestPipeline_FailsCorruptImages(t *testing.T) {
+	f := newFixture(t)
+	// Sniffs as a PNG but does not decode; retrying cannot help
+	data := append([]byte("\x89PNG\r\n\x1a\n"), "not really"...)
+	resp, err := f.service.Upload(context.Background(), 1, media.UploadRequest{File: bytes.NewReader(data), Size: int64(len(data))})
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+
+	f.process(t)
+	failed := f.repo.get(t, resp.ID)
+	if failed.ProcessingStatus != media.StatusFailed || failed.ProcessingAttempts != 1 || failed.ProcessingError == "" {
+		t.Errorf("expected failed after one attempt with an error, got %+v", failed)
+	}
+	if _, err := f.open(resp.ID, ""); !errors.Is(err, media.ErrNotReady) {
+		t.Errorf("expected a failed upload not to be served, got %v", err)
+	}
+}
+
+func TestPipeline_RetriesTransientFailures(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	resp := f.upload(t, 1)
+	// A missing blob looks like a storage outage
+	if err := f.store.Delete(ctx, f.repo.get(t, resp.ID).Key); err != nil {
+		t.Fatalf("failed to delete blob: %v", err)
+	}
+
+	f.process(t)
+	retrying := f.repo.get(t, resp.ID)
+	if retrying.ProcessingStatus != media.StatusPending || retrying.ProcessingAttempts != 1 {
+		t.Fatalf("expected pending after the first attempt, got %+v", retrying)
+	}
+	if retrying.ProcessAfter == nil || !retrying.ProcessAfter.After(time.Now()) {
+		t.Fatalf("expected the retry to be delayed, got %v", retrying.ProcessAfter)
+	}
+
+	// Not due yet
+	if count, err := f.pipeline.ProcessPending(ctx); err != nil || count != 0 {
+		t.Errorf("expected nothing due, got %d, %v", count, err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	f.repo.mu.Lock()
+	f.repo.media[resp.ID].ProcessAfter = &past
+	f.repo.mu.Unlock()
+	f.process(t)
+	if failed := f.repo.get(t, resp.ID); failed.ProcessingStatus != media.StatusFailed || failed.ProcessingAttempts != 2 {
+		t.Errorf("expected failed after the last attempt, got %+v", failed)
+	}
+}
+
+func TestProcessor_RunsOnUpload(t *testing.T) {
+	f := newFixture(t)
+	// A long interval, so only the upload event can start processing
+	processor := media.NewProcessor(f.pipeline, time.Hour)
+	media.RegisterProcessingTrigger(f.bus, processor)
+	processor.Start()
+	defer func() { _ = processor.Stop(context.Background()) }()
+
+	resp := f.upload(t, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for f.repo.get(t, resp.ID).ProcessingStatus != media.StatusReady {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the upload processed, got %s", f.repo.get(t, resp.ID).ProcessingStatus)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,522 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/storage"
+	"github.com/urdogan0000/social/media"
+)
+
+// mockRepository keeps media in memory with the same conditional updates as
+// the database repository; deleted posts are not modelled
+type mockRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	media  map[uint]*media.Model
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{media: make(map[uint]*media.Model)}
+}
+
+func (m *mockRepository) Create(ctx context.Context, item *media.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	item.ID = m.nextID
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = item.CreatedAt
+	stored := *item
+	m.media[item.ID] = &stored
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uint) (*media.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if item, ok := m.media[id]; ok {
+		copied := *item
+		return &copied, nil
+	}
+	return nil, media.ErrNotFound
+}
+
+func (m *mockRepository) GetByPostID(ctx context.Context, postID uint) ([]media.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []media.Model
+	for id := uint(1); id <= m.nextID; id++ {
+		if item, ok := m.media[id]; ok && item.PostID != nil && *item.PostID == postID {
+			result = append(result, *item)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) Attach(ctx context.Context, id, postID uint, altText string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.media[id]
+	if !ok || (item.PostID != nil && *item.PostID != postID) {
+		return false, nil
+	}
+	item.PostID, item.AltText, item.UpdatedAt = &postID, altText, time.Now()
+	return true, nil
+}
+
+func (m *mockRepository) Detach(ctx context.Context, id, postID uint) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.media[id]
+	if !ok || item.PostID == nil || *item.PostID != postID {
+		return false, nil
+	}
+	item.PostID, item.UpdatedAt = nil, time.Now()
+	return true, nil
+}
+
+func (m *mockRepository) isOrphan(item *media.Model, before time.Time) bool {
+	return item.PostID == nil && item.UpdatedAt.Before(before)
+}
+
+func (m *mockRepository) ListOrphans(ctx context.Context, before time.Time, limit int) ([]media.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []media.Model
+	for id := uint(1); id <= m.nextID && len(result) < limit; id++ {
+		if item, ok := m.media[id]; ok && m.isOrphan(item, before) {
+			result = append(result, *item)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) DeleteOrphan(ctx context.Context, id uint, before time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.media[id]
+	if !ok || !m.isOrphan(item, before) {
+		return false, nil
+	}
+	delete(m.media, id)
+	return true, nil
+}
+
+func (m *mockRepository) ClaimForProcessing(ctx context.Context, now, leaseUntil time.Time, limit int) ([]media.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []media.Model
+	for id := uint(1); id <= m.nextID && len(result) < limit; id++ {
+		item, ok := m.media[id]
+		if !ok || (item.ProcessingStatus != media.StatusPending && item.ProcessingStatus != media.StatusProcessing) {
+			continue
+		}
+		if item.ProcessAfter != nil && item.ProcessAfter.After(now) {
+			continue
+		}
+		item.ProcessingStatus, item.ProcessAfter = media.StatusProcessing, &leaseUntil
+		result = append(result, *item)
+	}
+	return result, nil
+}
+
+func (m *mockRepository) SaveProcessing(ctx context.Context, item *media.Model) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.media[item.ID]
+	if !ok {
+		return false, nil
+	}
+	stored.Key, stored.Size = item.Key, item.Size
+	stored.Width, stored.Height, stored.Blurhash = item.Width, item.Height, item.Blurhash
+	stored.Variants = item.Variants
+	stored.ProcessingStatus, stored.ProcessingAttempts = item.ProcessingStatus, item.ProcessingAttempts
+	stored.ProcessingError, stored.ProcessAfter = item.ProcessingError, item.ProcessAfter
+	return true, nil
+}
+
+// get returns a copy of the stored media, failing the test when it is gone
+func (m *mockRepository) get(t *testing.T, id uint) media.Model {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.media[id]
+	if !ok {
+		t.Fatalf("expected media %d to exist", id)
+	}
+	return *item
+}
+
+type mockPostRepository struct {
+	posts map[domain.PostID]*domain.Post
+}
+
+func (m *mockPostRepository) GetByID(ctx context.Context, id domain.PostID) (*domain.Post, error) {
+	if post, ok := m.posts[id]; ok {
+		return post, nil
+	}
+	return nil, domain.ErrPostNotFound
+}
+
+func (m *mockPostRepository) GetByUserID(ctx context.Context, userID domain.UserID) ([]*domain.Post, error) {
+	return nil, nil
+}
+
+func (m *mockPostRepository) Exists(ctx context.Context, id domain.PostID) (bool, error) {
+	_, ok := m.posts[id]
+	return ok, nil
+}
+
+type mockFollowRepository struct{}
+
+func (mockFollowRepository) IsFollowing(ctx context.Context, followerID, followeeID domain.UserID) (bool, error) {
+	return false, nil
+}
+
+type fixture struct {
+	service  *media.Service
+	pipeline *media.Pipeline
+	repo     *mockRepository
+	store    *storage.LocalStore
+	signer   *media.Signer
+	bus      events.EventBus
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	posts := &mockPostRepository{posts: map[domain.PostID]*domain.Post{
+		1: {ID: 1, UserID: 1, Visibility: domain.VisibilityPublic, Status: domain.PostStatusPublished},
+		2: {ID: 2, UserID: 1, Visibility: domain.VisibilityPrivate, Status: domain.PostStatusPublished},
+		3: {ID: 3, UserID: 2, Visibility: domain.VisibilityPublic, Status: domain.PostStatusPublished},
+	}}
+	repo := newMockRepository()
+	signer := media.NewSigner([]byte("secret"), time.Minute, "/v1/media")
+	limits := media.Limits{MaxSize: 1 << 16, AllowedTypes: []string{"image/png", "image/gif"}}
+	bus := events.NewInMemoryEventBus()
+	return &fixture{
+		service: media.NewService(repo, store, posts, mockFollowRepository{}, bus, signer, limits),
+		pipeline: media.NewPipeline(repo, store, media.PipelineOptions{
+			Sizes:       []media.Size{{Name: "small", MaxEdge: 4}, {Name: "large", MaxEdge: 100}},
+			MaxPixels:   1 << 20,
+			MaxAttempts: 2,
+			RetryDelay:  time.Minute,
+			Lease:       time.Minute,
+			BatchSize:   10,
+		}),
+		repo:   repo,
+		store:  store,
+		signer: signer,
+		bus:    bus,
+	}
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	img.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func (f *fixture) upload(t *testing.T, userID uint) *media.Response {
+	t.Helper()
+	data := pngBytes(t)
+	resp, err := f.service.Upload(context.Background(), userID, media.UploadRequest{
+		File: bytes.NewReader(data),
+		Size: int64(len(data)),
+	})
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+	return resp
+}
+
+// process runs the pipeline over everything that is due
+func (f *fixture) process(t *testing.T) {
+	t.Helper()
+	if _, err := f.pipeline.ProcessPending(context.Background()); err != nil {
+		t.Fatalf("expected processing to succeed, got %v", err)
+	}
+}
+
+// open downloads a variant of the media through a freshly signed link
+func (f *fixture) open(id uint, variant string) (*media.Content, error) {
+	rawURL, _ := f.signer.URL(id, variant, time.Now())
+	u, _ := url.Parse(rawURL)
+	return f.service.Open(context.Background(), id, variant, u.Query().Get("expires"), u.Query().Get("sig"))
+}
+
+func signedParams(t *testing.T, rawURL string) (string, string) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("invalid url %q: %v", rawURL, err)
+	}
+	return u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestUpload_SniffsTypeAndServesSignedContent(t *testing.T) {
+	f := newFixture(t)
+	data := pngBytes(t)
+
+	resp := f.upload(t, 1)
+	if resp.ContentType != "image/png" || resp.Size != int64(len(data)) {
+		t.Fatalf("expected a png of %d bytes, got %s of %d", len(data), resp.ContentType, resp.Size)
+	}
+	// Images are held back until the pipeline has stripped their metadata
+	if resp.ProcessingStatus != media.StatusPending || resp.URL != "" {
+		t.Errorf("expected a pending upload without URL, got %+v", resp)
+	}
+	if _, err := f.open(resp.ID, ""); !errors.Is(err, media.ErrNotReady) {
+		t.Errorf("expected ErrNotReady before processing, got %v", err)
+	}
+
+	f.process(t)
+	list, err := f.service.Attach(context.Background(), 1, 1, media.AttachRequest{MediaID: resp.ID})
+	if err != nil {
+		t.Fatalf("expected attach to succeed, got %v", err)
+	}
+	if !strings.HasPrefix(list.URL, "/v1/media/1/content?") {
+		t.Errorf("expected a signed content URL, got %s", list.URL)
+	}
+
+	expires, sig := signedParams(t, list.URL)
+	content, err := f.service.Open(context.Background(), resp.ID, "", expires, sig)
+	if err != nil {
+		t.Fatalf("expected the signed URL to open, got %v", err)
+	}
+	defer content.Close()
+	got, _ := io.ReadAll(content)
+	if !bytes.Equal(got, data) || content.ContentType != "image/png" {
+		t.Errorf("expected the uploaded png back, got %d bytes of %s", len(got), content.ContentType)
+	}
+}
+
+func TestUpload_SkipsProcessingForOtherTypes(t *testing.T) {
+	f := newFixture(t)
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	resp, err := f.service.Upload(context.Background(), 1, media.UploadRequest{File: bytes.NewReader(gif), Size: int64(len(gif))})
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+	if resp.ProcessingStatus != media.StatusSkipped || resp.URL == "" {
+		t.Errorf("expected a skipped upload served right away, got %+v", resp)
+	}
+	content, err := f.open(resp.ID, "")
+	if err != nil {
+		t.Fatalf("expected the gif to open, got %v", err)
+	}
+	content.Close()
+}
+
+func TestUpload_IgnoresDeclaredType(t *testing.T) {
+	f := newFixture(t)
+	// An HTML page sent as "image/png" must not be served as an image
+	page := []byte("<html><script>alert(1)</script></html>")
+	_, err := f.service.Upload(context.Background(), 1, media.UploadRequest{File: bytes.NewReader(page), Size: int64(len(page))})
+	if !errors.Is(err, media.ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestUpload_EnforcesSizeLimit(t *testing.T) {
+	f := newFixture(t)
+	big := append(pngBytes(t), make([]byte, 1<<16)...)
+
+	_, err := f.service.Upload(context.Background(), 1, media.UploadRequest{File: bytes.NewReader(big), Size: int64(len(big))})
+	if !errors.Is(err, media.ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for a declared size over the limit, got %v", err)
+	}
+
+	// A client that understates the size is stopped while streaming
+	_, err = f.service.Upload(context.Background(), 1, media.UploadRequest{File: bytes.NewReader(big), Size: 10})
+	if !errors.Is(err, media.ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for an understated size, got %v", err)
+	}
+	if len(f.repo.media) != 0 {
+		t.Errorf("expected no media stored, got %d", len(f.repo.media))
+	}
+}
+
+func TestAttach(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	upload := f.upload(t, 1)
+
+	if _, err := f.service.Attach(ctx, 1, 3, media.AttachRequest{MediaID: upload.ID}); !errors.Is(err, domain.ErrPostForbidden) {
+		t.Errorf("expected ErrPostForbidden on another user's post, got %v", err)
+	}
+	if _, err := f.service.Attach(ctx, 2, 3, media.AttachRequest{MediaID: upload.ID}); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user's media, got %v", err)
+	}
+
+	resp, err := f.service.Attach(ctx, 1, 1, media.AttachRequest{MediaID: upload.ID, AltText: "a white pixel"})
+	if err != nil {
+		t.Fatalf("expected attach to succeed, got %v", err)
+	}
+	if resp.PostID == nil || *resp.PostID != 1 || resp.AltText != "a white pixel" {
+		t.Errorf("expected media attached to post 1 with alt text, got %+v", resp)
+	}
+
+	// Attaching again updates the alt text; another post is a conflict
+	if _, err := f.service.Attach(ctx, 1, 1, media.AttachRequest{MediaID: upload.ID, AltText: "updated"}); err != nil {
+		t.Errorf("expected reattaching to the same post to succeed, got %v", err)
+	}
+	if _, err := f.service.Attach(ctx, 1, 2, media.AttachRequest{MediaID: upload.ID}); !errors.Is(err, media.ErrAlreadyAttached) {
+		t.Errorf("expected ErrAlreadyAttached, got %v", err)
+	}
+
+	list, err := f.service.GetByPostID(ctx, 1, 0)
+	if err != nil || len(list.Media) != 1 || list.Media[0].AltText != "updated" {
+		t.Fatalf("expected the attached media listed, got %+v, %v", list, err)
+	}
+}
+
+func TestGetByPostID_HidesPrivatePosts(t *testing.T) {
+	f := newFixture(t)
+	upload := f.upload(t, 1)
+	if _, err := f.service.Attach(context.Background(), 1, 2, media.AttachRequest{MediaID: upload.ID}); err != nil {
+		t.Fatalf("expected attach to succeed, got %v", err)
+	}
+
+	if _, err := f.service.GetByPostID(context.Background(), 2, 3); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound for a stranger, got %v", err)
+	}
+	if list, err := f.service.GetByPostID(context.Background(), 2, 1); err != nil || len(list.Media) != 1 {
+		t.Errorf("expected the author to see the media, got %+v, %v", list, err)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	upload := f.upload(t, 1)
+	if _, err := f.service.Attach(ctx, 1, 1, media.AttachRequest{MediaID: upload.ID}); err != nil {
+		t.Fatalf("expected attach to succeed, got %v", err)
+	}
+
+	if err := f.service.Detach(ctx, 1, 2, upload.ID); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("expected ErrNotFound when detaching from the wrong post, got %v", err)
+	}
+	if err := f.service.Detach(ctx, 1, 1, upload.ID); err != nil {
+		t.Fatalf("expected detach to succeed, got %v", err)
+	}
+	if list, _ := f.service.GetByPostID(ctx, 1, 0); len(list.Media) != 0 {
+		t.Errorf("expected no media after detach, got %d", len(list.Media))
+	}
+}
+
+func TestSigner(t *testing.T) {
+	signer := media.NewSigner([]byte("secret"), time.Minute, "/v1/media")
+	now := time.Now()
+	rawURL, expiresAt := signer.URL(7, "", now)
+	expires, sig := signedParams(t, rawURL)
+
+	if got, err := signer.Verify(7, "", expires, sig, now); err != nil || !got.Equal(expiresAt) {
+		t.Errorf("expected a fresh link to verify, got %v, %v", got, err)
+	}
+	if _, err := signer.Verify(8, "", expires, sig, now); !errors.Is(err, media.ErrInvalidSignature) {
+		t.Errorf("expected a link for other media to fail, got %v", err)
+	}
+	if _, err := signer.Verify(7, "small", expires, sig, now); !errors.Is(err, media.ErrInvalidSignature) {
+		t.Errorf("expected a link for another variant to fail, got %v", err)
+	}
+	extended := strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10)
+	if _, err := signer.Verify(7, "", extended, sig, now); !errors.Is(err, media.ErrInvalidSignature) {
+		t.Errorf("expected an extended expiry to fail, got %v", err)
+	}
+	if _, err := signer.Verify(7, "", expires, sig, now.Add(2*time.Minute)); !errors.Is(err, media.ErrInvalidSignature) {
+		t.Errorf("expected an expired link to fail, got %v", err)
+	}
+	other := media.NewSigner([]byte("other"), time.Minute, "/v1/media")
+	if _, err := other.Verify(7, "", expires, sig, now); !errors.Is(err, media.ErrInvalidSignature) {
+		t.Errorf("expected a link signed with another secret to fail, got %v", err)
+	}
+}
+
+func TestCollectOrphans(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	orphan := f.upload(t, 1)
+	attached := f.upload(t, 1)
+	if _, err := f.service.Attach(ctx, 1, 1, media.AttachRequest{MediaID: attached.ID}); err != nil {
+		t.Fatalf("expected attach to succeed, got %v", err)
+	}
+	f.process(t)
+	orphanKeys := []string{f.repo.get(t, orphan.ID).Key}
+	for _, v := range f.repo.get(t, orphan.ID).Variants {
+		orphanKeys = append(orphanKeys, v.Key)
+	}
+
+	// Fresh uploads are left alone
+	if count, err := f.service.CollectOrphans(ctx, time.Now().Add(-time.Hour)); err != nil || count != 0 {
+		t.Fatalf("expected nothing collected yet, got %d, %v", count, err)
+	}
+
+	count, err := f.service.CollectOrphans(ctx, time.Now().Add(time.Second))
+	if err != nil || count != 1 {
+		t.Fatalf("expected one orphan collected, got %d, %v", count, err)
+	}
+	if _, err := f.repo.GetByID(ctx, orphan.ID); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("expected the orphan deleted, got %v", err)
+	}
+	for _, key := range orphanKeys {
+		if _, err := f.store.Get(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("expected the orphan's blob %s deleted, got %v", key, err)
+		}
+	}
+	if _, err := f.repo.GetByID(ctx, attached.ID); err != nil {
+		t.Errorf("expected attached media kept, got %v", err)
+	}
+}
+
+func TestAvatar(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	upload := f.upload(t, 1)
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	animated, err := f.service.Upload(ctx, 1, media.UploadRequest{File: bytes.NewReader(gif), Size: int64(len(gif))})
+	if err != nil {
+		t.Fatalf("expected upload to succeed, got %v", err)
+	}
+
+	if err := f.service.CheckAvatar(ctx, domain.MediaID(upload.ID), 2); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user's upload, got %v", err)
+	}
+	if err := f.service.CheckAvatar(ctx, domain.MediaID(upload.ID), 1); err != nil {
+		t.Errorf("expected the uploader's image to be accepted, got %v", err)
+	}
+
+	if _, err := f.service.AvatarURL(ctx, domain.MediaID(upload.ID)); !errors.Is(err, media.ErrNotReady) {
+		t.Errorf("expected ErrNotReady before processing, got %v", err)
+	}
+	f.process(t)
+	// The smallest variant is linked
+	if url, err := f.service.AvatarURL(ctx, domain.MediaID(upload.ID)); err != nil || !strings.Contains(url, "variant=small") {
+		t.Errorf("expected a link to the small variant, got %q, %v", url, err)
+	}
+	if url, err := f.service.AvatarURL(ctx, domain.MediaID(animated.ID)); err != nil || strings.Contains(url, "variant=") {
+		t.Errorf("expected a link to the original gif, got %q, %v", url, err)
+	}
+}
@@ -0,0 +1,35 @@
+package mentions_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/mentions"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestRepository_GetByUserID_SkipsDisabledComments(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run db: %v", err)
+	}
+	var statement string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statement = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	repo := mentions.NewRepository(db)
+	if _, err := repo.GetByUserID(context.Background(), 1, 10, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(statement, `comments.post_id IN (SELECT "id" FROM "posts" WHERE posts.comment_status <> $`) {
+		t.Errorf("expected mentions in comments of posts with disabled comments excluded, got %s", statement)
+	}
+}
@@ -0,0 +1,86 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/metrics"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+func TestHTTPMetrics_LabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.Metrics())
+	r.Get("/v1/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	counter := metrics.HTTPRequests.WithLabelValues("GET", "/v1/posts/{id}", "404")
+	before := testutil.ToFloat64(counter)
+
+	for _, path := range []string{"/v1/posts/1", "/v1/posts/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("expected 2 requests under the route pattern, got %v", got)
+	}
+
+	unmatched := metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")
+	before = testutil.ToFloat64(unmatched)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope/123", nil))
+	if got := testutil.ToFloat64(unmatched) - before; got != 1 {
+		t.Errorf("expected unknown paths to share one label, got %v", got)
+	}
+}
+
+type pinged struct{}
+
+func (pinged) Type() string { return "test.pinged" }
+
+func TestInstrumentEventBus(t *testing.T) {
+	bus := metrics.InstrumentEventBus(events.NewInMemoryEventBus())
+
+	calls := 0
+	handler := func(ctx context.Context, event events.Event) error {
+		calls++
+		return errors.New("boom")
+	}
+	bus.Subscribe(pinged{}.Type(), handler)
+
+	published := metrics.EventsPublished.WithLabelValues("test.pinged")
+	failed := metrics.EventsHandled.WithLabelValues("test.pinged", "error")
+
+	_ = bus.Publish(context.Background(), pinged{})
+	if testutil.ToFloat64(published) != 1 || testutil.ToFloat64(failed) != 1 {
+		t.Errorf("expected one publish and one failed handling, got %v and %v",
+			testutil.ToFloat64(published), testutil.ToFloat64(failed))
+	}
+
+	// Unsubscribing with the original handler removes the instrumented wrapper
+	bus.Unsubscribe(pinged{}.Type(), handler)
+	_ = bus.Publish(context.Background(), pinged{})
+	if calls != 1 {
+		t.Errorf("expected handler to be unsubscribed, got %d calls", calls)
+	}
+}
+
+func TestHandler_ExposesCollectors(t *testing.T) {
+	metrics.PostsCreated.Inc()
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "social_posts_created_total") {
+		t.Errorf("expected business counters in the exposition")
+	}
+}
@@ -23,6 +23,7 @@ func (m *mockUserRepoForAuth) GetByID(ctx context.Context, id uint) (*users.Mode
 	}
 	return nil, users.ErrNotFound
 }
+func (m *mockUserRepoForAuth) GetByIDs(ctx context.Context, ids []uint) ([]users.Model, error) { return nil, nil }
 func (m *mockUserRepoForAuth) GetByUsername(ctx context.Context, username string) (*users.Model, error) { return nil, nil }
 func (m *mockUserRepoForAuth) GetByEmail(ctx context.Context, email string) (*users.Model, error) {
 	for _, user := range m.users {
@@ -33,7 +34,7 @@ func (m *mockUserRepoForAuth) GetByEmail(ctx context.Context, email string) (*us
 	return nil, users.ErrNotFound
 }
 func (m *mockUserRepoForAuth) Update(ctx context.Context, user *users.Model) error { return nil }
-func (m *mockUserRepoForAuth) Delete(ctx context.Context, id uint) error { return nil }
+func (m *mockUserRepoForAuth) Delete(ctx context.Context, id uint, version *uint) error { return nil }
 func (m *mockUserRepoForAuth) List(ctx context.Context, limit, offset int) ([]users.Model, error) { return nil, nil }
 func (m *mockUserRepoForAuth) Count(ctx context.Context) (int64, error) { return 0, nil }
 
@@ -0,0 +1,280 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/idempotency"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+type entryKey struct {
+	userID uint
+	key    string
+}
+
+// mockIdempotencyRepo keeps entries in memory with the same claim semantics
+// as the database repository
+type mockIdempotencyRepo struct {
+	mu      sync.Mutex
+	nextID  uint
+	entries map[entryKey]*idempotency.Model
+}
+
+func newMockIdempotencyRepo() *mockIdempotencyRepo {
+	return &mockIdempotencyRepo{entries: make(map[entryKey]*idempotency.Model)}
+}
+
+func (m *mockIdempotencyRepo) Insert(ctx context.Context, entry *idempotency.Model) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := entryKey{entry.UserID, entry.Key}
+	if _, ok := m.entries[k]; ok {
+		return false, nil
+	}
+	m.nextID++
+	entry.ID = m.nextID
+	stored := *entry
+	m.entries[k] = &stored
+	return true, nil
+}
+
+func (m *mockIdempotencyRepo) Get(ctx context.Context, userID uint, key string) (*idempotency.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[entryKey{userID, key}]; ok {
+		copied := *entry
+		return &copied, nil
+	}
+	return nil, idempotency.ErrNotFound
+}
+
+func (m *mockIdempotencyRepo) byID(id uint) *idempotency.Model {
+	for _, entry := range m.entries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepo) Reclaim(ctx context.Context, entry *idempotency.Model, now, staleBefore time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.byID(entry.ID)
+	if stored == nil {
+		return false, nil
+	}
+	if stored.ExpiresAt.After(now) && (stored.Completed() || stored.LockedAt.After(staleBefore)) {
+		return false, nil
+	}
+	*stored = *entry
+	return true, nil
+}
+
+func (m *mockIdempotencyRepo) Complete(ctx context.Context, entry *idempotency.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored := m.byID(entry.ID); stored != nil && !stored.Completed() {
+		stored.StatusCode, stored.Header, stored.Body = entry.StatusCode, entry.Header, entry.Body
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepo) Delete(ctx context.Context, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, entry := range m.entries {
+		if entry.ID == id {
+			delete(m.entries, k)
+		}
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockIdempotencyRepo) expireAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		entry.ExpiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+// createHandler stands in for a create endpoint and counts how often it runs
+type createHandler struct {
+	calls  atomic.Int32
+	status int
+	block  chan struct{}
+}
+
+func (h *createHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	if h.block != nil {
+		<-h.block
+	}
+	status := h.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"1"`)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]int32{"id": n})
+}
+
+func idempotentRequest(t *testing.T, h http.Handler, userID uint, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func problemCode(rec *httptest.ResponseRecorder) string {
+	var problem struct {
+		Code string `json:"code"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&problem)
+	return problem.Code
+}
+
+func newIdempotent(repo idempotency.Repository, next http.Handler) http.Handler {
+	service := idempotency.NewService(repo, time.Hour, time.Minute)
+	return middleware.Idempotency(service)(next)
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	next := &createHandler{}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	first := idempotentRequest(t, h, 1, "key-1", `{"title":"hello"}`)
+	retry := idempotentRequest(t, h, 1, "key-1", `{"title":"hello"}`)
+
+	if next.calls.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", next.calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the first response replayed, got %d %q", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("ETag") != `"1"` || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected stored headers, got %v", retry.Header())
+	}
+	if retry.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Error("expected the replay to be marked")
+	}
+	if first.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Error("expected the first response not to be marked")
+	}
+}
+
+func TestIdempotency_KeysAreScopedToUsers(t *testing.T) {
+	next := &createHandler{}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	idempotentRequest(t, h, 1, "shared", `{}`)
+	rec := idempotentRequest(t, h, 2, "shared", `{}`)
+
+	if next.calls.Load() != 2 || rec.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Errorf("expected another user's key to run the request, ran %d times", next.calls.Load())
+	}
+}
+
+func TestIdempotency_RejectsReuseWithDifferentBody(t *testing.T) {
+	next := &createHandler{}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	idempotentRequest(t, h, 1, "key-1", `{"title":"hello"}`)
+	rec := idempotentRequest(t, h, 1, "key-1", `{"title":"changed"}`)
+
+	if rec.Code != http.StatusUnprocessableEntity || problemCode(rec) != "idempotency_key_reused" {
+		t.Errorf("expected 422 idempotency_key_reused, got %d", rec.Code)
+	}
+	if next.calls.Load() != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", next.calls.Load())
+	}
+}
+
+func TestIdempotency_BlocksConcurrentDuplicates(t *testing.T) {
+	next := &createHandler{block: make(chan struct{})}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(t, h, 1, "key-1", `{}`) }()
+	for next.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := idempotentRequest(t, h, 1, "key-1", `{}`)
+	if rec.Code != http.StatusConflict || problemCode(rec) != "idempotency_key_in_use" {
+		t.Errorf("expected 409 idempotency_key_in_use, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After on the conflict")
+	}
+
+	close(next.block)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("expected the first request to complete, got %d", first.Code)
+	}
+	if rec := idempotentRequest(t, h, 1, "key-1", `{}`); rec.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected a replay once the first request finished, got %d", rec.Code)
+	}
+}
+
+func TestIdempotency_ServerErrorsCanBeRetried(t *testing.T) {
+	next := &createHandler{status: http.StatusInternalServerError}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	idempotentRequest(t, h, 1, "key-1", `{}`)
+	next.status = http.StatusCreated
+	rec := idempotentRequest(t, h, 1, "key-1", `{}`)
+
+	if next.calls.Load() != 2 || rec.Code != http.StatusCreated {
+		t.Errorf("expected the retry to run, ran %d times with %d", next.calls.Load(), rec.Code)
+	}
+}
+
+func TestIdempotency_ExpiredKeysRunAgain(t *testing.T) {
+	repo := newMockIdempotencyRepo()
+	next := &createHandler{}
+	h := newIdempotent(repo, next)
+
+	idempotentRequest(t, h, 1, "key-1", `{"title":"hello"}`)
+	repo.expireAll()
+	rec := idempotentRequest(t, h, 1, "key-1", `{"title":"other"}`)
+
+	if next.calls.Load() != 2 || rec.Code != http.StatusCreated {
+		t.Errorf("expected an expired key to be reusable, ran %d times with %d", next.calls.Load(), rec.Code)
+	}
+}
+
+func TestIdempotency_WithoutKeyPassesThrough(t *testing.T) {
+	next := &createHandler{}
+	h := newIdempotent(newMockIdempotencyRepo(), next)
+
+	idempotentRequest(t, h, 1, "", `{}`)
+	idempotentRequest(t, h, 1, "", `{}`)
+	if next.calls.Load() != 2 {
+		t.Errorf("expected every request without a key to run, ran %d times", next.calls.Load())
+	}
+
+	rec := idempotentRequest(t, h, 1, strings.Repeat("k", 256), `{}`)
+	if rec.Code != http.StatusBadRequest || problemCode(rec) != "invalid_idempotency_key" {
+		t.Errorf("expected 400 invalid_idempotency_key for a long key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+	return &buf
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogger(t *testing.T) {
+	buf := captureLogs(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
+	r.Get("/v1/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info().Msg("handler log")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("missing"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts/7", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected handler and access log lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-123" {
+			t.Errorf("expected request_id req-123, got %v", line["request_id"])
+		}
+		if line["route"] != "/v1/posts/{id}" {
+			t.Errorf("expected route pattern, got %v", line["route"])
+		}
+		if line["method"] != http.MethodGet {
+			t.Errorf("expected method GET, got %v", line["method"])
+		}
+	}
+
+	access := lines[1]
+	if access["status"] != float64(http.StatusNotFound) {
+		t.Errorf("expected status 404, got %v", access["status"])
+	}
+	if access["bytes"] != float64(len("missing")) {
+		t.Errorf("expected 7 bytes, got %v", access["bytes"])
+	}
+	if access["level"] != "warn" {
+		t.Errorf("expected 4xx to log at warn, got %v", access["level"])
+	}
+	if _, ok := access["duration"]; !ok {
+		t.Error("expected duration in access log")
+	}
+}
+
+func TestFromContext_FallsBackToGlobal(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if logger.FromContext(req.Context()) != logger.Logger() {
+		t.Error("expected global logger without a request logger")
+	}
+}
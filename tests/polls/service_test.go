@@ -0,0 +1,398 @@
+package polls_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/polls"
+)
+
+type ballot struct {
+	pollID, userID uint
+}
+
+type mockRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	polls   map[uint]*polls.Model
+	options map[uint][]polls.OptionModel
+	votes   map[ballot][]uint
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		polls:   make(map[uint]*polls.Model),
+		options: make(map[uint][]polls.OptionModel),
+		votes:   make(map[ballot][]uint),
+	}
+}
+
+func (m *mockRepository) Create(ctx context.Context, poll *polls.Model, options []polls.OptionModel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.polls {
+		if existing.PostID == poll.PostID {
+			return polls.ErrExists
+		}
+	}
+	m.nextID++
+	poll.ID = m.nextID
+	copied := *poll
+	m.polls[poll.ID] = &copied
+	m.setOptions(poll.ID, options)
+	return nil
+}
+
+func (m *mockRepository) setOptions(pollID uint, options []polls.OptionModel) {
+	for i := range options {
+		m.nextID++
+		options[i].ID = m.nextID
+		options[i].PollID = pollID
+		options[i].Position = i
+	}
+	m.options[pollID] = slices.Clone(options)
+}
+
+func (m *mockRepository) GetByPostID(ctx context.Context, postID uint) (*polls.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, poll := range m.polls {
+		if poll.PostID == postID {
+			copied := *poll
+			return &copied, nil
+		}
+	}
+	return nil, polls.ErrNotFound
+}
+
+func (m *mockRepository) LockByPostID(ctx context.Context, postID uint, exclusive bool) (*polls.Model, error) {
+	return m.GetByPostID(ctx, postID)
+}
+
+func (m *mockRepository) GetOptions(ctx context.Context, pollID uint) ([]polls.OptionModel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.options[pollID]), nil
+}
+
+func (m *mockRepository) Update(ctx context.Context, poll *polls.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.polls[poll.ID].MultipleChoice = poll.MultipleChoice
+	m.polls[poll.ID].EndsAt = poll.EndsAt
+	return nil
+}
+
+func (m *mockRepository) ReplaceOptions(ctx context.Context, pollID uint, options []polls.OptionModel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setOptions(pollID, options)
+	return nil
+}
+
+func (m *mockRepository) Delete(ctx context.Context, pollID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.polls, pollID)
+	delete(m.options, pollID)
+	for key := range m.votes {
+		if key.pollID == pollID {
+			delete(m.votes, key)
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) CountBallots(ctx context.Context, pollID uint) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := int64(0)
+	for key := range m.votes {
+		if key.pollID == pollID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockRepository) AddBallot(ctx context.Context, pollID, userID uint, optionIDs []uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := ballot{pollID, userID}
+	if _, ok := m.votes[key]; ok {
+		return polls.ErrAlreadyVoted
+	}
+	m.votes[key] = slices.Clone(optionIDs)
+	return nil
+}
+
+func (m *mockRepository) RemoveBallot(ctx context.Context, pollID, userID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := ballot{pollID, userID}
+	if _, ok := m.votes[key]; !ok {
+		return polls.ErrVoteNotFound
+	}
+	delete(m.votes, key)
+	return nil
+}
+
+func (m *mockRepository) GetVotes(ctx context.Context, pollID, userID uint) ([]uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.votes[ballot{pollID, userID}]), nil
+}
+
+func (m *mockRepository) Tally(ctx context.Context, pollID uint) (map[uint]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tally := make(map[uint]int)
+	for key, optionIDs := range m.votes {
+		if key.pollID == pollID {
+			for _, id := range optionIDs {
+				tally[id]++
+			}
+		}
+	}
+	return tally, nil
+}
+
+func (m *mockRepository) CloseDue(ctx context.Context, now time.Time, batchSize int) ([]polls.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var closed []polls.Model
+	for _, poll := range m.polls {
+		if poll.ClosedAt == nil && !poll.EndsAt.After(now) && len(closed) < batchSize {
+			poll.ClosedAt = &now
+			closed = append(closed, *poll)
+		}
+	}
+	return closed, nil
+}
+
+type mockPostRepository struct {
+	posts map[domain.PostID]*domain.Post
+}
+
+func (m *mockPostRepository) GetByID(ctx context.Context, id domain.PostID) (*domain.Post, error) {
+	if post, ok := m.posts[id]; ok {
+		return post, nil
+	}
+	return nil, domain.ErrPostNotFound
+}
+
+func (m *mockPostRepository) GetByUserID(ctx context.Context, userID domain.UserID) ([]*domain.Post, error) {
+	return nil, nil
+}
+
+func (m *mockPostRepository) Exists(ctx context.Context, id domain.PostID) (bool, error) {
+	_, ok := m.posts[id]
+	return ok, nil
+}
+
+const (
+	authorID = 1
+	voterID  = 2
+	otherID  = 3
+	postID   = 10
+)
+
+func newService() (*polls.Service, *mockRepository, events.EventBus) {
+	repo := newMockRepository()
+	postRepo := &mockPostRepository{posts: map[domain.PostID]*domain.Post{
+		postID: {ID: postID, UserID: authorID, Visibility: domain.VisibilityPublic, Status: domain.PostStatusPublished},
+		11:     {ID: 11, UserID: authorID, Visibility: domain.VisibilityPrivate, Status: domain.PostStatusPublished},
+	}}
+	eventBus := events.NewInMemoryEventBus()
+	return polls.NewService(repo, postRepo, nil, eventBus, nil), repo, eventBus
+}
+
+func putPoll(t *testing.T, service *polls.Service, multipleChoice bool) *polls.Response {
+	t.Helper()
+	poll, err := service.Put(context.Background(), authorID, postID, polls.PutRequest{
+		Options:        []string{"Yes", "No", "Maybe"},
+		MultipleChoice: multipleChoice,
+		EndsAt:         time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return poll
+}
+
+func TestService_Put_OnlyAuthor(t *testing.T) {
+	service, _, _ := newService()
+
+	_, err := service.Put(context.Background(), voterID, postID, polls.PutRequest{
+		Options: []string{"Yes", "No"},
+		EndsAt:  time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, domain.ErrPostForbidden) {
+		t.Errorf("expected ErrPostForbidden, got %v", err)
+	}
+}
+
+func TestService_Vote_TalliesVisibleToVoters(t *testing.T) {
+	service, _, _ := newService()
+	ctx := context.Background()
+	poll := putPoll(t, service, false)
+
+	if poll.TotalVoters != nil || poll.Options[0].Votes != nil {
+		t.Error("expected no tallies before voting")
+	}
+
+	voted, err := service.Vote(ctx, voterID, postID, polls.VoteRequest{OptionIDs: []uint{poll.Options[1].ID}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if voted.TotalVoters == nil || *voted.TotalVoters != 1 || *voted.Options[1].Votes != 1 {
+		t.Errorf("expected live tallies for the voter, got %+v", voted)
+	}
+	if !slices.Equal(voted.Voted, []uint{poll.Options[1].ID}) {
+		t.Errorf("expected the picked option in voted, got %v", voted.Voted)
+	}
+
+	other, _ := service.Get(ctx, postID, otherID)
+	if other.TotalVoters != nil || other.Options[1].Votes != nil {
+		t.Error("expected tallies hidden from non-voters while the poll is open")
+	}
+}
+
+func TestService_Vote_OncePerUser(t *testing.T) {
+	service, _, _ := newService()
+	ctx := context.Background()
+	poll := putPoll(t, service, false)
+	vote := polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID}}
+
+	if _, err := service.Vote(ctx, voterID, postID, vote); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Vote(ctx, voterID, postID, vote); !errors.Is(err, domain.ErrAlreadyVoted) {
+		t.Errorf("expected ErrAlreadyVoted, got %v", err)
+	}
+
+	if err := service.Retract(ctx, voterID, postID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.Retract(ctx, voterID, postID); !errors.Is(err, domain.ErrVoteNotFound) {
+		t.Errorf("expected ErrVoteNotFound, got %v", err)
+	}
+	if _, err := service.Vote(ctx, voterID, postID, vote); err != nil {
+		t.Errorf("expected voting again after retracting, got %v", err)
+	}
+}
+
+func TestService_Vote_Choices(t *testing.T) {
+	service, _, _ := newService()
+	ctx := context.Background()
+	poll := putPoll(t, service, false)
+
+	two := polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID, poll.Options[1].ID}}
+	if _, err := service.Vote(ctx, voterID, postID, two); !errors.Is(err, domain.ErrInvalidVote) {
+		t.Errorf("expected ErrInvalidVote for two options of a single choice poll, got %v", err)
+	}
+	if _, err := service.Vote(ctx, voterID, postID, polls.VoteRequest{OptionIDs: []uint{999}}); !errors.Is(err, domain.ErrInvalidVote) {
+		t.Errorf("expected ErrInvalidVote for an unknown option, got %v", err)
+	}
+
+	// Repeating an option counts it once
+	same := polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID, poll.Options[0].ID}}
+	if _, err := service.Vote(ctx, voterID, postID, same); err != nil {
+		t.Errorf("expected a repeated option to count once, got %v", err)
+	}
+}
+
+func TestService_Vote_HiddenPost(t *testing.T) {
+	service, _, _ := newService()
+	ctx := context.Background()
+
+	if _, err := service.Put(ctx, authorID, 11, polls.PutRequest{Options: []string{"Yes", "No"}, EndsAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Vote(ctx, voterID, 11, polls.VoteRequest{OptionIDs: []uint{1}}); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound for a private post, got %v", err)
+	}
+}
+
+func TestService_Put_FixedOnceVoted(t *testing.T) {
+	service, _, _ := newService()
+	ctx := context.Background()
+	poll := putPoll(t, service, false)
+
+	if _, err := service.Vote(ctx, voterID, postID, polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := service.Put(ctx, authorID, postID, polls.PutRequest{
+		Options: []string{"Yes", "No"},
+		EndsAt:  time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, domain.ErrPollHasVotes) {
+		t.Errorf("expected ErrPollHasVotes when changing options, got %v", err)
+	}
+	_, err = service.Put(ctx, authorID, postID, polls.PutRequest{
+		Options:        []string{"Yes", "No", "Maybe"},
+		MultipleChoice: true,
+		EndsAt:         time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, domain.ErrPollHasVotes) {
+		t.Errorf("expected ErrPollHasVotes when changing the choice mode, got %v", err)
+	}
+
+	extended, err := service.Put(ctx, authorID, postID, polls.PutRequest{
+		Options: []string{"Yes", "No", "Maybe"},
+		EndsAt:  time.Now().Add(48 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("expected the end time to change, got %v", err)
+	}
+	if extended.Options[0].ID != poll.Options[0].ID {
+		t.Error("expected the options and their votes to be kept")
+	}
+}
+
+func TestService_ClosedPoll(t *testing.T) {
+	service, repo, eventBus := newService()
+	ctx := context.Background()
+	poll := putPoll(t, service, true)
+
+	if _, err := service.Vote(ctx, voterID, postID, polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID, poll.Options[2].ID}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var closedEvents []events.PollClosed
+	eventBus.Subscribe(events.PollClosed{}.Type(), func(ctx context.Context, event events.Event) error {
+		closedEvents = append(closedEvents, event.(events.PollClosed))
+		return nil
+	})
+
+	repo.polls[poll.ID].EndsAt = time.Now().Add(-time.Minute)
+	count, err := service.CloseDuePolls(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || len(closedEvents) != 1 || closedEvents[0].AuthorID != authorID {
+		t.Errorf("expected one PollClosed event for the author, got %d closed and %+v", count, closedEvents)
+	}
+	if count, _ := service.CloseDuePolls(ctx, time.Now(), 10); count != 0 {
+		t.Errorf("expected closed polls to be closed once, got %d", count)
+	}
+
+	final, _ := service.Get(ctx, postID, 0)
+	if !final.Closed || final.TotalVoters == nil || *final.TotalVoters != 1 || *final.Options[2].Votes != 1 {
+		t.Errorf("expected final tallies for everyone, got %+v", final)
+	}
+
+	if _, err := service.Vote(ctx, otherID, postID, polls.VoteRequest{OptionIDs: []uint{poll.Options[0].ID}}); !errors.Is(err, domain.ErrPollClosed) {
+		t.Errorf("expected ErrPollClosed when voting, got %v", err)
+	}
+	if err := service.Retract(ctx, voterID, postID); !errors.Is(err, domain.ErrPollClosed) {
+		t.Errorf("expected ErrPollClosed when retracting, got %v", err)
+	}
+}
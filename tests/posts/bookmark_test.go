@@ -0,0 +1,88 @@
+package posts_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/posts"
+)
+
+type mockBookmarkRepository struct {
+	bookmarked map[domain.UserID][]domain.PostID
+}
+
+func (m *mockBookmarkRepository) BookmarkedPostIDs(ctx context.Context, userID domain.UserID, postIDs []domain.PostID) ([]domain.PostID, error) {
+	var result []domain.PostID
+	for _, id := range postIDs {
+		if slices.Contains(m.bookmarked[userID], id) {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func newBookmarkService() (*posts.Service, *mockBookmarkRepository) {
+	userRepo := &mockUserRepository{users: map[domain.UserID]*domain.User{
+		1: {ID: 1, Username: "ada"},
+		2: {ID: 2, Username: "grace"},
+	}}
+	bookmarkRepo := &mockBookmarkRepository{bookmarked: map[domain.UserID][]domain.PostID{}}
+	service := posts.NewService(&mockRepository{}, userRepo, nil, nil, nil, bookmarkRepo, events.NewInMemoryEventBus(), nil)
+	return service, bookmarkRepo
+}
+
+func TestService_FlagsBookmarkedPosts(t *testing.T) {
+	service, bookmarkRepo := newBookmarkService()
+	ctx := context.Background()
+
+	original, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+	repost, err := service.Repost(ctx, original.ID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bookmarkRepo.bookmarked[2] = []domain.PostID{domain.PostID(original.ID)}
+
+	got, err := service.GetByID(ctx, original.ID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Bookmarked {
+		t.Error("expected the post flagged as bookmarked for its bookmarker")
+	}
+
+	got, _ = service.GetByID(ctx, repost.ID, 2)
+	if got.Bookmarked || !got.RepostOf.Post.Bookmarked {
+		t.Errorf("expected only the embedded original flagged, got %v and %v", got.Bookmarked, got.RepostOf.Post.Bookmarked)
+	}
+
+	for _, viewerID := range []uint{0, 1} {
+		if got, _ := service.GetByID(ctx, original.ID, viewerID); got.Bookmarked {
+			t.Errorf("expected no flag for viewer %d", viewerID)
+		}
+	}
+}
+
+func TestService_GetByIDs(t *testing.T) {
+	service, _ := newBookmarkService()
+	ctx := context.Background()
+
+	first, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "First", Content: "Public"})
+	hidden, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Second", Content: "Private", Visibility: "private"})
+	third, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Third", Content: "Public"})
+
+	got, err := service.GetByIDs(ctx, []uint{third.ID, hidden.ID, 99, first.ID}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []uint
+	for _, post := range got {
+		ids = append(ids, post.ID)
+	}
+	if want := []uint{third.ID, first.ID}; !slices.Equal(ids, want) {
+		t.Errorf("expected readable posts %v in the given order, got %v", want, ids)
+	}
+}
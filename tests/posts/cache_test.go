@@ -0,0 +1,56 @@
+package posts_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/posts"
+)
+
+func TestCachedRepository_InvalidatedByEvents(t *testing.T) {
+	inner := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Original", Content: "Content", UserID: 1},
+		},
+	}
+	c := cache.NewLRU(10)
+	repo := posts.NewCachedRepository(inner, c, time.Minute)
+	eventBus := events.NewInMemoryEventBus()
+	posts.RegisterCacheInvalidation(eventBus, c)
+	ctx := context.Background()
+
+	if _, err := repo.GetByID(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A write that bypasses the cached repository is not seen until invalidated
+	inner.posts[1] = &posts.Model{ID: 1, Title: "Changed", Content: "Content", UserID: 1}
+	post, _ := repo.GetByID(ctx, 1)
+	if post.Title != "Original" {
+		t.Fatalf("expected cached title, got %q", post.Title)
+	}
+
+	_ = eventBus.Publish(ctx, events.PostUpdated{PostID: 1, UserID: 1, Title: "Changed"})
+	post, _ = repo.GetByID(ctx, 1)
+	if post.Title != "Changed" {
+		t.Errorf("expected fresh title after PostUpdated, got %q", post.Title)
+	}
+
+	// Writes through the cached repository invalidate immediately
+	post.Title = "Edited"
+	if err := repo.Update(ctx, post); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	post, _ = repo.GetByID(ctx, 1)
+	if post.Title != "Edited" {
+		t.Errorf("expected title after update, got %q", post.Title)
+	}
+
+	_ = repo.Delete(ctx, 1, nil)
+	if _, err := repo.GetByID(ctx, 1); err == nil {
+		t.Errorf("expected deleted post not to be served from cache")
+	}
+}
@@ -0,0 +1,41 @@
+package posts_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/urdogan0000/social/posts"
+)
+
+func TestService_CommentSettings(t *testing.T) {
+	service, _, _ := newRepostService()
+	ctx := context.Background()
+
+	post, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.CommentStatus != "open" || post.ReplyAudience != "everyone" {
+		t.Errorf("expected open/everyone by default, got %s/%s", post.CommentStatus, post.ReplyAudience)
+	}
+
+	locked := "locked"
+	updated, err := service.Update(ctx, post.ID, 1, posts.UpdateRequest{CommentStatus: &locked}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.CommentStatus != "locked" || updated.ReplyAudience != "everyone" {
+		t.Errorf("expected locked/everyone, got %s/%s", updated.CommentStatus, updated.ReplyAudience)
+	}
+	if updated.Edited {
+		t.Error("expected comment settings not to mark the post edited")
+	}
+
+	restricted, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Ask", Content: "Followers only", ReplyAudience: "followers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restricted.ReplyAudience != "followers" {
+		t.Errorf("expected followers, got %s", restricted.ReplyAudience)
+	}
+}
@@ -0,0 +1,129 @@
+package posts_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/posts"
+)
+
+type mockMentionRepository struct {
+	mentioned map[domain.MentionSource][]domain.UserID
+}
+
+func (m *mockMentionRepository) Replace(ctx context.Context, source domain.MentionSource, userIDs []domain.UserID) error {
+	if m.mentioned == nil {
+		m.mentioned = make(map[domain.MentionSource][]domain.UserID)
+	}
+	m.mentioned[source] = userIDs
+	return nil
+}
+
+// newMentionService returns a service whose users are ada (1), bob (2) and
+// carol (3), and records the users notified of mentions
+func newMentionService() (*posts.Service, *mockMentionRepository, *[]domain.UserID) {
+	userRepo := &mockUserRepository{users: map[domain.UserID]*domain.User{
+		1: {ID: 1, Username: "ada"},
+		2: {ID: 2, Username: "bob"},
+		3: {ID: 3, Username: "carol"},
+	}}
+	mentionRepo := &mockMentionRepository{}
+	eventBus := events.NewInMemoryEventBus()
+	var notified []domain.UserID
+	eventBus.Subscribe(events.UserMentioned{}.Type(), func(ctx context.Context, event events.Event) error {
+		notified = append(notified, event.(events.UserMentioned).UserID)
+		return nil
+	})
+	service := posts.NewService(&mockRepository{}, userRepo, nil, mentionRepo, nil, nil, eventBus, nil)
+	return service, mentionRepo, &notified
+}
+
+func TestService_Create_Mentions(t *testing.T) {
+	service, mentionRepo, notified := newMentionService()
+
+	post, err := service.Create(context.Background(), 1, posts.CreateRequest{
+		Title:   "Hello",
+		Content: "hi @bob, @nobody and me @ada",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []posts.Mention{
+		{UserID: 2, Username: "bob", Start: 3, End: 7},
+		{UserID: 1, Username: "ada", Start: 24, End: 28},
+	}
+	if !slices.Equal(post.Mentions, want) {
+		t.Errorf("expected mentions %+v, got %+v", want, post.Mentions)
+	}
+	source := domain.MentionSource{PostID: domain.PostID(post.ID), AuthorID: 1}
+	if got := mentionRepo.mentioned[source]; !slices.Equal(got, []domain.UserID{2}) {
+		t.Errorf("expected bob indexed without the author, got %v", got)
+	}
+	if !slices.Equal(*notified, []domain.UserID{2}) {
+		t.Errorf("expected only bob notified, got %v", *notified)
+	}
+}
+
+func TestService_Update_NotifiesOnlyNewMentions(t *testing.T) {
+	service, _, notified := newMentionService()
+	ctx := context.Background()
+
+	post, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "hi @bob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Editing around an existing mention does not notify again
+	content := "hello again @bob"
+	if _, err := service.Update(ctx, post.ID, 1, posts.UpdateRequest{Content: &content}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(*notified, []domain.UserID{2}) {
+		t.Errorf("expected no notification for an edit without new mentions, got %v", *notified)
+	}
+
+	content = "hello again @bob and @carol"
+	updated, err := service.Update(ctx, post.ID, 1, posts.UpdateRequest{Content: &content}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(*notified, []domain.UserID{2, 3}) {
+		t.Errorf("expected only carol notified of the edit, got %v", *notified)
+	}
+	if len(updated.Mentions) != 2 || updated.Mentions[1].Start != 21 {
+		t.Errorf("expected the offsets of the new text, got %+v", updated.Mentions)
+	}
+}
+
+func TestService_Mentions_WaitForPublishing(t *testing.T) {
+	service, _, notified := newMentionService()
+	ctx := context.Background()
+
+	draft, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Draft", Content: "for @bob", Status: "draft"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*notified) != 0 {
+		t.Fatalf("expected no notification for a draft, got %v", *notified)
+	}
+
+	published := "published"
+	if _, err := service.Update(ctx, draft.ID, 1, posts.UpdateRequest{Status: &published}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(*notified, []domain.UserID{2}) {
+		t.Errorf("expected bob notified once the draft is published, got %v", *notified)
+	}
+
+	// A private post is not announced to users who cannot read it
+	if _, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Private", Content: "@carol", Visibility: "private"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(*notified, []domain.UserID{2}) {
+		t.Errorf("expected carol not notified of a private post, got %v", *notified)
+	}
+}
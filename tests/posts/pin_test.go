@@ -0,0 +1,82 @@
+package posts_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/posts"
+)
+
+func TestService_Pin(t *testing.T) {
+	service, _, _ := newRepostService()
+	ctx := context.Background()
+
+	post, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+
+	if _, err := service.Pin(ctx, post.ID, 2); !errors.Is(err, domain.ErrPostForbidden) {
+		t.Errorf("expected ErrPostForbidden for another user's post, got %v", err)
+	}
+
+	pinned, err := service.Pin(ctx, post.ID, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pinned.PinnedAt == nil {
+		t.Fatal("expected pinned_at set")
+	}
+
+	// Editing the post keeps it pinned
+	content := "Edited"
+	updated, err := service.Update(ctx, post.ID, 1, posts.UpdateRequest{Content: &content}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.PinnedAt == nil || *updated.PinnedAt != *pinned.PinnedAt {
+		t.Errorf("expected the pin kept across edits, got %v", updated.PinnedAt)
+	}
+
+	if err := service.Unpin(ctx, post.ID, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := service.GetByID(ctx, post.ID, 1); got.PinnedAt != nil {
+		t.Error("expected the post unpinned")
+	}
+}
+
+func TestService_Pin_Limit(t *testing.T) {
+	service, _, _ := newRepostService()
+	ctx := context.Background()
+
+	for i := 0; i < domain.MaxPinnedPosts; i++ {
+		post, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Pinned", Content: "Post"})
+		if _, err := service.Pin(ctx, post.ID, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Pinning a pinned post again does not count twice
+		if _, err := service.Pin(ctx, post.ID, 1); err != nil {
+			t.Fatalf("expected pinning again to succeed, got %v", err)
+		}
+	}
+
+	extra, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "One", Content: "Too many"})
+	if _, err := service.Pin(ctx, extra.ID, 1); !errors.Is(err, domain.ErrPinLimit) {
+		t.Errorf("expected ErrPinLimit, got %v", err)
+	}
+
+	other, _ := service.Create(ctx, 2, posts.CreateRequest{Title: "Other", Content: "Author"})
+	if _, err := service.Pin(ctx, other.ID, 2); err != nil {
+		t.Errorf("expected the limit to be per author, got %v", err)
+	}
+}
+
+func TestService_Pin_Draft(t *testing.T) {
+	service, _, _ := newRepostService()
+	ctx := context.Background()
+
+	draft, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Draft", Content: "Post", Status: "draft"})
+	if _, err := service.Pin(ctx, draft.ID, 1); !errors.Is(err, domain.ErrPostNotPinnable) {
+		t.Errorf("expected ErrPostNotPinnable, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package posts_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/posts"
+)
+
+func newRepostService() (*posts.Service, *mockRepository, *mockFollowRepository) {
+	repo := &mockRepository{}
+	userRepo := &mockUserRepository{users: map[domain.UserID]*domain.User{
+		1: {ID: 1, Username: "ada"},
+		2: {ID: 2, Username: "grace"},
+		3: {ID: 3, Username: "linus"},
+	}}
+	followRepo := &mockFollowRepository{follows: map[[2]domain.UserID]bool{}}
+	service := posts.NewService(repo, userRepo, followRepo, nil, nil, nil, events.NewInMemoryEventBus(), nil)
+	return service, repo, followRepo
+}
+
+func TestService_Repost(t *testing.T) {
+	service, repo, _ := newRepostService()
+	ctx := context.Background()
+
+	original, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repost, err := service.Repost(ctx, original.ID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repost.UserID != 2 || repost.RepostOf == nil || repost.RepostOf.Post == nil || repost.RepostOf.Post.ID != original.ID {
+		t.Fatalf("expected a repost of post %d by user 2, got %+v", original.ID, repost)
+	}
+	if count := repo.posts[original.ID].RepostCount; count != 1 {
+		t.Errorf("expected the original to count 1 repost, got %d", count)
+	}
+
+	if _, err := service.Repost(ctx, original.ID, 2); !errors.Is(err, domain.ErrAlreadyReposted) {
+		t.Errorf("expected ErrAlreadyReposted, got %v", err)
+	}
+
+	// Reposting a repost shares the original
+	again, err := service.Repost(ctx, repost.ID, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.RepostOf.ID != original.ID {
+		t.Errorf("expected the repost to share post %d, got %d", original.ID, again.RepostOf.ID)
+	}
+	if count := repo.posts[original.ID].RepostCount; count != 2 {
+		t.Errorf("expected the original to count 2 reposts, got %d", count)
+	}
+
+	if _, err := service.Update(ctx, repost.ID, 2, posts.UpdateRequest{Content: &original.Content}, nil); !errors.Is(err, domain.ErrRepostNotEditable) {
+		t.Errorf("expected ErrRepostNotEditable, got %v", err)
+	}
+}
+
+func TestService_Repost_RespectsVisibility(t *testing.T) {
+	service, _, followRepo := newRepostService()
+	ctx := context.Background()
+
+	original, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "Friends only", Visibility: "followers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := service.Repost(ctx, original.ID, 2); !errors.Is(err, domain.ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound for a post the user may not read, got %v", err)
+	}
+
+	followRepo.follows[[2]domain.UserID{2, 1}] = true
+	if _, err := service.Repost(ctx, original.ID, 2); !errors.Is(err, domain.ErrPostNotShareable) {
+		t.Errorf("expected ErrPostNotShareable for a followers-only post, got %v", err)
+	}
+	if _, err := service.Create(ctx, 2, posts.CreateRequest{Title: "Look", Content: "At this", QuoteOfID: &original.ID}); !errors.Is(err, domain.ErrPostNotShareable) {
+		t.Errorf("expected ErrPostNotShareable when quoting a followers-only post, got %v", err)
+	}
+
+	own, err := service.Repost(ctx, original.ID, 1)
+	if err != nil {
+		t.Fatalf("expected authors to repost their own posts, got %v", err)
+	}
+	if own.Visibility != string(domain.VisibilityFollowers) {
+		t.Errorf("expected the repost to keep the original's visibility, got %q", own.Visibility)
+	}
+}
+
+func TestService_Unrepost(t *testing.T) {
+	service, repo, _ := newRepostService()
+	ctx := context.Background()
+
+	original, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+	repost, err := service.Repost(ctx, original.ID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.Unrepost(ctx, original.ID, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repo.posts[repost.ID]; ok {
+		t.Error("expected the repost to be deleted")
+	}
+	if count := repo.posts[original.ID].RepostCount; count != 0 {
+		t.Errorf("expected the repost count back at 0, got %d", count)
+	}
+
+	if err := service.Unrepost(ctx, original.ID, 2); !errors.Is(err, domain.ErrRepostNotFound) {
+		t.Errorf("expected ErrRepostNotFound, got %v", err)
+	}
+}
+
+func TestService_Delete_LeavesQuotesUnavailable(t *testing.T) {
+	service, repo, _ := newRepostService()
+	ctx := context.Background()
+
+	original, _ := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "World"})
+	quote, err := service.Create(ctx, 2, posts.CreateRequest{Title: "Look", Content: "At this", QuoteOfID: &original.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.QuoteOf == nil || quote.QuoteOf.Unavailable || quote.QuoteOf.Post.ID != original.ID {
+		t.Fatalf("expected the quote to embed post %d, got %+v", original.ID, quote.QuoteOf)
+	}
+	if count := repo.posts[original.ID].QuoteCount; count != 1 {
+		t.Errorf("expected the original to count 1 quote, got %d", count)
+	}
+	repost, err := service.Repost(ctx, original.ID, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := service.Delete(ctx, original.ID, 1, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := repo.posts[repost.ID]; ok {
+		t.Error("expected the repost to be deleted with the original")
+	}
+	got, err := service.GetByID(ctx, quote.ID, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.QuoteOf == nil || !got.QuoteOf.Unavailable || got.QuoteOf.ID != original.ID || got.QuoteOf.Post != nil {
+		t.Errorf("expected an unavailable stub for post %d, got %+v", original.ID, got.QuoteOf)
+	}
+}
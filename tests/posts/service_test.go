@@ -3,16 +3,21 @@ package posts_test
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/metrics"
 	"github.com/urdogan0000/social/posts"
 )
 
 type mockRepository struct {
 	posts      map[uint]*posts.Model
+	revisions  map[uint][]posts.RevisionModel
 	createErr  error
 	getByIDErr error
 	updateErr  error
@@ -41,10 +46,18 @@ func (m *mockRepository) GetByID(ctx context.Context, id uint) (*posts.Model, er
 	return nil, posts.ErrNotFound
 }
 
-func (m *mockRepository) GetByUserID(ctx context.Context, userID uint, limit, offset int) ([]posts.Model, error) {
+// listable mirrors posts.ListableBy for the mock, ignoring followers
+func listable(post *posts.Model, viewerID uint) bool {
+	if post.Status != "" && post.Status != string(domain.PostStatusPublished) {
+		return false
+	}
+	return post.Visibility == "" || post.Visibility == string(domain.VisibilityPublic) || (viewerID != 0 && post.UserID == viewerID)
+}
+
+func (m *mockRepository) GetByUserID(ctx context.Context, userID, viewerID uint, limit, offset int) ([]posts.Model, error) {
 	var result []posts.Model
 	for _, post := range m.posts {
-		if post.UserID == userID {
+		if post.UserID == userID && listable(post, viewerID) {
 			result = append(result, *post)
 		}
 	}
@@ -55,47 +68,64 @@ func (m *mockRepository) Update(ctx context.Context, post *posts.Model) error {
 	if m.updateErr != nil {
 		return m.updateErr
 	}
-	if _, ok := m.posts[post.ID]; !ok {
+	stored, ok := m.posts[post.ID]
+	if !ok {
 		return posts.ErrNotFound
 	}
+	if stored.Version != post.Version {
+		return domain.ErrVersionMismatch
+	}
+	post.Version++
 	m.posts[post.ID] = post
 	return nil
 }
 
-func (m *mockRepository) Delete(ctx context.Context, id uint) error {
+func (m *mockRepository) Delete(ctx context.Context, id uint, version *uint) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
 	}
-	if _, ok := m.posts[id]; !ok {
+	post, ok := m.posts[id]
+	if !ok {
 		return posts.ErrNotFound
 	}
+	if version != nil && post.Version != *version {
+		return domain.ErrVersionMismatch
+	}
 	delete(m.posts, id)
 	return nil
 }
 
-func (m *mockRepository) List(ctx context.Context, limit, offset int) ([]posts.Model, error) {
+func (m *mockRepository) List(ctx context.Context, viewerID uint, limit, offset int) ([]posts.Model, error) {
 	var result []posts.Model
 	for _, post := range m.posts {
-		result = append(result, *post)
+		if listable(post, viewerID) {
+			result = append(result, *post)
+		}
 	}
 	return result, nil
 }
 
-func (m *mockRepository) Count(ctx context.Context) (int64, error) {
-	return int64(len(m.posts)), nil
+func (m *mockRepository) Count(ctx context.Context, viewerID uint) (int64, error) {
+	count := int64(0)
+	for _, post := range m.posts {
+		if listable(post, viewerID) {
+			count++
+		}
+	}
+	return count, nil
 }
 
-func (m *mockRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+func (m *mockRepository) CountByUserID(ctx context.Context, userID, viewerID uint) (int64, error) {
 	count := int64(0)
 	for _, post := range m.posts {
-		if post.UserID == userID {
+		if post.UserID == userID && listable(post, viewerID) {
 			count++
 		}
 	}
 	return count, nil
 }
 
-func (m *mockRepository) SearchByTitle(ctx context.Context, title string, limit, offset int) ([]posts.Model, error) {
+func (m *mockRepository) SearchByTitle(ctx context.Context, title string, viewerID uint, limit, offset int) ([]posts.Model, error) {
 	var result []posts.Model
 	titleLower := strings.ToLower(title)
 	for _, post := range m.posts {
@@ -106,7 +136,7 @@ func (m *mockRepository) SearchByTitle(ctx context.Context, title string, limit,
 	return result, nil
 }
 
-func (m *mockRepository) GetByTags(ctx context.Context, tags []string, limit, offset int) ([]posts.Model, error) {
+func (m *mockRepository) GetByTags(ctx context.Context, tags []string, viewerID uint, limit, offset int) ([]posts.Model, error) {
 	var result []posts.Model
 	for _, post := range m.posts {
 		for _, tag := range tags {
@@ -121,6 +151,144 @@ func (m *mockRepository) GetByTags(ctx context.Context, tags []string, limit, of
 	return result, nil
 }
 
+func (m *mockRepository) CountByTags(ctx context.Context, tags []string, viewerID uint) (int64, error) {
+	result, _ := m.GetByTags(ctx, tags, viewerID, 0, 0)
+	return int64(len(result)), nil
+}
+
+func (m *mockRepository) GetDraftsByUserID(ctx context.Context, userID uint, limit, offset int) ([]posts.Model, error) {
+	var result []posts.Model
+	for _, post := range m.posts {
+		if post.UserID == userID && post.Status != string(domain.PostStatusPublished) {
+			result = append(result, *post)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) CountDraftsByUserID(ctx context.Context, userID uint) (int64, error) {
+	drafts, _ := m.GetDraftsByUserID(ctx, userID, 0, 0)
+	return int64(len(drafts)), nil
+}
+
+func (m *mockRepository) PublishDue(ctx context.Context, now time.Time, batchSize int) ([]posts.Model, error) {
+	var result []posts.Model
+	for _, post := range m.posts {
+		if post.Status == string(domain.PostStatusScheduled) && post.PublishAt != nil && !post.PublishAt.After(now) {
+			post.Status = string(domain.PostStatusPublished)
+			result = append(result, *post)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) CreateRevision(ctx context.Context, revision *posts.RevisionModel) error {
+	if m.revisions == nil {
+		m.revisions = make(map[uint][]posts.RevisionModel)
+	}
+	revision.Number = len(m.revisions[revision.PostID]) + 1
+	m.revisions[revision.PostID] = append(m.revisions[revision.PostID], *revision)
+	return nil
+}
+
+func (m *mockRepository) GetRevisions(ctx context.Context, postID uint, limit, offset int) ([]posts.RevisionModel, error) {
+	var result []posts.RevisionModel
+	for _, revision := range slices.Backward(m.revisions[postID]) {
+		result = append(result, revision)
+	}
+	return result, nil
+}
+
+func (m *mockRepository) CountRevisions(ctx context.Context, postID uint) (int64, error) {
+	return int64(len(m.revisions[postID])), nil
+}
+
+func (m *mockRepository) GetRevision(ctx context.Context, postID uint, number int) (*posts.RevisionModel, error) {
+	revisions := m.revisions[postID]
+	if number < 1 || number > len(revisions) {
+		return nil, posts.ErrRevisionNotFound
+	}
+	return &revisions[number-1], nil
+}
+
+func (m *mockRepository) CreateRepost(ctx context.Context, repost *posts.Model) error {
+	for _, post := range m.posts {
+		if post.RepostOfID != nil && *post.RepostOfID == *repost.RepostOfID && post.UserID == repost.UserID {
+			return posts.ErrAlreadyReposted
+		}
+	}
+	return m.Create(ctx, repost)
+}
+
+func (m *mockRepository) GetRepost(ctx context.Context, userID, postID uint) (*posts.Model, error) {
+	for _, post := range m.posts {
+		if post.RepostOfID != nil && *post.RepostOfID == postID && post.UserID == userID {
+			return post, nil
+		}
+	}
+	return nil, posts.ErrRepostNotFound
+}
+
+func (m *mockRepository) GetByIDs(ctx context.Context, ids []uint) ([]posts.Model, error) {
+	var result []posts.Model
+	for _, id := range ids {
+		if post, ok := m.posts[id]; ok {
+			result = append(result, *post)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) AdjustShareCounts(ctx context.Context, id uint, reposts, quotes int) error {
+	if post, ok := m.posts[id]; ok {
+		post.RepostCount += reposts
+		post.QuoteCount += quotes
+	}
+	return nil
+}
+
+func (m *mockRepository) DeleteReposts(ctx context.Context, postID uint) ([]uint, error) {
+	var ids []uint
+	for id, post := range m.posts {
+		if post.RepostOfID != nil && *post.RepostOfID == postID {
+			ids = append(ids, id)
+			delete(m.posts, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockRepository) Pin(ctx context.Context, id, userID uint, now time.Time, limit int) error {
+	pinned := 0
+	for _, post := range m.posts {
+		if post.UserID == userID && post.PinnedAt != nil && post.ID != id {
+			pinned++
+		}
+	}
+	if pinned >= limit {
+		return posts.ErrPinLimit
+	}
+	if post, ok := m.posts[id]; ok && post.PinnedAt == nil {
+		post.PinnedAt = &now
+	}
+	return nil
+}
+
+func (m *mockRepository) Unpin(ctx context.Context, id uint) error {
+	if post, ok := m.posts[id]; ok {
+		post.PinnedAt = nil
+	}
+	return nil
+}
+
+type mockFollowRepository struct {
+	follows map[[2]domain.UserID]bool
+}
+
+func (m *mockFollowRepository) IsFollowing(ctx context.Context, followerID, followeeID domain.UserID) (bool, error) {
+	return m.follows[[2]domain.UserID{followerID, followeeID}], nil
+}
+
 type mockUserRepository struct {
 	users map[domain.UserID]*domain.User
 }
@@ -132,6 +300,16 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id domain.UserID) (*do
 	return nil, domain.ErrUserNotFound
 }
 
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []domain.UserID) ([]*domain.User, error) {
+	var result []*domain.User
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			result = append(result, user)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockUserRepository) Exists(ctx context.Context, id domain.UserID) (bool, error) {
 	_, ok := m.users[id]
 	return ok, nil
@@ -210,7 +388,7 @@ func TestService_Create(t *testing.T) {
 				}
 			}
 			eventBus := events.NewInMemoryEventBus()
-			service := posts.NewService(repo, userRepo, eventBus, nil)
+			service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 			ctx := context.Background()
 			result, err := service.Create(ctx, tt.userID, tt.req)
@@ -245,10 +423,10 @@ func TestService_GetByID(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
-	post, err := service.GetByID(ctx, 1)
+	post, err := service.GetByID(ctx, 1, 0)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -260,7 +438,7 @@ func TestService_GetByID(t *testing.T) {
 		t.Errorf("expected post ID 1, got %d", post.ID)
 	}
 
-	_, err = service.GetByID(ctx, 999)
+	_, err = service.GetByID(ctx, 999, 0)
 	if err == nil {
 		t.Errorf("expected error for non-existent post")
 	}
@@ -277,14 +455,14 @@ func TestService_Update(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
 	newTitle := "Updated Title"
 	req := posts.UpdateRequest{Title: &newTitle}
 
 	// Test successful update by owner
-	post, err := service.Update(ctx, 1, 1, req)
+	post, err := service.Update(ctx, 1, 1, req, nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -296,7 +474,7 @@ func TestService_Update(t *testing.T) {
 	}
 
 	// Test forbidden - different user
-	_, err = service.Update(ctx, 1, 2, req)
+	_, err = service.Update(ctx, 1, 2, req, nil)
 	if err == nil {
 		t.Errorf("expected error for forbidden update")
 	}
@@ -313,19 +491,19 @@ func TestService_Delete(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
 
 	// Test successful delete by owner
-	err := service.Delete(ctx, 1, 1)
+	err := service.Delete(ctx, 1, 1, nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 
 	// Test forbidden - different user
 	repo.posts[1] = &posts.Model{ID: 1, Title: "Test", Content: "Content", UserID: 1}
-	err = service.Delete(ctx, 1, 2)
+	err = service.Delete(ctx, 1, 2, nil)
 	if err == nil {
 		t.Errorf("expected error for forbidden delete")
 	}
@@ -344,10 +522,10 @@ func TestService_List(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
-	result, err := service.List(ctx, 10, 0)
+	result, err := service.List(ctx, 0, 10, 0)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -373,10 +551,10 @@ func TestService_GetByUserID(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
-	result, err := service.GetByUserID(ctx, 1, 10, 0)
+	result, err := service.GetByUserID(ctx, 1, 0, 10, 0)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -402,10 +580,10 @@ func TestService_SearchByTitle(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
-	results, err := service.SearchByTitle(ctx, "Golang", 10, 0)
+	results, err := service.SearchByTitle(ctx, "Golang", 0, 10, 0)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -425,10 +603,10 @@ func TestService_GetByTags(t *testing.T) {
 	}
 	userRepo := &mockUserRepository{}
 	eventBus := events.NewInMemoryEventBus()
-	service := posts.NewService(repo, userRepo, eventBus, nil)
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
 
 	ctx := context.Background()
-	results, err := service.GetByTags(ctx, []string{"golang"}, 10, 0)
+	results, err := service.GetByTags(ctx, []string{"golang"}, 0, 10, 0)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -438,3 +616,313 @@ func TestService_GetByTags(t *testing.T) {
 	}
 }
 
+
+func TestService_GetByID_Visibility(t *testing.T) {
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Unlisted", Content: "Content", UserID: 1, Visibility: "unlisted"},
+			2: {ID: 2, Title: "Followers", Content: "Content", UserID: 1, Visibility: "followers"},
+			3: {ID: 3, Title: "Private", Content: "Content", UserID: 1, Visibility: "private"},
+		},
+	}
+	followRepo := &mockFollowRepository{
+		follows: map[[2]domain.UserID]bool{{2, 1}: true},
+	}
+	eventBus := events.NewInMemoryEventBus()
+	service := posts.NewService(repo, &mockUserRepository{}, followRepo, nil, nil, nil, eventBus, nil)
+
+	tests := []struct {
+		name     string
+		postID   uint
+		viewerID uint
+		visible  bool
+	}{
+		{"unlisted anonymous", 1, 0, true},
+		{"followers anonymous", 2, 0, false},
+		{"followers by follower", 2, 2, true},
+		{"followers by stranger", 2, 3, false},
+		{"private by author", 3, 1, true},
+		{"private by follower", 3, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetByID(context.Background(), tt.postID, tt.viewerID)
+			if tt.visible && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.visible && !errors.Is(err, posts.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestService_List_ExcludesHiddenPosts(t *testing.T) {
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Public", Content: "Content", UserID: 1, Visibility: "public"},
+			2: {ID: 2, Title: "Unlisted", Content: "Content", UserID: 1, Visibility: "unlisted"},
+			3: {ID: 3, Title: "Private", Content: "Content", UserID: 1, Visibility: "private"},
+		},
+	}
+	eventBus := events.NewInMemoryEventBus()
+	service := posts.NewService(repo, &mockUserRepository{}, nil, nil, nil, nil, eventBus, nil)
+
+	ctx := context.Background()
+	result, err := service.List(ctx, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("expected 1 post for anonymous viewer, got %d", result.Total)
+	}
+
+	result, err = service.List(ctx, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Total != 3 {
+		t.Errorf("expected author to see 3 posts, got %d", result.Total)
+	}
+}
+
+func TestService_Create_DraftDoesNotPublishEvent(t *testing.T) {
+	repo := &mockRepository{}
+	userRepo := &mockUserRepository{
+		users: map[domain.UserID]*domain.User{1: {ID: 1}},
+	}
+	eventBus := events.NewInMemoryEventBus()
+	created := 0
+	eventBus.Subscribe(events.PostCreated{}.Type(), func(ctx context.Context, event events.Event) error {
+		created++
+		return nil
+	})
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, eventBus, nil)
+
+	ctx := context.Background()
+	draft, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Draft", Content: "Content", Status: "draft"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if draft.Status != "draft" {
+		t.Errorf("expected status draft, got %q", draft.Status)
+	}
+	if created != 0 {
+		t.Errorf("expected no PostCreated for a draft, got %d", created)
+	}
+
+	if _, err := service.GetByID(ctx, draft.ID, 2); !errors.Is(err, posts.ErrNotFound) {
+		t.Errorf("expected draft to be hidden from other users, got %v", err)
+	}
+
+	drafts, err := service.GetDrafts(ctx, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if drafts.Total != 1 {
+		t.Errorf("expected 1 draft, got %d", drafts.Total)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if _, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Late", Content: "Content", PublishAt: &past}); !errors.Is(err, domain.ErrInvalidPublishAt) {
+		t.Errorf("expected ErrInvalidPublishAt for past publish time, got %v", err)
+	}
+}
+
+func TestService_PostsCreatedCountsPublishedPosts(t *testing.T) {
+	repo := &mockRepository{}
+	userRepo := &mockUserRepository{
+		users: map[domain.UserID]*domain.User{1: {ID: 1}},
+	}
+	service := posts.NewService(repo, userRepo, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	before := testutil.ToFloat64(metrics.PostsCreated)
+
+	draft, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Draft", Content: "Content", Status: "draft"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.PostsCreated) - before; got != 0 {
+		t.Errorf("expected a saved draft not to be counted, got %v", got)
+	}
+
+	published := "published"
+	if _, err := service.Update(ctx, draft.ID, 1, posts.UpdateRequest{Status: &published}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.PostsCreated) - before; got != 1 {
+		t.Errorf("expected the published draft to be counted once, got %v", got)
+	}
+
+	// Editing a published post does not count it again
+	title := "Edited"
+	if _, err := service.Update(ctx, draft.ID, 1, posts.UpdateRequest{Title: &title}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.PostsCreated) - before; got != 1 {
+		t.Errorf("expected edits not to be counted, got %v", got)
+	}
+}
+
+func TestService_PublishDuePosts(t *testing.T) {
+	due := time.Now().Add(-time.Minute)
+	later := time.Now().Add(time.Hour)
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Due", Content: "Content", UserID: 1, Status: "scheduled", PublishAt: &due},
+			2: {ID: 2, Title: "Later", Content: "Content", UserID: 1, Status: "scheduled", PublishAt: &later},
+			3: {ID: 3, Title: "Draft", Content: "Content", UserID: 1, Status: "draft"},
+		},
+	}
+	eventBus := events.NewInMemoryEventBus()
+	var createdIDs []domain.PostID
+	eventBus.Subscribe(events.PostCreated{}.Type(), func(ctx context.Context, event events.Event) error {
+		createdIDs = append(createdIDs, event.(events.PostCreated).PostID)
+		return nil
+	})
+	service := posts.NewService(repo, &mockUserRepository{}, nil, nil, nil, nil, eventBus, nil)
+
+	ctx := context.Background()
+	before := testutil.ToFloat64(metrics.PostsCreated)
+	count, err := service.PublishDuePosts(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 published post, got %d", count)
+	}
+	if got := testutil.ToFloat64(metrics.PostsCreated) - before; got != 1 {
+		t.Errorf("expected the published post to be counted, got %v", got)
+	}
+	if len(createdIDs) != 1 || createdIDs[0] != 1 {
+		t.Errorf("expected PostCreated for post 1, got %v", createdIDs)
+	}
+
+	// A second run must not publish the same post again
+	count, err = service.PublishDuePosts(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 || len(createdIDs) != 1 {
+		t.Errorf("expected no further publishing, got count %d and events %v", count, createdIDs)
+	}
+}
+
+func TestService_Update_RecordsRevisions(t *testing.T) {
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Original", Content: "line one\nline two", UserID: 1},
+			2: {ID: 2, Title: "Draft", Content: "Content", UserID: 1, Status: "draft"},
+		},
+	}
+	service := posts.NewService(repo, &mockUserRepository{}, nil, nil, nil, nil, events.NewInMemoryEventBus(), nil)
+	ctx := context.Background()
+
+	content := "line one\nline 2"
+	post, err := service.Update(ctx, 1, 1, posts.UpdateRequest{Content: &content}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !post.Edited || post.EditedAt == nil {
+		t.Errorf("expected post to be marked as edited")
+	}
+
+	// The first edit stores the original text as well as the new one
+	list, err := service.GetRevisions(ctx, 1, 0, 20, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Total != 2 || list.Revisions[0].Number != 2 {
+		t.Fatalf("expected 2 revisions newest first, got %+v", list)
+	}
+
+	detail, err := service.GetRevision(ctx, 1, 2, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []posts.DiffLine{
+		{Op: "equal", Text: "line one"},
+		{Op: "delete", Text: "line two"},
+		{Op: "insert", Text: "line 2"},
+	}
+	if !slices.Equal(detail.ContentDiff, want) {
+		t.Errorf("expected diff %v, got %v", want, detail.ContentDiff)
+	}
+
+	// Restoring is itself recorded as a new revision
+	restored, err := service.RestoreRevision(ctx, 1, 1, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.Content != "line one\nline two" {
+		t.Errorf("expected original content, got %q", restored.Content)
+	}
+	if count, _ := repo.CountRevisions(ctx, 1); count != 3 {
+		t.Errorf("expected 3 revisions after restore, got %d", count)
+	}
+
+	if _, err := service.RestoreRevision(ctx, 1, 1, 2, nil); !errors.Is(err, posts.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for restore by another user, got %v", err)
+	}
+	if _, err := service.GetRevision(ctx, 1, 9, 0); !errors.Is(err, posts.ErrRevisionNotFound) {
+		t.Errorf("expected ErrRevisionNotFound, got %v", err)
+	}
+
+	// Drafts are edited freely without history
+	title := "Draft v2"
+	if _, err := service.Update(ctx, 2, 1, posts.UpdateRequest{Title: &title}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count, _ := repo.CountRevisions(ctx, 2); count != 0 {
+		t.Errorf("expected no revisions for a draft, got %d", count)
+	}
+}
+
+func TestService_Update_Version(t *testing.T) {
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Original", Content: "Content", UserID: 1, Status: "draft", Version: 1},
+		},
+	}
+	service := posts.NewService(repo, &mockUserRepository{}, nil, nil, nil, nil, events.NewInMemoryEventBus(), nil)
+	ctx := context.Background()
+
+	title := "Updated"
+	stale := uint(1)
+	post, err := service.Update(ctx, 1, 1, posts.UpdateRequest{Title: &title}, &stale)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", post.Version)
+	}
+
+	// A second client still holding version 1 must not overwrite the change
+	if _, err := service.Update(ctx, 1, 1, posts.UpdateRequest{Title: &title}, &stale); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected conflict for stale version, got %v", err)
+	}
+	if err := service.Delete(ctx, 1, 1, &stale); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for stale delete, got %v", err)
+	}
+}
+
+func TestService_Update_ConflictRecordsNoRevision(t *testing.T) {
+	repo := &mockRepository{
+		posts: map[uint]*posts.Model{
+			1: {ID: 1, Title: "Original", Content: "Content", UserID: 1},
+		},
+		updateErr: domain.ErrVersionMismatch,
+	}
+	service := posts.NewService(repo, &mockUserRepository{}, nil, nil, nil, nil, events.NewInMemoryEventBus(), nil)
+	ctx := context.Background()
+
+	// A concurrent edit won the race, so this one fails before any revision
+	content := "Edited"
+	if _, err := service.Update(ctx, 1, 1, posts.UpdateRequest{Content: &content}, nil); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if count, _ := repo.CountRevisions(ctx, 1); count != 0 {
+		t.Errorf("expected no revisions after a conflict, got %d", count)
+	}
+}
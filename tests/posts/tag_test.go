@@ -0,0 +1,107 @@
+package posts_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/posts"
+)
+
+type mockTagRepository struct {
+	tagged map[domain.PostID][]string
+}
+
+func (m *mockTagRepository) SetPostTags(ctx context.Context, postID domain.PostID, tags []string) error {
+	if m.tagged == nil {
+		m.tagged = make(map[domain.PostID][]string)
+	}
+	m.tagged[postID] = tags
+	return nil
+}
+
+func newTagService() (*posts.Service, *mockRepository, *mockTagRepository) {
+	repo := &mockRepository{}
+	userRepo := &mockUserRepository{users: map[domain.UserID]*domain.User{1: {ID: 1, Username: "ada"}}}
+	tagRepo := &mockTagRepository{}
+	service := posts.NewService(repo, userRepo, nil, nil, tagRepo, nil, events.NewInMemoryEventBus(), nil)
+	return service, repo, tagRepo
+}
+
+func TestService_Create_IndexesTags(t *testing.T) {
+	service, repo, tagRepo := newTagService()
+
+	post, err := service.Create(context.Background(), 1, posts.CreateRequest{
+		Title:   "Hello",
+		Content: "Writing some #Rust and more #GO",
+		Tags:    []string{"Go", " go", "#Tutorial"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stored := []string(repo.posts[post.ID].Tags); !slices.Equal(stored, []string{"go", "tutorial"}) {
+		t.Errorf("expected the explicit tags stored normalized, got %v", stored)
+	}
+	want := []string{"go", "tutorial", "rust"}
+	if got := tagRepo.tagged[domain.PostID(post.ID)]; !slices.Equal(got, want) {
+		t.Errorf("expected the post indexed under %v, got %v", want, got)
+	}
+	if !slices.Equal(post.Tags, want) {
+		t.Errorf("expected the response to list %v, got %v", want, post.Tags)
+	}
+}
+
+func TestService_Create_RejectsInvalidTags(t *testing.T) {
+	service, _, tagRepo := newTagService()
+
+	_, err := service.Create(context.Background(), 1, posts.CreateRequest{Title: "Hello", Content: "Content", Tags: []string{"not a tag"}})
+	if !errors.Is(err, domain.ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+	if len(tagRepo.tagged) != 0 {
+		t.Errorf("expected nothing indexed, got %v", tagRepo.tagged)
+	}
+}
+
+func TestService_Update_ReindexesHashtags(t *testing.T) {
+	service, _, tagRepo := newTagService()
+	ctx := context.Background()
+
+	post, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "about #go", Tags: []string{"news"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := "about #rust now"
+	if _, err := service.Update(ctx, post.ID, 1, posts.UpdateRequest{Content: &content}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := tagRepo.tagged[domain.PostID(post.ID)]; !slices.Equal(got, []string{"news", "rust"}) {
+		t.Errorf("expected the hashtags of the new content, got %v", got)
+	}
+}
+
+func TestService_GetByTags_NormalizesQuery(t *testing.T) {
+	service, _, _ := newTagService()
+	ctx := context.Background()
+	if _, err := service.Create(ctx, 1, posts.CreateRequest{Title: "Hello", Content: "Content", Tags: []string{"golang"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := service.GetByTags(ctx, []string{" #GoLang", "not a tag"}, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected the post found by its normalized tag, got %d posts", len(results))
+	}
+
+	results, err = service.GetByTags(ctx, []string{"not a tag"}, 0, 10, 0)
+	if err != nil || len(results) != 0 || results == nil {
+		t.Errorf("expected an empty list for invalid tags, got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,320 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/auth"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/rpc"
+	"github.com/urdogan0000/social/internal/rpc/socialv1"
+	"github.com/urdogan0000/social/users"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockUserRepo struct {
+	users map[uint]*users.Model
+}
+
+func (m *mockUserRepo) Create(ctx context.Context, user *users.Model) error { return nil }
+func (m *mockUserRepo) GetByID(ctx context.Context, id uint) (*users.Model, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, users.ErrNotFound
+}
+func (m *mockUserRepo) GetByIDs(ctx context.Context, ids []uint) ([]users.Model, error) {
+	return nil, nil
+}
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*users.Model, error) {
+	return nil, users.ErrNotFound
+}
+func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*users.Model, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, users.ErrNotFound
+}
+func (m *mockUserRepo) Update(ctx context.Context, user *users.Model) error      { return nil }
+func (m *mockUserRepo) Delete(ctx context.Context, id uint, version *uint) error { return nil }
+func (m *mockUserRepo) List(ctx context.Context, limit, offset int) ([]users.Model, error) {
+	return nil, nil
+}
+func (m *mockUserRepo) Count(ctx context.Context) (int64, error) { return 0, nil }
+
+type testEnv struct {
+	conn        *grpc.ClientConn
+	bus         events.EventBus
+	authService *auth.Service
+}
+
+// newEnv serves the real services over an in-memory listener. Posts and
+// comments have no service behind them; the tests only reach RPCs that fail
+// before calling one.
+func newEnv(t *testing.T) *testEnv {
+	t.Helper()
+	password, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	repo := &mockUserRepo{users: map[uint]*users.Model{
+		1: {ID: 1, Username: "alice", Email: "alice@example.com", Password: password, Role: users.RoleUser},
+		2: {ID: 2, Username: "root", Email: "root@example.com", Password: password, Role: users.RoleAdmin},
+	}}
+	authService := auth.NewService(repo, "test-secret", 24)
+	bus := events.NewInMemoryEventBus()
+	hub := rpc.NewEventHub(bus)
+
+	srv := rpc.NewServer(
+		authService,
+		rpc.NewUserServer(users.NewService(repo, bus, nil, nil), authService),
+		rpc.NewPostServer(nil, authService, hub),
+		rpc.NewCommentServer(nil, authService, hub),
+		rpc.NewAuthServer(authService),
+	)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Stop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &testEnv{conn: conn, bus: bus, authService: authService}
+}
+
+func (e *testEnv) token(t *testing.T, email string) string {
+	t.Helper()
+	resp, err := e.authService.Login(context.Background(), auth.LoginRequest{Email: email, Password: "password123"})
+	if err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	return resp.Token
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func errorReason(t *testing.T, err error) (codes.Code, string) {
+	t.Helper()
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info.GetReason()
+		}
+	}
+	return st.Code(), st.Message()
+}
+
+func TestAuth_ValidateToken(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewAuthServiceClient(env.conn)
+
+	resp, err := client.ValidateToken(context.Background(), &socialv1.ValidateTokenRequest{Token: env.token(t, "alice@example.com")})
+	if err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+	if resp.GetUserId() != 1 || resp.GetEmail() != "alice@example.com" {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	_, err = client.ValidateToken(context.Background(), &socialv1.ValidateTokenRequest{Token: "not-a-token"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected UNAUTHENTICATED, got %v", err)
+	}
+}
+
+func TestInterceptor_RejectsInvalidToken(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewUserServiceClient(env.conn)
+
+	_, err := client.GetUser(withToken("not-a-token"), &socialv1.GetUserRequest{Id: 1})
+	if code, reason := errorReason(t, err); code != codes.Unauthenticated || reason != "invalid_token" {
+		t.Errorf("expected UNAUTHENTICATED invalid_token, got %v %q", code, reason)
+	}
+}
+
+func TestErrors_MapDomainErrors(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewUserServiceClient(env.conn)
+
+	user, err := client.GetUser(context.Background(), &socialv1.GetUserRequest{Id: 1})
+	if err != nil {
+		t.Fatalf("expected the user, got %v", err)
+	}
+	if user.GetUsername() != "alice" {
+		t.Errorf("expected alice, got %v", user)
+	}
+
+	_, err = client.GetUser(context.Background(), &socialv1.GetUserRequest{Id: 99})
+	if code, reason := errorReason(t, err); code != codes.NotFound || reason != "user_not_found" {
+		t.Errorf("expected NOT_FOUND user_not_found, got %v %q", code, reason)
+	}
+}
+
+func TestErrors_ValidationListsFields(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewUserServiceClient(env.conn)
+
+	_, err := client.CreateUser(context.Background(), &socialv1.CreateUserRequest{Username: "bob", Email: "not-an-email", Password: "password123"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != "validation_failed" {
+		t.Fatalf("expected INVALID_ARGUMENT validation_failed, got %v", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != "email" {
+		t.Errorf("expected a violation for email, got %v", fields)
+	}
+}
+
+func TestSetUserRole_RequiresAdmin(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewUserServiceClient(env.conn)
+	req := &socialv1.SetUserRoleRequest{Id: 1, Role: users.RoleAdmin}
+
+	_, err := client.SetUserRole(context.Background(), req)
+	if code, reason := errorReason(t, err); code != codes.Unauthenticated || reason != "authorization_required" {
+		t.Errorf("expected UNAUTHENTICATED authorization_required, got %v %q", code, reason)
+	}
+
+	_, err = client.SetUserRole(withToken(env.token(t, "alice@example.com")), req)
+	if code, reason := errorReason(t, err); code != codes.PermissionDenied || reason != "admin_required" {
+		t.Errorf("expected PERMISSION_DENIED admin_required, got %v %q", code, reason)
+	}
+}
+
+func TestUpdateAndDeleteUser_RequireSelfOrAdmin(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewUserServiceClient(env.conn)
+	email := "changed@example.com"
+
+	_, err := client.UpdateUser(context.Background(), &socialv1.UpdateUserRequest{Id: 2, Email: &email})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Errorf("expected UNAUTHENTICATED, got %v", code)
+	}
+
+	alice := withToken(env.token(t, "alice@example.com"))
+	_, err = client.UpdateUser(alice, &socialv1.UpdateUserRequest{Id: 2, Email: &email})
+	if code, reason := errorReason(t, err); code != codes.PermissionDenied || reason != "forbidden" {
+		t.Errorf("expected PERMISSION_DENIED forbidden, got %v %q", code, reason)
+	}
+	_, err = client.DeleteUser(alice, &socialv1.DeleteUserRequest{Id: 2})
+	if code, reason := errorReason(t, err); code != codes.PermissionDenied || reason != "forbidden" {
+		t.Errorf("expected PERMISSION_DENIED forbidden, got %v %q", code, reason)
+	}
+
+	if _, err := client.UpdateUser(alice, &socialv1.UpdateUserRequest{Id: 1, Email: &email}); err != nil {
+		t.Errorf("expected users to update themselves, got %v", err)
+	}
+	root := withToken(env.token(t, "root@example.com"))
+	if _, err := client.DeleteUser(root, &socialv1.DeleteUserRequest{Id: 1}); err != nil {
+		t.Errorf("expected admins to delete any user, got %v", err)
+	}
+}
+
+func TestRecovery_TurnsPanicsIntoInternal(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewPostServiceClient(env.conn)
+
+	// There is no posts service behind the server, so this panics
+	_, err := client.GetPost(context.Background(), &socialv1.GetPostRequest{Id: 1})
+	if code, _ := errorReason(t, err); code != codes.Internal {
+		t.Errorf("expected INTERNAL, got %v", err)
+	}
+}
+
+func TestSubscribePostEvents_StreamsFilteredEvents(t *testing.T) {
+	env := newEnv(t)
+	client := socialv1.NewPostServiceClient(env.conn)
+
+	// Stream errors arrive with the first receive
+	denied, err := client.SubscribePostEvents(withToken(env.token(t, "alice@example.com")), &socialv1.SubscribePostEventsRequest{})
+	if err == nil {
+		_, err = denied.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PERMISSION_DENIED for a non-admin, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(withToken(env.token(t, "root@example.com")), 5*time.Second)
+	defer cancel()
+	stream, err := client.SubscribePostEvents(ctx, &socialv1.SubscribePostEventsRequest{
+		Types:  []string{"post.created", "post.deleted"},
+		UserId: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("subscription did not start: %v", err)
+	}
+
+	bus := env.bus
+	_ = bus.Publish(context.Background(), events.PostCreated{PostID: 10, UserID: 2, Title: "other author"})
+	_ = bus.Publish(context.Background(), events.PostUpdated{PostID: 11, UserID: 1, Title: "filtered type"})
+	_ = bus.Publish(context.Background(), events.CommentCreated{CommentID: 5, PostID: 12, UserID: 1})
+	_ = bus.Publish(context.Background(), events.PostCreated{PostID: 12, UserID: 1, Title: "hello"})
+	_ = bus.Publish(context.Background(), events.PostDeleted{PostID: 12, UserID: 1})
+
+	for _, want := range []struct {
+		eventType string
+		title     string
+	}{{"post.created", "hello"}, {"post.deleted", ""}} {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("failed to receive: %v", err)
+		}
+		if event.GetType() != want.eventType || event.GetPostId() != 12 || event.GetTitle() != want.title || event.GetOccurredAt() == nil {
+			t.Errorf("unexpected event %v, want %s", event, want.eventType)
+		}
+	}
+}
+
+func TestEventHub_DropsForSlowSubscribersAndCloses(t *testing.T) {
+	bus := events.NewInMemoryEventBus()
+	hub := rpc.NewEventHub(bus)
+	filter := rpc.CommentEventFilter{PostID: 7}
+	ch, unsubscribe := hub.Subscribe(filter.Accept)
+	defer unsubscribe()
+
+	// Publishing must not block on a subscriber that does not read
+	for i := 0; i < 100; i++ {
+		_ = bus.Publish(context.Background(), events.CommentCreated{CommentID: 1, PostID: 7, UserID: 1})
+	}
+	_ = bus.Publish(context.Background(), events.CommentCreated{CommentID: 2, PostID: 8, UserID: 1})
+
+	hub.Close()
+	received := 0
+	for event := range ch {
+		if event.Event.(events.CommentCreated).PostID != 7 {
+			t.Errorf("unexpected event %v", event)
+		}
+		received++
+	}
+	if received == 0 || received >= 100 {
+		t.Errorf("expected a full buffer of events, got %d", received)
+	}
+}
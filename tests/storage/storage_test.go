@@ -0,0 +1,271 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/storage"
+)
+
+// fakeS3 is a local stand-in for MinIO that keeps objects in memory and
+// speaks just enough of the S3 API for the blob store
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func startFakeS3(t *testing.T, bucket string) (*fakeS3, string) {
+	t.Helper()
+	fake := &fakeS3{bucket: bucket, objects: make(map[string]fakeObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, strings.TrimPrefix(server.URL, "http://")
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	// Path-style addressing: /bucket/key
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" {
+		// BucketExists and bucket location lookups
+		if r.URL.Query().Has("location") {
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = io.WriteString(w, `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`)
+		}
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readPayload(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(data))+`"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(obj.data))+`"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) object(key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj, ok
+}
+
+// readPayload returns the object data of a PUT, decoding the aws-chunked
+// encoding clients use to sign or checksum a streamed body
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			// Trailing checksum headers follow the last chunk
+			_, _ = io.Copy(io.Discard, body)
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, err
+		}
+		if _, err := body.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func newS3Store(t *testing.T) (*storage.S3Store, *fakeS3) {
+	t.Helper()
+	fake, endpoint := startFakeS3(t, "media")
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  endpoint,
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Region:    "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store, fake
+}
+
+func newLocalStore(t *testing.T) *storage.LocalStore {
+	t.Helper()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store
+}
+
+// testBlobStore runs the behaviour every BlobStore must share
+func testBlobStore(t *testing.T, store storage.BlobStore) {
+	ctx := context.Background()
+	content := []byte(strings.Repeat("blob content ", 100))
+
+	if err := store.Put(ctx, "media/1/a", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("expected put to succeed, got %v", err)
+	}
+
+	blob, err := store.Get(ctx, "media/1/a")
+	if err != nil {
+		t.Fatalf("expected get to succeed, got %v", err)
+	}
+	got, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("expected the stored content back, got %d bytes, err %v", len(got), err)
+	}
+
+	if err := store.Delete(ctx, "media/1/a"); err != nil {
+		t.Fatalf("expected delete to succeed, got %v", err)
+	}
+	if _, err := store.Get(ctx, "media/1/a"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "media/1/a"); err != nil {
+		t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	testBlobStore(t, newLocalStore(t))
+}
+
+func TestS3Store(t *testing.T) {
+	store, _ := newS3Store(t)
+	testBlobStore(t, store)
+}
+
+func TestLocalStore_RejectsKeysOutsideDirectory(t *testing.T) {
+	store := newLocalStore(t)
+	for _, key := range []string{"../escape", "media/../../escape", "/etc/passwd", ""} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
+
+func TestLocalStore_LeavesNoPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	failing := io.MultiReader(strings.NewReader("partial"), iotestErrReader{})
+	if err := store.Put(context.Background(), "media/1/a", failing, 100, "image/png"); err == nil {
+		t.Fatal("expected a failing reader to fail the put")
+	}
+
+	var files []string
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) != 0 {
+		t.Errorf("expected no files after a failed put, found %v", files)
+	}
+}
+
+type iotestErrReader struct{}
+
+func (iotestErrReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestS3Store_StoresContentType(t *testing.T) {
+	store, fake := newS3Store(t)
+	if err := store.Put(context.Background(), "media/1/b", strings.NewReader("gif"), 3, "image/gif"); err != nil {
+		t.Fatalf("expected put to succeed, got %v", err)
+	}
+	obj, ok := fake.object("media/1/b")
+	if !ok || string(obj.data) != "gif" || obj.contentType != "image/gif" {
+		t.Errorf("expected the object with its content type, got %+v", obj)
+	}
+}
+
+func TestS3Store_Ping(t *testing.T) {
+	store, _ := newS3Store(t)
+	if err := store.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed, got %v", err)
+	}
+
+	_, endpoint := startFakeS3(t, "other")
+	missing, err := storage.NewS3Store(storage.S3Config{Endpoint: endpoint, Bucket: "media", AccessKey: "a", SecretKey: "s", Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := missing.Ping(context.Background()); err == nil {
+		t.Error("expected ping to fail for a missing bucket")
+	}
+}
@@ -0,0 +1,74 @@
+package tags_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/tags"
+)
+
+type mockRepository struct {
+	tags     []tags.Model
+	prefixes []string
+}
+
+func (m *mockRepository) SetPostTags(ctx context.Context, postID domain.PostID, names []string) error {
+	return nil
+}
+
+func (m *mockRepository) GetByName(ctx context.Context, name string) (*tags.Model, error) {
+	for i := range m.tags {
+		if m.tags[i].Name == name {
+			return &m.tags[i], nil
+		}
+	}
+	return nil, tags.ErrNotFound
+}
+
+func (m *mockRepository) SearchByPrefix(ctx context.Context, prefix string, limit int) ([]tags.Model, error) {
+	m.prefixes = append(m.prefixes, prefix)
+	return m.tags, nil
+}
+
+func (m *mockRepository) GetTrending(ctx context.Context, limit int) ([]tags.Model, error) {
+	return m.tags, nil
+}
+
+func (m *mockRepository) RefreshStats(ctx context.Context, now time.Time, halfLife, window time.Duration) error {
+	return nil
+}
+
+func TestService_Autocomplete(t *testing.T) {
+	repo := &mockRepository{tags: []tags.Model{{Name: "golang", PostCount: 3}}}
+	service := tags.NewService(repo, nil, nil)
+
+	result, err := service.Autocomplete(context.Background(), " #GO", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.prefixes) != 1 || repo.prefixes[0] != "go" {
+		t.Errorf("expected the prefix normalized to go, got %v", repo.prefixes)
+	}
+	if len(result.Tags) != 1 || result.Tags[0].Name != "golang" || result.Tags[0].PostCount != 3 {
+		t.Errorf("expected golang with its post count, got %+v", result.Tags)
+	}
+
+	if _, err := service.Autocomplete(context.Background(), "a b", 10); !errors.Is(err, domain.ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
+
+func TestService_GetByName_Errors(t *testing.T) {
+	service := tags.NewService(&mockRepository{}, nil, nil)
+	ctx := context.Background()
+
+	if _, err := service.GetByName(ctx, "no tag", 0, 20, 0); !errors.Is(err, domain.ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+	if _, err := service.GetByName(ctx, "Unused", 0, 20, 0); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Errorf("expected ErrTagNotFound, got %v", err)
+	}
+}
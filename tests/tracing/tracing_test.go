@@ -0,0 +1,114 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/internal/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider("social-test", 1, sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+func TestTracingMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	exporter := setupExporter(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Tracing())
+	r.Get("/v1/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "posts.Service.GetByID")
+		span.End()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/posts/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	service, server := spans[0], spans[1]
+
+	if server.Name != "GET /v1/posts/{id}" {
+		t.Errorf("expected span named after the route pattern, got %q", server.Name)
+	}
+	if server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected incoming trace id, got %s", server.SpanContext.TraceID())
+	}
+	if server.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected remote parent, got %s", server.Parent.SpanID())
+	}
+	if service.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("expected service span to be a child of the server span")
+	}
+}
+
+// asyncBus hands events to handlers on another goroutine with a fresh
+// context, keeping only the envelope headers as a real broker would
+type asyncBus struct {
+	events.EventBus
+	wg sync.WaitGroup
+}
+
+func (b *asyncBus) Publish(ctx context.Context, event events.Event) error {
+	headers := events.HeadersFromContext(ctx)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		_ = b.EventBus.Publish(events.ContextWithHeaders(context.Background(), headers), event)
+	}()
+	return nil
+}
+
+func TestInstrumentEventBus_AsyncHandlersJoinTrace(t *testing.T) {
+	exporter := setupExporter(t)
+	inner := &asyncBus{EventBus: events.NewInMemoryEventBus()}
+	bus := tracing.InstrumentEventBus(inner)
+
+	var handlerSpan trace.SpanContext
+	bus.Subscribe(events.PostCreated{}.Type(), func(ctx context.Context, event events.Event) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+
+	ctx, root := tracing.Start(context.Background(), "posts.Service.Create")
+	_ = bus.Publish(ctx, events.PostCreated{PostID: 1, UserID: 1, Title: "Hello"})
+	root.End()
+	inner.wg.Wait()
+
+	if handlerSpan.TraceID() != root.SpanContext().TraceID() {
+		t.Fatalf("expected handler to join trace %s, got %s", root.SpanContext().TraceID(), handlerSpan.TraceID())
+	}
+
+	var publish, handle sdktrace.ReadOnlySpan
+	for _, span := range exporter.GetSpans().Snapshots() {
+		switch span.Name() {
+		case "publish post.created":
+			publish = span
+		case "handle post.created":
+			handle = span
+		}
+	}
+	if publish == nil || handle == nil {
+		t.Fatalf("expected publish and handle spans")
+	}
+	if handle.Parent().SpanID() != publish.SpanContext().SpanID() {
+		t.Errorf("expected handle span to be a child of the publish span")
+	}
+}
@@ -0,0 +1,41 @@
+package users_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/users"
+)
+
+func TestCachedRepository_OmitsPassword(t *testing.T) {
+	inner := &mockRepository{
+		users: map[uint]*users.Model{
+			1: {ID: 1, Username: "alice", Email: "alice@example.com", Password: []byte("hash"), Version: 1},
+		},
+	}
+	c := cache.NewLRU(10)
+	repo := users.NewCachedRepository(inner, c, time.Minute)
+	ctx := context.Background()
+
+	user, err := repo.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(user.Password) != 0 {
+		t.Error("expected the password hash left out of cached users")
+	}
+	if !bytes.Equal(inner.users[1].Password, []byte("hash")) {
+		t.Error("expected the stored user untouched")
+	}
+
+	data, err := c.Get(ctx, users.CacheKey(1))
+	if err != nil {
+		t.Fatalf("expected the user cached, got %v", err)
+	}
+	if bytes.Contains(data, []byte("hash")) {
+		t.Error("expected no password hash in the cache entry")
+	}
+}
@@ -0,0 +1,142 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/urdogan0000/social/audit"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/middleware"
+	"github.com/urdogan0000/social/users"
+)
+
+// tokenAuthenticator maps fixed tokens to user IDs
+type tokenAuthenticator struct {
+	users map[string]uint
+}
+
+func (a *tokenAuthenticator) ValidateToken(token string) (uint, string, error) {
+	userID, ok := a.users[token]
+	if !ok {
+		return 0, "", errors.New("invalid token")
+	}
+	return userID, "", nil
+}
+
+func (a *tokenAuthenticator) IsAdmin(ctx context.Context, userID uint) (bool, error) {
+	return false, nil
+}
+
+func newHandlerRouter(t *testing.T) (http.Handler, *recordingAuditor) {
+	t.Helper()
+	auditor := &recordingAuditor{}
+	audit.SetRecorder(auditor)
+	t.Cleanup(func() { audit.SetRecorder(nil) })
+
+	repo := &mockRepository{
+		users: map[uint]*users.Model{
+			1: {ID: 1, Username: "alice", Email: "alice@example.com", Role: users.RoleUser, Version: 1},
+			2: {ID: 2, Username: "bob", Email: "bob@example.com", Role: users.RoleUser, Version: 1},
+			3: {ID: 3, Username: "root", Email: "root@example.com", Role: users.RoleAdmin, Version: 1},
+		},
+	}
+	handler := users.NewHandler(users.NewService(repo, events.NewInMemoryEventBus(), nil, nil))
+	auth := &tokenAuthenticator{users: map[string]uint{"alice": 1, "bob": 2, "root": 3}}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(auth))
+		r.Put("/v1/users/{id}", handler.Update)
+		r.Delete("/v1/users/{id}", handler.Delete)
+	})
+	return r, auditor
+}
+
+func userRequest(method, path, token, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func problemCode(rec *httptest.ResponseRecorder) string {
+	var problem struct {
+		Code string `json:"code"`
+	}
+	_ = json.NewDecoder(rec.Body).Decode(&problem)
+	return problem.Code
+}
+
+func TestHandler_UpdateAndDelete_RequireAuthentication(t *testing.T) {
+	router, auditor := newHandlerRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodPut, "/v1/users/1", "", `{"email":"new@example.com"}`))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an anonymous update, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodDelete, "/v1/users/1", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an anonymous delete, got %d", rec.Code)
+	}
+	if len(auditor.entries) != 0 {
+		t.Errorf("expected nothing audited, got %v", auditor.entries)
+	}
+}
+
+func TestHandler_UpdateAndDelete_RejectOtherAccounts(t *testing.T) {
+	router, auditor := newHandlerRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodPut, "/v1/users/2", "alice", `{"email":"new@example.com"}`))
+	if rec.Code != http.StatusForbidden || problemCode(rec) != "forbidden" {
+		t.Errorf("expected 403 forbidden for another user's account, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodDelete, "/v1/users/2", "alice", ""))
+	if rec.Code != http.StatusForbidden || problemCode(rec) != "forbidden" {
+		t.Errorf("expected 403 forbidden for another user's account, got %d", rec.Code)
+	}
+	if len(auditor.entries) != 0 {
+		t.Errorf("expected nothing audited, got %v", auditor.entries)
+	}
+}
+
+func TestHandler_UpdateAndDelete_AuditTheActor(t *testing.T) {
+	router, auditor := newHandlerRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodPut, "/v1/users/1", "alice", `{"email":"new@example.com"}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected users to update themselves, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Admins may delete any account
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, userRequest(http.MethodDelete, "/v1/users/2", "root", ""))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected admins to delete any user, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if len(auditor.entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(auditor.entries))
+	}
+	updated, deleted := auditor.entries[0], auditor.entries[1]
+	if updated.Action != audit.ActionUserUpdated || updated.ActorID == nil || *updated.ActorID != 1 {
+		t.Errorf("expected the update audited with actor 1, got %+v", updated)
+	}
+	if deleted.Action != audit.ActionUserDeleted || deleted.TargetID != 2 || deleted.ActorID == nil || *deleted.ActorID != 3 {
+		t.Errorf("expected the delete of user 2 audited with actor 3, got %+v", deleted)
+	}
+}
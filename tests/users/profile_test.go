@@ -0,0 +1,177 @@
+package users_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/urdogan0000/social/internal/domain"
+	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/users"
+)
+
+// mockAvatarStore knows image uploads by ID and who uploaded them
+type mockAvatarStore struct {
+	owners map[domain.MediaID]domain.UserID
+}
+
+func (m *mockAvatarStore) CheckAvatar(ctx context.Context, id domain.MediaID, userID domain.UserID) error {
+	if owner, ok := m.owners[id]; !ok || owner != userID {
+		return domain.ErrMediaNotFound
+	}
+	return nil
+}
+
+func (m *mockAvatarStore) AvatarURL(ctx context.Context, id domain.MediaID) (string, error) {
+	return fmt.Sprintf("/v1/media/%d/content?sig=x", id), nil
+}
+
+func newProfileService() (*users.Service, *mockRepository) {
+	repo := &mockRepository{
+		users: map[uint]*users.Model{
+			1: {ID: 1, Username: "ada", Email: "ada@example.com", Role: users.RoleUser, Version: 1},
+			2: {ID: 2, Username: "bob", Email: "bob@example.com", Role: users.RoleUser, Version: 1},
+		},
+	}
+	avatars := &mockAvatarStore{owners: map[domain.MediaID]domain.UserID{1: 1, 2: 2}}
+	return users.NewService(repo, events.NewInMemoryEventBus(), nil, avatars), repo
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestService_UpdateProfile(t *testing.T) {
+	service, repo := newProfileService()
+	ctx := context.Background()
+
+	me, err := service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{
+		DisplayName:   ptr("  Ada Lovelace "),
+		Bio:           ptr("Analyst.\nPoet of science."),
+		Website:       ptr("https://example.com/ada"),
+		Pronouns:      ptr("she/her"),
+		AvatarMediaID: ptr(uint(1)),
+		Fields:        &[]users.ProfileField{{Name: "Engine", Value: "Analytical"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if me.DisplayName != "Ada Lovelace" || me.Email != "ada@example.com" || me.Version != 2 {
+		t.Errorf("expected the trimmed profile with the email at version 2, got %+v", me)
+	}
+	if me.AvatarMediaID == nil || *me.AvatarMediaID != 1 || me.AvatarURL != "/v1/users/1/avatar" {
+		t.Errorf("expected avatar 1 behind a stable link, got %v %q", me.AvatarMediaID, me.AvatarURL)
+	}
+
+	// Only the given parts change
+	me, err = service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{Location: ptr("London")}, ptr(uint(2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if me.Location != "London" || me.DisplayName != "Ada Lovelace" || len(me.Fields) != 1 || me.AvatarMediaID == nil {
+		t.Errorf("expected the rest of the profile kept, got %+v", me)
+	}
+
+	if _, err := service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{Bio: ptr("stale")}, ptr(uint(1))); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for a stale version, got %v", err)
+	}
+
+	// 0 removes the avatar, an empty list removes the fields
+	me, err = service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{AvatarMediaID: ptr(uint(0)), Fields: &[]users.ProfileField{}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if me.AvatarMediaID != nil || me.AvatarURL != "" || len(me.Fields) != 0 {
+		t.Errorf("expected avatar and fields removed, got %+v", me)
+	}
+	if repo.users[1].AvatarMediaID != nil {
+		t.Errorf("expected the avatar removed from the model")
+	}
+}
+
+func TestService_UpdateProfile_Rejects(t *testing.T) {
+	service, repo := newProfileService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     users.UpdateProfileRequest
+		wantErr error
+	}{
+		{"another user's upload", users.UpdateProfileRequest{AvatarMediaID: ptr(uint(2))}, domain.ErrMediaNotFound},
+		{"unknown upload", users.UpdateProfileRequest{AvatarMediaID: ptr(uint(9))}, domain.ErrMediaNotFound},
+		{"bio too long", users.UpdateProfileRequest{Bio: ptr(strings.Repeat("a", domain.MaxBioLength+1))}, domain.ErrInvalidProfile},
+		{"unsafe website", users.UpdateProfileRequest{Website: ptr("javascript:alert(1)")}, domain.ErrInvalidProfile},
+		{"duplicate fields", users.UpdateProfileRequest{Fields: &[]users.ProfileField{{Name: "a", Value: "1"}, {Name: "A ", Value: "2"}}}, domain.ErrInvalidProfile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.UpdateProfile(ctx, 1, tt.req, nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if repo.users[1].Version != 1 {
+		t.Errorf("expected no change stored, got version %d", repo.users[1].Version)
+	}
+}
+
+func TestService_PublicResponseHidesEmail(t *testing.T) {
+	service, _ := newProfileService()
+	ctx := context.Background()
+	if _, err := service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{DisplayName: ptr("Ada")}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := service.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(user)
+	if strings.Contains(string(data), "ada@example.com") || strings.Contains(string(data), `"email"`) {
+		t.Errorf("expected no email in the public profile, got %s", data)
+	}
+	if user.DisplayName != "Ada" {
+		t.Errorf("expected the display name in the public profile, got %q", user.DisplayName)
+	}
+
+	me, err := service.GetMe(ctx, 1)
+	if err != nil || me.Email != "ada@example.com" {
+		t.Errorf("expected the email in the private response, got %+v, %v", me, err)
+	}
+}
+
+func TestService_Update_KeepsProfile(t *testing.T) {
+	service, _ := newProfileService()
+	ctx := context.Background()
+	if _, err := service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{Bio: ptr("Analyst"), AvatarMediaID: ptr(uint(1))}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := service.Update(ctx, 1, 1, users.UpdateRequest{Username: ptr("ada_l")}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Bio != "Analyst" || user.AvatarURL == "" {
+		t.Errorf("expected the profile to survive an account update, got %+v", user)
+	}
+}
+
+func TestService_AvatarURL(t *testing.T) {
+	service, _ := newProfileService()
+	ctx := context.Background()
+
+	if _, err := service.AvatarURL(ctx, 1); !errors.Is(err, domain.ErrMediaNotFound) {
+		t.Errorf("expected ErrMediaNotFound without an avatar, got %v", err)
+	}
+	if _, err := service.UpdateProfile(ctx, 1, users.UpdateProfileRequest{AvatarMediaID: ptr(uint(1))}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	url, err := service.AvatarURL(ctx, 1)
+	if err != nil || !strings.HasPrefix(url, "/v1/media/1/content") {
+		t.Errorf("expected a signed media link, got %q, %v", url, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/urdogan0000/social/audit"
+	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
 	"github.com/urdogan0000/social/users"
 	"golang.org/x/crypto/bcrypt"
@@ -40,6 +42,16 @@ func (m *mockRepository) GetByID(ctx context.Context, id uint) (*users.Model, er
 	return nil, users.ErrNotFound
 }
 
+func (m *mockRepository) GetByIDs(ctx context.Context, ids []uint) ([]users.Model, error) {
+	var result []users.Model
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			result = append(result, *user)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockRepository) GetByUsername(ctx context.Context, username string) (*users.Model, error) {
 	for _, user := range m.users {
 		if user.Username == username {
@@ -62,20 +74,29 @@ func (m *mockRepository) Update(ctx context.Context, user *users.Model) error {
 	if m.updateErr != nil {
 		return m.updateErr
 	}
-	if _, ok := m.users[user.ID]; !ok {
+	stored, ok := m.users[user.ID]
+	if !ok {
 		return users.ErrNotFound
 	}
+	if stored.Version != user.Version {
+		return domain.ErrVersionMismatch
+	}
+	user.Version++
 	m.users[user.ID] = user
 	return nil
 }
 
-func (m *mockRepository) Delete(ctx context.Context, id uint) error {
+func (m *mockRepository) Delete(ctx context.Context, id uint, version *uint) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
 	}
-	if _, ok := m.users[id]; !ok {
+	user, ok := m.users[id]
+	if !ok {
 		return users.ErrNotFound
 	}
+	if version != nil && user.Version != *version {
+		return domain.ErrVersionMismatch
+	}
 	delete(m.users, id)
 	return nil
 }
@@ -134,7 +155,7 @@ func TestService_Create(t *testing.T) {
 				createErr: tt.createErr,
 			}
 			eventBus := events.NewInMemoryEventBus()
-			service := users.NewService(repo, eventBus, nil)
+			service := users.NewService(repo, eventBus, nil, nil)
 
 			ctx := context.Background()
 			result, err := service.Create(ctx, tt.req)
@@ -169,7 +190,7 @@ func TestService_GetByID(t *testing.T) {
 		},
 	}
 	eventBus := events.NewInMemoryEventBus()
-	service := users.NewService(repo, eventBus, nil)
+	service := users.NewService(repo, eventBus, nil, nil)
 
 	ctx := context.Background()
 	user, err := service.GetByID(ctx, 1)
@@ -201,13 +222,13 @@ func TestService_Update(t *testing.T) {
 		},
 	}
 	eventBus := events.NewInMemoryEventBus()
-	service := users.NewService(repo, eventBus, nil)
+	service := users.NewService(repo, eventBus, nil, nil)
 
 	ctx := context.Background()
 	newUsername := "updateduser"
 	req := users.UpdateRequest{Username: &newUsername}
 
-	user, err := service.Update(ctx, 1, req)
+	user, err := service.Update(ctx, 1, 1, req, nil)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -228,10 +249,10 @@ func TestService_Delete(t *testing.T) {
 		},
 	}
 	eventBus := events.NewInMemoryEventBus()
-	service := users.NewService(repo, eventBus, nil)
+	service := users.NewService(repo, eventBus, nil, nil)
 
 	ctx := context.Background()
-	err := service.Delete(ctx, 1)
+	err := service.Delete(ctx, 1, 1, nil)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -244,7 +265,7 @@ func TestService_Delete(t *testing.T) {
 	}
 
 	// Test delete non-existent user
-	err = service.Delete(ctx, 999)
+	err = service.Delete(ctx, 999, 999, nil)
 	if err == nil {
 		t.Errorf("expected error for non-existent user")
 	}
@@ -260,7 +281,7 @@ func TestService_List(t *testing.T) {
 		},
 	}
 	eventBus := events.NewInMemoryEventBus()
-	service := users.NewService(repo, eventBus, nil)
+	service := users.NewService(repo, eventBus, nil, nil)
 
 	ctx := context.Background()
 	result, err := service.List(ctx, 10, 0)
@@ -287,7 +308,7 @@ func TestService_GetByUsername(t *testing.T) {
 		},
 	}
 	eventBus := events.NewInMemoryEventBus()
-	service := users.NewService(repo, eventBus, nil)
+	service := users.NewService(repo, eventBus, nil, nil)
 
 	ctx := context.Background()
 	user, err := service.GetByUsername(ctx, "testuser")
@@ -308,3 +329,82 @@ func TestService_GetByUsername(t *testing.T) {
 	}
 }
 
+
+type recordingAuditor struct {
+	entries []audit.Entry
+}
+
+func (r *recordingAuditor) Record(ctx context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestService_SetRole_RecordsAudit(t *testing.T) {
+	auditor := &recordingAuditor{}
+	audit.SetRecorder(auditor)
+	t.Cleanup(func() { audit.SetRecorder(nil) })
+
+	repo := &mockRepository{
+		users: map[uint]*users.Model{
+			1: {ID: 1, Username: "testuser", Email: "test@example.com", Role: users.RoleUser, Version: 1},
+		},
+	}
+	service := users.NewService(repo, events.NewInMemoryEventBus(), nil, nil)
+
+	if _, err := service.SetRole(context.Background(), 1, "superuser", nil); !errors.Is(err, users.ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+
+	user, err := service.SetRole(context.Background(), 1, users.RoleAdmin, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Role != users.RoleAdmin || user.Version != 2 {
+		t.Errorf("expected admin at version 2, got %s at %d", user.Role, user.Version)
+	}
+
+	if len(auditor.entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(auditor.entries))
+	}
+	entry := auditor.entries[0]
+	if entry.Action != audit.ActionUserRoleChanged || entry.TargetID != 1 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if c := entry.Changes["role"]; c.Before != users.RoleUser || c.After != users.RoleAdmin {
+		t.Errorf("unexpected role change %+v", c)
+	}
+}
+
+func TestService_Update_PreservesRoleAndRedactsPassword(t *testing.T) {
+	auditor := &recordingAuditor{}
+	audit.SetRecorder(auditor)
+	t.Cleanup(func() { audit.SetRecorder(nil) })
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	repo := &mockRepository{
+		users: map[uint]*users.Model{
+			1: {ID: 1, Username: "testuser", Email: "test@example.com", Password: hashedPassword, Role: users.RoleAdmin, Version: 1},
+		},
+	}
+	service := users.NewService(repo, events.NewInMemoryEventBus(), nil, nil)
+
+	newPassword := "new-password"
+	user, err := service.Update(context.Background(), 1, 1, users.UpdateRequest{Password: &newPassword}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Role != users.RoleAdmin {
+		t.Errorf("expected role to survive the update, got %q", user.Role)
+	}
+
+	if len(auditor.entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(auditor.entries))
+	}
+	changes := auditor.entries[0].Changes
+	if c := changes["password"]; c.Before != audit.Redacted || c.After != audit.Redacted {
+		t.Errorf("expected redacted password change, got %+v", c)
+	}
+	if len(changes) != 1 {
+		t.Errorf("expected only the password to change, got %v", changes)
+	}
+}
@@ -0,0 +1,68 @@
+package users
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/urdogan0000/social/internal/cache"
+	"github.com/urdogan0000/social/internal/events"
+)
+
+// CacheKey is the cache key of a single user
+func CacheKey(id uint) string {
+	return "users:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// cachedRepository serves GetByID from a read-through cache and drops entries
+// on every write it performs
+type cachedRepository struct {
+	Repository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedRepository wraps repo with a read-through cache for user lookups
+func NewCachedRepository(repo Repository, c cache.Cache, ttl time.Duration) Repository {
+	return &cachedRepository{Repository: repo, cache: c, ttl: ttl}
+}
+
+// GetByID never caches the password hash: cached users come back without it.
+// Password checks read users by email, which is not cached, and Update keeps
+// the stored hash of a user saved without one.
+func (r *cachedRepository) GetByID(ctx context.Context, id uint) (*Model, error) {
+	return cache.GetOrLoad(ctx, r.cache, CacheKey(id), r.ttl, func(ctx context.Context) (*Model, error) {
+		user, err := r.Repository.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		cached := *user
+		cached.Password = nil
+		return &cached, nil
+	})
+}
+
+func (r *cachedRepository) Update(ctx context.Context, user *Model) error {
+	err := r.Repository.Update(ctx, user)
+	cache.Invalidate(ctx, r.cache, CacheKey(user.ID))
+	return err
+}
+
+func (r *cachedRepository) Delete(ctx context.Context, id uint, version *uint) error {
+	err := r.Repository.Delete(ctx, id, version)
+	cache.Invalidate(ctx, r.cache, CacheKey(id))
+	return err
+}
+
+// RegisterCacheInvalidation drops cached users once user events are published,
+// after the writing transaction has committed
+func RegisterCacheInvalidation(bus events.EventBus, c cache.Cache) {
+	bus.Subscribe(events.UserUpdated{}.Type(), func(ctx context.Context, event events.Event) error {
+		cache.Invalidate(ctx, c, CacheKey(uint(event.(events.UserUpdated).UserID)))
+		return nil
+	})
+	bus.Subscribe(events.UserDeleted{}.Type(), func(ctx context.Context, event events.Event) error {
+		cache.Invalidate(ctx, c, CacheKey(uint(event.(events.UserDeleted).UserID)))
+		return nil
+	})
+}
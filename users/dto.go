@@ -1,5 +1,7 @@
 package users
 
+import "time"
+
 type CreateRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=100"`
 	Email    string `json:"email" validate:"required,email"`
@@ -12,12 +14,58 @@ type UpdateRequest struct {
 	Password *string `json:"password,omitempty" validate:"omitempty,min=6"`
 }
 
+// SetRoleRequest changes a user's role
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+// UpdateProfileRequest changes the given parts of the caller's profile
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	// AvatarMediaID is one of the caller's image uploads; 0 removes the avatar
+	AvatarMediaID *uint   `json:"avatar_media_id,omitempty"`
+	Website       *string `json:"website,omitempty"`
+	Location      *string `json:"location,omitempty"`
+	Pronouns      *string `json:"pronouns,omitempty"`
+	// Fields replaces all custom fields
+	Fields *[]ProfileField `json:"fields,omitempty"`
+}
+
+type ProfileField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Response is a user as anyone may see them
 type Response struct {
-	ID        uint   `json:"id"`
-	Username  string  `json:"username"`
-	Email     string  `json:"email"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	// AvatarURL redirects to the avatar image, see GET /users/{id}/avatar
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Website   string         `json:"website"`
+	Location  string         `json:"location"`
+	Pronouns  string         `json:"pronouns"`
+	Fields    []ProfileField `json:"fields"`
+	Role      string         `json:"role"`
+	Version   uint           `json:"version"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+}
+
+// PrivateResponse is a user as they see themselves
+type PrivateResponse struct {
+	Response
+	Email         string `json:"email"`
+	AvatarMediaID *uint  `json:"avatar_media_id,omitempty"`
+}
+
+// LastModified returns UpdatedAt as a time for the Last-Modified header
+func (r *Response) LastModified() time.Time {
+	updatedAt, _ := time.Parse(time.RFC3339, r.UpdatedAt)
+	return updatedAt
 }
 
 type ListResponse struct {
@@ -5,5 +5,7 @@ import "github.com/urdogan0000/social/internal/domain"
 var (
 	ErrNotFound      = domain.ErrUserNotFound
 	ErrAlreadyExists = domain.ErrUserAlreadyExists
+	ErrInvalidRole   = domain.ErrInvalidRole
+	ErrForbidden     = domain.ErrUserForbidden
 )
 
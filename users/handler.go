@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	httputil "github.com/urdogan0000/social/internal/http"
 	"github.com/urdogan0000/social/internal/logger"
+	"github.com/urdogan0000/social/internal/middleware"
 	"github.com/urdogan0000/social/internal/validator"
 )
 
@@ -28,10 +29,10 @@ func NewHandler(service *Service) *Handler {
 // @Accept json
 // @Produce json
 // @Param user body CreateRequest true "User creation request"
-// @Success 201 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 409 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 201 {object} PrivateResponse
+// @Failure 400 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	var req CreateRequest
@@ -41,30 +42,17 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validator.Validate(&req); err != nil {
-		httputil.RespondError(w, r, http.StatusBadRequest, "validation_failed")
+		httputil.RespondValidationError(w, r, err)
 		return
 	}
 
 	user, err := h.service.Create(r.Context(), req)
 	if err != nil {
-		if err == ErrAlreadyExists {
-			logger.Logger().Warn().
-				Str("username", req.Username).
-				Str("email", req.Email).
-				Msg("User creation failed: already exists")
-			httputil.RespondError(w, r, http.StatusConflict, "user_already_exists")
-			return
-		}
-		logger.Logger().Error().
-			Err(err).
-			Str("username", req.Username).
-			Str("email", req.Email).
-			Msg("Failed to create user")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_create_user")
+		httputil.RespondDomainError(w, r, err, "failed_to_create_user")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("user_id", user.ID).
 		Str("username", user.Username).
 		Str("email", user.Email).
@@ -79,10 +67,14 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Header 200 {string} Last-Modified "Time of the last change"
+// @Success 304 "Not modified"
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/{id} [get]
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
@@ -93,41 +85,168 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.GetByID(r.Context(), uint(id))
 	if err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Debug().Uint("user_id", uint(id)).Msg("User not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "user_not_found")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("user_id", uint(id)).Msg("Failed to get user")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
+		httputil.RespondDomainError(w, r, err, "failed_to_get_user")
 		return
 	}
 
-	logger.Logger().Debug().Uint("user_id", user.ID).Str("username", user.Username).Msg("User retrieved")
+	logger.FromContext(r.Context()).Debug().Uint("user_id", user.ID).Str("username", user.Username).Msg("User retrieved")
+	if httputil.NotModified(w, r, httputil.ETag(user.Version), user.LastModified()) {
+		return
+	}
+	httputil.RespondJSON(w, http.StatusOK, user)
+}
+
+// GetMe godoc
+// @Summary Get your account
+// @Description Get the authenticated user, including private details such as the email
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} PrivateResponse
+// @Header 200 {string} ETag "Version of the resource"
+// @Success 304 "Not modified"
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me [get]
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := h.service.GetMe(r.Context(), userID)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_user")
+		return
+	}
+
+	if httputil.NotModified(w, r, httputil.ETag(user.Version), user.LastModified()) {
+		return
+	}
+	httputil.RespondJSON(w, http.StatusOK, user)
+}
+
+// UpdateProfile godoc
+// @Summary Update your profile
+// @Description Change the given parts of the authenticated user's profile. The avatar must be one of your image uploads; 0 removes it. fields replaces all custom fields.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body UpdateProfileRequest true "Profile changes"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} PrivateResponse
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 415 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /me/profile [patch]
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), userID, req, expectedVersion)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_update_profile")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().Uint("user_id", user.ID).Msg("Profile updated successfully")
+	httputil.SetETag(w, user.Version)
 	httputil.RespondJSON(w, http.StatusOK, user)
 }
 
+// GetAvatar godoc
+// @Summary Get a user's avatar
+// @Description Redirect to a signed link to the user's avatar image
+// @Tags users
+// @Param id path int true "User ID"
+// @Success 302
+// @Header 302 {string} Location "Signed avatar URL"
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Router /users/{id}/avatar [get]
+func (h *Handler) Avatar(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	url, err := h.service.AvatarURL(r.Context(), uint(id))
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_get_avatar")
+		return
+	}
+
+	// The target expires, so the redirect itself must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
 // UpdateUser godoc
 // @Summary Update user
-// @Description Update an existing user
+// @Description Update an existing user. Users may update their own account; admins any account
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "User ID"
 // @Param user body UpdateRequest true "User update request"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 200 {object} Response
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 409 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
 		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
 	var req UpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
@@ -135,65 +254,125 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validator.Validate(&req); err != nil {
-		httputil.RespondError(w, r, http.StatusBadRequest, "validation_failed")
+		httputil.RespondValidationError(w, r, err)
 		return
 	}
 
-	user, err := h.service.Update(r.Context(), uint(id), req)
+	user, err := h.service.Update(r.Context(), uint(id), actorID, req, expectedVersion)
 	if err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("user_id", uint(id)).Msg("User update failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "user_not_found")
-			return
-		}
-		if err == ErrAlreadyExists {
-			logger.Logger().Warn().Uint("user_id", uint(id)).Msg("User update failed: already exists")
-			httputil.RespondError(w, r, http.StatusConflict, "user_already_exists")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("user_id", uint(id)).Msg("Failed to update user")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_update_user")
+		httputil.RespondDomainError(w, r, err, "failed_to_update_user")
 		return
 	}
 
-	logger.Logger().Info().
+	logger.FromContext(r.Context()).Info().
 		Uint("user_id", user.ID).
 		Str("username", user.Username).
 		Msg("User updated successfully")
+	httputil.SetETag(w, user.Version)
+	httputil.RespondJSON(w, http.StatusOK, user)
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Grant or revoke admin rights. Admins only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body SetRoleRequest true "New role"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Success 200 {object} Response
+// @Header 200 {string} ETag "Version of the resource"
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
+// @Security BearerAuth
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	var req SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		httputil.RespondValidationError(w, r, err)
+		return
+	}
+
+	user, err := h.service.SetRole(r.Context(), uint(id), req.Role, expectedVersion)
+	if err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_change_role")
+		return
+	}
+
+	logger.FromContext(r.Context()).Info().
+		Uint("user_id", user.ID).
+		Str("role", user.Role).
+		Msg("User role changed")
+	httputil.SetETag(w, user.Version)
 	httputil.RespondJSON(w, http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary Delete user
-// @Description Soft delete a user by ID
+// @Description Soft delete a user by ID. Users may delete their own account; admins any account
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "User ID"
+// @Param If-Match header string false "ETag of the version the change is based on"
 // @Success 204
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 409 {object} httputil.Problem
+// @Failure 412 {object} httputil.Problem
+// @Failure 500 {object} httputil.Problem
 // @Router /users/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		httputil.RespondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
 		httputil.RespondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
-	if err := h.service.Delete(r.Context(), uint(id)); err != nil {
-		if err == ErrNotFound {
-			logger.Logger().Warn().Uint("user_id", uint(id)).Msg("User delete failed: not found")
-			httputil.RespondError(w, r, http.StatusNotFound, "user_not_found")
-			return
-		}
-		logger.Logger().Error().Err(err).Uint("user_id", uint(id)).Msg("Failed to delete user")
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_delete_user")
+	expectedVersion, err := httputil.IfMatch(r)
+	if err != nil {
+		httputil.RespondError(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), uint(id), actorID, expectedVersion); err != nil {
+		httputil.RespondDomainError(w, r, err, "failed_to_delete_user")
 		return
 	}
 
-	logger.Logger().Info().Uint("user_id", uint(id)).Msg("User deleted successfully")
+	logger.FromContext(r.Context()).Info().Uint("user_id", uint(id)).Msg("User deleted successfully")
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -206,17 +385,17 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {object} ListResponse
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} httputil.Problem
 // @Router /users [get]
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	limit, offset := httputil.GetPaginationParams(r)
 
 	result, err := h.service.List(r.Context(), limit, offset)
 	if err != nil {
-		httputil.RespondError(w, r, http.StatusInternalServerError, "failed_to_list_users")
+		httputil.RespondDomainError(w, r, err, "failed_to_list_users")
 		return
 	}
 
-	httputil.RespondJSON(w, http.StatusOK, result)
+	httputil.RespondJSONConditional(w, r, http.StatusOK, result)
 }
 
@@ -1,22 +1,73 @@
 package users
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Roles a user can have. Admins can read the audit log and change roles.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 type Model struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `gorm:"uniqueIndex;not null;size:100" json:"username"`
-	Email     string         `gorm:"uniqueIndex;not null;size:255" json:"email"`
-	Password  []byte         `gorm:"not null" json:"-"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"uniqueIndex;not null;size:100" json:"username"`
+	Email    string `gorm:"uniqueIndex;not null;size:255" json:"email"`
+	Password []byte `gorm:"not null" json:"-"`
+	Role     string `gorm:"not null;size:20;default:user" json:"role"`
+	// Profile
+	DisplayName   string         `gorm:"size:100" json:"display_name"`
+	Bio           string         `gorm:"type:text" json:"bio"`
+	AvatarMediaID *uint          `json:"avatar_media_id,omitempty"`
+	Website       string         `gorm:"size:255" json:"website"`
+	Location      string         `gorm:"size:100" json:"location"`
+	Pronouns      string         `gorm:"size:40" json:"pronouns"`
+	ProfileFields ProfileFields  `gorm:"type:jsonb" json:"profile_fields"`
+	Version       uint           `gorm:"not null;default:1" json:"version"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (Model) TableName() string {
 	return "users"
 }
 
+// ProfileFields is stored as a JSON array
+type ProfileFields []ProfileField
+
+func (f ProfileFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (f *ProfileFields) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, f)
+	case string:
+		return json.Unmarshal([]byte(data), f)
+	default:
+		return errors.New("unsupported type for ProfileFields")
+	}
+}
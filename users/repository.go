@@ -6,16 +6,20 @@ import (
 	"fmt"
 
 	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/domain"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	Create(ctx context.Context, user *Model) error
 	GetByID(ctx context.Context, id uint) (*Model, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]Model, error)
 	GetByUsername(ctx context.Context, username string) (*Model, error)
 	GetByEmail(ctx context.Context, email string) (*Model, error)
 	Update(ctx context.Context, user *Model) error
-	Delete(ctx context.Context, id uint) error
+	// Delete removes the user. A non-nil version makes the delete conditional
+	// on the stored version, failing with domain.ErrVersionMismatch otherwise.
+	Delete(ctx context.Context, id uint, version *uint) error
 	List(ctx context.Context, limit, offset int) ([]Model, error)
 	Count(ctx context.Context) (int64, error)
 }
@@ -34,6 +38,12 @@ func (r *repository) getDB(ctx context.Context) *gorm.DB {
 }
 
 func (r *repository) Create(ctx context.Context, user *Model) error {
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
 	if err := r.getDB(ctx).WithContext(ctx).Create(user).Error; err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -51,6 +61,19 @@ func (r *repository) GetByID(ctx context.Context, id uint) (*Model, error) {
 	return &user, nil
 }
 
+// GetByIDs loads the users with the given IDs in one query. Missing IDs are
+// skipped and the result is in no particular order.
+func (r *repository) GetByIDs(ctx context.Context, ids []uint) ([]Model, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var users []Model
+	if err := r.getDB(ctx).WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users by ids: %w", err)
+	}
+	return users, nil
+}
+
 func (r *repository) GetByUsername(ctx context.Context, username string) (*Model, error) {
 	var user Model
 	if err := r.getDB(ctx).WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
@@ -73,19 +96,48 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (*Model, erro
 	return &user, nil
 }
 
+// Update saves the user only if the stored version is still the one it was
+// read with, and bumps the version. A concurrent write in between makes it fail
+// with domain.ErrVersionMismatch instead of being silently overwritten. A user
+// without a password hash, as served from the cache, keeps the stored one.
 func (r *repository) Update(ctx context.Context, user *Model) error {
-	if err := r.getDB(ctx).WithContext(ctx).Save(user).Error; err != nil {
-		return fmt.Errorf("failed to update user %d: %w", user.ID, err)
+	omit := []string{"created_at"}
+	if len(user.Password) == 0 {
+		omit = append(omit, "password")
+	}
+
+	version := user.Version
+	user.Version++
+	result := r.getDB(ctx).WithContext(ctx).
+		Model(user).
+		Where("version = ?", version).
+		Select("*").
+		Omit(omit...).
+		Updates(user)
+	if result.Error != nil {
+		user.Version = version
+		return fmt.Errorf("failed to update user %d: %w", user.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		user.Version = version
+		return domain.ErrVersionMismatch
 	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, id uint) error {
-	result := r.getDB(ctx).WithContext(ctx).Delete(&Model{}, id)
+func (r *repository) Delete(ctx context.Context, id uint, version *uint) error {
+	query := r.getDB(ctx).WithContext(ctx).Where("id = ?", id)
+	if version != nil {
+		query = query.Where("version = ?", *version)
+	}
+	result := query.Delete(&Model{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete user %d: %w", id, result.Error)
 	}
 	if result.RowsAffected == 0 {
+		if version != nil {
+			return domain.ErrVersionMismatch
+		}
 		return ErrNotFound
 	}
 	return nil
@@ -2,28 +2,42 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/urdogan0000/social/audit"
 	"github.com/urdogan0000/social/internal/domain"
 	"github.com/urdogan0000/social/internal/events"
+	"github.com/urdogan0000/social/internal/metrics"
 	"github.com/urdogan0000/social/internal/db"
+	"github.com/urdogan0000/social/internal/tracing"
 )
 
 type Service struct {
 	repo            Repository
 	eventBus        events.EventBus
 	transactionMgr  db.TransactionManager
+	avatars         domain.AvatarStore
 }
 
-func NewService(repo Repository, eventBus events.EventBus, transactionMgr db.TransactionManager) *Service {
+// NewService creates the service. Without an avatar store, avatars cannot be
+// set.
+func NewService(repo Repository, eventBus events.EventBus, transactionMgr db.TransactionManager, avatars domain.AvatarStore) *Service {
 	return &Service{
 		repo:           repo,
 		eventBus:       eventBus,
 		transactionMgr: transactionMgr,
+		avatars:        avatars,
 	}
 }
 
-func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, error) {
+// Create registers a user. The response includes the email, which only the
+// user themselves may see.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*PrivateResponse, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.Create")
+	defer span.End()
+
 	// Create domain user
 	user := &domain.User{
 		Username: req.Username,
@@ -42,7 +56,7 @@ func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, err
 
 	// Check if username exists
 	existingUser, err := s.repo.GetByUsername(ctx, req.Username)
-	if err != nil && err != ErrNotFound {
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		return nil, fmt.Errorf("failed to check username existence: %w", err)
 	}
 	if existingUser != nil {
@@ -51,7 +65,7 @@ func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, err
 
 	// Check if email exists
 	existingUser, err = s.repo.GetByEmail(ctx, req.Email)
-	if err != nil && err != ErrNotFound {
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		return nil, fmt.Errorf("failed to check email existence: %w", err)
 	}
 	if existingUser != nil {
@@ -77,6 +91,13 @@ func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, err
 
 	// Update domain user with generated ID
 	user.ID = domain.UserID(model.ID)
+	metrics.UsersRegistered.Inc()
+	audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserCreated,
+		TargetType: audit.TargetUser,
+		TargetID:   model.ID,
+		Changes:    audit.Diff(nil, model),
+	})
 
 	// Publish event
 	if s.eventBus != nil {
@@ -87,10 +108,13 @@ func (s *Service) Create(ctx context.Context, req CreateRequest) (*Response, err
 		})
 	}
 
-	return s.toResponse(model), nil
+	return s.toPrivateResponse(model), nil
 }
 
 func (s *Service) GetByID(ctx context.Context, id uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.GetByID")
+	defer span.End()
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by id %d: %w", id, err)
@@ -98,7 +122,22 @@ func (s *Service) GetByID(ctx context.Context, id uint) (*Response, error) {
 	return s.toResponse(user), nil
 }
 
+// GetMe returns the user's own account, including private details
+func (s *Service) GetMe(ctx context.Context, id uint) (*PrivateResponse, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.GetMe")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id %d: %w", id, err)
+	}
+	return s.toPrivateResponse(user), nil
+}
+
 func (s *Service) GetByUsername(ctx context.Context, username string) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.GetByUsername")
+	defer span.End()
+
 	user, err := s.repo.GetByUsername(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by username %q: %w", username, err)
@@ -106,11 +145,23 @@ func (s *Service) GetByUsername(ctx context.Context, username string) (*Response
 	return s.toResponse(user), nil
 }
 
-func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Response, error) {
+// Update applies the request to the user on behalf of actorID, who must be
+// the user or an admin. A non-nil expectedVersion makes the update
+// conditional on the version the client last saw.
+func (s *Service) Update(ctx context.Context, id, actorID uint, req UpdateRequest, expectedVersion *uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.Update")
+	defer span.End()
+
+	if err := s.authorize(ctx, id, actorID); err != nil {
+		return nil, err
+	}
 	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by id %d: %w", id, err)
 	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Convert to domain model
 	user := s.modelToDomain(model)
@@ -118,7 +169,7 @@ func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Resp
 	// Update fields using domain methods
 	if req.Username != nil {
 		existingUser, err := s.repo.GetByUsername(ctx, *req.Username)
-		if err != nil && err != ErrNotFound {
+		if err != nil && !errors.Is(err, ErrNotFound) {
 			return nil, fmt.Errorf("failed to check username existence: %w", err)
 		}
 		if existingUser != nil && existingUser.ID != id {
@@ -131,7 +182,7 @@ func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Resp
 
 	if req.Email != nil {
 		existingUser, err := s.repo.GetByEmail(ctx, *req.Email)
-		if err != nil && err != ErrNotFound {
+		if err != nil && !errors.Is(err, ErrNotFound) {
 			return nil, fmt.Errorf("failed to check email existence: %w", err)
 		}
 		if existingUser != nil && existingUser.ID != id {
@@ -157,6 +208,8 @@ func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Resp
 	updatedModel := s.domainToModel(user)
 	updatedModel.ID = model.ID
 	updatedModel.CreatedAt = model.CreatedAt
+	updatedModel.Version = model.Version
+	updatedModel.Role = model.Role
 
 	// Use transaction if available
 	var updateErr error
@@ -171,6 +224,13 @@ func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Resp
 	if updateErr != nil {
 		return nil, fmt.Errorf("failed to update user %d: %w", id, updateErr)
 	}
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &actorID,
+		Action:     audit.ActionUserUpdated,
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Changes:    audit.Diff(model, updatedModel),
+	})
 
 	// Publish event
 	if s.eventBus != nil {
@@ -184,12 +244,121 @@ func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest) (*Resp
 	return s.toResponse(updatedModel), nil
 }
 
-func (s *Service) Delete(ctx context.Context, id uint) error {
+// UpdateProfile applies the given parts of the request to the user's profile.
+// Like Update, a non-nil expectedVersion makes the change conditional on the
+// version the client last saw.
+func (s *Service) UpdateProfile(ctx context.Context, id uint, req UpdateProfileRequest, expectedVersion *uint) (*PrivateResponse, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.UpdateProfile")
+	defer span.End()
+
+	model, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id %d: %w", id, err)
+	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	user := s.modelToDomain(model)
+	profile := user.Profile
+	for _, field := range []struct {
+		dst *string
+		src *string
+	}{
+		{&profile.DisplayName, req.DisplayName},
+		{&profile.Bio, req.Bio},
+		{&profile.Website, req.Website},
+		{&profile.Location, req.Location},
+		{&profile.Pronouns, req.Pronouns},
+	} {
+		if field.src != nil {
+			*field.dst = strings.TrimSpace(*field.src)
+		}
+	}
+	if req.Fields != nil {
+		profile.Fields = make([]domain.ProfileField, len(*req.Fields))
+		for i, field := range *req.Fields {
+			profile.Fields[i] = domain.ProfileField{Name: strings.TrimSpace(field.Name), Value: strings.TrimSpace(field.Value)}
+		}
+	}
+	if req.AvatarMediaID != nil {
+		profile.AvatarID = nil
+		if *req.AvatarMediaID != 0 {
+			avatarID := domain.MediaID(*req.AvatarMediaID)
+			if s.avatars == nil {
+				return nil, domain.ErrMediaNotFound
+			}
+			if err := s.avatars.CheckAvatar(ctx, avatarID, user.ID); err != nil {
+				return nil, fmt.Errorf("failed to check avatar: %w", err)
+			}
+			profile.AvatarID = &avatarID
+		}
+	}
+	if err := user.UpdateProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	updated := *model
+	s.applyProfile(&updated, user.Profile)
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update profile of user %d: %w", id, err)
+	}
+	audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserUpdated,
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Changes:    audit.Diff(model, &updated),
+	})
+
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.UserUpdated{
+			UserID:   user.ID,
+			Username: updated.Username,
+			Email:    updated.Email,
+		})
+	}
+
+	return s.toPrivateResponse(&updated), nil
+}
+
+// AvatarURL returns a signed link to the user's avatar, or
+// domain.ErrMediaNotFound when they have none
+func (s *Service) AvatarURL(ctx context.Context, id uint) (string, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.AvatarURL")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user by id %d: %w", id, err)
+	}
+	if user.AvatarMediaID == nil || s.avatars == nil {
+		return "", domain.ErrMediaNotFound
+	}
+	url, err := s.avatars.AvatarURL(ctx, domain.MediaID(*user.AvatarMediaID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get avatar of user %d: %w", id, err)
+	}
+	return url, nil
+}
+
+// Delete removes the user on behalf of actorID, who must be the user or an
+// admin
+func (s *Service) Delete(ctx context.Context, id, actorID uint, expectedVersion *uint) error {
+	ctx, span := tracing.Start(ctx, "users.Service.Delete")
+	defer span.End()
+
+	if err := s.authorize(ctx, id, actorID); err != nil {
+		return err
+	}
+
 	// Check if user exists
-	_, err := s.repo.GetByID(ctx, id)
+	model, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get user by id %d: %w", id, err)
 	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return err
+	}
 
 	userID := domain.UserID(id)
 
@@ -197,15 +366,22 @@ func (s *Service) Delete(ctx context.Context, id uint) error {
 	var deleteErr error
 	if s.transactionMgr != nil {
 		deleteErr = s.transactionMgr.WithTransaction(ctx, func(txCtx context.Context) error {
-			return s.repo.Delete(txCtx, id)
+			return s.repo.Delete(txCtx, id, expectedVersion)
 		})
 	} else {
-		deleteErr = s.repo.Delete(ctx, id)
+		deleteErr = s.repo.Delete(ctx, id, expectedVersion)
 	}
 
 	if deleteErr != nil {
 		return fmt.Errorf("failed to delete user %d: %w", id, deleteErr)
 	}
+	audit.Record(ctx, audit.Entry{
+		ActorID:    &actorID,
+		Action:     audit.ActionUserDeleted,
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Changes:    audit.Diff(model, nil),
+	})
 
 	// Publish event
 	if s.eventBus != nil {
@@ -217,7 +393,54 @@ func (s *Service) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// SetRole changes the user's role. Like Update, a non-nil expectedVersion
+// makes the change conditional on the version the client last saw.
+func (s *Service) SetRole(ctx context.Context, id uint, role string, expectedVersion *uint) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.SetRole")
+	defer span.End()
+
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	model, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by id %d: %w", id, err)
+	}
+	if err := domain.CheckVersion(model.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	if model.Role == role {
+		return s.toResponse(model), nil
+	}
+
+	updated := *model
+	updated.Role = role
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return nil, fmt.Errorf("failed to change role of user %d: %w", id, err)
+	}
+	audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserRoleChanged,
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Changes:    audit.Diff(model, &updated),
+	})
+
+	if s.eventBus != nil {
+		_ = s.eventBus.Publish(ctx, events.UserUpdated{
+			UserID:   domain.UserID(id),
+			Username: updated.Username,
+			Email:    updated.Email,
+		})
+	}
+
+	return s.toResponse(&updated), nil
+}
+
 func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, error) {
+	ctx, span := tracing.Start(ctx, "users.Service.List")
+	defer span.End()
+
 	users, err := s.repo.List(ctx, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
@@ -241,32 +464,107 @@ func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, e
 	}, nil
 }
 
+// authorize lets actorID change their own account, and admins any account
+func (s *Service) authorize(ctx context.Context, id, actorID uint) error {
+	if actorID == id {
+		return nil
+	}
+	actor, err := s.repo.GetByID(ctx, actorID)
+	if errors.Is(err, ErrNotFound) {
+		return ErrForbidden
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user by id %d: %w", actorID, err)
+	}
+	if actor.Role != RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
 func (s *Service) toResponse(user *Model) *Response {
-	return &Response{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	fields := make([]ProfileField, len(user.ProfileFields))
+	copy(fields, user.ProfileFields)
+	response := &Response{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Website:     user.Website,
+		Location:    user.Location,
+		Pronouns:    user.Pronouns,
+		Fields:      fields,
+		Role:        user.Role,
+		Version:     user.Version,
+		CreatedAt:   user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	// Signed media links expire, so the profile links to a stable redirect
+	if user.AvatarMediaID != nil {
+		response.AvatarURL = fmt.Sprintf("/v1/users/%d/avatar", user.ID)
+	}
+	return response
+}
+
+func (s *Service) toPrivateResponse(user *Model) *PrivateResponse {
+	return &PrivateResponse{
+		Response:      *s.toResponse(user),
+		Email:         user.Email,
+		AvatarMediaID: user.AvatarMediaID,
 	}
 }
 
 // domainToModel converts domain User to repository Model
 func (s *Service) domainToModel(user *domain.User) *Model {
-	return &Model{
+	model := &Model{
 		Username: user.Username,
 		Email:    user.Email,
 		Password: user.Password,
 	}
+	s.applyProfile(model, user.Profile)
+	return model
+}
+
+// applyProfile copies a domain Profile onto the model's profile columns
+func (s *Service) applyProfile(model *Model, profile domain.Profile) {
+	model.DisplayName = profile.DisplayName
+	model.Bio = profile.Bio
+	model.AvatarMediaID = nil
+	if profile.AvatarID != nil {
+		avatarID := uint(*profile.AvatarID)
+		model.AvatarMediaID = &avatarID
+	}
+	model.Website = profile.Website
+	model.Location = profile.Location
+	model.Pronouns = profile.Pronouns
+	model.ProfileFields = nil
+	for _, field := range profile.Fields {
+		model.ProfileFields = append(model.ProfileFields, ProfileField{Name: field.Name, Value: field.Value})
+	}
 }
 
 // modelToDomain converts repository Model to domain User
 func (s *Service) modelToDomain(model *Model) *domain.User {
-	return &domain.User{
+	user := &domain.User{
 		ID:       domain.UserID(model.ID),
 		Username: model.Username,
 		Email:    model.Email,
 		Password: model.Password,
+		Profile: domain.Profile{
+			DisplayName: model.DisplayName,
+			Bio:         model.Bio,
+			Website:     model.Website,
+			Location:    model.Location,
+			Pronouns:    model.Pronouns,
+		},
+	}
+	if model.AvatarMediaID != nil {
+		avatarID := domain.MediaID(*model.AvatarMediaID)
+		user.Profile.AvatarID = &avatarID
+	}
+	for _, field := range model.ProfileFields {
+		user.Profile.Fields = append(user.Profile.Fields, domain.ProfileField{Name: field.Name, Value: field.Value})
 	}
+	return user
 }
 